@@ -0,0 +1,18 @@
+package protofirestore
+
+import (
+	"github.com/daviddomkar/protofirestore/annotations"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldOptions returns the firestore options of the given field. It never
+// returns nil, fields without options yield empty options.
+func fieldOptions(fd protoreflect.FieldDescriptor) *annotations.FieldOptions {
+	return annotations.FieldOptionsOf(fd)
+}
+
+// isSubcollection reports whether the field fd is stored in a subcollection
+// rather than in the document of its message.
+func isSubcollection(fd protoreflect.FieldDescriptor) bool {
+	return fieldOptions(fd).GetSubcollection() != ""
+}
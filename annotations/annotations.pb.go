@@ -0,0 +1,702 @@
+// Firestore specific options of protobuf messages and fields.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: annotations/annotations.proto
+
+package annotations
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// QueryScope is the scope of queries an index is used by.
+type Index_QueryScope int32
+
+const (
+	// Defaults to COLLECTION.
+	Index_QUERY_SCOPE_UNSPECIFIED Index_QueryScope = 0
+	// Queries of a single collection.
+	Index_COLLECTION Index_QueryScope = 1
+	// Queries of all collections with the same collection ID.
+	Index_COLLECTION_GROUP Index_QueryScope = 2
+)
+
+// Enum value maps for Index_QueryScope.
+var (
+	Index_QueryScope_name = map[int32]string{
+		0: "QUERY_SCOPE_UNSPECIFIED",
+		1: "COLLECTION",
+		2: "COLLECTION_GROUP",
+	}
+	Index_QueryScope_value = map[string]int32{
+		"QUERY_SCOPE_UNSPECIFIED": 0,
+		"COLLECTION":              1,
+		"COLLECTION_GROUP":        2,
+	}
+)
+
+func (x Index_QueryScope) Enum() *Index_QueryScope {
+	p := new(Index_QueryScope)
+	*p = x
+	return p
+}
+
+func (x Index_QueryScope) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Index_QueryScope) Descriptor() protoreflect.EnumDescriptor {
+	return file_annotations_annotations_proto_enumTypes[0].Descriptor()
+}
+
+func (Index_QueryScope) Type() protoreflect.EnumType {
+	return &file_annotations_annotations_proto_enumTypes[0]
+}
+
+func (x Index_QueryScope) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Index_QueryScope.Descriptor instead.
+func (Index_QueryScope) EnumDescriptor() ([]byte, []int) {
+	return file_annotations_annotations_proto_rawDescGZIP(), []int{1, 0}
+}
+
+// Order is the order of an indexed field.
+type IndexField_Order int32
+
+const (
+	// Defaults to ASCENDING unless the field is indexed for array-contains.
+	IndexField_ORDER_UNSPECIFIED IndexField_Order = 0
+	IndexField_ASCENDING         IndexField_Order = 1
+	IndexField_DESCENDING        IndexField_Order = 2
+)
+
+// Enum value maps for IndexField_Order.
+var (
+	IndexField_Order_name = map[int32]string{
+		0: "ORDER_UNSPECIFIED",
+		1: "ASCENDING",
+		2: "DESCENDING",
+	}
+	IndexField_Order_value = map[string]int32{
+		"ORDER_UNSPECIFIED": 0,
+		"ASCENDING":         1,
+		"DESCENDING":        2,
+	}
+)
+
+func (x IndexField_Order) Enum() *IndexField_Order {
+	p := new(IndexField_Order)
+	*p = x
+	return p
+}
+
+func (x IndexField_Order) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (IndexField_Order) Descriptor() protoreflect.EnumDescriptor {
+	return file_annotations_annotations_proto_enumTypes[1].Descriptor()
+}
+
+func (IndexField_Order) Type() protoreflect.EnumType {
+	return &file_annotations_annotations_proto_enumTypes[1]
+}
+
+func (x IndexField_Order) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use IndexField_Order.Descriptor instead.
+func (IndexField_Order) EnumDescriptor() ([]byte, []int) {
+	return file_annotations_annotations_proto_rawDescGZIP(), []int{2, 0}
+}
+
+// EtagSource is the source of the etag of a document.
+type FieldOptions_EtagSource int32
+
+const (
+	// The field is not an etag.
+	FieldOptions_ETAG_SOURCE_UNSPECIFIED FieldOptions_EtagSource = 0
+	// The etag is the update time of the document, which is not stored in
+	// the document.
+	FieldOptions_UPDATE_TIME FieldOptions_EtagSource = 1
+	// The etag is a version counter stored in the field, which is
+	// incremented by every write of the repository package.
+	FieldOptions_VERSION FieldOptions_EtagSource = 2
+)
+
+// Enum value maps for FieldOptions_EtagSource.
+var (
+	FieldOptions_EtagSource_name = map[int32]string{
+		0: "ETAG_SOURCE_UNSPECIFIED",
+		1: "UPDATE_TIME",
+		2: "VERSION",
+	}
+	FieldOptions_EtagSource_value = map[string]int32{
+		"ETAG_SOURCE_UNSPECIFIED": 0,
+		"UPDATE_TIME":             1,
+		"VERSION":                 2,
+	}
+)
+
+func (x FieldOptions_EtagSource) Enum() *FieldOptions_EtagSource {
+	p := new(FieldOptions_EtagSource)
+	*p = x
+	return p
+}
+
+func (x FieldOptions_EtagSource) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FieldOptions_EtagSource) Descriptor() protoreflect.EnumDescriptor {
+	return file_annotations_annotations_proto_enumTypes[2].Descriptor()
+}
+
+func (FieldOptions_EtagSource) Type() protoreflect.EnumType {
+	return &file_annotations_annotations_proto_enumTypes[2]
+}
+
+func (x FieldOptions_EtagSource) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FieldOptions_EtagSource.Descriptor instead.
+func (FieldOptions_EtagSource) EnumDescriptor() ([]byte, []int) {
+	return file_annotations_annotations_proto_rawDescGZIP(), []int{3, 0}
+}
+
+// MessageOptions contains the firestore specific options of a message stored
+// as a document.
+type MessageOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The path pattern of documents of the message, alternating collection IDs
+	// and document ID variables, e.g. "users/{user}/orders/{order}".
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// The composite indexes of the collection of the message.
+	Indexes []*Index `protobuf:"bytes,2,rep,name=indexes,proto3" json:"indexes,omitempty"`
+}
+
+func (x *MessageOptions) Reset() {
+	*x = MessageOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_annotations_annotations_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MessageOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageOptions) ProtoMessage() {}
+
+func (x *MessageOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_annotations_annotations_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageOptions.ProtoReflect.Descriptor instead.
+func (*MessageOptions) Descriptor() ([]byte, []int) {
+	return file_annotations_annotations_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MessageOptions) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *MessageOptions) GetIndexes() []*Index {
+	if x != nil {
+		return x.Indexes
+	}
+	return nil
+}
+
+// Index is a composite index over fields of a message.
+type Index struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The scope of queries using the index.
+	QueryScope Index_QueryScope `protobuf:"varint,1,opt,name=query_scope,json=queryScope,proto3,enum=protofirestore.Index_QueryScope" json:"query_scope,omitempty"`
+	// The indexed fields in the order of the index.
+	Fields []*IndexField `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty"`
+}
+
+func (x *Index) Reset() {
+	*x = Index{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_annotations_annotations_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Index) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Index) ProtoMessage() {}
+
+func (x *Index) ProtoReflect() protoreflect.Message {
+	mi := &file_annotations_annotations_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Index.ProtoReflect.Descriptor instead.
+func (*Index) Descriptor() ([]byte, []int) {
+	return file_annotations_annotations_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Index) GetQueryScope() Index_QueryScope {
+	if x != nil {
+		return x.QueryScope
+	}
+	return Index_QUERY_SCOPE_UNSPECIFIED
+}
+
+func (x *Index) GetFields() []*IndexField {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+// IndexField is a field of a composite index.
+type IndexField struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The proto field path of the field, e.g. "create_time" or "author.name".
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// The order of the field.
+	Order IndexField_Order `protobuf:"varint,2,opt,name=order,proto3,enum=protofirestore.IndexField_Order" json:"order,omitempty"`
+	// Indexes a repeated field for array-contains and array-contains-any
+	// queries instead of ordering it.
+	ArrayContains bool `protobuf:"varint,3,opt,name=array_contains,json=arrayContains,proto3" json:"array_contains,omitempty"`
+}
+
+func (x *IndexField) Reset() {
+	*x = IndexField{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_annotations_annotations_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IndexField) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexField) ProtoMessage() {}
+
+func (x *IndexField) ProtoReflect() protoreflect.Message {
+	mi := &file_annotations_annotations_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexField.ProtoReflect.Descriptor instead.
+func (*IndexField) Descriptor() ([]byte, []int) {
+	return file_annotations_annotations_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *IndexField) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *IndexField) GetOrder() IndexField_Order {
+	if x != nil {
+		return x.Order
+	}
+	return IndexField_ORDER_UNSPECIFIED
+}
+
+func (x *IndexField) GetArrayContains() bool {
+	if x != nil {
+		return x.ArrayContains
+	}
+	return false
+}
+
+// FieldOptions contains the firestore specific options of a field.
+type FieldOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Marks a numeric field as a counter. Changes of counters are written as
+	// increment transforms instead of the new value when diffing messages.
+	Counter bool `protobuf:"varint,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	// Exempts the field from the automatic single-field indexes, e.g. for large
+	// text blobs which are never queried.
+	ExcludeFromIndexes bool `protobuf:"varint,2,opt,name=exclude_from_indexes,json=excludeFromIndexes,proto3" json:"exclude_from_indexes,omitempty"`
+	// Marks a top-level string field as the etag of the document as described
+	// by AIP-154. The repository package fills the etag when reading documents
+	// and rejects updates of messages with an outdated etag.
+	Etag FieldOptions_EtagSource `protobuf:"varint,3,opt,name=etag,proto3,enum=protofirestore.FieldOptions_EtagSource" json:"etag,omitempty"`
+	// Marks a top-level google.protobuf.Timestamp field as the delete time of
+	// soft-deleted documents as described by AIP-164. The repository package
+	// sets it instead of deleting documents and hides documents which have it.
+	DeleteTime bool `protobuf:"varint,4,opt,name=delete_time,json=deleteTime,proto3" json:"delete_time,omitempty"`
+	// Marks a top-level google.protobuf.Timestamp field as the time after which
+	// soft-deleted documents are purged. The indexes generator configures a
+	// firestore TTL policy for it, so that firestore purges the documents.
+	PurgeTime bool `protobuf:"varint,5,opt,name=purge_time,json=purgeTime,proto3" json:"purge_time,omitempty"`
+	// Stores a repeated or map message field as the subcollection with this
+	// ID below the document instead of in the document itself. Elements of
+	// repeated fields are stored as documents named by their index and
+	// elements of maps as documents named by their key. The option is ignored
+	// for fields of embedded messages which are not stored as documents.
+	Subcollection string `protobuf:"bytes,6,opt,name=subcollection,proto3" json:"subcollection,omitempty"`
+}
+
+func (x *FieldOptions) Reset() {
+	*x = FieldOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_annotations_annotations_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FieldOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldOptions) ProtoMessage() {}
+
+func (x *FieldOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_annotations_annotations_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldOptions.ProtoReflect.Descriptor instead.
+func (*FieldOptions) Descriptor() ([]byte, []int) {
+	return file_annotations_annotations_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FieldOptions) GetCounter() bool {
+	if x != nil {
+		return x.Counter
+	}
+	return false
+}
+
+func (x *FieldOptions) GetExcludeFromIndexes() bool {
+	if x != nil {
+		return x.ExcludeFromIndexes
+	}
+	return false
+}
+
+func (x *FieldOptions) GetEtag() FieldOptions_EtagSource {
+	if x != nil {
+		return x.Etag
+	}
+	return FieldOptions_ETAG_SOURCE_UNSPECIFIED
+}
+
+func (x *FieldOptions) GetDeleteTime() bool {
+	if x != nil {
+		return x.DeleteTime
+	}
+	return false
+}
+
+func (x *FieldOptions) GetPurgeTime() bool {
+	if x != nil {
+		return x.PurgeTime
+	}
+	return false
+}
+
+func (x *FieldOptions) GetSubcollection() string {
+	if x != nil {
+		return x.Subcollection
+	}
+	return ""
+}
+
+var file_annotations_annotations_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MessageOptions)(nil),
+		ExtensionType: (*MessageOptions)(nil),
+		Field:         50871,
+		Name:          "protofirestore.message",
+		Tag:           "bytes,50871,opt,name=message",
+		Filename:      "annotations/annotations.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*FieldOptions)(nil),
+		Field:         50871,
+		Name:          "protofirestore.field",
+		Tag:           "bytes,50871,opt,name=field",
+		Filename:      "annotations/annotations.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MessageOptions.
+var (
+	// optional protofirestore.MessageOptions message = 50871;
+	E_Message = &file_annotations_annotations_proto_extTypes[0]
+)
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// optional protofirestore.FieldOptions field = 50871;
+	E_Field = &file_annotations_annotations_proto_extTypes[1]
+)
+
+var File_annotations_annotations_proto protoreflect.FileDescriptor
+
+var file_annotations_annotations_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2f, 0x61, 0x6e,
+	0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x66, 0x69, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x1a,
+	0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x22, 0x55, 0x0a, 0x0e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x2f, 0x0a, 0x07, 0x69, 0x6e, 0x64, 0x65, 0x78,
+	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x66, 0x69, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x52,
+	0x07, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x22, 0xcf, 0x01, 0x0a, 0x05, 0x49, 0x6e, 0x64,
+	0x65, 0x78, 0x12, 0x41, 0x0a, 0x0b, 0x71, 0x75, 0x65, 0x72, 0x79, 0x5f, 0x73, 0x63, 0x6f, 0x70,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x20, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x66,
+	0x69, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x2e, 0x51,
+	0x75, 0x65, 0x72, 0x79, 0x53, 0x63, 0x6f, 0x70, 0x65, 0x52, 0x0a, 0x71, 0x75, 0x65, 0x72, 0x79,
+	0x53, 0x63, 0x6f, 0x70, 0x65, 0x12, 0x32, 0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x66, 0x69, 0x72,
+	0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x46, 0x69, 0x65, 0x6c,
+	0x64, 0x52, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x22, 0x4f, 0x0a, 0x0a, 0x51, 0x75, 0x65,
+	0x72, 0x79, 0x53, 0x63, 0x6f, 0x70, 0x65, 0x12, 0x1b, 0x0a, 0x17, 0x51, 0x55, 0x45, 0x52, 0x59,
+	0x5f, 0x53, 0x43, 0x4f, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
+	0x45, 0x44, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x43, 0x4f, 0x4c, 0x4c, 0x45, 0x43, 0x54, 0x49,
+	0x4f, 0x4e, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x4f, 0x4c, 0x4c, 0x45, 0x43, 0x54, 0x49,
+	0x4f, 0x4e, 0x5f, 0x47, 0x52, 0x4f, 0x55, 0x50, 0x10, 0x02, 0x22, 0xbe, 0x01, 0x0a, 0x0a, 0x49,
+	0x6e, 0x64, 0x65, 0x78, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74,
+	0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x36, 0x0a,
+	0x05, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x20, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x66, 0x69, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x49, 0x6e,
+	0x64, 0x65, 0x78, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x05,
+	0x6f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x72, 0x72, 0x61, 0x79, 0x5f, 0x63,
+	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x61,
+	0x72, 0x72, 0x61, 0x79, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x73, 0x22, 0x3d, 0x0a, 0x05,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x15, 0x0a, 0x11, 0x4f, 0x52, 0x44, 0x45, 0x52, 0x5f, 0x55,
+	0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09,
+	0x41, 0x53, 0x43, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a, 0x44,
+	0x45, 0x53, 0x43, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x22, 0xc6, 0x02, 0x0a, 0x0c,
+	0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x12, 0x30, 0x0a, 0x14, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64,
+	0x65, 0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x46, 0x72, 0x6f,
+	0x6d, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x12, 0x3b, 0x0a, 0x04, 0x65, 0x74, 0x61, 0x67,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x27, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x66, 0x69,
+	0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x45, 0x74, 0x61, 0x67, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52,
+	0x04, 0x65, 0x74, 0x61, 0x67, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x64, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x75, 0x72, 0x67, 0x65, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x70, 0x75, 0x72, 0x67,
+	0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x75,
+	0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x47, 0x0a, 0x0a, 0x45,
+	0x74, 0x61, 0x67, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1b, 0x0a, 0x17, 0x45, 0x54, 0x41,
+	0x47, 0x5f, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49,
+	0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45,
+	0x5f, 0x54, 0x49, 0x4d, 0x45, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x56, 0x45, 0x52, 0x53, 0x49,
+	0x4f, 0x4e, 0x10, 0x02, 0x3a, 0x5b, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0xb7, 0x8d, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x66, 0x69, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x3a, 0x53, 0x0a, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xb7, 0x8d, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x66, 0x69, 0x72, 0x65, 0x73, 0x74, 0x6f,
+	0x72, 0x65, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52,
+	0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x42, 0x33, 0x5a, 0x31, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64, 0x61, 0x76, 0x69, 0x64, 0x64, 0x6f, 0x6d, 0x6b, 0x61, 0x72,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x66, 0x69, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2f,
+	0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_annotations_annotations_proto_rawDescOnce sync.Once
+	file_annotations_annotations_proto_rawDescData = file_annotations_annotations_proto_rawDesc
+)
+
+func file_annotations_annotations_proto_rawDescGZIP() []byte {
+	file_annotations_annotations_proto_rawDescOnce.Do(func() {
+		file_annotations_annotations_proto_rawDescData = protoimpl.X.CompressGZIP(file_annotations_annotations_proto_rawDescData)
+	})
+	return file_annotations_annotations_proto_rawDescData
+}
+
+var file_annotations_annotations_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_annotations_annotations_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_annotations_annotations_proto_goTypes = []interface{}{
+	(Index_QueryScope)(0),               // 0: protofirestore.Index.QueryScope
+	(IndexField_Order)(0),               // 1: protofirestore.IndexField.Order
+	(FieldOptions_EtagSource)(0),        // 2: protofirestore.FieldOptions.EtagSource
+	(*MessageOptions)(nil),              // 3: protofirestore.MessageOptions
+	(*Index)(nil),                       // 4: protofirestore.Index
+	(*IndexField)(nil),                  // 5: protofirestore.IndexField
+	(*FieldOptions)(nil),                // 6: protofirestore.FieldOptions
+	(*descriptorpb.MessageOptions)(nil), // 7: google.protobuf.MessageOptions
+	(*descriptorpb.FieldOptions)(nil),   // 8: google.protobuf.FieldOptions
+}
+var file_annotations_annotations_proto_depIdxs = []int32{
+	4, // 0: protofirestore.MessageOptions.indexes:type_name -> protofirestore.Index
+	0, // 1: protofirestore.Index.query_scope:type_name -> protofirestore.Index.QueryScope
+	5, // 2: protofirestore.Index.fields:type_name -> protofirestore.IndexField
+	1, // 3: protofirestore.IndexField.order:type_name -> protofirestore.IndexField.Order
+	2, // 4: protofirestore.FieldOptions.etag:type_name -> protofirestore.FieldOptions.EtagSource
+	7, // 5: protofirestore.message:extendee -> google.protobuf.MessageOptions
+	8, // 6: protofirestore.field:extendee -> google.protobuf.FieldOptions
+	3, // 7: protofirestore.message:type_name -> protofirestore.MessageOptions
+	6, // 8: protofirestore.field:type_name -> protofirestore.FieldOptions
+	9, // [9:9] is the sub-list for method output_type
+	9, // [9:9] is the sub-list for method input_type
+	7, // [7:9] is the sub-list for extension type_name
+	5, // [5:7] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_annotations_annotations_proto_init() }
+func file_annotations_annotations_proto_init() {
+	if File_annotations_annotations_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_annotations_annotations_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MessageOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_annotations_annotations_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Index); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_annotations_annotations_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IndexField); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_annotations_annotations_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FieldOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_annotations_annotations_proto_rawDesc,
+			NumEnums:      3,
+			NumMessages:   4,
+			NumExtensions: 2,
+			NumServices:   0,
+		},
+		GoTypes:           file_annotations_annotations_proto_goTypes,
+		DependencyIndexes: file_annotations_annotations_proto_depIdxs,
+		EnumInfos:         file_annotations_annotations_proto_enumTypes,
+		MessageInfos:      file_annotations_annotations_proto_msgTypes,
+		ExtensionInfos:    file_annotations_annotations_proto_extTypes,
+	}.Build()
+	File_annotations_annotations_proto = out.File
+	file_annotations_annotations_proto_rawDesc = nil
+	file_annotations_annotations_proto_goTypes = nil
+	file_annotations_annotations_proto_depIdxs = nil
+}
@@ -0,0 +1,24 @@
+package annotations
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MessageOptionsOf returns the firestore options of the given message. It
+// never returns nil, messages without options yield empty options.
+func MessageOptionsOf(md protoreflect.MessageDescriptor) *MessageOptions {
+	if opts, ok := proto.GetExtension(md.Options(), E_Message).(*MessageOptions); ok && opts != nil {
+		return opts
+	}
+	return &MessageOptions{}
+}
+
+// FieldOptionsOf returns the firestore options of the given field. It never
+// returns nil, fields without options yield empty options.
+func FieldOptionsOf(fd protoreflect.FieldDescriptor) *FieldOptions {
+	if opts, ok := proto.GetExtension(fd.Options(), E_Field).(*FieldOptions); ok && opts != nil {
+		return opts
+	}
+	return &FieldOptions{}
+}
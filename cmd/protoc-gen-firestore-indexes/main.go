@@ -0,0 +1,59 @@
+// The protoc-gen-firestore-indexes binary is a protoc plugin generating a
+// firestore.indexes.json file from the index annotations of the messages in
+// the proto files it is invoked for.
+//
+// All indexes are written into a single file, named firestore.indexes.json
+// unless the out parameter says otherwise:
+//
+//	protoc --firestore-indexes_out=out=firebase/firestore.indexes.json:. *.proto
+package main
+
+import (
+	"flag"
+
+	"github.com/daviddomkar/protofirestore/indexgen"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func main() {
+	var flags flag.FlagSet
+	out := flags.String("out", "firestore.indexes.json", "name of the generated file")
+
+	protogen.Options{
+		ParamFunc: flags.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		var mds []protoreflect.MessageDescriptor
+		for _, f := range gen.Files {
+			if f.Generate {
+				mds = appendMessages(mds, f.Messages)
+			}
+		}
+
+		config, err := indexgen.Generate(mds...)
+		if err != nil {
+			return err
+		}
+
+		b, err := config.MarshalIndent()
+		if err != nil {
+			return err
+		}
+
+		_, err = gen.NewGeneratedFile(*out, "").Write(b)
+		return err
+	})
+}
+
+// appendMessages appends the descriptors of messages and their nested
+// messages to mds.
+func appendMessages(mds []protoreflect.MessageDescriptor, messages []*protogen.Message) []protoreflect.MessageDescriptor {
+	for _, m := range messages {
+		if m.Desc.IsMapEntry() {
+			continue
+		}
+		mds = append(mds, m.Desc)
+		mds = appendMessages(mds, m.Messages)
+	}
+	return mds
+}
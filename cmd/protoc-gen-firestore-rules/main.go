@@ -0,0 +1,75 @@
+// The protoc-gen-firestore-rules binary is a protoc plugin generating
+// firestore security rules functions validating the documents of the
+// messages in the proto files it is invoked for.
+//
+// All functions are written into a single file, named
+// firestore.validation.rules unless the out parameter says otherwise, whose
+// content is meant to be pasted into or concatenated with the firestore.rules
+// file. Function names do not include the proto package, so the plugin fails
+// if messages of different packages share a name.
+//
+// The emit_defaults parameter makes the functions require the keys encoded
+// with EmitFirestoreSensibleDefaults:
+//
+//	protoc --firestore-rules_out=emit_defaults=true:. *.proto
+//
+// The emit_type parameter allows the type key stored by EmitType and the
+// emit_oneof_case parameter requires the case keys stored by EmitOneofCase.
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/daviddomkar/protofirestore/rulesgen"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func main() {
+	var flags flag.FlagSet
+	out := flags.String("out", "firestore.validation.rules", "name of the generated file")
+	emitDefaults := flags.Bool("emit_defaults", false, "require keys encoded with EmitFirestoreSensibleDefaults")
+	emitType := flags.Bool("emit_type", false, "allow the type key encoded with EmitType")
+	emitOneofCase := flags.Bool("emit_oneof_case", false, "require the oneof case keys encoded with EmitOneofCase")
+
+	protogen.Options{
+		ParamFunc: flags.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		var mds []protoreflect.MessageDescriptor
+		for _, f := range gen.Files {
+			if f.Generate {
+				mds = appendMessages(mds, f.Messages)
+			}
+		}
+
+		opts := rulesgen.Options{}
+		opts.MarshalOptions.EmitFirestoreSensibleDefaults = *emitDefaults
+		opts.MarshalOptions.EmitType = *emitType
+		opts.MarshalOptions.EmitOneofCase = *emitOneofCase
+
+		rules, err := opts.Generate(mds...)
+		if err != nil {
+			return err
+		}
+
+		g := gen.NewGeneratedFile(*out, "")
+		g.P("// Code generated by protoc-gen-firestore-rules. DO NOT EDIT.")
+		g.P()
+		_, err = g.Write([]byte(strings.TrimSuffix(rules, "\n") + "\n"))
+		return err
+	})
+}
+
+// appendMessages appends the descriptors of messages and their nested
+// messages to mds.
+func appendMessages(mds []protoreflect.MessageDescriptor, messages []*protogen.Message) []protoreflect.MessageDescriptor {
+	for _, m := range messages {
+		if m.Desc.IsMapEntry() {
+			continue
+		}
+		mds = append(mds, m.Desc)
+		mds = appendMessages(mds, m.Messages)
+	}
+	return mds
+}
@@ -0,0 +1,466 @@
+package internal_gengo
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// genMarshal generates the MarshalFirestore method of m, which produces the
+// same document as the reflection based encoder.
+func genMarshal(g *protogen.GeneratedFile, m *protogen.Message) {
+	g.P("// MarshalFirestore marshals x into a firestore document without reflection.")
+	g.P("// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.")
+	g.P("func (x *", m.GoIdent.GoName, ") MarshalFirestore(o ", protofirestorePackage.Ident("MarshalOptions"), ") (map[string]interface{}, error) {")
+	g.P("document := make(map[string]interface{})")
+	g.P("if x == nil {")
+	g.P("return document, nil")
+	g.P("}")
+
+	for _, f := range m.Fields {
+		if f.Oneof != nil && !f.Oneof.Desc.IsSynthetic() {
+			if f.Oneof.Fields[0] == f {
+				genMarshalOneof(g, f.Oneof)
+			}
+			continue
+		}
+		genMarshalField(g, f)
+	}
+
+	g.P("return document, nil")
+	g.P("}")
+	g.P()
+}
+
+// genMarshalField generates the encoding of the field f, which is not part of
+// a oneof.
+func genMarshalField(g *protogen.GeneratedFile, f *protogen.Field) {
+	fd := f.Desc
+	key := strconv.Quote(fd.JSONName())
+	field := "x." + f.GoName
+
+	store := func(value string, mayBeNil bool) {
+		if mayBeNil {
+			g.P("if ", value, " != nil {")
+			g.P("document[", key, "] = ", value)
+			g.P("}")
+		} else {
+			g.P("document[", key, "] = ", value)
+		}
+	}
+
+	switch {
+	case fd.IsList():
+		g.P("if len(", field, ") != 0 {")
+		if wellKnownType(fd) == genid.Empty_message_name {
+			// Empty messages encode to nil elements.
+			g.P("document[", key, "] = make([]interface{}, len(", field, "))")
+		} else {
+			g.P("array := make([]interface{}, len(", field, "))")
+			g.P("for i, v := range ", field, " {")
+			genEncodeValue(g, fd, "v", func(value string, mayBeNil bool) {
+				g.P("array[i] = ", value)
+			})
+			g.P("}")
+			g.P("document[", key, "] = array")
+		}
+		g.P("}")
+
+	case fd.IsMap():
+		if wellKnownType(fd.MapValue()) == genid.Empty_message_name {
+			// Maps of empty messages have no entries with values and are
+			// omitted.
+			return
+		}
+		g.P("if len(", field, ") != 0 {")
+		g.P("object := make(map[string]interface{}, len(", field, "))")
+		g.P("for k, v := range ", field, " {")
+		genEncodeValue(g, fd.MapValue(), "v", func(value string, mayBeNil bool) {
+			if mayBeNil {
+				g.P("if ", value, " != nil {")
+				g.P("object[", mapKeyString(g, fd.MapKey(), "k"), "] = ", value)
+				g.P("}")
+			} else {
+				g.P("object[", mapKeyString(g, fd.MapKey(), "k"), "] = ", value)
+			}
+		})
+		g.P("}")
+		g.P("if len(object) != 0 {")
+		g.P("document[", key, "] = object")
+		g.P("}")
+		g.P("}")
+
+	case fd.Message() != nil:
+		if wellKnownType(fd) == genid.Empty_message_name {
+			// Empty messages encode to nil and are omitted.
+			return
+		}
+		g.P("if ", field, " != nil {")
+		genEncodeValue(g, fd, field, store)
+		g.P("}")
+
+	case fd.HasPresence() && fd.Kind() == protoreflect.BytesKind:
+		// Optional bytes fields are unset when nil.
+		g.P("if ", field, " != nil {")
+		genEncodeValue(g, fd, field, store)
+		g.P("}")
+
+	case fd.HasPresence():
+		g.P("if ", field, " != nil {")
+		genEncodeValue(g, fd, "(*"+field+")", store)
+		g.P("}")
+
+	default:
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			g.P("if ", field, ` != "" {`)
+		case protoreflect.BytesKind:
+			g.P("if len(", field, ") != 0 {")
+		case protoreflect.BoolKind:
+			g.P("if ", field, " || o.EmitFirestoreSensibleDefaults {")
+		case protoreflect.FloatKind, protoreflect.DoubleKind:
+			// Negative zero is not the zero value, since presence of
+			// floats compares bits.
+			g.P("if ", field, " != 0 || ", mathPackage.Ident("Signbit"), "(float64(", field, ")) || o.EmitFirestoreSensibleDefaults {")
+		default:
+			g.P("if ", field, " != 0 || o.EmitFirestoreSensibleDefaults {")
+		}
+		genEncodeValue(g, fd, field, store)
+		g.P("}")
+	}
+}
+
+// genMarshalOneof generates the encoding of the set field of the oneof.
+func genMarshalOneof(g *protogen.GeneratedFile, oneof *protogen.Oneof) {
+	encoded := false
+	for _, f := range oneof.Fields {
+		encoded = encoded || wellKnownType(f.Desc) != genid.Empty_message_name
+	}
+	if !encoded {
+		// Empty messages encode to nil and are omitted.
+		return
+	}
+
+	g.P("switch v := x.", oneof.GoName, ".(type) {")
+	for _, f := range oneof.Fields {
+		key := strconv.Quote(f.Desc.JSONName())
+
+		g.P("case *", f.GoIdent, ":")
+		if wellKnownType(f.Desc) == genid.Empty_message_name {
+			// Empty messages encode to nil and are omitted.
+			continue
+		}
+		genEncodeValue(g, f.Desc, "v."+f.GoName, func(value string, mayBeNil bool) {
+			if mayBeNil {
+				g.P("if ", value, " != nil {")
+				g.P("document[", key, "] = ", value)
+				g.P("}")
+			} else {
+				g.P("document[", key, "] = ", value)
+			}
+		})
+	}
+	g.P("}")
+}
+
+// genEncodeValue generates the encoding of the singular value expr of the
+// field fd. It calls store with the expression of the encoded value and
+// whether it may be nil, which means the value is omitted.
+func genEncodeValue(g *protogen.GeneratedFile, fd protoreflect.FieldDescriptor, expr string, store func(value string, mayBeNil bool)) {
+	name := strconv.Quote(string(fd.FullName()))
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		g.P("value, err := ", firestoreimplPackage.Ident("EncodeString"), "(", expr, ", ", name, ")")
+		g.P("if err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		store("value", true)
+
+	case protoreflect.BytesKind:
+		g.P("value := ", firestoreimplPackage.Ident("EncodeBytes"), "(", expr, ")")
+		store("value", true)
+
+	case protoreflect.EnumKind:
+		store(g.QualifiedGoIdent(firestoreimplPackage.Ident("EncodeEnum"))+"("+expr+".Number(), "+expr+".Descriptor())", false)
+
+	case protoreflect.MessageKind:
+		if wellKnownType(fd) == genid.Timestamp_message_name {
+			g.P("value, err := ", firestoreimplPackage.Ident("EncodeTimestamp"), "(", expr, ".GetSeconds(), ", expr, ".GetNanos())")
+			g.P("if err != nil {")
+			g.P("return nil, err")
+			g.P("}")
+			store("value", false)
+			return
+		}
+
+		g.P("nested, err := o.Marshal(", expr, ")")
+		g.P("if err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		if fd.ContainingOneof() != nil {
+			g.P("if len(nested) != 0 || o.EmitFirestoreSensibleDefaults {")
+		} else {
+			g.P("if len(nested) != 0 {")
+		}
+		store("nested", false)
+		g.P("}")
+
+	default:
+		// The Go types of the other scalars are the types of their encoded
+		// values.
+		store(expr, false)
+	}
+}
+
+// mapKeyString returns the expression converting the map key expr of the key
+// field fd to a string like protoreflect.MapKey.String.
+func mapKeyString(g *protogen.GeneratedFile, fd protoreflect.FieldDescriptor, expr string) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return g.QualifiedGoIdent(strconvPackage.Ident("FormatBool")) + "(" + expr + ")"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return g.QualifiedGoIdent(strconvPackage.Ident("FormatInt")) + "(int64(" + expr + "), 10)"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return g.QualifiedGoIdent(strconvPackage.Ident("FormatInt")) + "(" + expr + ", 10)"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return g.QualifiedGoIdent(strconvPackage.Ident("FormatUint")) + "(uint64(" + expr + "), 10)"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return g.QualifiedGoIdent(strconvPackage.Ident("FormatUint")) + "(" + expr + ", 10)"
+	}
+	return expr
+}
+
+// genUnmarshal generates the UnmarshalFirestore method of m, which reads
+// documents the same way as the reflection based decoder.
+func genUnmarshal(g *protogen.GeneratedFile, m *protogen.Message) {
+	g.P("// UnmarshalFirestore reads the firestore document into x without reflection.")
+	g.P("// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.")
+	g.P("func (x *", m.GoIdent.GoName, ") UnmarshalFirestore(o ", protofirestorePackage.Ident("UnmarshalOptions"), ", document map[string]interface{}) error {")
+	g.P("x.Reset()")
+	g.P("for _, key := range ", firestoreimplPackage.Ident("SortedKeys"), "(document) {")
+	g.P("value := document[key]")
+	g.P("switch key {")
+
+	for _, f := range m.Fields {
+		g.P("case ", strconv.Quote(f.Desc.JSONName()), ":")
+		g.P("if value == nil {")
+		g.P("continue")
+		g.P("}")
+		genUnmarshalField(g, f)
+	}
+
+	g.P("default:")
+	g.P("if !o.DiscardUnknown {")
+	g.P("return ", firestoreimplPackage.Ident("UnknownFieldError"), "(key, ", strconv.Quote(string(m.Desc.FullName())), ")")
+	g.P("}")
+	g.P("}")
+	g.P("}")
+	g.P("return nil")
+	g.P("}")
+	g.P()
+}
+
+// genUnmarshalField generates the decoding of the non-nil value of the field
+// f.
+func genUnmarshalField(g *protogen.GeneratedFile, f *protogen.Field) {
+	fd := f.Desc
+	name := strconv.Quote(string(fd.FullName()))
+	field := "x." + f.GoName
+
+	switch {
+	case fd.IsList():
+		g.P("array, err := ", firestoreimplPackage.Ident("DecodeList"), "(value, ", name, ")")
+		g.P("if err != nil {")
+		g.P("return err")
+		g.P("}")
+		g.P(field, " = make([]", goType(g, f), ", len(array))")
+		g.P("for i, item := range array {")
+		g.P("if item == nil {")
+		if fd.Message() != nil {
+			g.P(field, "[i] = new(", g.QualifiedGoIdent(f.Message.GoIdent), ")")
+		}
+		g.P("continue")
+		g.P("}")
+		genDecodeValue(g, f, fd, "item", func(expr string) {
+			g.P(field, "[i] = ", expr)
+		})
+		g.P("}")
+
+	case fd.IsMap():
+		key, value := f.Message.Fields[0], f.Message.Fields[1]
+
+		g.P("object, err := ", firestoreimplPackage.Ident("DecodeObject"), "(value, ", name, ")")
+		g.P("if err != nil {")
+		g.P("return err")
+		g.P("}")
+		g.P(field, " = make(map[", goType(g, key), "]", goType(g, value), ", len(object))")
+		g.P("for k, item := range object {")
+		g.P("mk, err := ", firestoreimplPackage.Ident("DecodeMapKey"), "(k, ", protoreflectPackage.Ident(kindName(key.Desc.Kind())), ", ", name, ")")
+		g.P("if err != nil {")
+		g.P("return err")
+		g.P("}")
+		mapKey := mapKeyValue(key.Desc, "mk")
+		g.P("if item == nil {")
+		g.P(field, "[", mapKey, "] = ", zeroValue(g, value))
+		g.P("continue")
+		g.P("}")
+		genDecodeValue(g, value, value.Desc, "item", func(expr string) {
+			g.P(field, "[", mapKey, "] = ", expr)
+		})
+		g.P("}")
+
+	case f.Oneof != nil && !f.Oneof.Desc.IsSynthetic():
+		g.P("if x.", f.Oneof.GoName, " != nil {")
+		g.P("return ", firestoreimplPackage.Ident("OneofConflictError"), "(", strconv.Quote(string(f.Oneof.Desc.FullName())), ")")
+		g.P("}")
+		genDecodeValue(g, f, fd, "value", func(expr string) {
+			g.P("x.", f.Oneof.GoName, " = &", f.GoIdent, "{", f.GoName, ": ", expr, "}")
+		})
+
+	case fd.Message() == nil && fd.HasPresence() && fd.Kind() != protoreflect.BytesKind:
+		genDecodeValue(g, f, fd, "value", func(expr string) {
+			g.P("p := ", expr)
+			g.P(field, " = &p")
+		})
+
+	default:
+		genDecodeValue(g, f, fd, "value", func(expr string) {
+			g.P(field, " = ", expr)
+		})
+	}
+}
+
+// genDecodeValue generates the decoding of the singular non-nil value expr of
+// the field fd, which is f or the value field of the map f. It calls assign
+// with the expression of the decoded value.
+func genDecodeValue(g *protogen.GeneratedFile, f *protogen.Field, fd protoreflect.FieldDescriptor, expr string, assign func(value string)) {
+	name := strconv.Quote(string(fd.FullName()))
+
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		enum := g.QualifiedGoIdent(f.Enum.GoIdent)
+		g.P("n, err := ", firestoreimplPackage.Ident("DecodeEnum"), "(", expr, ", ", enum, "(0).Descriptor(), ", name, ")")
+		g.P("if err != nil {")
+		g.P("return err")
+		g.P("}")
+		assign(enum + "(n)")
+
+	case protoreflect.MessageKind:
+		switch wellKnownType(fd) {
+		case genid.Timestamp_message_name:
+			g.P("v, err := ", firestoreimplPackage.Ident("DecodeTimestamp"), "(", expr, ", ", name, ")")
+			g.P("if err != nil {")
+			g.P("return err")
+			g.P("}")
+			assign("v")
+
+		case genid.Empty_message_name:
+			g.P("if _, err := ", firestoreimplPackage.Ident("DecodeObject"), "(", expr, ", ", name, "); err != nil {")
+			g.P("return err")
+			g.P("}")
+			assign("new(" + g.QualifiedGoIdent(f.Message.GoIdent) + ")")
+
+		default:
+			g.P("nested, err := ", firestoreimplPackage.Ident("DecodeObject"), "(", expr, ", ", name, ")")
+			g.P("if err != nil {")
+			g.P("return err")
+			g.P("}")
+			g.P("v := new(", f.Message.GoIdent, ")")
+			g.P("if err := o.Unmarshal(nested, v); err != nil {")
+			g.P("return err")
+			g.P("}")
+			assign("v")
+		}
+
+	default:
+		g.P("v, err := ", firestoreimplPackage.Ident("Decode"+scalarName(fd.Kind())), "(", expr, ", ", name, ")")
+		g.P("if err != nil {")
+		g.P("return err")
+		g.P("}")
+		assign("v")
+	}
+}
+
+// scalarName returns the name of the Go type of the scalar kind with the
+// first letter in upper case, as used by the decode functions of
+// firestoreimpl.
+func scalarName(kind protoreflect.Kind) string {
+	switch kind {
+	case protoreflect.BoolKind:
+		return "Bool"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "Int32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return "Int64"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "Uint32"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "Uint64"
+	case protoreflect.FloatKind:
+		return "Float32"
+	case protoreflect.DoubleKind:
+		return "Float64"
+	case protoreflect.StringKind:
+		return "String"
+	case protoreflect.BytesKind:
+		return "Bytes"
+	}
+	panic("unsupported kind " + kind.String())
+}
+
+// goType returns the Go type of a singular value of the field f.
+func goType(g *protogen.GeneratedFile, f *protogen.Field) string {
+	switch f.Desc.Kind() {
+	case protoreflect.EnumKind:
+		return g.QualifiedGoIdent(f.Enum.GoIdent)
+	case protoreflect.MessageKind:
+		return "*" + g.QualifiedGoIdent(f.Message.GoIdent)
+	case protoreflect.BytesKind:
+		return "[]byte"
+	}
+	return strings.ToLower(scalarName(f.Desc.Kind()))
+}
+
+// zeroValue returns the zero value of a singular value of the field f, which
+// is the empty message for message fields.
+func zeroValue(g *protogen.GeneratedFile, f *protogen.Field) string {
+	switch f.Desc.Kind() {
+	case protoreflect.BoolKind:
+		return "false"
+	case protoreflect.StringKind:
+		return `""`
+	case protoreflect.BytesKind:
+		return "nil"
+	case protoreflect.MessageKind:
+		return "new(" + g.QualifiedGoIdent(f.Message.GoIdent) + ")"
+	}
+	return "0"
+}
+
+// kindName returns the name of the protoreflect constant of the kind.
+func kindName(kind protoreflect.Kind) string {
+	s := kind.String()
+	return strings.ToUpper(s[:1]) + s[1:] + "Kind"
+}
+
+// mapKeyValue returns the expression converting the protoreflect.MapKey expr
+// to the Go type of the map key field fd.
+func mapKeyValue(fd protoreflect.FieldDescriptor, expr string) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return expr + ".Bool()"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "int32(" + expr + ".Int())"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return expr + ".Int()"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "uint32(" + expr + ".Uint())"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return expr + ".Uint()"
+	}
+	return expr + ".String()"
+}
@@ -0,0 +1,229 @@
+// Package internal_gengo is internal to the protofirestore module and
+// generates the code of protoc-gen-go-firestore.
+package internal_gengo
+
+import (
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/docpath"
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// SupportedFeatures reports the set of supported protobuf language features.
+var SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+
+const (
+	fmtPackage            = protogen.GoImportPath("fmt")
+	mathPackage           = protogen.GoImportPath("math")
+	strconvPackage        = protogen.GoImportPath("strconv")
+	protoreflectPackage   = protogen.GoImportPath("google.golang.org/protobuf/reflect/protoreflect")
+	protofirestorePackage = protogen.GoImportPath("github.com/daviddomkar/protofirestore")
+	firestoreimplPackage  = protogen.GoImportPath("github.com/daviddomkar/protofirestore/firestoreimpl")
+	docpathPackage        = protogen.GoImportPath("github.com/daviddomkar/protofirestore/docpath")
+)
+
+// GenerateFile generates the contents of a _firestore.pb.go file. It returns
+// nil if the file has no messages.
+func GenerateFile(gen *protogen.Plugin, file *protogen.File) (*protogen.GeneratedFile, error) {
+	messages := allMessages(file.Messages)
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+"_firestore.pb.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-firestore. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	for _, m := range messages {
+		if err := genFieldPaths(g, m); err != nil {
+			return nil, err
+		}
+		if err := genDocumentPath(g, m); err != nil {
+			return nil, err
+		}
+		if isSupported(m) {
+			genMarshal(g, m)
+			genUnmarshal(g, m)
+		}
+	}
+
+	return g, nil
+}
+
+// allMessages returns messages and their nested messages except map entries.
+func allMessages(messages []*protogen.Message) []*protogen.Message {
+	var all []*protogen.Message
+	for _, m := range messages {
+		if m.Desc.IsMapEntry() {
+			continue
+		}
+		all = append(all, m)
+		all = append(all, allMessages(m.Messages)...)
+	}
+	return all
+}
+
+// genFieldPaths generates the constants with the firestore field paths of the
+// fields of m.
+func genFieldPaths(g *protogen.GeneratedFile, m *protogen.Message) error {
+	var fields []*protogen.Field
+	for _, f := range m.Fields {
+		if annotations.FieldOptionsOf(f.Desc).GetSubcollection() == "" {
+			fields = append(fields, f) // subcollections have no field path
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	g.P("// Firestore field paths of the fields of ", m.GoIdent.GoName, " documents.")
+	g.P("const (")
+	for _, f := range fields {
+		path, err := protofirestore.FieldPath(m.Desc, string(f.Desc.Name()))
+		if err != nil {
+			return err
+		}
+		g.P(m.GoIdent.GoName, "_", f.GoName, "_FieldPath = ", strconv.Quote(path))
+	}
+	g.P(")")
+	g.P()
+
+	return nil
+}
+
+// genDocumentPath generates the path pattern constants of m and the functions
+// building and parsing the paths of its documents if m has a path option.
+func genDocumentPath(g *protogen.GeneratedFile, m *protogen.Message) error {
+	opts := annotations.MessageOptionsOf(m.Desc)
+	if opts.GetPath() == "" {
+		return nil
+	}
+
+	pattern, err := docpath.Parse(opts.GetPath())
+	if err != nil {
+		return fmt.Errorf("message %v: %w", m.Desc.FullName(), err)
+	}
+
+	name := m.GoIdent.GoName
+	variables := pattern.Variables()
+
+	params := make([]string, len(variables))
+	results := make([]string, len(variables))
+	returns := make([]string, len(variables))
+	empty := make([]string, len(variables))
+	for i, variable := range variables {
+		params[i] = goParamName(variable)
+		results[i] = "string"
+		returns[i] = "ids[" + strconv.Itoa(i) + "]"
+		empty[i] = `""`
+	}
+
+	g.P("// ", name, "_PathPattern is the path pattern of ", name, " documents.")
+	g.P("const ", name, "_PathPattern = ", strconv.Quote(pattern.String()))
+	g.P()
+	g.P("// ", name, "_CollectionID is the ID of the collection of ", name, " documents.")
+	g.P("const ", name, "_CollectionID = ", strconv.Quote(pattern.CollectionID()))
+	g.P()
+	g.P("var _", name, "_pathPattern = ", g.QualifiedGoIdent(docpathPackage.Ident("MustParse")), "(", name, "_PathPattern)")
+	g.P()
+	g.P("// ", name, "Path returns the path of the ", name, " document with the given")
+	g.P("// document IDs.")
+	g.P("func ", name, "Path(", strings.Join(params, ", "), " string) (string, error) {")
+	g.P("return _", name, "_pathPattern.Expand(", strings.Join(params, ", "), ")")
+	g.P("}")
+	g.P()
+	g.P("// Parse", name, "Path returns the document IDs ", strings.Join(variables, ", "), " of the ", name)
+	g.P("// document with the given path.")
+	g.P("func Parse", name, "Path(path string) (", strings.Join(results, ", "), ", error) {")
+	g.P("ids, ok := _", name, "_pathPattern.Match(path)")
+	g.P("if !ok {")
+	g.P("return ", strings.Join(empty, ", "), ", ", fmtPackage.Ident("Errorf"), `("%q does not match %v", path, `, name, "_PathPattern)")
+	g.P("}")
+	g.P("return ", strings.Join(returns, ", "), ", nil")
+	g.P("}")
+	g.P()
+
+	return nil
+}
+
+// goParamName converts a variable of a path pattern to a Go parameter name.
+func goParamName(variable string) string {
+	parts := strings.Split(variable, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+
+	name := strings.Join(parts, "")
+	if token.IsKeyword(name) || name == "" || name[0] == '_' {
+		name = "id_" + name
+	}
+	return name
+}
+
+// isSupported reports whether MarshalFirestore and UnmarshalFirestore can be
+// generated for m. The methods are only generated for proto3 messages whose
+// fields are scalars, enums, messages other than well known types besides
+// google.protobuf.Timestamp and google.protobuf.Empty, or lists and maps of
+// those. Other messages are encoded using reflection.
+func isSupported(m *protogen.Message) bool {
+	if m.Desc.Syntax() != protoreflect.Proto3 {
+		return false
+	}
+
+	for _, f := range m.Fields {
+		fd := f.Desc
+		if fd.IsWeak() || annotations.FieldOptionsOf(fd).GetSubcollection() != "" {
+			return false // subcollections are written by MarshalDocuments
+		}
+		if fd.IsMap() {
+			fd = fd.MapValue()
+		}
+		if !isSupportedKind(fd) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSupportedKind reports whether the generated code supports values of the
+// singular field fd.
+func isSupportedKind(fd protoreflect.FieldDescriptor) bool {
+	switch fd.Kind() {
+	case protoreflect.GroupKind:
+		return false
+	case protoreflect.EnumKind:
+		return fd.Enum().FullName() != genid.NullValue_enum_fullname
+	case protoreflect.MessageKind:
+		if fd.Message().FullName().Parent() == genid.GoogleProtobuf_package {
+			switch fd.Message().Name() {
+			case genid.Timestamp_message_name, genid.Empty_message_name:
+				return true
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// wellKnownType returns the name of the well known type of the message field
+// fd, or an empty name for other messages.
+func wellKnownType(fd protoreflect.FieldDescriptor) protoreflect.Name {
+	if md := fd.Message(); md != nil && md.FullName().Parent() == genid.GoogleProtobuf_package {
+		return md.Name()
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package internal_gengo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gengo "github.com/daviddomkar/protofirestore/cmd/protoc-gen-go-firestore/internal_gengo"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	_ "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	_ "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+)
+
+// TestGeneratedFilesAreUpToDate regenerates the code of the test protos and
+// compares it with the checked in files.
+func TestGeneratedFilesAreUpToDate(t *testing.T) {
+	files := []string{
+		"internal/testprotos/annotatedpb/test.proto",
+		"internal/testprotos/textpb3/test.proto",
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: files,
+		Parameter:      proto.String("paths=source_relative"),
+	}
+
+	seen := make(map[string]bool)
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(fd))
+	}
+
+	for _, file := range files {
+		fd, err := protoregistry.GlobalFiles.FindFileByPath(file)
+		if err != nil {
+			t.Fatalf("FindFileByPath(%q) returned error: %v\n", file, err)
+		}
+		add(fd)
+	}
+
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatalf("protogen.Options.New() returned error: %v\n", err)
+	}
+
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+
+		g, err := gengo.GenerateFile(gen, f)
+		if err != nil {
+			t.Fatalf("GenerateFile(%q) returned error: %v\n", f.Desc.Path(), err)
+		}
+
+		got, err := g.Content()
+		if err != nil {
+			t.Fatalf("Content() returned error: %v\n", err)
+		}
+
+		name := f.GeneratedFilenamePrefix + "_firestore.pb.go"
+		want, err := os.ReadFile(filepath.Join("..", "..", "..", name))
+		if err != nil {
+			t.Fatalf("ReadFile(%q) returned error: %v\n", name, err)
+		}
+
+		if string(got) != string(want) {
+			t.Errorf("%v is not up to date, regenerate it with protoc-gen-go-firestore\n", name)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// The protoc-gen-go-firestore binary is a protoc plugin generating firestore
+// helpers for the messages in the proto files it is invoked for, next to the
+// code generated by protoc-gen-go:
+//
+//	protoc --go_out=. --go-firestore_out=. *.proto
+//
+// For every message it generates constants with the firestore field paths of
+// its fields and, for messages with a path option, constants and functions to
+// build and parse document paths. Messages whose fields are all supported get
+// MarshalFirestore and UnmarshalFirestore methods, which the protofirestore
+// package uses instead of reflection.
+package main
+
+import (
+	gengo "github.com/daviddomkar/protofirestore/cmd/protoc-gen-go-firestore/internal_gengo"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+func main() {
+	protogen.Options{}.Run(func(gen *protogen.Plugin) error {
+		for _, f := range gen.Files {
+			if f.Generate {
+				if _, err := gengo.GenerateFile(gen, f); err != nil {
+					return err
+				}
+			}
+		}
+		gen.SupportedFeatures = gengo.SupportedFeatures
+		return nil
+	})
+}
@@ -0,0 +1,302 @@
+package internal_tsgen
+
+import (
+	"strconv"
+
+	"github.com/daviddomkar/protofirestore/internal/shape"
+)
+
+// genEncode generates the function encoding objects of m into the same
+// documents as the encoder of the protofirestore package.
+func (f *fileGenerator) genEncode(m *shape.Message) {
+	name := tsName(m.Desc)
+	documentData := f.firestore("DocumentData")
+
+	f.P("// encode", name, " encodes the ", name, " message into a firestore document.")
+	f.P("export function encode", name, "(message: ", name, "): ", documentData, " {")
+	f.P("  const data: ", documentData, " = {};")
+	for _, field := range m.Fields {
+		f.genEncodeField(field)
+	}
+	for _, oneof := range m.OneofCases {
+		f.genEncodeOneofCase(m, oneof)
+	}
+	f.P("  return data;")
+	f.P("}")
+	f.P()
+}
+
+// genEncodeField generates the encoding of a field.
+func (f *fileGenerator) genEncodeField(field shape.Field) {
+	value := access("message", field.Key)
+	store := access("data", field.Key)
+	presence := field.Desc.ContainingOneof() != nil
+
+	switch t := field.Type; t.Kind {
+	case shape.List:
+		f.P("  if (", value, ".length > 0) {")
+		if encode := f.encodeElem(*t.Elem); encode != "" {
+			f.P("    ", store, " = ", value, ".map(", encode, ");")
+		} else {
+			f.P("    ", store, " = ", value, ";")
+		}
+		f.P("  }")
+
+	case shape.Map:
+		f.P("  if (Object.keys(", value, ").length > 0) {")
+		if encode := f.encodeElem(*t.Elem); encode != "" {
+			f.P("    ", store, " = ", f.helper("encodeMap"), "(", value, ", ", encode, ");")
+		} else {
+			f.P("    ", store, " = ", value, ";")
+		}
+		f.P("  }")
+
+	case shape.Object:
+		f.P("  if (", value, " !== undefined) {")
+		if presence && f.opts.MarshalOptions.EmitFirestoreSensibleDefaults {
+			// Empty messages of oneofs are stored as empty maps.
+			f.P("    ", store, " = ", f.ident(t.Message, "encode"), "(", value, ");")
+		} else {
+			f.P("    const encoded = ", f.ident(t.Message, "encode"), "(", value, ");")
+			f.P("    if (Object.keys(encoded).length > 0) {")
+			f.P("      ", store, " = encoded;")
+			f.P("    }")
+		}
+		f.P("  }")
+
+	default:
+		var conditions []string
+		if presence || t.Kind == shape.Timestamp {
+			conditions = append(conditions, value+" !== undefined")
+		}
+		switch {
+		case t.Kind == shape.String || t.Kind == shape.Bytes:
+			// Empty strings and bytes are never stored.
+			conditions = append(conditions, f.nonEmpty(t, value))
+		case !presence && t.Kind != shape.Timestamp && !f.opts.MarshalOptions.EmitFirestoreSensibleDefaults:
+			conditions = append(conditions, f.nonZero(t, value))
+		}
+
+		if len(conditions) == 0 {
+			f.P("  ", store, " = ", value, ";")
+			return
+		}
+		cond := conditions[0]
+		if len(conditions) > 1 {
+			cond += " && " + conditions[1]
+		}
+		f.P("  if (", cond, ") {")
+		f.P("    ", store, " = ", value, ";")
+		f.P("  }")
+	}
+}
+
+// genEncodeOneofCase generates the encoding of the case of a oneof, which is
+// the key of the first property of the oneof that is defined. Fields of the
+// oneof which are never encoded have no property and thus no case.
+func (f *fileGenerator) genEncodeOneofCase(m *shape.Message, oneof shape.OneofCase) {
+	value := "null"
+	for i := len(m.Fields) - 1; i >= 0; i-- {
+		if field := m.Fields[i]; field.Oneof == oneof.Desc {
+			value = access("message", field.Key) + " !== undefined ? " + strconv.Quote(field.Key) + " : " + value
+		}
+	}
+	f.P("  ", access("data", oneof.Key), " = ", value, ";")
+}
+
+// encodeElem returns the function encoding elements of lists and values of
+// maps of type t, or an empty string if they are stored as they are. The
+// function returns null for values which encode to nil.
+func (f *fileGenerator) encodeElem(t shape.Type) string {
+	switch t.Kind {
+	case shape.String, shape.Bytes:
+		return "(e) => (" + f.nonEmpty(t, "e") + " ? e : null)"
+	case shape.Object:
+		return "(e) => " + f.helper("nonEmpty") + "(" + f.ident(t.Message, "encode") + "(e))"
+	}
+	return ""
+}
+
+// nonEmpty returns the condition checking that the string or bytes value is
+// not empty.
+func (f *fileGenerator) nonEmpty(t shape.Type, value string) string {
+	if t.Kind == shape.Bytes {
+		return value + ".toUint8Array().length > 0"
+	}
+	return value + ` !== ""`
+}
+
+// nonZero returns the condition checking that the bool, number or enum value
+// is not the zero value, which is not stored unless firestore sensible
+// defaults are emitted.
+func (f *fileGenerator) nonZero(t shape.Type, value string) string {
+	switch t.Kind {
+	case shape.Bool:
+		return value
+	case shape.Float:
+		// Negative zero is not the zero value.
+		return "!Object.is(" + value + ", 0)"
+	case shape.Enum:
+		return f.ident(t.Enum, "") + "[" + value + "] !== 0"
+	}
+	return value + " !== 0"
+}
+
+// genDecode generates the function decoding documents into objects of m the
+// same way the decoder of the protofirestore package does. Missing keys and
+// null values decode into zero values.
+func (f *fileGenerator) genDecode(m *shape.Message) {
+	name := tsName(m.Desc)
+
+	f.P("// decode", name, " decodes a firestore document into a ", name, " message.")
+	f.P("export function decode", name, "(data: ", f.firestore("DocumentData"), "): ", name, " {")
+	f.P("  return {")
+	for _, field := range m.Fields {
+		f.P("    ", propertyName(field.Key), ": ", f.decodeField(m, field), ",")
+	}
+	f.P("  };")
+	f.P("}")
+	f.P()
+}
+
+// decodeField returns the expression decoding the value of a field.
+func (f *fileGenerator) decodeField(m *shape.Message, field shape.Field) string {
+	value := access("data", field.Key)
+
+	for _, oneof := range m.OneofCases {
+		if field.Oneof != oneof.Desc {
+			continue
+		}
+		// The case names the field of the oneof that is set even if its
+		// value is empty and thus not stored.
+		present := value + " != null || " + access("data", oneof.Key) + " === " + strconv.Quote(field.Key)
+		return present + " ? " + f.decodeValue(field) + " : undefined"
+	}
+
+	switch t := field.Type; t.Kind {
+	case shape.List:
+		if decode := f.decodeElem(*t.Elem, false); decode != "" {
+			return "(" + value + " ?? []).map(" + decode + ")"
+		}
+		return value + " ?? []"
+
+	case shape.Map:
+		return f.helper("decodeMap") + "(" + value + ", " + f.decodeElem(*t.Elem, true) + ")"
+
+	case shape.Object:
+		return value + " != null ? " + f.ident(t.Message, "decode") + "(" + value + ") : undefined"
+
+	case shape.Enum:
+		zero := "undefined"
+		if !hasPresence(field) {
+			zero = f.zeroValue(t)
+		}
+		return value + " != null ? " + f.ident(t.Enum, "decode") + "(" + value + ") : " + zero
+	}
+
+	if hasPresence(field) {
+		return value + " ?? undefined"
+	}
+	return value + " ?? " + f.zeroValue(field.Type)
+}
+
+// decodeValue returns the expression decoding the value of a field, which
+// decodes a missing key or null value into the zero value.
+func (f *fileGenerator) decodeValue(field shape.Field) string {
+	value := access("data", field.Key)
+
+	switch t := field.Type; t.Kind {
+	case shape.Object:
+		return f.ident(t.Message, "decode") + "(" + value + " ?? {})"
+	case shape.Enum:
+		return "(" + value + " != null ? " + f.ident(t.Enum, "decode") + "(" + value + ") : " + f.zeroValue(t) + ")"
+	}
+	return "(" + value + " ?? " + f.zeroValue(field.Type) + ")"
+}
+
+// decodeElem returns the function decoding elements of lists and values of
+// maps of type t. Unless always is set, it returns an empty string for types
+// whose elements are used as they are stored, because lists only contain null
+// for empty strings, bytes and messages.
+func (f *fileGenerator) decodeElem(t shape.Type, always bool) string {
+	switch t.Kind {
+	case shape.Enum:
+		return "(e: unknown) => (e != null ? " + f.ident(t.Enum, "decode") + "(e) : " + f.zeroValue(t) + ")"
+	case shape.Object:
+		return "(e: " + f.firestore("DocumentData") + " | null) => " + f.ident(t.Message, "decode") + "(e ?? {})"
+	case shape.String, shape.Bytes:
+	default:
+		if !always {
+			return ""
+		}
+	}
+	return "(e: " + f.tsType(t) + " | null) => e ?? " + f.zeroValue(t)
+}
+
+// zeroValue returns the expression of the zero value of type t.
+func (f *fileGenerator) zeroValue(t shape.Type) string {
+	switch t.Kind {
+	case shape.Bool:
+		return "false"
+	case shape.Int, shape.Float:
+		return "0"
+	case shape.String:
+		return `""`
+	case shape.Bytes:
+		return f.firestore("Bytes") + ".fromUint8Array(new Uint8Array())"
+	case shape.Timestamp:
+		return "new " + f.firestore("Timestamp") + "(0, 0)"
+	case shape.Enum:
+		return strconv.Quote(string(t.Enum.Values().ByNumber(0).Name()))
+	case shape.Object:
+		return f.ident(t.Message, "decode") + "({})"
+	}
+	panic("no zero value of kind " + t.Kind.String())
+}
+
+// genHelpers generates the helper functions used by the generated code.
+func (f *fileGenerator) genHelpers() {
+	if f.helpers["nonEmpty"] {
+		documentData := f.firestore("DocumentData")
+		f.P("// nonEmpty returns data unless it is empty, in which case it returns null.")
+		f.P("function nonEmpty(data: ", documentData, "): ", documentData, " | null {")
+		f.P("  return Object.keys(data).length > 0 ? data : null;")
+		f.P("}")
+		f.P()
+	}
+	if f.helpers["encodeMap"] {
+		documentData := f.firestore("DocumentData")
+		f.P("// encodeMap encodes the values of map, omitting values encoded to null.")
+		f.P("function encodeMap<T>(map: { [key: string]: T }, encode: (value: T) => unknown): ", documentData, " {")
+		f.P("  const data: ", documentData, " = {};")
+		f.P("  for (const [key, value] of Object.entries(map)) {")
+		f.P("    const encoded = encode(value);")
+		f.P("    if (encoded !== null) {")
+		f.P("      data[key] = encoded;")
+		f.P("    }")
+		f.P("  }")
+		f.P("  return data;")
+		f.P("}")
+		f.P()
+	}
+	if f.helpers["decodeMap"] {
+		documentData := f.firestore("DocumentData")
+		f.P("// decodeMap decodes the values of a stored map.")
+		f.P("function decodeMap<T>(data: ", documentData, " | null | undefined, decode: (value: any) => T): { [key: string]: T } {")
+		f.P("  const map: { [key: string]: T } = {};")
+		f.P("  for (const [key, value] of Object.entries(data ?? {})) {")
+		f.P("    map[key] = decode(value);")
+		f.P("  }")
+		f.P("  return map;")
+		f.P("}")
+		f.P()
+	}
+}
+
+// access returns the expression accessing the property key of object.
+func access(object, key string) string {
+	if name := propertyName(key); name[0] != '"' {
+		return object + "." + name
+	}
+	return object + "[" + strconv.Quote(key) + "]"
+}
@@ -0,0 +1,399 @@
+// Package internal_tsgen is internal to the protofirestore module and
+// generates the code of protoc-gen-ts-firestore.
+package internal_tsgen
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"github.com/daviddomkar/protofirestore/internal/shape"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// SupportedFeatures reports the set of supported protobuf language features.
+var SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+
+// firestoreModule is the module of the Firebase JS SDK the generated code
+// imports its types from.
+const firestoreModule = "firebase/firestore"
+
+// Options configures the generated code.
+type Options struct {
+	// MarshalOptions are the options the documents are encoded with, which
+	// the generated code reproduces.
+	MarshalOptions protofirestore.MarshalOptions
+}
+
+// GenerateFile generates the contents of a _firestore.ts file, which is named
+// after the path of the proto file so that generated files can import each
+// other. It returns nil if the file has no enums and no supported messages.
+func (o Options) GenerateFile(gen *protogen.Plugin, file *protogen.File) (*protogen.GeneratedFile, error) {
+	enums := allEnums(file.Enums, file.Messages)
+
+	var messages []*shape.Message
+	for _, m := range allMessages(file.Messages) {
+		if !isSupported(m.Desc, nil) {
+			continue
+		}
+		sm, err := shape.Of(m.Desc, o.MarshalOptions)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, sm)
+	}
+
+	if len(enums) == 0 && len(messages) == 0 {
+		return nil, nil
+	}
+
+	f := &fileGenerator{
+		opts:     o,
+		file:     file,
+		imports:  make(map[string]map[string]bool),
+		comments: make(map[protoreflect.FullName]protogen.Comments),
+	}
+	for _, m := range allMessages(file.Messages) {
+		f.comments[m.Desc.FullName()] = m.Comments.Leading
+		for _, field := range m.Fields {
+			f.comments[field.Desc.FullName()] = field.Comments.Leading
+		}
+	}
+	for _, e := range enums {
+		f.comments[e.Desc.FullName()] = e.Comments.Leading
+	}
+
+	for _, e := range enums {
+		f.genEnum(e.Desc)
+	}
+	for _, m := range messages {
+		f.genInterface(m)
+		f.genEncode(m)
+		f.genDecode(m)
+		f.genConverter(m)
+	}
+	f.genHelpers()
+
+	g := gen.NewGeneratedFile(strings.TrimSuffix(file.Desc.Path(), ".proto")+"_firestore.ts", "")
+	g.P("// Code generated by protoc-gen-ts-firestore. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	f.genImports(g)
+	g.P(strings.TrimSuffix(f.body.String(), "\n"))
+
+	return g, nil
+}
+
+// fileGenerator accumulates the body of a generated file along with the
+// imports and helpers it needs.
+type fileGenerator struct {
+	opts     Options
+	file     *protogen.File
+	body     strings.Builder
+	imports  map[string]map[string]bool // module -> imported names
+	helpers  map[string]bool
+	comments map[protoreflect.FullName]protogen.Comments
+}
+
+// P writes a line of the body.
+func (f *fileGenerator) P(v ...string) {
+	for _, s := range v {
+		f.body.WriteString(s)
+	}
+	f.body.WriteByte('\n')
+}
+
+// comment writes the leading comment of the declaration with the given name.
+func (f *fileGenerator) comment(name protoreflect.FullName, indent string) {
+	if c := f.comments[name]; c != "" {
+		for _, line := range strings.Split(strings.TrimSuffix(c.String(), "\n"), "\n") {
+			f.P(indent, line)
+		}
+	}
+}
+
+// importName imports name from the given module and returns it.
+func (f *fileGenerator) importName(module, name string) string {
+	if f.imports[module] == nil {
+		f.imports[module] = make(map[string]bool)
+	}
+	f.imports[module][name] = true
+	return name
+}
+
+// firestore imports name from the Firebase JS SDK.
+func (f *fileGenerator) firestore(name string) string {
+	return f.importName(firestoreModule, name)
+}
+
+// ident returns the name of a declaration generated for the enum or message
+// desc, prefixed by prefix, importing it if it is declared in another file.
+func (f *fileGenerator) ident(desc protoreflect.Descriptor, prefix string) string {
+	name := prefix + tsName(desc)
+	if desc.ParentFile().Path() != f.file.Desc.Path() {
+		f.importName(importPath(f.file.Desc.Path(), desc.ParentFile().Path()), name)
+	}
+	return name
+}
+
+// helper marks the helper function with the given name as used.
+func (f *fileGenerator) helper(name string) string {
+	if f.helpers == nil {
+		f.helpers = make(map[string]bool)
+	}
+	f.helpers[name] = true
+	return name
+}
+
+// genImports generates the import declarations of the file.
+func (f *fileGenerator) genImports(g *protogen.GeneratedFile) {
+	modules := make([]string, 0, len(f.imports))
+	for module := range f.imports {
+		modules = append(modules, module)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		// The SDK is imported before relative imports.
+		if (modules[i] == firestoreModule) != (modules[j] == firestoreModule) {
+			return modules[i] == firestoreModule
+		}
+		return modules[i] < modules[j]
+	})
+
+	for _, module := range modules {
+		names := make([]string, 0, len(f.imports[module]))
+		for name := range f.imports[module] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		g.P("import {")
+		for _, name := range names {
+			g.P("  ", name, ",")
+		}
+		g.P("} from ", strconv.Quote(module), ";")
+	}
+	if len(modules) > 0 {
+		g.P()
+	}
+}
+
+// genEnum generates the enum ed as an object mapping the names of its values
+// to their numbers, a union type of the names and a function decoding stored
+// names and numbers into names.
+func (f *fileGenerator) genEnum(ed protoreflect.EnumDescriptor) {
+	name := tsName(ed)
+
+	f.comment(ed.FullName(), "")
+	f.P("export const ", name, " = {")
+	values := ed.Values()
+	for i := 0; i < values.Len(); i++ {
+		f.P("  ", string(values.Get(i).Name()), ": ", strconv.Itoa(int(values.Get(i).Number())), ",")
+	}
+	f.P("} as const;")
+	f.P()
+	f.P("export type ", name, " = keyof typeof ", name, ";")
+	f.P()
+	f.P("// decode", name, " decodes a stored ", string(ed.FullName()), " value,")
+	f.P("// which is the name of the enum value or its number.")
+	f.P("export function decode", name, "(value: unknown): ", name, " {")
+	f.P("  for (const [name, number] of Object.entries(", name, ")) {")
+	f.P("    if (value === name || value === number) {")
+	f.P("      return name as ", name, ";")
+	f.P("    }")
+	f.P("  }")
+	f.P("  throw new Error(`invalid ", string(ed.FullName()), " value ${String(value)}`);")
+	f.P("}")
+	f.P()
+}
+
+// genInterface generates the interface of the documents of m, whose
+// properties are named like the keys of the documents.
+func (f *fileGenerator) genInterface(m *shape.Message) {
+	f.comment(m.Desc.FullName(), "")
+	f.P("export interface ", tsName(m.Desc), " {")
+	for _, field := range m.Fields {
+		optional := ""
+		if hasPresence(field) {
+			optional = "?"
+		}
+		f.comment(field.Desc.FullName(), "  ")
+		f.P("  ", propertyName(field.Key), optional, ": ", f.tsType(field.Type), ";")
+	}
+	f.P("}")
+	f.P()
+}
+
+// genConverter generates the FirestoreDataConverter of m.
+func (f *fileGenerator) genConverter(m *shape.Message) {
+	name := tsName(m.Desc)
+
+	f.P("// ", name, "Converter converts ", name, " messages to and from firestore documents.")
+	f.P("export const ", name, "Converter: ", f.firestore("FirestoreDataConverter"), "<", name, "> = {")
+	f.P("  toFirestore(message: ", name, "): ", f.firestore("DocumentData"), " {")
+	if m.Type != "" {
+		f.P("    return { ...encode", name, "(message), ", strconv.Quote(protofirestore.TypeKey), ": ", strconv.Quote(m.Type), " };")
+	} else {
+		f.P("    return encode", name, "(message);")
+	}
+	f.P("  },")
+	f.P("  fromFirestore(snapshot: ", f.firestore("QueryDocumentSnapshot"), ", options?: ", f.firestore("SnapshotOptions"), "): ", name, " {")
+	f.P("    return decode", name, "(snapshot.data(options));")
+	f.P("  },")
+	f.P("};")
+	f.P()
+}
+
+// tsType returns the TypeScript type of values of type t.
+func (f *fileGenerator) tsType(t shape.Type) string {
+	switch t.Kind {
+	case shape.Bool:
+		return "boolean"
+	case shape.Int, shape.Float:
+		return "number"
+	case shape.String:
+		return "string"
+	case shape.Bytes:
+		return f.firestore("Bytes")
+	case shape.Timestamp:
+		return f.firestore("Timestamp")
+	case shape.Enum:
+		return f.ident(t.Enum, "")
+	case shape.Object:
+		return f.ident(t.Message, "")
+	case shape.List:
+		return f.tsType(*t.Elem) + "[]"
+	case shape.Map:
+		return "{ [key: string]: " + f.tsType(*t.Elem) + " }"
+	}
+	panic("unknown kind " + t.Kind.String())
+}
+
+// hasPresence reports whether the property of the field is optional, which
+// is the case for fields with explicit presence and singular messages.
+func hasPresence(field shape.Field) bool {
+	fd := field.Desc
+	return fd.ContainingOneof() != nil || field.Type.Kind == shape.Object || field.Type.Kind == shape.Timestamp
+}
+
+// isSupported reports whether code can be generated for the message md. Code
+// is generated for proto3 messages whose fields are supported by the
+// encoder, except for lists and maps of google.protobuf.Empty, and whose
+// message fields are supported.
+func isSupported(md protoreflect.MessageDescriptor, visiting map[protoreflect.FullName]bool) bool {
+	if md.Syntax() != protoreflect.Proto3 {
+		return false
+	}
+	if _, err := shape.Of(md, protofirestore.MarshalOptions{}); err != nil {
+		return false
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	if visiting[md.FullName()] {
+		return true
+	}
+	visiting[md.FullName()] = true
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.IsMap() {
+			fd = fd.MapValue()
+		}
+		if fd.Message() == nil || fd.Message().FullName() == genid.Timestamp_message_fullname {
+			continue
+		}
+		if fd.Message().FullName() == genid.Empty_message_fullname {
+			if fields.Get(i).Cardinality() == protoreflect.Repeated {
+				return false
+			}
+			continue
+		}
+		if !isSupported(fd.Message(), visiting) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// allMessages returns messages and their nested messages except map entries.
+func allMessages(messages []*protogen.Message) []*protogen.Message {
+	var all []*protogen.Message
+	for _, m := range messages {
+		if m.Desc.IsMapEntry() {
+			continue
+		}
+		all = append(all, m)
+		all = append(all, allMessages(m.Messages)...)
+	}
+	return all
+}
+
+// allEnums returns enums and the enums nested in messages.
+func allEnums(enums []*protogen.Enum, messages []*protogen.Message) []*protogen.Enum {
+	all := append([]*protogen.Enum(nil), enums...)
+	for _, m := range allMessages(messages) {
+		all = append(all, m.Enums...)
+	}
+	return all
+}
+
+// tsName returns the TypeScript name of an enum or message, which is its name
+// and the names of the messages it is nested in, separated by underscores.
+func tsName(desc protoreflect.Descriptor) string {
+	name := strings.TrimPrefix(string(desc.FullName()), string(desc.ParentFile().Package())+".")
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// importPath returns the module path of the file generated for the proto
+// file dep relative to the file generated for the proto file from.
+func importPath(from, dep string) string {
+	fromDir := strings.Split(path.Dir(from), "/")
+	depDir := strings.Split(path.Dir(dep), "/")
+	if fromDir[0] == "." {
+		fromDir = nil
+	}
+	if depDir[0] == "." {
+		depDir = nil
+	}
+
+	common := 0
+	for common < len(fromDir) && common < len(depDir) && fromDir[common] == depDir[common] {
+		common++
+	}
+
+	var parts []string
+	for range fromDir[common:] {
+		parts = append(parts, "..")
+	}
+	if len(parts) == 0 {
+		parts = append(parts, ".")
+	}
+	parts = append(parts, depDir[common:]...)
+	parts = append(parts, strings.TrimSuffix(path.Base(dep), ".proto")+"_firestore")
+
+	return strings.Join(parts, "/")
+}
+
+// propertyName returns the key as a property name, quoting it unless it is
+// an identifier.
+func propertyName(key string) string {
+	for i, r := range key {
+		switch {
+		case r == '_', r == '$', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return strconv.Quote(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
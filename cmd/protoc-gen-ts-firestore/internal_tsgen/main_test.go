@@ -0,0 +1,121 @@
+package internal_tsgen_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daviddomkar/protofirestore"
+	tsgen "github.com/daviddomkar/protofirestore/cmd/protoc-gen-ts-firestore/internal_tsgen"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	_ "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	_ "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+)
+
+// TestGeneratedFilesAreUpToDate generates the code of the test protos and
+// compares it with the golden files in testdata.
+func TestGeneratedFilesAreUpToDate(t *testing.T) {
+	files := []string{
+		"internal/testprotos/annotatedpb/test.proto",
+		"internal/testprotos/textpb3/test.proto",
+	}
+
+	tests := []struct {
+		dir  string
+		opts tsgen.Options
+	}{{
+		dir: "default",
+	}, {
+		dir: "emit_defaults",
+		opts: tsgen.Options{
+			MarshalOptions: protofirestore.MarshalOptions{EmitFirestoreSensibleDefaults: true},
+		},
+	}, {
+		dir: "emit_type",
+		opts: tsgen.Options{
+			MarshalOptions: protofirestore.MarshalOptions{EmitType: true},
+		},
+	}, {
+		dir: "emit_oneof_case",
+		opts: tsgen.Options{
+			MarshalOptions: protofirestore.MarshalOptions{EmitOneofCase: true},
+		},
+	}}
+
+	req := &pluginpb.CodeGeneratorRequest{FileToGenerate: files}
+
+	seen := make(map[string]bool)
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(fd))
+	}
+
+	for _, file := range files {
+		fd, err := protoregistry.GlobalFiles.FindFileByPath(file)
+		if err != nil {
+			t.Fatalf("FindFileByPath(%q) returned error: %v\n", file, err)
+		}
+		add(fd)
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.dir, func(t *testing.T) {
+			gen, err := protogen.Options{}.New(req)
+			if err != nil {
+				t.Fatalf("protogen.Options.New() returned error: %v\n", err)
+			}
+
+			for _, f := range gen.Files {
+				if !f.Generate {
+					continue
+				}
+
+				g, err := tt.opts.GenerateFile(gen, f)
+				if err != nil {
+					t.Fatalf("GenerateFile(%q) returned error: %v\n", f.Desc.Path(), err)
+				}
+
+				got, err := g.Content()
+				if err != nil {
+					t.Fatalf("Content() returned error: %v\n", err)
+				}
+
+				name := filepath.Join("testdata", tt.dir, filepath.FromSlash(f.Desc.Path()[:len(f.Desc.Path())-len(".proto")]+"_firestore.ts"))
+				if os.Getenv("UPDATE_GOLDEN") != "" {
+					if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(name, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+
+				want, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatalf("ReadFile(%q) returned error: %v\n", name, err)
+				}
+
+				if string(got) != string(want) {
+					t.Errorf("%v is not up to date, regenerate it with UPDATE_GOLDEN=1 go test\n", name)
+				}
+			}
+		})
+	}
+}
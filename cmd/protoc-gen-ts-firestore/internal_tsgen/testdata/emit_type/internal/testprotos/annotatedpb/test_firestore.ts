@@ -0,0 +1,479 @@
+// Code generated by protoc-gen-ts-firestore. DO NOT EDIT.
+// source: internal/testprotos/annotatedpb/test.proto
+
+import {
+  Bytes,
+  DocumentData,
+  FirestoreDataConverter,
+  QueryDocumentSnapshot,
+  SnapshotOptions,
+  Timestamp,
+} from "firebase/firestore";
+
+export const Order_State = {
+  STATE_UNSPECIFIED: 0,
+  OPEN: 1,
+  SHIPPED: 2,
+} as const;
+
+export type Order_State = keyof typeof Order_State;
+
+// decodeOrder_State decodes a stored annotated.Order.State value,
+// which is the name of the enum value or its number.
+export function decodeOrder_State(value: unknown): Order_State {
+  for (const [name, number] of Object.entries(Order_State)) {
+    if (value === name || value === number) {
+      return name as Order_State;
+    }
+  }
+  throw new Error(`invalid annotated.Order.State value ${String(value)}`);
+}
+
+export interface Counters {
+  name: string;
+  tags: string[];
+  scores: number[];
+  likes: number;
+  views: number;
+  shares: number;
+  rating: number;
+  version: number;
+  child?: Counters;
+  totals: { [key: string]: number };
+  children: Counters[];
+}
+
+// encodeCounters encodes the Counters message into a firestore document.
+export function encodeCounters(message: Counters): DocumentData {
+  const data: DocumentData = {};
+  if (message.name !== "") {
+    data.name = message.name;
+  }
+  if (message.tags.length > 0) {
+    data.tags = message.tags.map((e) => (e !== "" ? e : null));
+  }
+  if (message.scores.length > 0) {
+    data.scores = message.scores;
+  }
+  if (message.likes !== 0) {
+    data.likes = message.likes;
+  }
+  if (message.views !== 0) {
+    data.views = message.views;
+  }
+  if (message.shares !== 0) {
+    data.shares = message.shares;
+  }
+  if (!Object.is(message.rating, 0)) {
+    data.rating = message.rating;
+  }
+  if (message.version !== 0) {
+    data.version = message.version;
+  }
+  if (message.child !== undefined) {
+    const encoded = encodeCounters(message.child);
+    if (Object.keys(encoded).length > 0) {
+      data.child = encoded;
+    }
+  }
+  if (Object.keys(message.totals).length > 0) {
+    data.totals = message.totals;
+  }
+  if (message.children.length > 0) {
+    data.children = message.children.map((e) => nonEmpty(encodeCounters(e)));
+  }
+  return data;
+}
+
+// decodeCounters decodes a firestore document into a Counters message.
+export function decodeCounters(data: DocumentData): Counters {
+  return {
+    name: data.name ?? "",
+    tags: (data.tags ?? []).map((e: string | null) => e ?? ""),
+    scores: data.scores ?? [],
+    likes: data.likes ?? 0,
+    views: data.views ?? 0,
+    shares: data.shares ?? 0,
+    rating: data.rating ?? 0,
+    version: data.version ?? 0,
+    child: data.child != null ? decodeCounters(data.child) : undefined,
+    totals: decodeMap(data.totals, (e: number | null) => e ?? 0),
+    children: (data.children ?? []).map((e: DocumentData | null) => decodeCounters(e ?? {})),
+  };
+}
+
+// CountersConverter converts Counters messages to and from firestore documents.
+export const CountersConverter: FirestoreDataConverter<Counters> = {
+  toFirestore(message: Counters): DocumentData {
+    return { ...encodeCounters(message), "@type": "annotated.Counters" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Counters {
+    return decodeCounters(snapshot.data(options));
+  },
+};
+
+export interface Order {
+  name: string;
+  state: Order_State;
+  createTime?: Timestamp;
+  tags: string[];
+  total: number;
+  notes: string;
+  address?: Address;
+  stops: Address[];
+}
+
+// encodeOrder encodes the Order message into a firestore document.
+export function encodeOrder(message: Order): DocumentData {
+  const data: DocumentData = {};
+  if (message.name !== "") {
+    data.name = message.name;
+  }
+  if (Order_State[message.state] !== 0) {
+    data.state = message.state;
+  }
+  if (message.createTime !== undefined) {
+    data.createTime = message.createTime;
+  }
+  if (message.tags.length > 0) {
+    data.tags = message.tags.map((e) => (e !== "" ? e : null));
+  }
+  if (!Object.is(message.total, 0)) {
+    data.total = message.total;
+  }
+  if (message.notes !== "") {
+    data.notes = message.notes;
+  }
+  if (message.address !== undefined) {
+    const encoded = encodeAddress(message.address);
+    if (Object.keys(encoded).length > 0) {
+      data.address = encoded;
+    }
+  }
+  if (message.stops.length > 0) {
+    data.stops = message.stops.map((e) => nonEmpty(encodeAddress(e)));
+  }
+  return data;
+}
+
+// decodeOrder decodes a firestore document into a Order message.
+export function decodeOrder(data: DocumentData): Order {
+  return {
+    name: data.name ?? "",
+    state: data.state != null ? decodeOrder_State(data.state) : "STATE_UNSPECIFIED",
+    createTime: data.createTime ?? undefined,
+    tags: (data.tags ?? []).map((e: string | null) => e ?? ""),
+    total: data.total ?? 0,
+    notes: data.notes ?? "",
+    address: data.address != null ? decodeAddress(data.address) : undefined,
+    stops: (data.stops ?? []).map((e: DocumentData | null) => decodeAddress(e ?? {})),
+  };
+}
+
+// OrderConverter converts Order messages to and from firestore documents.
+export const OrderConverter: FirestoreDataConverter<Order> = {
+  toFirestore(message: Order): DocumentData {
+    return { ...encodeOrder(message), "@type": "annotated.Order" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Order {
+    return decodeOrder(snapshot.data(options));
+  },
+};
+
+export interface Address {
+  street: string;
+  instructions: string;
+}
+
+// encodeAddress encodes the Address message into a firestore document.
+export function encodeAddress(message: Address): DocumentData {
+  const data: DocumentData = {};
+  if (message.street !== "") {
+    data.street = message.street;
+  }
+  if (message.instructions !== "") {
+    data.instructions = message.instructions;
+  }
+  return data;
+}
+
+// decodeAddress decodes a firestore document into a Address message.
+export function decodeAddress(data: DocumentData): Address {
+  return {
+    street: data.street ?? "",
+    instructions: data.instructions ?? "",
+  };
+}
+
+// AddressConverter converts Address messages to and from firestore documents.
+export const AddressConverter: FirestoreDataConverter<Address> = {
+  toFirestore(message: Address): DocumentData {
+    return { ...encodeAddress(message), "@type": "annotated.Address" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Address {
+    return decodeAddress(snapshot.data(options));
+  },
+};
+
+export interface User {
+  name: string;
+  avatar: Bytes;
+  home?: Address;
+}
+
+// encodeUser encodes the User message into a firestore document.
+export function encodeUser(message: User): DocumentData {
+  const data: DocumentData = {};
+  if (message.name !== "") {
+    data.name = message.name;
+  }
+  if (message.avatar.toUint8Array().length > 0) {
+    data.avatar = message.avatar;
+  }
+  if (message.home !== undefined) {
+    const encoded = encodeAddress(message.home);
+    if (Object.keys(encoded).length > 0) {
+      data.home = encoded;
+    }
+  }
+  return data;
+}
+
+// decodeUser decodes a firestore document into a User message.
+export function decodeUser(data: DocumentData): User {
+  return {
+    name: data.name ?? "",
+    avatar: data.avatar ?? Bytes.fromUint8Array(new Uint8Array()),
+    home: data.home != null ? decodeAddress(data.home) : undefined,
+  };
+}
+
+// UserConverter converts User messages to and from firestore documents.
+export const UserConverter: FirestoreDataConverter<User> = {
+  toFirestore(message: User): DocumentData {
+    return { ...encodeUser(message), "@type": "annotated.User" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): User {
+    return decodeUser(snapshot.data(options));
+  },
+};
+
+export interface Article {
+  title: string;
+  etag: string;
+}
+
+// encodeArticle encodes the Article message into a firestore document.
+export function encodeArticle(message: Article): DocumentData {
+  const data: DocumentData = {};
+  if (message.title !== "") {
+    data.title = message.title;
+  }
+  if (message.etag !== "") {
+    data.etag = message.etag;
+  }
+  return data;
+}
+
+// decodeArticle decodes a firestore document into a Article message.
+export function decodeArticle(data: DocumentData): Article {
+  return {
+    title: data.title ?? "",
+    etag: data.etag ?? "",
+  };
+}
+
+// ArticleConverter converts Article messages to and from firestore documents.
+export const ArticleConverter: FirestoreDataConverter<Article> = {
+  toFirestore(message: Article): DocumentData {
+    return { ...encodeArticle(message), "@type": "annotated.Article" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Article {
+    return decodeArticle(snapshot.data(options));
+  },
+};
+
+export interface Draft {
+  title: string;
+  etag: string;
+}
+
+// encodeDraft encodes the Draft message into a firestore document.
+export function encodeDraft(message: Draft): DocumentData {
+  const data: DocumentData = {};
+  if (message.title !== "") {
+    data.title = message.title;
+  }
+  if (message.etag !== "") {
+    data.etag = message.etag;
+  }
+  return data;
+}
+
+// decodeDraft decodes a firestore document into a Draft message.
+export function decodeDraft(data: DocumentData): Draft {
+  return {
+    title: data.title ?? "",
+    etag: data.etag ?? "",
+  };
+}
+
+// DraftConverter converts Draft messages to and from firestore documents.
+export const DraftConverter: FirestoreDataConverter<Draft> = {
+  toFirestore(message: Draft): DocumentData {
+    return { ...encodeDraft(message), "@type": "annotated.Draft" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Draft {
+    return decodeDraft(snapshot.data(options));
+  },
+};
+
+export interface Book {
+  title: string;
+  deleteTime?: Timestamp;
+  purgeTime?: Timestamp;
+}
+
+// encodeBook encodes the Book message into a firestore document.
+export function encodeBook(message: Book): DocumentData {
+  const data: DocumentData = {};
+  if (message.title !== "") {
+    data.title = message.title;
+  }
+  if (message.deleteTime !== undefined) {
+    data.deleteTime = message.deleteTime;
+  }
+  if (message.purgeTime !== undefined) {
+    data.purgeTime = message.purgeTime;
+  }
+  return data;
+}
+
+// decodeBook decodes a firestore document into a Book message.
+export function decodeBook(data: DocumentData): Book {
+  return {
+    title: data.title ?? "",
+    deleteTime: data.deleteTime ?? undefined,
+    purgeTime: data.purgeTime ?? undefined,
+  };
+}
+
+// BookConverter converts Book messages to and from firestore documents.
+export const BookConverter: FirestoreDataConverter<Book> = {
+  toFirestore(message: Book): DocumentData {
+    return { ...encodeBook(message), "@type": "annotated.Book" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Book {
+    return decodeBook(snapshot.data(options));
+  },
+};
+
+export interface Comment {
+  text: string;
+}
+
+// encodeComment encodes the Comment message into a firestore document.
+export function encodeComment(message: Comment): DocumentData {
+  const data: DocumentData = {};
+  if (message.text !== "") {
+    data.text = message.text;
+  }
+  return data;
+}
+
+// decodeComment decodes a firestore document into a Comment message.
+export function decodeComment(data: DocumentData): Comment {
+  return {
+    text: data.text ?? "",
+  };
+}
+
+// CommentConverter converts Comment messages to and from firestore documents.
+export const CommentConverter: FirestoreDataConverter<Comment> = {
+  toFirestore(message: Comment): DocumentData {
+    return { ...encodeComment(message), "@type": "annotated.Comment" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Comment {
+    return decodeComment(snapshot.data(options));
+  },
+};
+
+export interface Post {
+  title: string;
+}
+
+// encodePost encodes the Post message into a firestore document.
+export function encodePost(message: Post): DocumentData {
+  const data: DocumentData = {};
+  if (message.title !== "") {
+    data.title = message.title;
+  }
+  return data;
+}
+
+// decodePost decodes a firestore document into a Post message.
+export function decodePost(data: DocumentData): Post {
+  return {
+    title: data.title ?? "",
+  };
+}
+
+// PostConverter converts Post messages to and from firestore documents.
+export const PostConverter: FirestoreDataConverter<Post> = {
+  toFirestore(message: Post): DocumentData {
+    return { ...encodePost(message), "@type": "annotated.Post" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Post {
+    return decodePost(snapshot.data(options));
+  },
+};
+
+export interface Thread {
+  title: string;
+  deleteTime?: Timestamp;
+}
+
+// encodeThread encodes the Thread message into a firestore document.
+export function encodeThread(message: Thread): DocumentData {
+  const data: DocumentData = {};
+  if (message.title !== "") {
+    data.title = message.title;
+  }
+  if (message.deleteTime !== undefined) {
+    data.deleteTime = message.deleteTime;
+  }
+  return data;
+}
+
+// decodeThread decodes a firestore document into a Thread message.
+export function decodeThread(data: DocumentData): Thread {
+  return {
+    title: data.title ?? "",
+    deleteTime: data.deleteTime ?? undefined,
+  };
+}
+
+// ThreadConverter converts Thread messages to and from firestore documents.
+export const ThreadConverter: FirestoreDataConverter<Thread> = {
+  toFirestore(message: Thread): DocumentData {
+    return { ...encodeThread(message), "@type": "annotated.Thread" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Thread {
+    return decodeThread(snapshot.data(options));
+  },
+};
+
+// nonEmpty returns data unless it is empty, in which case it returns null.
+function nonEmpty(data: DocumentData): DocumentData | null {
+  return Object.keys(data).length > 0 ? data : null;
+}
+
+// decodeMap decodes the values of a stored map.
+function decodeMap<T>(data: DocumentData | null | undefined, decode: (value: any) => T): { [key: string]: T } {
+  const map: { [key: string]: T } = {};
+  for (const [key, value] of Object.entries(data ?? {})) {
+    map[key] = decode(value);
+  }
+  return map;
+}
+
@@ -0,0 +1,559 @@
+// Code generated by protoc-gen-ts-firestore. DO NOT EDIT.
+// source: internal/testprotos/textpb3/test.proto
+
+import {
+  Bytes,
+  DocumentData,
+  FirestoreDataConverter,
+  QueryDocumentSnapshot,
+  SnapshotOptions,
+} from "firebase/firestore";
+
+export const Enum = {
+  ZERO: 0,
+  ONE: 1,
+  TWO: 2,
+  TEN: 10,
+} as const;
+
+export type Enum = keyof typeof Enum;
+
+// decodeEnum decodes a stored pb3.Enum value,
+// which is the name of the enum value or its number.
+export function decodeEnum(value: unknown): Enum {
+  for (const [name, number] of Object.entries(Enum)) {
+    if (value === name || value === number) {
+      return name as Enum;
+    }
+  }
+  throw new Error(`invalid pb3.Enum value ${String(value)}`);
+}
+
+export const Enums_NestedEnum = {
+  CERO: 0,
+  UNO: 1,
+  DOS: 2,
+  DIEZ: 10,
+} as const;
+
+export type Enums_NestedEnum = keyof typeof Enums_NestedEnum;
+
+// decodeEnums_NestedEnum decodes a stored pb3.Enums.NestedEnum value,
+// which is the name of the enum value or its number.
+export function decodeEnums_NestedEnum(value: unknown): Enums_NestedEnum {
+  for (const [name, number] of Object.entries(Enums_NestedEnum)) {
+    if (value === name || value === number) {
+      return name as Enums_NestedEnum;
+    }
+  }
+  throw new Error(`invalid pb3.Enums.NestedEnum value ${String(value)}`);
+}
+
+export interface Scalars {
+  sBool: boolean;
+  sInt32: number;
+  sInt64: number;
+  sUint32: number;
+  sUint64: number;
+  sSint32: number;
+  sSint64: number;
+  sFixed32: number;
+  sFixed64: number;
+  sSfixed32: number;
+  sSfixed64: number;
+  sFloat: number;
+  sDouble: number;
+  sBytes: Bytes;
+  sString: string;
+}
+
+// encodeScalars encodes the Scalars message into a firestore document.
+export function encodeScalars(message: Scalars): DocumentData {
+  const data: DocumentData = {};
+  if (message.sBool) {
+    data.sBool = message.sBool;
+  }
+  if (message.sInt32 !== 0) {
+    data.sInt32 = message.sInt32;
+  }
+  if (message.sInt64 !== 0) {
+    data.sInt64 = message.sInt64;
+  }
+  if (message.sUint32 !== 0) {
+    data.sUint32 = message.sUint32;
+  }
+  if (message.sUint64 !== 0) {
+    data.sUint64 = message.sUint64;
+  }
+  if (message.sSint32 !== 0) {
+    data.sSint32 = message.sSint32;
+  }
+  if (message.sSint64 !== 0) {
+    data.sSint64 = message.sSint64;
+  }
+  if (message.sFixed32 !== 0) {
+    data.sFixed32 = message.sFixed32;
+  }
+  if (message.sFixed64 !== 0) {
+    data.sFixed64 = message.sFixed64;
+  }
+  if (message.sSfixed32 !== 0) {
+    data.sSfixed32 = message.sSfixed32;
+  }
+  if (message.sSfixed64 !== 0) {
+    data.sSfixed64 = message.sSfixed64;
+  }
+  if (!Object.is(message.sFloat, 0)) {
+    data.sFloat = message.sFloat;
+  }
+  if (!Object.is(message.sDouble, 0)) {
+    data.sDouble = message.sDouble;
+  }
+  if (message.sBytes.toUint8Array().length > 0) {
+    data.sBytes = message.sBytes;
+  }
+  if (message.sString !== "") {
+    data.sString = message.sString;
+  }
+  return data;
+}
+
+// decodeScalars decodes a firestore document into a Scalars message.
+export function decodeScalars(data: DocumentData): Scalars {
+  return {
+    sBool: data.sBool ?? false,
+    sInt32: data.sInt32 ?? 0,
+    sInt64: data.sInt64 ?? 0,
+    sUint32: data.sUint32 ?? 0,
+    sUint64: data.sUint64 ?? 0,
+    sSint32: data.sSint32 ?? 0,
+    sSint64: data.sSint64 ?? 0,
+    sFixed32: data.sFixed32 ?? 0,
+    sFixed64: data.sFixed64 ?? 0,
+    sSfixed32: data.sSfixed32 ?? 0,
+    sSfixed64: data.sSfixed64 ?? 0,
+    sFloat: data.sFloat ?? 0,
+    sDouble: data.sDouble ?? 0,
+    sBytes: data.sBytes ?? Bytes.fromUint8Array(new Uint8Array()),
+    sString: data.sString ?? "",
+  };
+}
+
+// ScalarsConverter converts Scalars messages to and from firestore documents.
+export const ScalarsConverter: FirestoreDataConverter<Scalars> = {
+  toFirestore(message: Scalars): DocumentData {
+    return { ...encodeScalars(message), "@type": "pb3.Scalars" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Scalars {
+    return decodeScalars(snapshot.data(options));
+  },
+};
+
+export interface Repeats {
+  rptBool: boolean[];
+  rptInt32: number[];
+  rptInt64: number[];
+  rptUint32: number[];
+  rptUint64: number[];
+  rptFloat: number[];
+  rptDouble: number[];
+  rptString: string[];
+  rptBytes: Bytes[];
+}
+
+// encodeRepeats encodes the Repeats message into a firestore document.
+export function encodeRepeats(message: Repeats): DocumentData {
+  const data: DocumentData = {};
+  if (message.rptBool.length > 0) {
+    data.rptBool = message.rptBool;
+  }
+  if (message.rptInt32.length > 0) {
+    data.rptInt32 = message.rptInt32;
+  }
+  if (message.rptInt64.length > 0) {
+    data.rptInt64 = message.rptInt64;
+  }
+  if (message.rptUint32.length > 0) {
+    data.rptUint32 = message.rptUint32;
+  }
+  if (message.rptUint64.length > 0) {
+    data.rptUint64 = message.rptUint64;
+  }
+  if (message.rptFloat.length > 0) {
+    data.rptFloat = message.rptFloat;
+  }
+  if (message.rptDouble.length > 0) {
+    data.rptDouble = message.rptDouble;
+  }
+  if (message.rptString.length > 0) {
+    data.rptString = message.rptString.map((e) => (e !== "" ? e : null));
+  }
+  if (message.rptBytes.length > 0) {
+    data.rptBytes = message.rptBytes.map((e) => (e.toUint8Array().length > 0 ? e : null));
+  }
+  return data;
+}
+
+// decodeRepeats decodes a firestore document into a Repeats message.
+export function decodeRepeats(data: DocumentData): Repeats {
+  return {
+    rptBool: data.rptBool ?? [],
+    rptInt32: data.rptInt32 ?? [],
+    rptInt64: data.rptInt64 ?? [],
+    rptUint32: data.rptUint32 ?? [],
+    rptUint64: data.rptUint64 ?? [],
+    rptFloat: data.rptFloat ?? [],
+    rptDouble: data.rptDouble ?? [],
+    rptString: (data.rptString ?? []).map((e: string | null) => e ?? ""),
+    rptBytes: (data.rptBytes ?? []).map((e: Bytes | null) => e ?? Bytes.fromUint8Array(new Uint8Array())),
+  };
+}
+
+// RepeatsConverter converts Repeats messages to and from firestore documents.
+export const RepeatsConverter: FirestoreDataConverter<Repeats> = {
+  toFirestore(message: Repeats): DocumentData {
+    return { ...encodeRepeats(message), "@type": "pb3.Repeats" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Repeats {
+    return decodeRepeats(snapshot.data(options));
+  },
+};
+
+export interface Proto3Optional {
+  optBool?: boolean;
+  optInt32?: number;
+  optInt64?: number;
+  optUint32?: number;
+  optUint64?: number;
+  optFloat?: number;
+  optDouble?: number;
+  optString?: string;
+  optBytes?: Bytes;
+  optEnum?: Enum;
+  optMessage?: Nested;
+}
+
+// encodeProto3Optional encodes the Proto3Optional message into a firestore document.
+export function encodeProto3Optional(message: Proto3Optional): DocumentData {
+  const data: DocumentData = {};
+  if (message.optBool !== undefined) {
+    data.optBool = message.optBool;
+  }
+  if (message.optInt32 !== undefined) {
+    data.optInt32 = message.optInt32;
+  }
+  if (message.optInt64 !== undefined) {
+    data.optInt64 = message.optInt64;
+  }
+  if (message.optUint32 !== undefined) {
+    data.optUint32 = message.optUint32;
+  }
+  if (message.optUint64 !== undefined) {
+    data.optUint64 = message.optUint64;
+  }
+  if (message.optFloat !== undefined) {
+    data.optFloat = message.optFloat;
+  }
+  if (message.optDouble !== undefined) {
+    data.optDouble = message.optDouble;
+  }
+  if (message.optString !== undefined && message.optString !== "") {
+    data.optString = message.optString;
+  }
+  if (message.optBytes !== undefined && message.optBytes.toUint8Array().length > 0) {
+    data.optBytes = message.optBytes;
+  }
+  if (message.optEnum !== undefined) {
+    data.optEnum = message.optEnum;
+  }
+  if (message.optMessage !== undefined) {
+    const encoded = encodeNested(message.optMessage);
+    if (Object.keys(encoded).length > 0) {
+      data.optMessage = encoded;
+    }
+  }
+  return data;
+}
+
+// decodeProto3Optional decodes a firestore document into a Proto3Optional message.
+export function decodeProto3Optional(data: DocumentData): Proto3Optional {
+  return {
+    optBool: data.optBool ?? undefined,
+    optInt32: data.optInt32 ?? undefined,
+    optInt64: data.optInt64 ?? undefined,
+    optUint32: data.optUint32 ?? undefined,
+    optUint64: data.optUint64 ?? undefined,
+    optFloat: data.optFloat ?? undefined,
+    optDouble: data.optDouble ?? undefined,
+    optString: data.optString ?? undefined,
+    optBytes: data.optBytes ?? undefined,
+    optEnum: data.optEnum != null ? decodeEnum(data.optEnum) : undefined,
+    optMessage: data.optMessage != null ? decodeNested(data.optMessage) : undefined,
+  };
+}
+
+// Proto3OptionalConverter converts Proto3Optional messages to and from firestore documents.
+export const Proto3OptionalConverter: FirestoreDataConverter<Proto3Optional> = {
+  toFirestore(message: Proto3Optional): DocumentData {
+    return { ...encodeProto3Optional(message), "@type": "pb3.Proto3Optional" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Proto3Optional {
+    return decodeProto3Optional(snapshot.data(options));
+  },
+};
+
+export interface Enums {
+  sEnum: Enum;
+  sNestedEnum: Enums_NestedEnum;
+}
+
+// encodeEnums encodes the Enums message into a firestore document.
+export function encodeEnums(message: Enums): DocumentData {
+  const data: DocumentData = {};
+  if (Enum[message.sEnum] !== 0) {
+    data.sEnum = message.sEnum;
+  }
+  if (Enums_NestedEnum[message.sNestedEnum] !== 0) {
+    data.sNestedEnum = message.sNestedEnum;
+  }
+  return data;
+}
+
+// decodeEnums decodes a firestore document into a Enums message.
+export function decodeEnums(data: DocumentData): Enums {
+  return {
+    sEnum: data.sEnum != null ? decodeEnum(data.sEnum) : "ZERO",
+    sNestedEnum: data.sNestedEnum != null ? decodeEnums_NestedEnum(data.sNestedEnum) : "CERO",
+  };
+}
+
+// EnumsConverter converts Enums messages to and from firestore documents.
+export const EnumsConverter: FirestoreDataConverter<Enums> = {
+  toFirestore(message: Enums): DocumentData {
+    return { ...encodeEnums(message), "@type": "pb3.Enums" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Enums {
+    return decodeEnums(snapshot.data(options));
+  },
+};
+
+export interface Nests {
+  sNested?: Nested;
+}
+
+// encodeNests encodes the Nests message into a firestore document.
+export function encodeNests(message: Nests): DocumentData {
+  const data: DocumentData = {};
+  if (message.sNested !== undefined) {
+    const encoded = encodeNested(message.sNested);
+    if (Object.keys(encoded).length > 0) {
+      data.sNested = encoded;
+    }
+  }
+  return data;
+}
+
+// decodeNests decodes a firestore document into a Nests message.
+export function decodeNests(data: DocumentData): Nests {
+  return {
+    sNested: data.sNested != null ? decodeNested(data.sNested) : undefined,
+  };
+}
+
+// NestsConverter converts Nests messages to and from firestore documents.
+export const NestsConverter: FirestoreDataConverter<Nests> = {
+  toFirestore(message: Nests): DocumentData {
+    return { ...encodeNests(message), "@type": "pb3.Nests" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Nests {
+    return decodeNests(snapshot.data(options));
+  },
+};
+
+export interface Nested {
+  sString: string;
+  sNested?: Nested;
+}
+
+// encodeNested encodes the Nested message into a firestore document.
+export function encodeNested(message: Nested): DocumentData {
+  const data: DocumentData = {};
+  if (message.sString !== "") {
+    data.sString = message.sString;
+  }
+  if (message.sNested !== undefined) {
+    const encoded = encodeNested(message.sNested);
+    if (Object.keys(encoded).length > 0) {
+      data.sNested = encoded;
+    }
+  }
+  return data;
+}
+
+// decodeNested decodes a firestore document into a Nested message.
+export function decodeNested(data: DocumentData): Nested {
+  return {
+    sString: data.sString ?? "",
+    sNested: data.sNested != null ? decodeNested(data.sNested) : undefined,
+  };
+}
+
+// NestedConverter converts Nested messages to and from firestore documents.
+export const NestedConverter: FirestoreDataConverter<Nested> = {
+  toFirestore(message: Nested): DocumentData {
+    return { ...encodeNested(message), "@type": "pb3.Nested" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Nested {
+    return decodeNested(snapshot.data(options));
+  },
+};
+
+export interface Oneofs {
+  oneofEnum?: Enum;
+  oneofString?: string;
+  oneofNested?: Nested;
+}
+
+// encodeOneofs encodes the Oneofs message into a firestore document.
+export function encodeOneofs(message: Oneofs): DocumentData {
+  const data: DocumentData = {};
+  if (message.oneofEnum !== undefined) {
+    data.oneofEnum = message.oneofEnum;
+  }
+  if (message.oneofString !== undefined && message.oneofString !== "") {
+    data.oneofString = message.oneofString;
+  }
+  if (message.oneofNested !== undefined) {
+    const encoded = encodeNested(message.oneofNested);
+    if (Object.keys(encoded).length > 0) {
+      data.oneofNested = encoded;
+    }
+  }
+  return data;
+}
+
+// decodeOneofs decodes a firestore document into a Oneofs message.
+export function decodeOneofs(data: DocumentData): Oneofs {
+  return {
+    oneofEnum: data.oneofEnum != null ? decodeEnum(data.oneofEnum) : undefined,
+    oneofString: data.oneofString ?? undefined,
+    oneofNested: data.oneofNested != null ? decodeNested(data.oneofNested) : undefined,
+  };
+}
+
+// OneofsConverter converts Oneofs messages to and from firestore documents.
+export const OneofsConverter: FirestoreDataConverter<Oneofs> = {
+  toFirestore(message: Oneofs): DocumentData {
+    return { ...encodeOneofs(message), "@type": "pb3.Oneofs" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Oneofs {
+    return decodeOneofs(snapshot.data(options));
+  },
+};
+
+export interface Maps {
+  int32ToStr: { [key: string]: string };
+  boolToUint32: { [key: string]: number };
+  uint64ToEnum: { [key: string]: Enum };
+  strToNested: { [key: string]: Nested };
+  strToOneofs: { [key: string]: Oneofs };
+}
+
+// encodeMaps encodes the Maps message into a firestore document.
+export function encodeMaps(message: Maps): DocumentData {
+  const data: DocumentData = {};
+  if (Object.keys(message.int32ToStr).length > 0) {
+    data.int32ToStr = encodeMap(message.int32ToStr, (e) => (e !== "" ? e : null));
+  }
+  if (Object.keys(message.boolToUint32).length > 0) {
+    data.boolToUint32 = message.boolToUint32;
+  }
+  if (Object.keys(message.uint64ToEnum).length > 0) {
+    data.uint64ToEnum = message.uint64ToEnum;
+  }
+  if (Object.keys(message.strToNested).length > 0) {
+    data.strToNested = encodeMap(message.strToNested, (e) => nonEmpty(encodeNested(e)));
+  }
+  if (Object.keys(message.strToOneofs).length > 0) {
+    data.strToOneofs = encodeMap(message.strToOneofs, (e) => nonEmpty(encodeOneofs(e)));
+  }
+  return data;
+}
+
+// decodeMaps decodes a firestore document into a Maps message.
+export function decodeMaps(data: DocumentData): Maps {
+  return {
+    int32ToStr: decodeMap(data.int32ToStr, (e: string | null) => e ?? ""),
+    boolToUint32: decodeMap(data.boolToUint32, (e: number | null) => e ?? 0),
+    uint64ToEnum: decodeMap(data.uint64ToEnum, (e: unknown) => (e != null ? decodeEnum(e) : "ZERO")),
+    strToNested: decodeMap(data.strToNested, (e: DocumentData | null) => decodeNested(e ?? {})),
+    strToOneofs: decodeMap(data.strToOneofs, (e: DocumentData | null) => decodeOneofs(e ?? {})),
+  };
+}
+
+// MapsConverter converts Maps messages to and from firestore documents.
+export const MapsConverter: FirestoreDataConverter<Maps> = {
+  toFirestore(message: Maps): DocumentData {
+    return { ...encodeMaps(message), "@type": "pb3.Maps" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): Maps {
+    return decodeMaps(snapshot.data(options));
+  },
+};
+
+export interface JSONNames {
+  foo_bar: string;
+}
+
+// encodeJSONNames encodes the JSONNames message into a firestore document.
+export function encodeJSONNames(message: JSONNames): DocumentData {
+  const data: DocumentData = {};
+  if (message.foo_bar !== "") {
+    data.foo_bar = message.foo_bar;
+  }
+  return data;
+}
+
+// decodeJSONNames decodes a firestore document into a JSONNames message.
+export function decodeJSONNames(data: DocumentData): JSONNames {
+  return {
+    foo_bar: data.foo_bar ?? "",
+  };
+}
+
+// JSONNamesConverter converts JSONNames messages to and from firestore documents.
+export const JSONNamesConverter: FirestoreDataConverter<JSONNames> = {
+  toFirestore(message: JSONNames): DocumentData {
+    return { ...encodeJSONNames(message), "@type": "pb3.JSONNames" };
+  },
+  fromFirestore(snapshot: QueryDocumentSnapshot, options?: SnapshotOptions): JSONNames {
+    return decodeJSONNames(snapshot.data(options));
+  },
+};
+
+// nonEmpty returns data unless it is empty, in which case it returns null.
+function nonEmpty(data: DocumentData): DocumentData | null {
+  return Object.keys(data).length > 0 ? data : null;
+}
+
+// encodeMap encodes the values of map, omitting values encoded to null.
+function encodeMap<T>(map: { [key: string]: T }, encode: (value: T) => unknown): DocumentData {
+  const data: DocumentData = {};
+  for (const [key, value] of Object.entries(map)) {
+    const encoded = encode(value);
+    if (encoded !== null) {
+      data[key] = encoded;
+    }
+  }
+  return data;
+}
+
+// decodeMap decodes the values of a stored map.
+function decodeMap<T>(data: DocumentData | null | undefined, decode: (value: any) => T): { [key: string]: T } {
+  const map: { [key: string]: T } = {};
+  for (const [key, value] of Object.entries(data ?? {})) {
+    map[key] = decode(value);
+  }
+  return map;
+}
+
@@ -0,0 +1,56 @@
+// The protoc-gen-ts-firestore binary is a protoc plugin generating TypeScript
+// code for the Firebase JS SDK, which reads and writes the same documents as
+// the protofirestore package:
+//
+//	protoc --ts-firestore_out=. *.proto
+//
+// For every proto file it generates a file named after the proto file with a
+// _firestore.ts suffix. Enums are generated as objects mapping the names of
+// their values, which documents store, to their numbers. Messages are
+// generated as interfaces whose properties are named like the keys of their
+// documents, along with functions encoding and decoding documents and a
+// FirestoreDataConverter. Integers, including 64-bit integers, are numbers
+// like in the SDK, timestamps are Timestamps and bytes are Bytes.
+//
+// Only proto3 messages are supported. The emit_defaults parameter makes the
+// generated code encode documents like EmitFirestoreSensibleDefaults does:
+//
+//	protoc --ts-firestore_out=emit_defaults=true:. *.proto
+//
+// The emit_type parameter makes the converters store the type of messages
+// like EmitType does, and the emit_oneof_case parameter makes the generated
+// code store the cases of oneofs like EmitOneofCase does.
+package main
+
+import (
+	"flag"
+
+	tsgen "github.com/daviddomkar/protofirestore/cmd/protoc-gen-ts-firestore/internal_tsgen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+func main() {
+	var flags flag.FlagSet
+	emitDefaults := flags.Bool("emit_defaults", false, "encode documents with EmitFirestoreSensibleDefaults")
+	emitType := flags.Bool("emit_type", false, "encode documents with EmitType")
+	emitOneofCase := flags.Bool("emit_oneof_case", false, "encode documents with EmitOneofCase")
+
+	protogen.Options{
+		ParamFunc: flags.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		opts := tsgen.Options{}
+		opts.MarshalOptions.EmitFirestoreSensibleDefaults = *emitDefaults
+		opts.MarshalOptions.EmitType = *emitType
+		opts.MarshalOptions.EmitOneofCase = *emitOneofCase
+
+		for _, f := range gen.Files {
+			if f.Generate {
+				if _, err := opts.GenerateFile(gen, f); err != nil {
+					return err
+				}
+			}
+		}
+		gen.SupportedFeatures = tsgen.SupportedFeatures
+		return nil
+	})
+}
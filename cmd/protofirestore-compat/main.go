@@ -0,0 +1,83 @@
+// The protofirestore-compat command reports the changes between two
+// revisions of proto definitions which break reading documents stored by
+// the protofirestore package. The revisions are descriptor sets including
+// their imports, as written by protoc:
+//
+//	protoc --include_imports --descriptor_set_out=new.pb *.proto
+//	protofirestore-compat -old=old.pb -new=new.pb
+//
+// The command exits with status 1 if it reports changes, which makes it
+// suitable for running in CI before deploying schema changes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/daviddomkar/protofirestore/compat"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func main() {
+	oldFile := flag.String("old", "", "descriptor set of the deployed revision")
+	newFile := flag.String("new", "", "descriptor set of the new revision")
+	emitDefaults := flag.Bool("emit_defaults", false, "documents are encoded with EmitFirestoreSensibleDefaults")
+	flag.Parse()
+
+	if *oldFile == "" || *newFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	changes, err := run(*oldFile, *newFile, *emitDefaults)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "protofirestore-compat:", err)
+		os.Exit(2)
+	}
+
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	if len(changes) > 0 {
+		os.Exit(1)
+	}
+}
+
+func run(oldFile, newFile string, emitDefaults bool) ([]compat.Change, error) {
+	old, err := readFiles(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	new, err := readFiles(newFile)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := compat.Options{}
+	opts.MarshalOptions.EmitFirestoreSensibleDefaults = emitDefaults
+
+	return opts.Check(old, new), nil
+}
+
+// readFiles reads the descriptor set in the named file.
+func readFiles(name string) (*protoregistry.Files, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return files, nil
+}
@@ -0,0 +1,221 @@
+// The protofirestore-decode command decodes exported firestore documents
+// into the messages registered for their collections, which it reads from a
+// descriptor set including its imports, as written by protoc:
+//
+//	protoc --include_imports --descriptor_set_out=schema.pb *.proto
+//	protofirestore-decode -descriptor_set=schema.pb export.ndjson
+//
+// The documents are newline delimited JSON objects with the path of the
+// document under "path" and its data under "data", read from the given
+// files or standard input. Every message with a path option is registered
+// at its path pattern, and the -polymorphic flag registers patterns of
+// collections holding several message types. Each document is written to
+// standard output as a JSON object with its path, the full name of its
+// message and the message in the canonical protobuf JSON encoding.
+//
+// JSON numbers without a fraction or exponent are integers, other numbers are
+// floats. With -timestamps, strings in RFC 3339 format are timestamps.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/daviddomkar/protofirestore/registry"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// patterns is a flag collecting repeated values.
+type patterns []string
+
+func (p *patterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patterns) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+func main() {
+	var polymorphic patterns
+	descriptorSet := flag.String("descriptor_set", "", "descriptor set of the messages")
+	flag.Var(&polymorphic, "polymorphic", "path pattern of a collection holding several message types (repeatable)")
+	timestamps := flag.Bool("timestamps", false, "treat RFC 3339 strings as timestamps")
+	discardUnknown := flag.Bool("discard_unknown", false, "ignore keys which are not fields of the message")
+	flag.Parse()
+
+	if *descriptorSet == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	r, err := newRegistry(*descriptorSet, polymorphic)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "protofirestore-decode:", err)
+		os.Exit(2)
+	}
+	r.UnmarshalOptions.DiscardUnknown = *discardUnknown
+
+	if err := run(r, *timestamps, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "protofirestore-decode:", err)
+		os.Exit(1)
+	}
+}
+
+// newRegistry returns a registry of the messages of the descriptor set in
+// the named file and of the given polymorphic collections.
+func newRegistry(name string, polymorphic []string) (*registry.Registry, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	r := &registry.Registry{}
+	r.UnmarshalOptions.Resolver = dynamicpb.NewTypes(files)
+
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		err = r.RegisterFile(fd)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range polymorphic {
+		if err := r.Register(pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func run(r *registry.Registry, timestamps bool, files []string) error {
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	if len(files) == 0 {
+		return decodeDocuments(r, w, os.Stdin, "stdin", timestamps)
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		err = decodeDocuments(r, w, f, file, timestamps)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// document is a line of the input.
+type document struct {
+	Path string                 `json:"path"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// decoded is a line of the output.
+type decoded struct {
+	Path    string          `json:"path"`
+	Type    string          `json:"type"`
+	Message json.RawMessage `json:"message"`
+}
+
+// decodeDocuments decodes the documents read from r and writes them to w.
+func decodeDocuments(reg *registry.Registry, w io.Writer, r io.Reader, name string, timestamps bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20) // firestore documents are at most 1 MiB, their JSON may be larger
+
+	encoder := json.NewEncoder(w)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		decoder.UseNumber()
+
+		var doc document
+		if err := decoder.Decode(&doc); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+
+		data, _ := convert(doc.Data, timestamps).(map[string]interface{})
+		m, err := reg.Decode(doc.Path, data)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+
+		b, err := protojson.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+
+		out := decoded{Path: doc.Path, Type: string(m.ProtoReflect().Descriptor().FullName()), Message: b}
+		if err := encoder.Encode(out); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// convert converts JSON numbers to integers and floats and, if timestamps is
+// set, RFC 3339 strings to timestamps.
+func convert(v interface{}, timestamps bool) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if !strings.ContainsAny(v.String(), ".eE") {
+			if n, err := v.Int64(); err == nil {
+				return n
+			}
+		}
+		f, _ := v.Float64()
+		return f
+	case string:
+		if timestamps {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t
+			}
+		}
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = convert(value, timestamps)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = convert(value, timestamps)
+		}
+	}
+	return v
+}
@@ -0,0 +1,130 @@
+// The protofirestore-infer command infers a draft proto file from sample
+// firestore documents stored as newline delimited JSON objects, one document
+// per line, read from the given files or standard input:
+//
+//	protofirestore-infer -package=shop -message=Order orders.ndjson > order.proto
+//
+// JSON numbers without a fraction or exponent are integers, other numbers are
+// floats. With -timestamps, strings in RFC 3339 format are timestamps. Paths
+// with values of conflicting types are reported on standard error.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/daviddomkar/protofirestore/infer"
+)
+
+func main() {
+	var opts infer.Options
+	flag.StringVar(&opts.Package, "package", "", "package of the proto file")
+	flag.StringVar(&opts.Message, "message", "Document", "name of the message of the documents")
+	flag.IntVar(&opts.MaxEnumValues, "max_enum_values", 16, "maximum number of values of enums")
+	flag.IntVar(&opts.MaxMessageFields, "max_message_fields", 64, "maximum number of fields of messages")
+	timestamps := flag.Bool("timestamps", false, "treat RFC 3339 strings as timestamps")
+	flag.Parse()
+
+	if err := run(opts, *timestamps, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "protofirestore-infer:", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts infer.Options, timestamps bool, files []string) error {
+	var documents []map[string]interface{}
+
+	if len(files) == 0 {
+		var err error
+		if documents, err = readDocuments(os.Stdin, "stdin", timestamps, documents); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		documents, err = readDocuments(f, file, timestamps, documents)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := opts.Infer(documents)
+	if err != nil {
+		return err
+	}
+
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintln(os.Stderr, conflict)
+	}
+
+	_, err = io.WriteString(os.Stdout, result.Proto)
+	return err
+}
+
+// readDocuments appends the documents read from r to documents.
+func readDocuments(r io.Reader, name string, timestamps bool, documents []map[string]interface{}) ([]map[string]interface{}, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20) // firestore documents are at most 1 MiB, their JSON may be larger
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		decoder.UseNumber()
+
+		var document map[string]interface{}
+		if err := decoder.Decode(&document); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+
+		documents = append(documents, convert(document, timestamps).(map[string]interface{}))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return documents, nil
+}
+
+// convert converts JSON numbers to integers and floats and, if timestamps is
+// set, RFC 3339 strings to timestamps.
+func convert(v interface{}, timestamps bool) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if !strings.ContainsAny(v.String(), ".eE") {
+			if n, err := v.Int64(); err == nil {
+				return n
+			}
+		}
+		f, _ := v.Float64()
+		return f
+	case string:
+		if timestamps {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t
+			}
+		}
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = convert(value, timestamps)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = convert(value, timestamps)
+		}
+	}
+	return v
+}
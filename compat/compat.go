@@ -0,0 +1,348 @@
+// Package compat checks whether changes between two revisions of proto
+// definitions keep documents stored by the protofirestore package readable.
+//
+// Unlike the wire format, which only depends on field numbers, documents
+// store the JSON names of fields and the names of enum values, so renaming
+// them breaks reading existing documents.
+package compat
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/internal/shape"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Change is a change altering the encoding of documents.
+type Change struct {
+	// Message is the full name of the message whose documents are affected,
+	// or of the enum whose values are removed.
+	Message protoreflect.FullName
+
+	// Path is the firestore field path of the changed key relative to the
+	// message, or empty for changes of the message. Elements of lists are
+	// denoted by [] and values of maps by *.
+	Path string
+
+	// Description describes the change.
+	Description string
+}
+
+func (c Change) String() string {
+	if c.Path == "" {
+		return fmt.Sprintf("%v: %s", c.Message, c.Description)
+	}
+	return fmt.Sprintf("%v: %s: %s", c.Message, c.Path, c.Description)
+}
+
+// Options configures the compatibility check.
+type Options struct {
+	// MarshalOptions are the options documents are encoded with. With
+	// EmitFirestoreSensibleDefaults, changes of the keys which are always
+	// encoded are reported.
+	MarshalOptions protofirestore.MarshalOptions
+}
+
+// Check reports the changes between the old and new revision of proto
+// definitions which alter the encoding of documents.
+func Check(old, new *protoregistry.Files) []Change {
+	return Options{}.Check(old, new)
+}
+
+// Check reports the changes between the old and new revision of proto
+// definitions which alter the encoding of documents, sorted by message and
+// path. Messages and fields are matched by full name and number. It reports:
+//
+//   - removed messages with a path option and changed path options.
+//   - removed fields, whose keys are unknown to the decoder.
+//   - changed JSON names, which rename keys.
+//   - changed types, except for widened integers, integers changed to floats
+//     and enums changed to strings, which the decoder accepts.
+//   - changed map key types, except for widened integers.
+//   - removed or renamed enum values, whose names are stored.
+//   - fields moved into, out of or between oneofs, whose members must not be
+//     present at the same time.
+//   - fields moved into, out of or between subcollections, whose values are
+//     stored in child documents instead of the document.
+//
+// Messages which changed the type of a field are compared recursively.
+func (o Options) Check(old, new *protoregistry.Files) []Change {
+	c := checker{opts: o, new: new}
+
+	old.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		c.checkEnums(fd.Enums())
+		c.checkMessages(fd.Messages())
+		return true
+	})
+
+	sort.SliceStable(c.changes, func(i, j int) bool {
+		if c.changes[i].Message != c.changes[j].Message {
+			return c.changes[i].Message < c.changes[j].Message
+		}
+		return c.changes[i].Path < c.changes[j].Path
+	})
+
+	return c.changes
+}
+
+// checker accumulates the changes between two revisions.
+type checker struct {
+	opts    Options
+	new     *protoregistry.Files
+	changes []Change
+}
+
+func (c *checker) report(message protoreflect.FullName, path, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{Message: message, Path: path, Description: fmt.Sprintf(format, args...)})
+}
+
+// checkMessages checks the messages and their nested messages and enums
+// against the messages of the new revision with the same name.
+func (c *checker) checkMessages(messages protoreflect.MessageDescriptors) {
+	for i := 0; i < messages.Len(); i++ {
+		md := messages.Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+
+		c.checkEnums(md.Enums())
+		c.checkMessages(md.Messages())
+
+		desc, err := c.new.FindDescriptorByName(md.FullName())
+		newMD, ok := desc.(protoreflect.MessageDescriptor)
+		if err != nil || !ok {
+			if path := annotations.MessageOptionsOf(md).GetPath(); path != "" {
+				c.report(md.FullName(), "", "message with path %q removed", path)
+			}
+			continue
+		}
+
+		oldPath := annotations.MessageOptionsOf(md).GetPath()
+		newPath := annotations.MessageOptionsOf(newMD).GetPath()
+		if oldPath != newPath && oldPath != "" {
+			c.report(md.FullName(), "", "path changed from %q to %q", oldPath, newPath)
+		}
+
+		c.checkFields(md.FullName(), "", md, newMD, make(map[[2]protoreflect.FullName]bool))
+	}
+}
+
+// checkEnums checks the enums against the enums of the new revision with the
+// same name.
+func (c *checker) checkEnums(enums protoreflect.EnumDescriptors) {
+	for i := 0; i < enums.Len(); i++ {
+		ed := enums.Get(i)
+
+		desc, err := c.new.FindDescriptorByName(ed.FullName())
+		newED, ok := desc.(protoreflect.EnumDescriptor)
+		if err != nil || !ok {
+			continue // unused enums can be removed, used ones change field types
+		}
+
+		for _, name := range removedEnumNames(ed, newED) {
+			c.report(ed.FullName(), "", "value %v removed", name)
+		}
+	}
+}
+
+// checkFields checks the fields of the message md against the fields of the
+// message newMD with the same number. Pairs of messages which are already
+// being compared are skipped.
+func (c *checker) checkFields(message protoreflect.FullName, prefix string, md, newMD protoreflect.MessageDescriptor, visiting map[[2]protoreflect.FullName]bool) {
+	pair := [2]protoreflect.FullName{md.FullName(), newMD.FullName()}
+	if visiting[pair] {
+		return
+	}
+	visiting[pair] = true
+	defer delete(visiting, pair)
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := joinPath(prefix, fd.JSONName())
+
+		newFD := newMD.Fields().ByNumber(fd.Number())
+		if newFD == nil {
+			c.report(message, path, "field %v removed", fd.Name())
+			continue
+		}
+
+		if fd.JSONName() != newFD.JSONName() {
+			c.report(message, path, "key renamed to %q", newFD.JSONName())
+		}
+
+		oldOneof, newOneof := realOneof(fd), realOneof(newFD)
+		switch {
+		case oldOneof == "" && newOneof != "":
+			c.report(message, path, "moved into oneof %v", newOneof)
+		case oldOneof != "" && newOneof == "":
+			c.report(message, path, "moved out of oneof %v", oldOneof)
+		case oldOneof != newOneof:
+			c.report(message, path, "moved from oneof %v to oneof %v", oldOneof, newOneof)
+		}
+
+		oldSub := annotations.FieldOptionsOf(fd).GetSubcollection()
+		newSub := annotations.FieldOptionsOf(newFD).GetSubcollection()
+		switch {
+		case oldSub == "" && newSub != "":
+			c.report(message, path, "moved into subcollection %q", newSub)
+		case oldSub != "" && newSub == "":
+			c.report(message, path, "moved out of subcollection %q", oldSub)
+		case oldSub != newSub:
+			c.report(message, path, "moved from subcollection %q to subcollection %q", oldSub, newSub)
+		}
+
+		if c.opts.MarshalOptions.EmitFirestoreSensibleDefaults {
+			oldRequired := shape.IsRequired(fd, c.opts.MarshalOptions)
+			newRequired := shape.IsRequired(newFD, c.opts.MarshalOptions)
+			switch {
+			case oldRequired && !newRequired:
+				c.report(message, path, "no longer stored with default values")
+			case !oldRequired && newRequired:
+				c.report(message, path, "now stored with default values")
+			}
+		}
+
+		c.checkType(message, path, fd, newFD, visiting)
+	}
+}
+
+// checkType checks the type of the field fd against the type of the field
+// newFD.
+func (c *checker) checkType(message protoreflect.FullName, path string, fd, newFD protoreflect.FieldDescriptor, visiting map[[2]protoreflect.FullName]bool) {
+	oldType, oldErr := shape.TypeOf(fd)
+	newType, newErr := shape.TypeOf(newFD)
+	if oldErr != nil || newErr != nil {
+		// Fields of types the encoder does not support store no documents,
+		// so only changes into those types are reported.
+		if oldErr == nil {
+			c.report(message, path, "changed to unsupported type: %v", newErr)
+		}
+		return
+	}
+
+	if oldType.Kind != newType.Kind {
+		c.report(message, path, "type changed from %v to %v", describe(oldType), describe(newType))
+		return
+	}
+
+	switch oldType.Kind {
+	case shape.List:
+		c.checkValue(message, path+"[]", *oldType.Elem, *newType.Elem, visiting)
+	case shape.Map:
+		if !compatibleKeys(oldType.Key, newType.Key) {
+			c.report(message, path, "map key type changed from %v to %v", oldType.Key, newType.Key)
+		}
+		c.checkValue(message, path+".*", *oldType.Elem, *newType.Elem, visiting)
+	default:
+		c.checkValue(message, path, oldType, newType, visiting)
+	}
+}
+
+// checkValue checks singular values of type t against values of type newT.
+func (c *checker) checkValue(message protoreflect.FullName, path string, t, newT shape.Type, visiting map[[2]protoreflect.FullName]bool) {
+	if t.Kind != newT.Kind {
+		if !(t.Kind == shape.Int && newT.Kind == shape.Float) && !(t.Kind == shape.Enum && newT.Kind == shape.String) {
+			c.report(message, path, "type changed from %v to %v", t.Kind, newT.Kind)
+		}
+		return
+	}
+
+	switch t.Kind {
+	case shape.Int:
+		if !compatibleInts(t.Scalar, newT.Scalar) {
+			c.report(message, path, "integer type changed from %v to %v", t.Scalar, newT.Scalar)
+		}
+	case shape.Enum:
+		if t.Enum.FullName() != newT.Enum.FullName() {
+			for _, name := range removedEnumNames(t.Enum, newT.Enum) {
+				c.report(message, path, "value %v missing from enum %v", name, newT.Enum.FullName())
+			}
+		}
+	case shape.Object:
+		if t.Message.FullName() != newT.Message.FullName() {
+			c.checkFields(message, path, t.Message, newT.Message, visiting)
+		}
+	}
+}
+
+// removedEnumNames returns the names of the values of ed which are not
+// names of values of newED.
+func removedEnumNames(ed, newED protoreflect.EnumDescriptor) []protoreflect.Name {
+	var removed []protoreflect.Name
+	values := ed.Values()
+	for i := 0; i < values.Len(); i++ {
+		if name := values.Get(i).Name(); newED.Values().ByName(name) == nil {
+			removed = append(removed, name)
+		}
+	}
+	return removed
+}
+
+// intRanges are the ranges of integer kinds, ordered by their bounds.
+var intRanges = map[protoreflect.Kind][2]int{
+	protoreflect.Int32Kind:    {-32, 31},
+	protoreflect.Sint32Kind:   {-32, 31},
+	protoreflect.Sfixed32Kind: {-32, 31},
+	protoreflect.Int64Kind:    {-64, 63},
+	protoreflect.Sint64Kind:   {-64, 63},
+	protoreflect.Sfixed64Kind: {-64, 63},
+	protoreflect.Uint32Kind:   {0, 32},
+	protoreflect.Fixed32Kind:  {0, 32},
+	protoreflect.Uint64Kind:   {0, 64},
+	protoreflect.Fixed64Kind:  {0, 64},
+}
+
+// compatibleInts reports whether every value of the integer kind fits the
+// integer kind newKind.
+func compatibleInts(kind, newKind protoreflect.Kind) bool {
+	r, newR := intRanges[kind], intRanges[newKind]
+	return newR[0] <= r[0] && r[1] <= newR[1]
+}
+
+// compatibleKeys reports whether keys of maps with keys of the kind are valid
+// keys of maps with keys of the kind newKind.
+func compatibleKeys(kind, newKind protoreflect.Kind) bool {
+	if kind == newKind {
+		return true
+	}
+	if _, ok := intRanges[kind]; ok {
+		if _, ok := intRanges[newKind]; ok {
+			return compatibleInts(kind, newKind)
+		}
+		return newKind == protoreflect.StringKind
+	}
+	return kind == protoreflect.BoolKind && newKind == protoreflect.StringKind
+}
+
+// describe describes the type t of a field.
+func describe(t shape.Type) string {
+	switch t.Kind {
+	case shape.List:
+		return "repeated " + t.Elem.Kind.String()
+	case shape.Map:
+		return "map"
+	}
+	return t.Kind.String()
+}
+
+// realOneof returns the name of the oneof containing the field fd unless it
+// is synthetic.
+func realOneof(fd protoreflect.FieldDescriptor) protoreflect.Name {
+	if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() {
+		return od.Name()
+	}
+	return ""
+}
+
+// joinPath appends the key to the path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
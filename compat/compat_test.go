@@ -0,0 +1,258 @@
+package compat_test
+
+import (
+	"testing"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/compat"
+	"github.com/go-test/deep"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// oldFile is the old revision of the proto file compared in tests.
+const oldFile = `
+name: "test.proto"
+package: "test"
+syntax: "proto3"
+dependency: "google/protobuf/timestamp.proto"
+dependency: "annotations/annotations.proto"
+message_type: {
+	name: "Order"
+	options: { [protofirestore.message]: { path: "orders/{order}" } }
+	field: { name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "name" }
+	field: { name: "count" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "count" }
+	field: { name: "state" number: 3 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.State" json_name: "state" }
+	field: { name: "tags" number: 4 label: LABEL_REPEATED type: TYPE_STRING json_name: "tags" }
+	field: { name: "address" number: 5 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Address" json_name: "address" }
+	field: { name: "email" number: 6 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "email" oneof_index: 0 }
+	field: { name: "phone" number: 7 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "phone" }
+	field: { name: "totals" number: 8 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Order.TotalsEntry" json_name: "totals" }
+	field: { name: "create_time" number: 9 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" json_name: "createTime" }
+	field: { name: "stops" number: 10 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Address" json_name: "stops" options: { [protofirestore.field]: { subcollection: "stops" } } }
+	field: { name: "notes" number: 11 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Address" json_name: "notes" }
+	nested_type: {
+		name: "TotalsEntry"
+		field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "key" }
+		field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_DOUBLE json_name: "value" }
+		options: { map_entry: true }
+	}
+	oneof_decl: { name: "contact" }
+}
+message_type: {
+	name: "Address"
+	field: { name: "street" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "street" }
+}
+enum_type: {
+	name: "State"
+	value: { name: "STATE_UNSPECIFIED" number: 0 }
+	value: { name: "OPEN" number: 1 }
+	value: { name: "SHIPPED" number: 2 }
+}
+`
+
+// revision returns the files of oldFile with the edit applied.
+func revision(t *testing.T, edit func(*descriptorpb.FileDescriptorProto)) *protoregistry.Files {
+	t.Helper()
+
+	fdp := &descriptorpb.FileDescriptorProto{}
+	if err := prototext.Unmarshal([]byte(oldFile), fdp); err != nil {
+		t.Fatalf("prototext.Unmarshal() returned error: %v\n", err)
+	}
+	if edit != nil {
+		edit(fdp)
+	}
+
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("protodesc.NewFile() returned error: %v\n", err)
+	}
+
+	files := &protoregistry.Files{}
+	if err := files.RegisterFile(fd); err != nil {
+		t.Fatalf("RegisterFile() returned error: %v\n", err)
+	}
+	return files
+}
+
+// field returns the field of the Order message with the given number.
+func field(fdp *descriptorpb.FileDescriptorProto, number int32) *descriptorpb.FieldDescriptorProto {
+	for _, f := range fdp.MessageType[0].Field {
+		if f.GetNumber() == number {
+			return f
+		}
+	}
+	panic("no such field")
+}
+
+func TestCheck(t *testing.T) {
+	type edit = func(*descriptorpb.FileDescriptorProto)
+
+	tests := []struct {
+		desc string
+		mo   protofirestore.MarshalOptions
+		edit edit
+		want []compat.Change
+	}{{
+		desc: "no changes",
+	}, {
+		desc: "compatible changes",
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			// Renamed field with the same JSON name.
+			field(fdp, 1).Name = proto.String("title")
+			// Widened integer.
+			field(fdp, 2).Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+			// Added enum value and field.
+			fdp.EnumType[0].Value = append(fdp.EnumType[0].Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String("CANCELLED"), Number: proto.Int32(3)})
+			fdp.MessageType[1].Field = append(fdp.MessageType[1].Field, &descriptorpb.FieldDescriptorProto{
+				Name: proto.String("city"), Number: proto.Int32(2), JsonName: proto.String("city"),
+				Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			})
+			// Widened map key.
+			fdp.MessageType[0].NestedType[0].Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+		},
+	}, {
+		desc: "renamed keys and removed fields",
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			field(fdp, 1).JsonName = proto.String("title")
+			fdp.MessageType[1].Field = nil
+		},
+		want: []compat.Change{
+			{Message: "test.Address", Path: "street", Description: "field street removed"},
+			{Message: "test.Order", Path: "name", Description: `key renamed to "title"`},
+		},
+	}, {
+		desc: "changed types",
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			field(fdp, 1).Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+			field(fdp, 2).Type = descriptorpb.FieldDescriptorProto_TYPE_UINT32.Enum()
+			field(fdp, 4).Label = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+			field(fdp, 9).TypeName = proto.String(".google.protobuf.Duration")
+			fdp.Dependency = append(fdp.Dependency, "google/protobuf/duration.proto")
+			fdp.MessageType[0].NestedType[0].Field[1].Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+		},
+		want: []compat.Change{
+			{Message: "test.Order", Path: "count", Description: "integer type changed from int32 to uint32"},
+			{Message: "test.Order", Path: "createTime", Description: "changed to unsupported type: field test.Order.create_time: no support for google.protobuf.Duration well known type"},
+			{Message: "test.Order", Path: "name", Description: "type changed from string to int"},
+			{Message: "test.Order", Path: "tags", Description: "type changed from repeated string to string"},
+			{Message: "test.Order", Path: "totals.*", Description: "type changed from float to string"},
+		},
+	}, {
+		desc: "changed message types are compared",
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			fdp.MessageType = append(fdp.MessageType, &descriptorpb.DescriptorProto{
+				Name: proto.String("Location"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name: proto.String("street_name"), Number: proto.Int32(1), JsonName: proto.String("streetName"),
+					Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				}},
+			})
+			field(fdp, 5).TypeName = proto.String(".test.Location")
+		},
+		want: []compat.Change{
+			{Message: "test.Order", Path: "address.street", Description: `key renamed to "streetName"`},
+		},
+	}, {
+		desc: "changed paths",
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			fdp.MessageType[0].Options = nil
+		},
+		want: []compat.Change{
+			{Message: "test.Order", Description: `path changed from "orders/{order}" to ""`},
+		},
+	}, {
+		desc: "removed documents",
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			fdp.MessageType[0].Name = proto.String("Purchase")
+			fdp.MessageType[0].NestedType[0].Name = proto.String("TotalsEntry")
+			field(fdp, 8).TypeName = proto.String(".test.Purchase.TotalsEntry")
+		},
+		want: []compat.Change{
+			{Message: "test.Order", Description: `message with path "orders/{order}" removed`},
+		},
+	}, {
+		desc: "removed enum names",
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			fdp.EnumType[0].Value[1].Name = proto.String("OPENED")
+		},
+		want: []compat.Change{
+			{Message: "test.State", Description: "value OPEN removed"},
+		},
+	}, {
+		desc: "oneof moves",
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			field(fdp, 6).OneofIndex = nil
+			field(fdp, 7).OneofIndex = proto.Int32(0)
+		},
+		want: []compat.Change{
+			{Message: "test.Order", Path: "email", Description: "moved out of oneof contact"},
+			{Message: "test.Order", Path: "phone", Description: "moved into oneof contact"},
+		},
+	}, {
+		desc: "subcollection moves",
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			field(fdp, 10).Options = nil
+			field(fdp, 11).Options = &descriptorpb.FieldOptions{}
+			proto.SetExtension(field(fdp, 11).Options, annotations.E_Field, &annotations.FieldOptions{Subcollection: "notes"})
+		},
+		want: []compat.Change{
+			{Message: "test.Order", Path: "notes", Description: `moved into subcollection "notes"`},
+			{Message: "test.Order", Path: "stops", Description: `moved out of subcollection "stops"`},
+		},
+	}, {
+		desc: "renamed subcollection",
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			proto.SetExtension(field(fdp, 10).Options, annotations.E_Field, &annotations.FieldOptions{Subcollection: "legs"})
+		},
+		want: []compat.Change{
+			{Message: "test.Order", Path: "stops", Description: `moved from subcollection "stops" to subcollection "legs"`},
+		},
+	}, {
+		desc: "keys stored with default values",
+		mo:   protofirestore.MarshalOptions{EmitFirestoreSensibleDefaults: true},
+		edit: func(fdp *descriptorpb.FileDescriptorProto) {
+			field(fdp, 2).OneofIndex = proto.Int32(1)
+			field(fdp, 2).Proto3Optional = proto.Bool(true)
+			fdp.MessageType[0].OneofDecl = append(fdp.MessageType[0].OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_count")})
+		},
+		want: []compat.Change{
+			{Message: "test.Order", Path: "count", Description: "no longer stored with default values"},
+		},
+	}}
+
+	old := revision(t, nil)
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			got := compat.Options{MarshalOptions: tt.mo}.Check(old, revision(t, tt.edit))
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	tests := []struct {
+		input compat.Change
+		want  string
+	}{
+		{input: compat.Change{Message: "test.Order", Path: "name", Description: "field name removed"}, want: "test.Order: name: field name removed"},
+		{input: compat.Change{Message: "test.Order", Description: "path changed"}, want: "test.Order: path changed"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.input.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package protofirestore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/daviddomkar/protofirestore/firestoreimpl"
+	"github.com/daviddomkar/protofirestore/internal/encoding/messageset"
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Unmarshal reads the given firestore document into the given proto.Message.
+func Unmarshal(object map[string]interface{}, m proto.Message) error {
+	return UnmarshalOptions{}.Unmarshal(object, m)
+}
+
+// UnmarshalOptions is a configurable firestore document parser.
+type UnmarshalOptions struct {
+	// DiscardUnknown specifies whether to ignore keys of the document which
+	// are not fields of the message instead of returning an error.
+	DiscardUnknown bool
+
+	// TypeAliases are the short names of messages stored under TypeKey by
+	// MarshalOptions.EmitType.
+	TypeAliases TypeAliases
+
+	// Resolver is used for looking up types of extension fields and of the
+	// messages read by UnmarshalNew. If nil, this defaults to using
+	// protoregistry.GlobalTypes.
+	Resolver interface {
+		protoregistry.ExtensionTypeResolver
+		protoregistry.MessageTypeResolver
+	}
+}
+
+// Unmarshal reads the given firestore document into the given proto.Message.
+// The message is reset before reading.
+//
+// It is the inverse of MarshalOptions.Marshal: keys are the JSON names of
+// fields, enums are read from their value names or numbers and timestamps
+// from time.Time values. Numbers of any Go type are accepted as long as they
+// fit the field without loss, since documents written by other clients may
+// store them differently. Keys with nil values are treated as absent. The
+// type stored under TypeKey, if any, must be the type of m. The cases stored
+// under OneofCaseKey, if any, must agree with the fields of the oneofs.
+func (o UnmarshalOptions) Unmarshal(object map[string]interface{}, m proto.Message) error {
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+
+	if m == nil {
+		return errors.New("cannot unmarshal into nil message")
+	}
+
+	proto.Reset(m)
+
+	if marshal := wellKnownTypeMarshaler(m.ProtoReflect().Descriptor().FullName()); marshal != nil {
+		return errors.New("no support for well known types as top level objects in firestore documents")
+	}
+
+	if _, ok := object[TypeKey]; ok {
+		if err := o.checkType(object, m.ProtoReflect().Descriptor()); err != nil {
+			return err
+		}
+		object = withoutKey(object, TypeKey)
+	}
+
+	if err := (decoder{o}).unmarshalMessage(object, m.ProtoReflect()); err != nil {
+		return err
+	}
+
+	return proto.CheckInitialized(m)
+}
+
+type decoder struct {
+	opts UnmarshalOptions
+}
+
+// unmarshalMessage unmarshals the given firestore object into the given
+// empty protoreflect.Message.
+func (d decoder) unmarshalMessage(object map[string]interface{}, m protoreflect.Message) error {
+	md := m.Descriptor()
+
+	if messageset.IsMessageSet(md) {
+		return errors.New("no support for proto1 MessageSets")
+	}
+
+	// Generated code does not read the cases of oneofs.
+	if u, ok := m.Interface().(firestoreUnmarshaler); ok && !hasOneofCases(object, md) {
+		return u.UnmarshalFirestore(d.opts, object)
+	}
+
+	for _, key := range firestoreimpl.SortedKeys(object) {
+		fd, err := d.findField(md, key)
+		if err != nil {
+			return err
+		}
+
+		if fd != nil && isSubcollection(fd) {
+			fd = nil // stored as separate documents, see UnmarshalDocuments
+		}
+
+		if fd == nil && findOneofCase(md, key) != nil {
+			continue // checked by unmarshalOneofCases
+		}
+
+		if fd == nil {
+			if d.opts.DiscardUnknown {
+				continue
+			}
+			return firestoreimpl.UnknownFieldError(key, md.FullName())
+		}
+
+		value := object[key]
+		if value == nil {
+			continue
+		}
+
+		if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() && m.WhichOneof(od) != nil {
+			return firestoreimpl.OneofConflictError(od.FullName())
+		}
+
+		switch {
+		case fd.IsList():
+			err = d.unmarshalList(value, m.Mutable(fd).List(), fd)
+		case fd.IsMap():
+			err = d.unmarshalMap(value, m.Mutable(fd).Map(), fd)
+		default:
+			var v protoreflect.Value
+			if v, err = d.unmarshalSingular(value, fd, func() protoreflect.Value { return m.NewField(fd) }); err == nil {
+				m.Set(fd, v)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.unmarshalOneofCases(object, m)
+}
+
+// findField returns the field of md which is encoded under the given key, or
+// nil if there is none.
+func (d decoder) findField(md protoreflect.MessageDescriptor, key string) (protoreflect.FieldDescriptor, error) {
+	if fd := md.Fields().ByJSONName(key); fd != nil {
+		return fd, nil
+	}
+
+	if len(key) < 2 || key[0] != '[' || key[len(key)-1] != ']' {
+		return nil, nil
+	}
+
+	xt, err := d.opts.Resolver.FindExtensionByName(protoreflect.FullName(key[1 : len(key)-1]))
+	if err != nil {
+		if err == protoregistry.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to resolve %v: %w", key, err)
+	}
+
+	if xd := xt.TypeDescriptor(); xd.ContainingMessage().FullName() == md.FullName() {
+		return xd, nil
+	}
+
+	return nil, nil
+}
+
+// unmarshalList unmarshals the given firestore array into list. Nil elements
+// are the encoding of empty strings, bytes and messages and unmarshal to the
+// zero value.
+func (d decoder) unmarshalList(value interface{}, list protoreflect.List, fd protoreflect.FieldDescriptor) error {
+	array, err := firestoreimpl.DecodeList(value, fd.FullName())
+	if err != nil {
+		return err
+	}
+
+	for _, item := range array {
+		v, err := d.unmarshalSingular(item, fd, list.NewElement)
+		if err != nil {
+			return err
+		}
+		list.Append(v)
+	}
+
+	return nil
+}
+
+// unmarshalMap unmarshals the given firestore object into mmap.
+func (d decoder) unmarshalMap(value interface{}, mmap protoreflect.Map, fd protoreflect.FieldDescriptor) error {
+	object, err := firestoreimpl.DecodeObject(value, fd.FullName())
+	if err != nil {
+		return err
+	}
+
+	for key, item := range object {
+		k, err := firestoreimpl.DecodeMapKey(key, fd.MapKey().Kind(), fd.FullName())
+		if err != nil {
+			return err
+		}
+
+		v, err := d.unmarshalSingular(item, fd.MapValue(), mmap.NewValue)
+		if err != nil {
+			return err
+		}
+
+		mmap.Set(k, v)
+	}
+
+	return nil
+}
+
+// unmarshalSingular unmarshals a single value of the field fd. newValue
+// returns the zero value of the field, which is also the empty message to
+// unmarshal into for message fields. A nil value unmarshals to the zero
+// value.
+func (d decoder) unmarshalSingular(value interface{}, fd protoreflect.FieldDescriptor, newValue func() protoreflect.Value) (protoreflect.Value, error) {
+	name := fd.FullName()
+
+	if value == nil {
+		return newValue(), nil
+	}
+
+	if fd.Message() != nil {
+		v := newValue()
+		return v, d.unmarshalMessageValue(value, v.Message(), fd)
+	}
+
+	switch kind := fd.Kind(); kind {
+	case protoreflect.BoolKind:
+		b, err := firestoreimpl.DecodeBool(value, name)
+		return protoreflect.ValueOfBool(b), err
+
+	case protoreflect.StringKind:
+		s, err := firestoreimpl.DecodeString(value, name)
+		return protoreflect.ValueOfString(s), err
+
+	case protoreflect.BytesKind:
+		b, err := firestoreimpl.DecodeBytes(value, name)
+		return protoreflect.ValueOfBytes(b), err
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := firestoreimpl.DecodeInt32(value, name)
+		return protoreflect.ValueOfInt32(n), err
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := firestoreimpl.DecodeInt64(value, name)
+		return protoreflect.ValueOfInt64(n), err
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := firestoreimpl.DecodeUint32(value, name)
+		return protoreflect.ValueOfUint32(n), err
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := firestoreimpl.DecodeUint64(value, name)
+		return protoreflect.ValueOfUint64(n), err
+
+	case protoreflect.FloatKind:
+		f, err := firestoreimpl.DecodeFloat32(value, name)
+		return protoreflect.ValueOfFloat32(f), err
+
+	case protoreflect.DoubleKind:
+		f, err := firestoreimpl.DecodeFloat64(value, name)
+		return protoreflect.ValueOfFloat64(f), err
+
+	case protoreflect.EnumKind:
+		n, err := firestoreimpl.DecodeEnum(value, fd.Enum(), name)
+		return protoreflect.ValueOfEnum(n), err
+
+	default:
+		panic(fmt.Sprintf("%v has unknown kind: %v", fd.FullName(), kind))
+	}
+}
+
+// unmarshalMessageValue unmarshals the value of the message field fd into the
+// empty message m.
+func (d decoder) unmarshalMessageValue(value interface{}, m protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	md := m.Descriptor()
+
+	if md.FullName().Parent() == genid.GoogleProtobuf_package {
+		switch md.Name() {
+		case genid.Timestamp_message_name:
+			ts, err := firestoreimpl.DecodeTimestamp(value, fd.FullName())
+			if err != nil {
+				return err
+			}
+			m.Set(md.Fields().ByNumber(genid.Timestamp_Seconds_field_number), protoreflect.ValueOfInt64(ts.GetSeconds()))
+			m.Set(md.Fields().ByNumber(genid.Timestamp_Nanos_field_number), protoreflect.ValueOfInt32(ts.GetNanos()))
+			return nil
+
+		case genid.Empty_message_name:
+			_, err := firestoreimpl.DecodeObject(value, fd.FullName())
+			return err
+		}
+
+		if wellKnownTypeMarshaler(md.FullName()) != nil {
+			return fmt.Errorf("no support for %v well known type", md.FullName())
+		}
+	}
+
+	object, err := firestoreimpl.DecodeObject(value, fd.FullName())
+	if err != nil {
+		return err
+	}
+
+	return d.unmarshalMessage(object, m)
+}
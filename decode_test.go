@@ -0,0 +1,260 @@
+package protofirestore_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pkg "github.com/daviddomkar/protofirestore"
+	pb2 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb2"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+)
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		desc           string
+		input          map[string]interface{}
+		discardUnknown bool
+		into           proto.Message
+		want           proto.Message
+		wantErr        bool
+	}{
+		{
+			desc:  "proto3 scalars",
+			input: map[string]interface{}{"sBool": true, "sInt32": int32(-1), "sInt64": int64(math.MinInt64), "sUint32": uint32(math.MaxUint32), "sUint64": uint64(math.MaxUint64), "sSint32": int32(2), "sSint64": int64(3), "sFixed32": uint32(4), "sFixed64": uint64(5), "sSfixed32": int32(6), "sSfixed64": int64(7), "sFloat": float32(1.5), "sDouble": math.Inf(-1), "sBytes": []byte("hello"), "sString": "谷歌"},
+			want: &pb3.Scalars{
+				SBool:     true,
+				SInt32:    -1,
+				SInt64:    math.MinInt64,
+				SUint32:   math.MaxUint32,
+				SUint64:   math.MaxUint64,
+				SSint32:   2,
+				SSint64:   3,
+				SFixed32:  4,
+				SFixed64:  5,
+				SSfixed32: 6,
+				SSfixed64: 7,
+				SFloat:    1.5,
+				SDouble:   math.Inf(-1),
+				SBytes:    []byte("hello"),
+				SString:   "谷歌",
+			},
+		}, {
+			desc:  "numbers of other go types",
+			input: map[string]interface{}{"sInt32": int64(-1), "sUint64": 5, "sFloat": 1.25, "sDouble": int64(2), "sInt64": float64(1 << 60)},
+			want:  &pb3.Scalars{SInt32: -1, SUint64: 5, SFloat: 1.25, SDouble: 2, SInt64: 1 << 60},
+		}, {
+			desc:    "int32 out of range",
+			input:   map[string]interface{}{"sInt32": int64(math.MaxInt32 + 1)},
+			wantErr: true,
+		}, {
+			desc:    "negative uint",
+			input:   map[string]interface{}{"sUint32": int64(-1)},
+			wantErr: true,
+		}, {
+			desc:    "fractional int",
+			input:   map[string]interface{}{"sInt64": 1.5},
+			wantErr: true,
+		}, {
+			desc:    "float out of range",
+			input:   map[string]interface{}{"sFloat": math.MaxFloat64},
+			wantErr: true,
+		}, {
+			desc:    "string for number",
+			input:   map[string]interface{}{"sInt64": "1"},
+			wantErr: true,
+		}, {
+			desc:    "invalid UTF-8",
+			input:   map[string]interface{}{"sString": "abc\xff"},
+			wantErr: true,
+		}, {
+			desc:  "nil values",
+			input: map[string]interface{}{"sString": nil, "sBool": nil},
+			want:  &pb3.Scalars{},
+		}, {
+			desc:  "proto2 optional scalars set to zero values",
+			input: map[string]interface{}{"optBool": false, "optInt32": int32(0), "optString": ""},
+			want:  &pb2.Scalars{OptBool: proto.Bool(false), OptInt32: proto.Int32(0), OptString: proto.String("")},
+		}, {
+			desc:  "enums",
+			input: map[string]interface{}{"sEnum": "TEN", "sNestedEnum": int64(42)},
+			want:  &pb3.Enums{SEnum: pb3.Enum_TEN, SNestedEnum: 42},
+		}, {
+			desc:    "invalid enum name",
+			input:   map[string]interface{}{"sEnum": "ELEVEN"},
+			wantErr: true,
+		}, {
+			desc:  "repeated fields",
+			input: map[string]interface{}{"rptString": []interface{}{"a", nil, "c"}, "rptInt64": []interface{}{int64(1), int64(2)}, "rptBytes": []interface{}{nil}},
+			want:  &pb3.Repeats{RptString: []string{"a", "", "c"}, RptInt64: []int64{1, 2}, RptBytes: [][]byte{{}}},
+		}, {
+			desc:    "repeated field not an array",
+			input:   map[string]interface{}{"rptString": "a"},
+			wantErr: true,
+		}, {
+			desc: "maps",
+			input: map[string]interface{}{
+				"int32ToStr":   map[string]interface{}{"-1": "minus one", "10": "ten"},
+				"boolToUint32": map[string]interface{}{"true": uint32(1)},
+				"uint64ToEnum": map[string]interface{}{"1": "ONE"},
+				"strToNested":  map[string]interface{}{"nested": map[string]interface{}{"sString": "nested value"}},
+			},
+			want: &pb3.Maps{
+				Int32ToStr:   map[int32]string{-1: "minus one", 10: "ten"},
+				BoolToUint32: map[bool]uint32{true: 1},
+				Uint64ToEnum: map[uint64]pb3.Enum{1: pb3.Enum_ONE},
+				StrToNested:  map[string]*pb3.Nested{"nested": {SString: "nested value"}},
+			},
+		}, {
+			desc:    "invalid map key",
+			input:   map[string]interface{}{"int32ToStr": map[string]interface{}{"one": "one"}},
+			wantErr: true,
+		}, {
+			desc:  "nested messages",
+			input: map[string]interface{}{"sNested": map[string]interface{}{"sString": "a", "sNested": map[string]interface{}{}}},
+			want:  &pb3.Nests{SNested: &pb3.Nested{SString: "a", SNested: &pb3.Nested{}}},
+		}, {
+			desc:  "oneof",
+			input: map[string]interface{}{"oneofNested": map[string]interface{}{}},
+			want:  &pb3.Oneofs{Union: &pb3.Oneofs_OneofNested{OneofNested: &pb3.Nested{}}},
+		}, {
+			desc:    "more than one oneof field",
+			input:   map[string]interface{}{"oneofEnum": "ONE", "oneofString": "a"},
+			wantErr: true,
+		}, {
+			desc:  "json names",
+			input: map[string]interface{}{"foo_bar": "json name"},
+			want:  &pb3.JSONNames{SString: "json name"},
+		}, {
+			desc:    "unknown field",
+			input:   map[string]interface{}{"sUnknown": "a"},
+			wantErr: true,
+		}, {
+			desc:           "discard unknown field",
+			input:          map[string]interface{}{"sUnknown": "a", "sString": "b"},
+			discardUnknown: true,
+			want:           &pb3.Scalars{SString: "b"},
+		}, {
+			desc: "extensions",
+			input: map[string]interface{}{
+				"optString":          "non-extension field",
+				"[pb2.opt_ext_bool]": true,
+				"[pb2.ExtensionsContainer.opt_ext_nested]": map[string]interface{}{"optString": "nested in an extension"},
+			},
+			want: func() proto.Message {
+				m := &pb2.Extensions{OptString: proto.String("non-extension field")}
+				proto.SetExtension(m, pb2.E_OptExtBool, true)
+				proto.SetExtension(m, pb2.E_ExtensionsContainer_OptExtNested, &pb2.Nested{OptString: proto.String("nested in an extension")})
+				return m
+			}(),
+		}, {
+			desc:    "missing required field",
+			input:   map[string]interface{}{"reqBool": true},
+			into:    &pb2.Requireds{},
+			wantErr: true,
+		}, {
+			desc: "well known types",
+			input: map[string]interface{}{
+				"optTimestamp": time.Unix(1553036601, 5).In(time.FixedZone("", 3600)),
+				"optEmpty":     map[string]interface{}{},
+			},
+			want: &pb2.KnownTypes{
+				OptTimestamp: &timestamppb.Timestamp{Seconds: 1553036601, Nanos: 5},
+				OptEmpty:     &emptypb.Empty{},
+			},
+		}, {
+			desc:    "unsupported well known type",
+			input:   map[string]interface{}{"optDuration": "1s"},
+			into:    &pb2.KnownTypes{},
+			wantErr: true,
+		}, {
+			desc:    "string for timestamp",
+			input:   map[string]interface{}{"optTimestamp": "2019-03-19T23:03:21Z"},
+			into:    &pb2.KnownTypes{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, form := range forms {
+			t.Run(tt.desc+"/"+form.name, func(t *testing.T) {
+				var into proto.Message = &pb3.Scalars{}
+				switch {
+				case tt.into != nil:
+					into = tt.into
+				case tt.want != nil:
+					into = tt.want.ProtoReflect().New().Interface()
+				}
+				got := form.of(into)
+
+				err := pkg.UnmarshalOptions{
+					DiscardUnknown: tt.discardUnknown,
+				}.Unmarshal(tt.input, got)
+
+				if err != nil && !tt.wantErr {
+					t.Errorf("Unmarshal() returned error: %v\n", err)
+				}
+
+				if err == nil && tt.wantErr {
+					t.Errorf("Unmarshal() got nil error, want error\n")
+				}
+
+				if tt.want != nil && !proto.Equal(got, tt.want) {
+					t.Errorf("Unmarshal()\n got: %v\nwant: %v\n", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestMarshalUnmarshalRoundTrip(t *testing.T) {
+	// Empty strings, bytes and messages are omitted, so they only round trip
+	// where the encoding keeps them.
+	tests := []struct {
+		input        proto.Message
+		defaultsOnly bool
+	}{
+		{input: &pb3.Scalars{SBool: true, SInt32: -5, SUint64: math.MaxUint64, SFloat: 0.1, SDouble: math.NaN(), SBytes: []byte{0}, SString: "x"}},
+		{input: &pb3.Repeats{RptBool: []bool{true, false}, RptString: []string{"", "b"}, RptBytes: [][]byte{{}, {1}}, RptFloat: []float32{1.1}}},
+		{input: &pb3.Proto3Optional{OptBool: proto.Bool(false), OptInt64: proto.Int64(0), OptEnum: pb3.Enum_ZERO.Enum()}},
+		{input: &pb3.Proto3Optional{OptMessage: &pb3.Nested{}}, defaultsOnly: true},
+		{input: &pb3.Enums{SEnum: pb3.Enum_TWO, SNestedEnum: 7}},
+		{input: &pb3.Nests{SNested: &pb3.Nested{SNested: &pb3.Nested{SString: "deep"}}}},
+		{input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofNested{OneofNested: &pb3.Nested{}}}, defaultsOnly: true},
+		{input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofEnum{OneofEnum: pb3.Enum_ZERO}}},
+		{input: &pb3.Maps{Int32ToStr: map[int32]string{1: "a"}, StrToOneofs: map[string]*pb3.Oneofs{"a": {Union: &pb3.Oneofs_OneofString{OneofString: "b"}}}}},
+		{input: &pb2.KnownTypes{OptTimestamp: timestamppb.New(time.Unix(0, 999999999))}},
+	}
+
+	for _, defaults := range []bool{false, true} {
+		for _, tt := range tests {
+			if tt.defaultsOnly && !defaults {
+				continue
+			}
+
+			for _, form := range forms {
+				input := form.of(tt.input)
+
+				object, err := pkg.MarshalOptions{EmitFirestoreSensibleDefaults: defaults}.Marshal(input)
+				if err != nil {
+					t.Errorf("Marshal(%v) returned error: %v\n", input, err)
+					continue
+				}
+
+				got := input.ProtoReflect().New().Interface()
+				if err := pkg.Unmarshal(object, got); err != nil {
+					t.Errorf("Unmarshal(%v) returned error: %v\n", object, err)
+					continue
+				}
+
+				if !proto.Equal(got, input) {
+					t.Errorf("%v round trip with defaults %v\n got: %v\nwant: %v\n", form.name, defaults, got, input)
+				}
+			}
+		}
+	}
+}
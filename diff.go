@@ -0,0 +1,386 @@
+package protofirestore
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// MarshalDiff marshals the fields which differ between old and new into a map
+// of firestore field paths to values, suitable for a firestore update.
+func MarshalDiff(old, new proto.Message) (map[string]interface{}, error) {
+	return MarshalOptions{}.MarshalDiff(old, new)
+}
+
+// MarshalDiff marshals the fields which differ between old and new into a map
+// of firestore field paths to values, suitable for a firestore update which
+// turns the document marshaled from old into the document marshaled from new.
+//
+// Rather than rewriting whole arrays and counters, changes are expressed as
+// transforms where possible, which keeps concurrent writes from overwriting
+// each other:
+//   - a repeated scalar field with unique elements which only gained elements
+//     at its end is mapped to ArrayUnion,
+//   - a repeated scalar field with unique elements which only lost elements
+//     is mapped to ArrayRemove,
+//   - a numeric field, or a map field with numeric values, annotated with
+//     (protofirestore.field).counter is mapped to Increment.
+//
+// Nested messages and maps are compared field by field and fields which are
+// no longer present are mapped to Delete. With EmitOneofCase, oneofs whose
+// case changed also update their case.
+func (o MarshalOptions) MarshalDiff(old, new proto.Message) (map[string]interface{}, error) {
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+
+	if old == nil || new == nil {
+		return nil, errors.New("cannot diff nil message")
+	}
+
+	om, nm := old.ProtoReflect(), new.ProtoReflect()
+
+	if om.Descriptor().FullName() != nm.Descriptor().FullName() {
+		return nil, fmt.Errorf("cannot diff %v with %v", om.Descriptor().FullName(), nm.Descriptor().FullName())
+	}
+
+	if marshal := wellKnownTypeMarshaler(nm.Descriptor().FullName()); marshal != nil {
+		return nil, errors.New("no support for well known types as top level objects in firestore documents")
+	}
+
+	enc := encoder{o}
+	updates := make(map[string]interface{})
+
+	if err := enc.diffMessage(updates, nil, om, nm); err != nil {
+		return nil, err
+	}
+
+	return updates, nil
+}
+
+// diffMessage adds the updates turning old into new to the given map, with
+// the field paths prefixed by the given segments.
+func (e encoder) diffMessage(updates map[string]interface{}, prefix []string, old, new protoreflect.Message) error {
+	var fds []protoreflect.FieldDescriptor
+
+	fields := new.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fds = append(fds, fields.Get(i))
+	}
+
+	// Extensions are only known once they are populated in either message.
+	extensions := make(map[protoreflect.FullName]bool)
+	collectExtensions := func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if fd.IsExtension() && !extensions[fd.FullName()] {
+			extensions[fd.FullName()] = true
+			fds = append(fds, fd)
+		}
+		return true
+	}
+	old.Range(collectExtensions)
+	new.Range(collectExtensions)
+
+	for _, fd := range fds {
+		path := append(prefix[:len(prefix):len(prefix)], fd.JSONName())
+		if err := e.diffField(updates, path, old, new, fd); err != nil {
+			return err
+		}
+	}
+
+	if e.opts.EmitOneofCase {
+		for _, od := range oneofs(new.Descriptor()) {
+			if err := checkOneofCaseKey(od); err != nil {
+				return err
+			}
+			if oc, nc := oneofCase(old, od), oneofCase(new, od); oc != nc {
+				updates[joinFieldPath(append(prefix[:len(prefix):len(prefix)], OneofCaseKey(od)))] = nc
+			}
+		}
+	}
+
+	return nil
+}
+
+// diffField adds the updates turning the field fd of old into the field fd
+// of new to the given map.
+func (e encoder) diffField(updates map[string]interface{}, path []string, old, new protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	ov, err := e.marshalField(old, fd)
+	if err != nil {
+		return err
+	}
+
+	nv, err := e.marshalField(new, fd)
+	if err != nil {
+		return err
+	}
+
+	if equalValues(ov, nv) {
+		return nil
+	}
+
+	key := joinFieldPath(path)
+
+	if nv == nil {
+		updates[key] = Delete
+		return nil
+	}
+
+	counter := fieldOptions(fd).GetCounter()
+
+	switch {
+	case counter && fd.IsList():
+		return fmt.Errorf("field %v: repeated fields cannot be counters", fd.FullName())
+
+	case counter && fd.IsMap():
+		if !isNumericKind(fd.MapValue().Kind()) {
+			return fmt.Errorf("field %v: counter map values must be numeric", fd.FullName())
+		}
+
+		om, _ := ov.(map[string]interface{})
+		nm := nv.(map[string]interface{})
+
+		for k := range om {
+			if _, ok := nm[k]; !ok {
+				updates[joinFieldPath(append(path, k))] = Delete
+			}
+		}
+
+		for k, v := range nm {
+			if equalValues(om[k], v) {
+				continue
+			}
+			if n, ok := incrementValue(om[k], v); ok {
+				updates[joinFieldPath(append(path, k))] = Increment{N: n}
+			} else {
+				updates[joinFieldPath(append(path, k))] = v
+			}
+		}
+
+	case counter:
+		if !isNumericKind(fd.Kind()) {
+			return fmt.Errorf("field %v: counters must be numeric", fd.FullName())
+		}
+
+		if n, ok := incrementValue(ov, nv); ok {
+			updates[key] = Increment{N: n}
+		} else {
+			updates[key] = nv
+		}
+
+	case fd.IsList() && fd.Message() == nil:
+		// Empty arrays are not stored unless defaults are emitted, so a
+		// missing array gains its elements by a union as well.
+		oa, _ := ov.([]interface{})
+		if oa == nil {
+			oa = []interface{}{}
+		}
+		if transform := arrayTransform(oa, nv.([]interface{})); transform != nil {
+			updates[key] = transform
+		} else {
+			updates[key] = nv
+		}
+
+	case ov == nil, fd.IsList():
+		updates[key] = nv
+
+	case fd.IsMap():
+		om, nm := ov.(map[string]interface{}), nv.(map[string]interface{})
+
+		for k := range om {
+			if _, ok := nm[k]; !ok {
+				updates[joinFieldPath(append(path, k))] = Delete
+			}
+		}
+
+		for k, v := range nm {
+			if !equalValues(om[k], v) {
+				updates[joinFieldPath(append(path, k))] = v
+			}
+		}
+
+	case fd.Message() != nil && wellKnownTypeMarshaler(fd.Message().FullName()) == nil:
+		return e.diffMessage(updates, path, old.Get(fd).Message(), new.Get(fd).Message())
+
+	default:
+		updates[key] = nv
+	}
+
+	return nil
+}
+
+func isNumericKind(kind protoreflect.Kind) bool {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind,
+		protoreflect.FloatKind, protoreflect.DoubleKind:
+		return true
+	}
+	return false
+}
+
+// incrementValue returns the value which needs to be added to the marshaled
+// numeric value old to get new. A nil old value counts as zero, the same way
+// firestore treats missing fields when incrementing them. It reports false if
+// the difference cannot be represented by the type of new.
+func incrementValue(old, new interface{}) (interface{}, bool) {
+	switch n := new.(type) {
+	case int32:
+		o, _ := old.(int32)
+		d := int64(n) - int64(o)
+		return int32(d), d >= math.MinInt32 && d <= math.MaxInt32
+	case int64:
+		o, _ := old.(int64)
+		d := n - o
+		return d, (d < n) == (o > 0)
+	case uint32:
+		o, _ := old.(uint32)
+		return n - o, n >= o
+	case uint64:
+		o, _ := old.(uint64)
+		return n - o, n >= o
+	case float32:
+		o, _ := old.(float32)
+		d := n - o
+		return d, !math.IsNaN(float64(d)) && !math.IsInf(float64(d), 0)
+	case float64:
+		o, _ := old.(float64)
+		d := n - o
+		return d, !math.IsNaN(d) && !math.IsInf(d, 0)
+	}
+	return nil, false
+}
+
+// arrayTransform returns the ArrayUnion or ArrayRemove turning the marshaled
+// array old into new, or nil if there is none because the arrays do not have
+// set semantics.
+func arrayTransform(old, new []interface{}) Sentinel {
+	if !uniqueValues(old) || !uniqueValues(new) {
+		return nil
+	}
+
+	switch {
+	case len(new) > len(old):
+		if !equalValues(old, new[:len(old)]) {
+			return nil
+		}
+
+		added := new[len(old):]
+		for _, v := range added {
+			if containsValue(old, v) {
+				return nil
+			}
+		}
+
+		return ArrayUnion{Elems: added}
+
+	case len(new) < len(old):
+		var removed []interface{}
+
+		i := 0
+		for _, v := range old {
+			if i < len(new) && equalValues(v, new[i]) {
+				i++
+			} else {
+				removed = append(removed, v)
+			}
+		}
+
+		if i != len(new) {
+			return nil
+		}
+
+		return ArrayRemove{Elems: removed}
+	}
+
+	return nil
+}
+
+func uniqueValues(values []interface{}) bool {
+	for i, v := range values {
+		if containsValue(values[:i], v) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, w := range values {
+		if equalValues(v, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceUpdates returns the updates of the top level keys which turn the
+// document old into the document new: keys of old missing from new are
+// mapped to Delete and keys of new whose values differ from old are set.
+// Unchanged keys are omitted, so the updates are empty if the documents are
+// equal. Replacing the keys of a document rather than setting it allows the
+// write to be guarded by a precondition.
+func ReplaceUpdates(old, new map[string]interface{}) map[string]interface{} {
+	updates := make(map[string]interface{})
+
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			updates[JoinFieldPath(k)] = Delete
+		}
+	}
+
+	for k, v := range new {
+		if w, ok := old[k]; !ok || !equalValues(v, w) {
+			updates[JoinFieldPath(k)] = v
+		}
+	}
+
+	return updates
+}
+
+// equalValues reports whether the marshaled values x and y are equal. Unlike
+// reflect.DeepEqual, it considers NaNs equal to each other.
+func equalValues(x, y interface{}) bool {
+	switch x := x.(type) {
+	case float32:
+		y, ok := y.(float32)
+		return ok && (x == y || x != x && y != y)
+	case float64:
+		y, ok := y.(float64)
+		return ok && (x == y || x != x && y != y)
+	case time.Time:
+		y, ok := y.(time.Time)
+		return ok && x.Equal(y)
+	case []interface{}:
+		y, ok := y.([]interface{})
+		if !ok || len(x) != len(y) || (x == nil) != (y == nil) {
+			return false
+		}
+		for i := range x {
+			if !equalValues(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := y.(map[string]interface{})
+		if !ok || len(x) != len(y) || (x == nil) != (y == nil) {
+			return false
+		}
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !equalValues(v, w) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(x, y)
+	}
+}
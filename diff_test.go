@@ -0,0 +1,403 @@
+package protofirestore_test
+
+import (
+	"math"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pkg "github.com/daviddomkar/protofirestore"
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	pb2 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb2"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/go-test/deep"
+)
+
+func TestMarshalDiff(t *testing.T) {
+	tests := []struct {
+		desc     string
+		old      proto.Message
+		new      proto.Message
+		defaults bool
+		want     map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			desc: "equal messages",
+			old: &pb3.Scalars{
+				SString: "hello",
+				SDouble: math.NaN(),
+			},
+			new: &pb3.Scalars{
+				SString: "hello",
+				SDouble: math.NaN(),
+			},
+			want: map[string]interface{}{},
+		}, {
+			desc: "changed and removed scalars",
+			old: &pb3.Scalars{
+				SBool:   true,
+				SString: "hello",
+			},
+			new: &pb3.Scalars{
+				SString: "world",
+				SInt64:  42,
+			},
+			want: map[string]interface{}{
+				"sBool":   pkg.Delete,
+				"sString": "world",
+				"sInt64":  int64(42),
+			},
+		}, {
+			desc: "nested messages",
+			old: &pb2.Nests{
+				OptNested: &pb2.Nested{
+					OptString: proto.String("nested message"),
+					OptNested: &pb2.Nested{
+						OptString: proto.String("another nested message"),
+					},
+				},
+			},
+			new: &pb2.Nests{
+				OptNested: &pb2.Nested{
+					OptString: proto.String("nested message"),
+					OptNested: &pb2.Nested{
+						OptString: proto.String("changed nested message"),
+					},
+				},
+				Optgroup: &pb2.Nests_OptGroup{
+					OptString: proto.String("inside a group"),
+				},
+			},
+			want: map[string]interface{}{
+				"optNested.optNested.optString": "changed nested message",
+				"optgroup": map[string]interface{}{
+					"optString": "inside a group",
+				},
+			},
+		}, {
+			desc: "oneof switched",
+			old: &pb3.Oneofs{
+				Union: &pb3.Oneofs_OneofString{OneofString: "hello"},
+			},
+			new: &pb3.Oneofs{
+				Union: &pb3.Oneofs_OneofEnum{OneofEnum: pb3.Enum_ONE},
+			},
+			want: map[string]interface{}{
+				"oneofString": pkg.Delete,
+				"oneofEnum":   "ONE",
+			},
+		}, {
+			desc: "map entries",
+			old: &pb3.Maps{
+				Int32ToStr: map[int32]string{1: "one", 2: "two", 3: "three"},
+			},
+			new: &pb3.Maps{
+				Int32ToStr: map[int32]string{1: "one", 2: "dos", 4: "four"},
+			},
+			want: map[string]interface{}{
+				"int32ToStr.`2`": "dos",
+				"int32ToStr.`3`": pkg.Delete,
+				"int32ToStr.`4`": "four",
+			},
+		}, {
+			desc: "array union",
+			old: &pbann.Counters{
+				Tags: []string{"a", "b"},
+			},
+			new: &pbann.Counters{
+				Tags: []string{"a", "b", "c", "d"},
+			},
+			want: map[string]interface{}{
+				"tags": pkg.ArrayUnion{Elems: []interface{}{"c", "d"}},
+			},
+		}, {
+			desc: "array union from empty array",
+			old:  &pbann.Counters{},
+			new: &pbann.Counters{
+				Tags: []string{"a"},
+			},
+			want: map[string]interface{}{
+				"tags": pkg.ArrayUnion{Elems: []interface{}{"a"}},
+			},
+		}, {
+			desc: "array with duplicate elements from empty array",
+			old:  &pbann.Counters{},
+			new: &pbann.Counters{
+				Scores: []int64{1, 1},
+			},
+			want: map[string]interface{}{
+				"scores": []interface{}{int64(1), int64(1)},
+			},
+		}, {
+			desc: "array remove",
+			old: &pbann.Counters{
+				Scores: []int64{1, 2, 3, 4},
+			},
+			new: &pbann.Counters{
+				Scores: []int64{1, 3},
+			},
+			want: map[string]interface{}{
+				"scores": pkg.ArrayRemove{Elems: []interface{}{int64(2), int64(4)}},
+			},
+		}, {
+			desc: "array with added and removed elements",
+			old: &pbann.Counters{
+				Tags: []string{"a", "b"},
+			},
+			new: &pbann.Counters{
+				Tags: []string{"a", "c"},
+			},
+			want: map[string]interface{}{
+				"tags": []interface{}{"a", "c"},
+			},
+		}, {
+			desc: "array with reordered elements",
+			old: &pbann.Counters{
+				Tags: []string{"a", "b"},
+			},
+			new: &pbann.Counters{
+				Tags: []string{"b", "a", "c"},
+			},
+			want: map[string]interface{}{
+				"tags": []interface{}{"b", "a", "c"},
+			},
+		}, {
+			desc: "array with duplicate elements",
+			old: &pbann.Counters{
+				Scores: []int64{1, 1},
+			},
+			new: &pbann.Counters{
+				Scores: []int64{1, 1, 2},
+			},
+			want: map[string]interface{}{
+				"scores": []interface{}{int64(1), int64(1), int64(2)},
+			},
+		}, {
+			desc: "array union with element already present",
+			old: &pbann.Counters{
+				Tags: []string{"a", "b"},
+			},
+			new: &pbann.Counters{
+				Tags: []string{"a", "b", "a"},
+			},
+			want: map[string]interface{}{
+				"tags": []interface{}{"a", "b", "a"},
+			},
+		}, {
+			desc: "array of messages",
+			old: &pbann.Counters{
+				Children: []*pbann.Counters{{Name: "a"}},
+			},
+			new: &pbann.Counters{
+				Children: []*pbann.Counters{{Name: "a"}, {Name: "b"}},
+			},
+			want: map[string]interface{}{
+				"children": []interface{}{
+					map[string]interface{}{"name": "a"},
+					map[string]interface{}{"name": "b"},
+				},
+			},
+		}, {
+			desc: "counters",
+			old: &pbann.Counters{
+				Likes:   10,
+				Views:   100,
+				Shares:  5,
+				Rating:  4.5,
+				Version: 1,
+			},
+			new: &pbann.Counters{
+				Likes:   7,
+				Views:   101,
+				Shares:  6,
+				Rating:  4,
+				Version: 2,
+			},
+			want: map[string]interface{}{
+				"likes":   pkg.Increment{N: int32(-3)},
+				"views":   pkg.Increment{N: int64(1)},
+				"shares":  pkg.Increment{N: uint64(1)},
+				"rating":  pkg.Increment{N: float64(-0.5)},
+				"version": int64(2),
+			},
+		}, {
+			desc: "counters from zero",
+			old:  &pbann.Counters{},
+			new: &pbann.Counters{
+				Views: 3,
+			},
+			want: map[string]interface{}{
+				"views": pkg.Increment{N: int64(3)},
+			},
+		}, {
+			desc: "counters to zero with firestore sensible defaults",
+			old: &pbann.Counters{
+				Views: 3,
+			},
+			new:      &pbann.Counters{},
+			defaults: true,
+			want: map[string]interface{}{
+				"views": pkg.Increment{N: int64(-3)},
+			},
+		}, {
+			desc: "counters to zero",
+			old: &pbann.Counters{
+				Views: 3,
+			},
+			new: &pbann.Counters{},
+			want: map[string]interface{}{
+				"views": pkg.Delete,
+			},
+		}, {
+			desc: "decreased unsigned counter",
+			old: &pbann.Counters{
+				Shares: 6,
+			},
+			new: &pbann.Counters{
+				Shares: 5,
+			},
+			want: map[string]interface{}{
+				"shares": uint64(5),
+			},
+		}, {
+			desc: "overflowing counter",
+			old: &pbann.Counters{
+				Views: math.MinInt64,
+			},
+			new: &pbann.Counters{
+				Views: math.MaxInt64,
+			},
+			want: map[string]interface{}{
+				"views": int64(math.MaxInt64),
+			},
+		}, {
+			desc: "counters in nested message",
+			old: &pbann.Counters{
+				Child: &pbann.Counters{Name: "child", Likes: 1},
+			},
+			new: &pbann.Counters{
+				Child: &pbann.Counters{Name: "child", Likes: 2},
+			},
+			want: map[string]interface{}{
+				"child.likes": pkg.Increment{N: int32(1)},
+			},
+		}, {
+			desc: "map of counters",
+			old: &pbann.Counters{
+				Totals: map[string]int64{"a": 1, "b": 2, "e": 1},
+			},
+			new: &pbann.Counters{
+				Totals: map[string]int64{"a": 1, "b": 5, "c d": 1},
+			},
+			want: map[string]interface{}{
+				"totals.b":     pkg.Increment{N: int64(3)},
+				"totals.e":     pkg.Delete,
+				"totals.`c d`": pkg.Increment{N: int64(1)},
+			},
+		}, {
+			desc:    "different message types",
+			old:     &pb3.Scalars{},
+			new:     &pb3.Nests{},
+			wantErr: true,
+		}, {
+			desc: "invalid value",
+			old:  &pb3.Scalars{},
+			new: &pb3.Scalars{
+				SString: "abc\xff",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := pkg.MarshalOptions{
+				EmitFirestoreSensibleDefaults: tt.defaults,
+			}.MarshalDiff(tt.old, tt.new)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("MarshalDiff() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("MarshalDiff() got nil error, want error\n")
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			diff := deep.Equal(got, tt.want)
+
+			if diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestReplaceUpdates(t *testing.T) {
+	tests := []struct {
+		desc string
+		old  map[string]interface{}
+		new  map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			desc: "equal documents",
+			old: map[string]interface{}{
+				"name":  "hello",
+				"score": math.NaN(),
+				"tags":  []interface{}{"a", "b"},
+			},
+			new: map[string]interface{}{
+				"name":  "hello",
+				"score": math.NaN(),
+				"tags":  []interface{}{"a", "b"},
+			},
+			want: map[string]interface{}{},
+		}, {
+			desc: "changed, added and removed keys",
+			old: map[string]interface{}{
+				"name":  "hello",
+				"count": int64(1),
+				"flag":  true,
+			},
+			new: map[string]interface{}{
+				"name":  "world",
+				"count": int64(1),
+				"tags":  []interface{}{"a"},
+			},
+			want: map[string]interface{}{
+				"name": "world",
+				"flag": pkg.Delete,
+				"tags": []interface{}{"a"},
+			},
+		}, {
+			desc: "keys which are not simple field names",
+			old: map[string]interface{}{
+				"a.b": "x",
+			},
+			new: map[string]interface{}{
+				"c-d": "y",
+			},
+			want: map[string]interface{}{
+				"`a.b`": pkg.Delete,
+				"`c-d`": "y",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := pkg.ReplaceUpdates(tt.old, tt.new)
+
+			diff := deep.Equal(got, tt.want)
+
+			if diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
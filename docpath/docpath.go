@@ -0,0 +1,165 @@
+// Package docpath implements the document path patterns of the path message
+// option, e.g. "users/{user}/orders/{order}", which alternate collection IDs
+// and document ID variables like the resource name patterns of resource
+// oriented APIs.
+package docpath
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Pattern is a parsed document path pattern.
+type Pattern struct {
+	// collections are the collection IDs of the pattern, from the root
+	// collection to the collection of the document.
+	collections []string
+
+	// variables are the names of the document ID variables, one for each
+	// collection.
+	variables []string
+}
+
+// Parse parses a document path pattern. A pattern consists of an even number
+// of segments separated by slashes, where every odd segment is a collection
+// ID and every even segment is a variable in curly braces standing for a
+// document ID.
+func Parse(pattern string) (*Pattern, error) {
+	if pattern == "" {
+		return nil, errors.New("empty document path pattern")
+	}
+
+	segments := strings.Split(pattern, "/")
+	if len(segments)%2 != 0 {
+		return nil, fmt.Errorf("document path pattern %q has an odd number of segments", pattern)
+	}
+
+	p := &Pattern{}
+	seen := make(map[string]bool)
+
+	for i := 0; i < len(segments); i += 2 {
+		collection, variable := segments[i], segments[i+1]
+
+		if !isValidID(collection) || strings.ContainsAny(collection, "{}") {
+			return nil, fmt.Errorf("document path pattern %q has invalid collection ID %q", pattern, collection)
+		}
+
+		if len(variable) < 3 || variable[0] != '{' || variable[len(variable)-1] != '}' {
+			return nil, fmt.Errorf("document path pattern %q has segment %q instead of a variable", pattern, variable)
+		}
+
+		name := variable[1 : len(variable)-1]
+		if !isVariableName(name) {
+			return nil, fmt.Errorf("document path pattern %q has invalid variable name %q", pattern, name)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("document path pattern %q has duplicate variable %q", pattern, name)
+		}
+		seen[name] = true
+
+		p.collections = append(p.collections, collection)
+		p.variables = append(p.variables, name)
+	}
+
+	return p, nil
+}
+
+// MustParse is like Parse but panics if the pattern cannot be parsed. It
+// simplifies the initialization of global variables holding patterns.
+func MustParse(pattern string) *Pattern {
+	p, err := Parse(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the pattern in its textual form.
+func (p *Pattern) String() string {
+	var b strings.Builder
+	for i := range p.collections {
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		b.WriteString(p.collections[i])
+		b.WriteString("/{")
+		b.WriteString(p.variables[i])
+		b.WriteByte('}')
+	}
+	return b.String()
+}
+
+// CollectionID returns the ID of the collection of the documents, which is
+// also their collection group.
+func (p *Pattern) CollectionID() string {
+	return p.collections[len(p.collections)-1]
+}
+
+// Variables returns the names of the document ID variables of the pattern in
+// order.
+func (p *Pattern) Variables() []string {
+	return append([]string(nil), p.variables...)
+}
+
+// Expand returns the path of the document identified by the given document
+// IDs, one for each variable of the pattern in order.
+func (p *Pattern) Expand(ids ...string) (string, error) {
+	if len(ids) != len(p.variables) {
+		return "", fmt.Errorf("document path pattern %q has %d variables, got %d document IDs", p, len(p.variables), len(ids))
+	}
+
+	var b strings.Builder
+	for i, id := range ids {
+		if !isValidID(id) {
+			return "", fmt.Errorf("invalid document ID %q for variable %q", id, p.variables[i])
+		}
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		b.WriteString(p.collections[i])
+		b.WriteByte('/')
+		b.WriteString(id)
+	}
+
+	return b.String(), nil
+}
+
+// Match reports whether path is the path of a document matching the pattern
+// and returns the document IDs of the variables of the pattern in order.
+func (p *Pattern) Match(path string) ([]string, bool) {
+	segments := strings.Split(path, "/")
+	if len(segments) != 2*len(p.collections) {
+		return nil, false
+	}
+
+	ids := make([]string, len(p.variables))
+	for i := range p.collections {
+		if segments[2*i] != p.collections[i] || !isValidID(segments[2*i+1]) {
+			return nil, false
+		}
+		ids[i] = segments[2*i+1]
+	}
+
+	return ids, true
+}
+
+// isValidID reports whether s is a valid firestore collection or document ID.
+func isValidID(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.Contains(s, "/") &&
+		!(strings.HasPrefix(s, "__") && strings.HasSuffix(s, "__")) && len(s) <= 1500
+}
+
+// isVariableName reports whether s is a valid variable name.
+func isVariableName(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,129 @@
+package docpath_test
+
+import (
+	"testing"
+
+	"github.com/daviddomkar/protofirestore/docpath"
+	"github.com/go-test/deep"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		desc         string
+		pattern      string
+		collectionID string
+		variables    []string
+		wantErr      bool
+	}{
+		{
+			desc:         "root collection",
+			pattern:      "users/{user}",
+			collectionID: "users",
+			variables:    []string{"user"},
+		}, {
+			desc:         "subcollection",
+			pattern:      "users/{user}/orders/{order_id}",
+			collectionID: "orders",
+			variables:    []string{"user", "order_id"},
+		}, {
+			desc:    "empty",
+			pattern: "",
+			wantErr: true,
+		}, {
+			desc:    "collection only",
+			pattern: "users",
+			wantErr: true,
+		}, {
+			desc:    "literal document ID",
+			pattern: "users/alice",
+			wantErr: true,
+		}, {
+			desc:    "variable collection ID",
+			pattern: "{users}/{user}",
+			wantErr: true,
+		}, {
+			desc:    "empty collection ID",
+			pattern: "/{user}",
+			wantErr: true,
+		}, {
+			desc:    "reserved collection ID",
+			pattern: "__users__/{user}",
+			wantErr: true,
+		}, {
+			desc:    "invalid variable name",
+			pattern: "users/{1user}",
+			wantErr: true,
+		}, {
+			desc:    "duplicate variable",
+			pattern: "users/{id}/orders/{id}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := docpath.Parse(tt.pattern)
+
+			if err != nil && !tt.wantErr {
+				t.Fatalf("Parse() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Fatalf("Parse() got nil error, want error\n")
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got.String() != tt.pattern {
+				t.Errorf("String() = %q, want %q\n", got.String(), tt.pattern)
+			}
+
+			if got.CollectionID() != tt.collectionID {
+				t.Errorf("CollectionID() = %q, want %q\n", got.CollectionID(), tt.collectionID)
+			}
+
+			if diff := deep.Equal(got.Variables(), tt.variables); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestPatternExpandMatch(t *testing.T) {
+	p, err := docpath.Parse("users/{user}/orders/{order}")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v\n", err)
+	}
+
+	path, err := p.Expand("alice", "o1")
+	if err != nil {
+		t.Fatalf("Expand() returned error: %v\n", err)
+	}
+
+	if path != "users/alice/orders/o1" {
+		t.Errorf("Expand() = %q, want %q\n", path, "users/alice/orders/o1")
+	}
+
+	ids, ok := p.Match(path)
+	if !ok {
+		t.Fatalf("Match(%q) did not match\n", path)
+	}
+
+	if diff := deep.Equal(ids, []string{"alice", "o1"}); diff != nil {
+		t.Error(diff)
+	}
+
+	for _, ids := range [][]string{{"alice"}, {"alice", ""}, {"alice", "a/b"}, {"..", "o1"}} {
+		if _, err := p.Expand(ids...); err == nil {
+			t.Errorf("Expand(%q) got nil error, want error\n", ids)
+		}
+	}
+
+	for _, path := range []string{"users/alice", "users/alice/orders", "users/alice/carts/o1", "users/alice/orders/o1/items/i1", "users//orders/o1"} {
+		if _, ok := p.Match(path); ok {
+			t.Errorf("Match(%q) matched, want no match\n", path)
+		}
+	}
+}
@@ -3,8 +3,8 @@ package protofirestore
 import (
 	"errors"
 	"fmt"
-	"unicode/utf8"
 
+	"github.com/daviddomkar/protofirestore/firestoreimpl"
 	"github.com/daviddomkar/protofirestore/internal/encoding/messageset"
 	"github.com/daviddomkar/protofirestore/internal/order"
 	"google.golang.org/protobuf/proto"
@@ -41,6 +41,25 @@ type MarshalOptions struct {
 	// then it will just be nil.
 	EmitFirestoreSensibleDefaults bool
 
+	// EmitType stores the type of the message under TypeKey, so that
+	// documents of collections holding several message types can be read
+	// with UnmarshalNew. The type is the alias of the message in
+	// TypeAliases, or its full name if it has none. Embedded messages are
+	// encoded without their type.
+	EmitType bool
+
+	// TypeAliases are the short names stored by EmitType instead of the full
+	// names of messages.
+	TypeAliases TypeAliases
+
+	// EmitOneofCase stores the case of each oneof of a message under
+	// OneofCaseKey, which is the JSON name of the field of the oneof that is
+	// set, or null if none is. Documents can then be filtered by the case of
+	// a oneof, which is not possible otherwise since firestore queries cannot
+	// test for the absence of a key. The case also tells Unmarshal which
+	// field of the oneof is set when its value is empty and thus omitted.
+	EmitOneofCase bool
+
 	// Resolver is used for looking up types when expanding google.protobuf.Any
 	// messages. If nil, this defaults to using protoregistry.GlobalTypes.
 	Resolver interface {
@@ -71,6 +90,9 @@ func (o MarshalOptions) marshal(m proto.Message) (map[string]interface{}, error)
 	if object, err := enc.marshalMessage(m.ProtoReflect()); err != nil {
 		return nil, err
 	} else {
+		if o.EmitType {
+			object[TypeKey] = o.TypeName(m.ProtoReflect().Descriptor())
+		}
 		return object, proto.CheckInitialized(m)
 	}
 }
@@ -94,10 +116,7 @@ func (m firestoreFieldRanger) Range(f func(protoreflect.FieldDescriptor, protore
 		}
 
 		v := m.Get(fd)
-		isProto2Scalar := fd.Syntax() == protoreflect.Proto2 && fd.Default().IsValid()
-		isSingularMessage := fd.Cardinality() != protoreflect.Repeated && fd.Message() != nil
-
-		if fd.IsList() && v.List().Len() == 0 || fd.IsMap() && v.Map().Len() == 0 || isProto2Scalar || isSingularMessage {
+		if !emitsDefault(fd, v) {
 			continue // ignore empty lists, maps, proto2 scalars, and singular messages
 		}
 
@@ -108,6 +127,19 @@ func (m firestoreFieldRanger) Range(f func(protoreflect.FieldDescriptor, protore
 	m.Message.Range(f)
 }
 
+// emitsDefault reports whether the unpopulated field fd with value v is
+// emitted by firestoreFieldRanger.
+func emitsDefault(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+	if fd.ContainingOneof() != nil {
+		return false
+	}
+
+	isProto2Scalar := fd.Syntax() == protoreflect.Proto2 && fd.Default().IsValid()
+	isSingularMessage := fd.Cardinality() != protoreflect.Repeated && fd.Message() != nil
+
+	return !(fd.IsList() && v.List().Len() == 0 || fd.IsMap() && v.Map().Len() == 0 || isProto2Scalar || isSingularMessage)
+}
+
 // marshalMessage marshals the fields in the given protoreflect.Message.
 // If the typeURL is non-empty, then a synthetic "@type" field is injected
 // containing the URL as the value.
@@ -116,6 +148,16 @@ func (e encoder) marshalMessage(m protoreflect.Message) (map[string]interface{},
 		return nil, errors.New("no support for proto1 MessageSets")
 	}
 
+	// Generated code does not store the cases of oneofs, so messages with
+	// oneofs are encoded by reflection if EmitOneofCase is set.
+	if fm, ok := m.Interface().(firestoreMarshaler); ok && !(e.opts.EmitOneofCase && len(oneofs(m.Descriptor())) > 0) {
+		// Generated code marshals embedded messages with Marshal, which
+		// would store their type.
+		opts := e.opts
+		opts.EmitType = false
+		return fm.MarshalFirestore(opts)
+	}
+
 	var fields order.FieldRanger = m
 	switch {
 	case e.opts.EmitFirestoreSensibleDefaults:
@@ -126,6 +168,9 @@ func (e encoder) marshalMessage(m protoreflect.Message) (map[string]interface{},
 
 	var err error
 	order.RangeFields(fields, order.IndexNameFieldOrder, func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if isSubcollection(fd) {
+			return true // stored as separate documents, see MarshalDocuments
+		}
 		if value, e := e.marshalValue(v, fd); e != nil {
 			err = e
 			return false
@@ -140,9 +185,27 @@ func (e encoder) marshalMessage(m protoreflect.Message) (map[string]interface{},
 		return nil, err
 	}
 
+	if e.opts.EmitOneofCase {
+		if err := e.marshalOneofCases(object, m); err != nil {
+			return nil, err
+		}
+	}
+
 	return object, nil
 }
 
+// marshalField marshals the field fd of the given protoreflect.Message the
+// same way marshalMessage does. It returns nil if marshalMessage would omit
+// the field.
+func (e encoder) marshalField(m protoreflect.Message, fd protoreflect.FieldDescriptor) (interface{}, error) {
+	v := m.Get(fd)
+	if isSubcollection(fd) || !m.Has(fd) && !(e.opts.EmitFirestoreSensibleDefaults && emitsDefault(fd, v)) {
+		return nil, nil
+	}
+
+	return e.marshalValue(v, fd)
+}
+
 // marshalValue marshals the given protoreflect.Value.
 func (e encoder) marshalValue(val protoreflect.Value, fd protoreflect.FieldDescriptor) (interface{}, error) {
 	switch {
@@ -175,15 +238,7 @@ func (e encoder) marshalSingular(val protoreflect.Value, fd protoreflect.FieldDe
 		return val.Bool(), nil
 
 	case protoreflect.StringKind:
-		if val.String() == "" {
-			return nil, nil
-		}
-
-		if !utf8.ValidString(val.String()) {
-			return nil, fmt.Errorf("field %v contains invalid UTF-8", string(fd.FullName()))
-		}
-
-		return val.String(), nil
+		return firestoreimpl.EncodeString(val.String(), fd.FullName())
 
 	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
 		return int32(val.Int()), nil
@@ -204,23 +259,10 @@ func (e encoder) marshalSingular(val protoreflect.Value, fd protoreflect.FieldDe
 		return val.Float(), nil
 
 	case protoreflect.BytesKind:
-		if len(val.Bytes()) == 0 {
-			return nil, nil
-		}
-		return val.Bytes(), nil
+		return firestoreimpl.EncodeBytes(val.Bytes()), nil
 
 	case protoreflect.EnumKind:
-		if fd.Enum().FullName() == "google.protobuf.NullValue" {
-			return nil, nil
-		} else {
-			desc := fd.Enum().Values().ByNumber(val.Enum())
-
-			if desc == nil {
-				return int64(val.Enum()), nil
-			}
-
-			return string(desc.Name()), nil
-		}
+		return firestoreimpl.EncodeEnum(val.Enum(), fd.Enum()), nil
 
 	case protoreflect.MessageKind, protoreflect.GroupKind:
 		m := val.Message()
@@ -955,24 +955,26 @@ func TestMarshal(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.desc, func(t *testing.T) {
-			got, err := pkg.MarshalOptions{
-				EmitFirestoreSensibleDefaults: tt.defaults,
-			}.Marshal(tt.input)
+		for _, form := range forms {
+			t.Run(tt.desc+"/"+form.name, func(t *testing.T) {
+				got, err := pkg.MarshalOptions{
+					EmitFirestoreSensibleDefaults: tt.defaults,
+				}.Marshal(form.of(tt.input))
 
-			if err != nil && !tt.wantErr {
-				t.Errorf("Marshal() returned error: %v\n", err)
-			}
+				if err != nil && !tt.wantErr {
+					t.Errorf("Marshal() returned error: %v\n", err)
+				}
 
-			if err == nil && tt.wantErr {
-				t.Errorf("Marshal() got nil error, want error\n")
-			}
+				if err == nil && tt.wantErr {
+					t.Errorf("Marshal() got nil error, want error\n")
+				}
 
-			diff := deep.Equal(got, tt.want)
+				diff := deep.Equal(got, tt.want)
 
-			if diff != nil {
-				t.Error(diff)
-			}
-		})
+				if diff != nil {
+					t.Error(diff)
+				}
+			})
+		}
 	}
 }
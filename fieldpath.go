@@ -0,0 +1,371 @@
+package protofirestore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/daviddomkar/protofirestore/firestoreimpl"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// FieldPath resolves a path of proto field names against the given
+// protoreflect.MessageDescriptor and returns the firestore field path of the
+// value Marshal produces for it.
+func FieldPath(md protoreflect.MessageDescriptor, path string) (string, error) {
+	return MarshalOptions{}.FieldPath(md, path)
+}
+
+// FieldPath resolves a path of proto field names against the given
+// protoreflect.MessageDescriptor and returns the firestore field path of the
+// value Marshal produces for it, e.g. "opt_nested.opt_string" resolves to
+// "optNested.optString".
+//
+// Path segments are separated by dots. A segment following a map field is a
+// key of the map, which must be quoted with backticks if it contains dots
+// or backticks. Extension fields are referenced by their full name enclosed
+// in brackets, e.g. "[pb2.opt_ext_string]", and resolved using Resolver.
+//
+// Resolving fails for unknown fields, invalid map keys and paths which
+// traverse repeated fields, scalar fields or well known types, because their
+// values are not stored as firestore maps.
+func (o MarshalOptions) FieldPath(md protoreflect.MessageDescriptor, path string) (string, error) {
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+
+	segments, err := resolveFieldPath(o.Resolver, md, path)
+	if err != nil {
+		return "", err
+	}
+
+	return firestoreFieldPath(segments), nil
+}
+
+// ResolveFieldPath resolves a proto field path like FieldPath and returns the
+// firestore field path along with the descriptor of the field holding the
+// value the path refers to.
+func ResolveFieldPath(md protoreflect.MessageDescriptor, path string) (string, protoreflect.FieldDescriptor, error) {
+	return MarshalOptions{}.ResolveFieldPath(md, path)
+}
+
+// ResolveFieldPath resolves a proto field path like FieldPath and returns the
+// firestore field path along with the descriptor of the field holding the
+// value the path refers to. For paths ending with a map key, this is the
+// descriptor of the map value.
+func (o MarshalOptions) ResolveFieldPath(md protoreflect.MessageDescriptor, path string) (string, protoreflect.FieldDescriptor, error) {
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+
+	segments, err := resolveFieldPath(o.Resolver, md, path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return firestoreFieldPath(segments), segments[len(segments)-1].valueField(), nil
+}
+
+// JoinFieldPath joins the given keys of nested document maps into a firestore
+// field path, quoting the keys which are not simple identifiers with
+// backticks the same way Flatten does.
+func JoinFieldPath(keys ...string) string {
+	return joinFieldPath(keys)
+}
+
+// SplitFieldPath splits a firestore field path into the keys of the nested
+// document maps it refers to. It is the inverse of JoinFieldPath.
+func SplitFieldPath(path string) ([]string, error) {
+	return splitFieldPath(path)
+}
+
+// fieldPathSegment is a resolved segment of a proto field path. It refers
+// either to a field or, if key is valid, to a key of the preceding map field.
+type fieldPathSegment struct {
+	fd  protoreflect.FieldDescriptor
+	key protoreflect.MapKey
+}
+
+func (s fieldPathSegment) isMapKey() bool {
+	return s.key.IsValid()
+}
+
+// name returns the firestore name of the segment, which is the key Marshal
+// uses for its value.
+func (s fieldPathSegment) name() string {
+	if s.isMapKey() {
+		return s.key.String()
+	}
+	return s.fd.JSONName()
+}
+
+// valueField returns the descriptor of the field holding the value of the
+// segment.
+func (s fieldPathSegment) valueField() protoreflect.FieldDescriptor {
+	if s.isMapKey() {
+		return s.fd.MapValue()
+	}
+	return s.fd
+}
+
+// resolveFieldPath resolves a proto field path against the given
+// protoreflect.MessageDescriptor as described by MarshalOptions.FieldPath.
+func resolveFieldPath(resolver protoregistry.ExtensionTypeResolver, md protoreflect.MessageDescriptor, path string) ([]fieldPathSegment, error) {
+	tokens, err := splitProtoFieldPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]fieldPathSegment, 0, len(tokens))
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		var fd protoreflect.FieldDescriptor
+		if name, ok := extensionName(token); ok {
+			xt, err := resolver.FindExtensionByName(name)
+			if err != nil {
+				return nil, fmt.Errorf("field path %q: unable to resolve extension %v: %w", path, name, err)
+			}
+			fd = xt.TypeDescriptor()
+			if fd.ContainingMessage().FullName() != md.FullName() {
+				return nil, fmt.Errorf("field path %q: extension %v does not extend %v", path, name, md.FullName())
+			}
+		} else if !token.quoted {
+			fd = md.Fields().ByName(protoreflect.Name(token.text))
+		}
+		if fd == nil {
+			return nil, fmt.Errorf("field path %q: %v has no field named %q", path, md.FullName(), token.text)
+		}
+		if isSubcollection(fd) {
+			return nil, fmt.Errorf("field path %q: field %v is stored in a subcollection", path, fd.FullName())
+		}
+
+		segments = append(segments, fieldPathSegment{fd: fd})
+
+		if i == len(tokens)-1 {
+			break
+		}
+
+		if fd.IsMap() {
+			i++
+			key, err := parseMapKey(fd, tokens[i].text)
+			if err != nil {
+				return nil, fmt.Errorf("field path %q: %w", path, err)
+			}
+
+			segments = append(segments, fieldPathSegment{fd: fd, key: key})
+
+			if i == len(tokens)-1 {
+				break
+			}
+
+			fd = fd.MapValue()
+		}
+
+		switch {
+		case fd.IsList():
+			return nil, fmt.Errorf("field path %q: cannot traverse repeated field %v", path, fd.FullName())
+		case fd.Message() == nil:
+			return nil, fmt.Errorf("field path %q: cannot traverse non-message field %v", path, fd.FullName())
+		case wellKnownTypeMarshaler(fd.Message().FullName()) != nil:
+			return nil, fmt.Errorf("field path %q: cannot traverse well known type field %v", path, fd.FullName())
+		}
+
+		md = fd.Message()
+	}
+
+	return segments, nil
+}
+
+// protoFieldPathToken is a segment of an unresolved proto field path.
+type protoFieldPathToken struct {
+	text   string
+	quoted bool
+}
+
+// splitProtoFieldPath splits a proto field path into its segments. Dots are
+// not treated as separators within brackets and backtick quoted segments.
+func splitProtoFieldPath(path string) ([]protoFieldPathToken, error) {
+	if path == "" {
+		return nil, errors.New("empty field path")
+	}
+
+	var tokens []protoFieldPathToken
+
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '`':
+			text, n, ok := scanQuotedSegment(path[i:])
+			if !ok {
+				return nil, fmt.Errorf("field path %q: unterminated quoted segment", path)
+			}
+			tokens = append(tokens, protoFieldPathToken{text: text, quoted: true})
+			i += n
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("field path %q: unterminated extension name", path)
+			}
+			tokens = append(tokens, protoFieldPathToken{text: path[i : i+end+1]})
+			i += end + 1
+		default:
+			end := strings.IndexByte(path[i:], '.')
+			if end < 0 {
+				end = len(path) - i
+			}
+			tokens = append(tokens, protoFieldPathToken{text: path[i : i+end]})
+			i += end
+		}
+
+		if i < len(path) {
+			if path[i] != '.' || i == len(path)-1 {
+				return nil, fmt.Errorf("field path %q: invalid segment separator at offset %d", path, i)
+			}
+			i++
+		}
+	}
+
+	for _, token := range tokens {
+		if token.text == "" && !token.quoted {
+			return nil, fmt.Errorf("field path %q: empty segment", path)
+		}
+	}
+
+	return tokens, nil
+}
+
+// extensionName returns the full name of the extension referenced by the
+// given token, if it is enclosed in brackets.
+func extensionName(token protoFieldPathToken) (protoreflect.FullName, bool) {
+	if token.quoted || len(token.text) < 2 || token.text[0] != '[' || token.text[len(token.text)-1] != ']' {
+		return "", false
+	}
+	return protoreflect.FullName(token.text[1 : len(token.text)-1]), true
+}
+
+// parseMapKey parses the given text as a key of the map field fd.
+func parseMapKey(fd protoreflect.FieldDescriptor, text string) (protoreflect.MapKey, error) {
+	return firestoreimpl.DecodeMapKey(text, fd.MapKey().Kind(), fd.FullName())
+}
+
+// firestoreFieldPath returns the firestore field path of the segments
+// returned by resolveFieldPath.
+func firestoreFieldPath(segments []fieldPathSegment) string {
+	names := make([]string, len(segments))
+	for i, segment := range segments {
+		names[i] = segment.name()
+	}
+	return joinFieldPath(names)
+}
+
+// joinFieldPath joins the given segments into a firestore field path,
+// quoting the segments which are not simple identifiers.
+func joinFieldPath(segments []string) string {
+	var b strings.Builder
+	for i, segment := range segments {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(quoteFieldPathSegment(segment))
+	}
+	return b.String()
+}
+
+// quoteFieldPathSegment quotes the given firestore field path segment with
+// backticks unless it is a simple identifier which firestore accepts as is.
+func quoteFieldPathSegment(segment string) string {
+	if isSimpleFieldPathSegment(segment) {
+		return segment
+	}
+
+	var b strings.Builder
+	b.WriteByte('`')
+	for _, r := range segment {
+		if r == '`' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('`')
+	return b.String()
+}
+
+// isSimpleFieldPathSegment reports whether the segment matches
+// [a-zA-Z_][a-zA-Z_0-9]*.
+func isSimpleFieldPathSegment(segment string) bool {
+	if segment == "" {
+		return false
+	}
+
+	for i, r := range segment {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// splitFieldPath splits the given firestore field path into its segments,
+// removing the backtick quoting added by joinFieldPath.
+func splitFieldPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, errors.New("empty field path")
+	}
+
+	var segments []string
+
+	for i := 0; i < len(path); {
+		if path[i] != '`' {
+			j := strings.IndexByte(path[i:], '.')
+			if j < 0 {
+				j = len(path) - i
+			}
+			segment := path[i : i+j]
+			if !isSimpleFieldPathSegment(segment) {
+				return nil, fmt.Errorf("field path %q: invalid unquoted segment %q", path, segment)
+			}
+			segments = append(segments, segment)
+			i += j
+		} else {
+			segment, n, ok := scanQuotedSegment(path[i:])
+			if !ok {
+				return nil, fmt.Errorf("field path %q: unterminated quoted segment", path)
+			}
+			segments = append(segments, segment)
+			i += n
+		}
+
+		if i < len(path) {
+			if path[i] != '.' || i == len(path)-1 {
+				return nil, fmt.Errorf("field path %q: invalid segment separator at offset %d", path, i)
+			}
+			i++
+		}
+	}
+
+	return segments, nil
+}
+
+// scanQuotedSegment scans the backtick quoted field path segment at the start
+// of s. It returns the unquoted segment and the number of bytes consumed.
+func scanQuotedSegment(s string) (string, int, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '`':
+			return b.String(), i + 1, true
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", 0, false
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return "", 0, false
+}
@@ -0,0 +1,252 @@
+package protofirestore_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"google.golang.org/protobuf/proto"
+
+	pkg "github.com/daviddomkar/protofirestore"
+	pb2 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb2"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+)
+
+func TestFieldPath(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   proto.Message
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:  "scalar field",
+			input: &pb3.Scalars{},
+			path:  "s_string",
+			want:  "sString",
+		}, {
+			desc:  "nested message fields",
+			input: &pb2.Nests{},
+			path:  "opt_nested.opt_nested.opt_string",
+			want:  "optNested.optNested.optString",
+		}, {
+			desc:  "group fields",
+			input: &pb2.Nests{},
+			path:  "optgroup.optnestedgroup.opt_fixed32",
+			want:  "optgroup.optnestedgroup.optFixed32",
+		}, {
+			desc:  "json_name",
+			input: &pb3.JSONNames{},
+			path:  "s_string",
+			want:  "foo_bar",
+		}, {
+			desc:  "repeated field",
+			input: &pb2.Nests{},
+			path:  "rpt_nested",
+			want:  "rptNested",
+		}, {
+			desc:  "map field",
+			input: &pb3.Maps{},
+			path:  "str_to_nested",
+			want:  "strToNested",
+		}, {
+			desc:  "string map key",
+			input: &pb3.Maps{},
+			path:  "str_to_nested.nested.s_string",
+			want:  "strToNested.nested.sString",
+		}, {
+			desc:  "quoted string map key",
+			input: &pb3.Maps{},
+			path:  "str_to_nested.`a.b\\`c`.s_nested.s_string",
+			want:  "strToNested.`a.b\\`c`.sNested.sString",
+		}, {
+			desc:  "string map key needing quotes",
+			input: &pb3.Maps{},
+			path:  "str_to_oneofs.hello world.oneof_string",
+			want:  "strToOneofs.`hello world`.oneofString",
+		}, {
+			desc:  "integer map key",
+			input: &pb3.Maps{},
+			path:  "int32_to_str.-101",
+			want:  "int32ToStr.`-101`",
+		}, {
+			desc:  "bool map key",
+			input: &pb3.Maps{},
+			path:  "bool_to_uint32.true",
+			want:  "boolToUint32.true",
+		}, {
+			desc:  "extension field",
+			input: &pb2.Extensions{},
+			path:  "[pb2.opt_ext_nested].opt_nested.opt_string",
+			want:  "`[pb2.opt_ext_nested]`.optNested.optString",
+		}, {
+			desc:  "extension field declared in another message",
+			input: &pb2.Extensions{},
+			path:  "[pb2.ExtensionsContainer.opt_ext_string]",
+			want:  "`[pb2.ExtensionsContainer.opt_ext_string]`",
+		}, {
+			desc:    "empty path",
+			input:   &pb3.Scalars{},
+			path:    "",
+			wantErr: true,
+		}, {
+			desc:    "empty segment",
+			input:   &pb2.Nests{},
+			path:    "opt_nested..opt_string",
+			wantErr: true,
+		}, {
+			desc:    "unknown field",
+			input:   &pb3.Scalars{},
+			path:    "s_unknown",
+			wantErr: true,
+		}, {
+			desc:    "json name",
+			input:   &pb2.Nests{},
+			path:    "optNested.optString",
+			wantErr: true,
+		}, {
+			desc:    "path into repeated field",
+			input:   &pb2.Nests{},
+			path:    "rpt_nested.opt_string",
+			wantErr: true,
+		}, {
+			desc:    "path into scalar field",
+			input:   &pb3.Scalars{},
+			path:    "s_string.length",
+			wantErr: true,
+		}, {
+			desc:    "path into well known type",
+			input:   &pb2.KnownTypes{},
+			path:    "opt_timestamp.seconds",
+			wantErr: true,
+		}, {
+			desc:    "path into scalar map value",
+			input:   &pb3.Maps{},
+			path:    "int32_to_str.1.length",
+			wantErr: true,
+		}, {
+			desc:    "invalid integer map key",
+			input:   &pb3.Maps{},
+			path:    "int32_to_str.one",
+			wantErr: true,
+		}, {
+			desc:    "out of range integer map key",
+			input:   &pb3.Maps{},
+			path:    "int32_to_str.4294967296",
+			wantErr: true,
+		}, {
+			desc:    "invalid bool map key",
+			input:   &pb3.Maps{},
+			path:    "bool_to_uint32.yes",
+			wantErr: true,
+		}, {
+			desc:    "unknown extension",
+			input:   &pb2.Extensions{},
+			path:    "[pb2.unknown_ext]",
+			wantErr: true,
+		}, {
+			desc:    "extension of another message",
+			input:   &pb2.Nests{},
+			path:    "[pb2.opt_ext_string]",
+			wantErr: true,
+		}, {
+			desc:    "unterminated quoted map key",
+			input:   &pb3.Maps{},
+			path:    "str_to_nested.`nested",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := pkg.FieldPath(tt.input.ProtoReflect().Descriptor(), tt.path)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("FieldPath() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("FieldPath() got nil error, want error\n")
+			}
+
+			if got != tt.want {
+				t.Errorf("FieldPath() = %q, want %q\n", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinFieldPath(t *testing.T) {
+	tests := []struct {
+		desc string
+		keys []string
+		want string
+	}{
+		{
+			desc: "simple keys",
+			keys: []string{"optNested", "optString"},
+			want: "optNested.optString",
+		}, {
+			desc: "quoted keys",
+			keys: []string{"strToNested", "a.b`c", "1"},
+			want: "strToNested.`a.b\\`c`.`1`",
+		}, {
+			desc: "empty key",
+			keys: []string{""},
+			want: "``",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := pkg.JoinFieldPath(tt.keys...); got != tt.want {
+				t.Errorf("JoinFieldPath() = %q, want %q\n", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitFieldPath(t *testing.T) {
+	tests := []struct {
+		desc    string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			desc: "simple keys",
+			path: "optNested.optString",
+			want: []string{"optNested", "optString"},
+		}, {
+			desc: "quoted keys",
+			path: "strToNested.`a.b\\`c`.`1`",
+			want: []string{"strToNested", "a.b`c", "1"},
+		}, {
+			desc:    "empty path",
+			path:    "",
+			wantErr: true,
+		}, {
+			desc:    "invalid unquoted key",
+			path:    "a.1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := pkg.SplitFieldPath(tt.path)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("SplitFieldPath() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("SplitFieldPath() got nil error, want error\n")
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("SplitFieldPath() mismatch (-want +got):\n%v\n", diff)
+			}
+		})
+	}
+}
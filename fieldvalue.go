@@ -0,0 +1,220 @@
+package protofirestore
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/daviddomkar/protofirestore/firestoreimpl"
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MarshalFieldValue marshals a single value of the field fd the same way
+// Marshal does, e.g. to use it as a value in query filters and cursors.
+func MarshalFieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (interface{}, error) {
+	return MarshalOptions{}.MarshalFieldValue(fd, v)
+}
+
+// MarshalFieldValue marshals a single value of the field fd the same way
+// Marshal does, e.g. to use it as a value in query filters and cursors.
+//
+// For repeated fields, v may either be a protoreflect.List, which marshals to
+// an array, or a single element, which is useful for array-contains filters.
+// Likewise for map fields, v may either be a protoreflect.Map or a single map
+// value. A nil result means Marshal would omit the value.
+func (o MarshalOptions) MarshalFieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (interface{}, error) {
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+
+	v, err := fieldValueOf(fd, v.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	return encoder{o}.marshalFieldValue(fd, v)
+}
+
+// MarshalPathValue resolves a proto field path and marshals a single value of
+// the field it refers to.
+func MarshalPathValue(md protoreflect.MessageDescriptor, path string, v interface{}) (string, interface{}, error) {
+	return MarshalOptions{}.MarshalPathValue(md, path, v)
+}
+
+// MarshalPathValue resolves a proto field path against md like FieldPath and
+// marshals v as a value of the field it refers to like MarshalFieldValue. It
+// returns the firestore field path along with the marshaled value.
+//
+// Besides protoreflect.Value, v may be any Go value which converts to the
+// field without loss: an integer for integer fields, a float for floating
+// point fields, a generated enum, enum number or value name for enum fields,
+// a proto.Message for message fields and a time.Time for
+// google.protobuf.Timestamp fields.
+func (o MarshalOptions) MarshalPathValue(md protoreflect.MessageDescriptor, path string, v interface{}) (string, interface{}, error) {
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+
+	segments, err := resolveFieldPath(o.Resolver, md, path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fd := segments[len(segments)-1].valueField()
+
+	if pv, ok := v.(protoreflect.Value); ok {
+		v = pv.Interface()
+	}
+
+	value, err := fieldValueOf(fd, v)
+	if err != nil {
+		return "", nil, fmt.Errorf("field path %q: %w", path, err)
+	}
+
+	marshaled, err := encoder{o}.marshalFieldValue(fd, value)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return firestoreFieldPath(segments), marshaled, nil
+}
+
+// marshalFieldValue marshals a value returned by fieldValueOf.
+func (e encoder) marshalFieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (interface{}, error) {
+	switch value := v.Interface().(type) {
+	case protoreflect.List:
+		if array, err := e.marshalList(value, fd); err != nil || array == nil {
+			return nil, err
+		} else {
+			return array, nil
+		}
+	case protoreflect.Map:
+		if object, err := e.marshalMap(value, fd); err != nil || len(object) == 0 {
+			return nil, err
+		} else {
+			return object, nil
+		}
+	}
+
+	if fd.IsMap() {
+		return e.marshalSingular(v, fd.MapValue())
+	}
+
+	return e.marshalSingular(v, fd)
+}
+
+// fieldValueOf converts v into a protoreflect.Value of the field fd. For
+// repeated and map fields, v may also be a single element of the field.
+func fieldValueOf(fd protoreflect.FieldDescriptor, v interface{}) (protoreflect.Value, error) {
+	switch v := v.(type) {
+	case protoreflect.List:
+		if !fd.IsList() {
+			return protoreflect.Value{}, fmt.Errorf("field %v is not repeated", fd.FullName())
+		}
+		return protoreflect.ValueOfList(v), nil
+	case protoreflect.Map:
+		if !fd.IsMap() {
+			return protoreflect.Value{}, fmt.Errorf("field %v is not a map", fd.FullName())
+		}
+		return protoreflect.ValueOfMap(v), nil
+	}
+
+	if fd.IsMap() {
+		fd = fd.MapValue()
+	}
+
+	invalid := func() (protoreflect.Value, error) {
+		return protoreflect.Value{}, fmt.Errorf("invalid value %v of type %T for %v field %v", v, v, fd.Kind(), fd.FullName())
+	}
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if b, ok := v.(bool); ok {
+			return protoreflect.ValueOfBool(b), nil
+		}
+
+	case protoreflect.StringKind:
+		if s, ok := v.(string); ok {
+			return protoreflect.ValueOfString(s), nil
+		}
+
+	case protoreflect.BytesKind:
+		if b, ok := v.([]byte); ok {
+			return protoreflect.ValueOfBytes(b), nil
+		}
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if n, err := firestoreimpl.DecodeInt32(v, fd.FullName()); err == nil {
+			return protoreflect.ValueOfInt32(n), nil
+		}
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if n, err := firestoreimpl.DecodeInt64(v, fd.FullName()); err == nil {
+			return protoreflect.ValueOfInt64(n), nil
+		}
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if n, err := firestoreimpl.DecodeUint32(v, fd.FullName()); err == nil {
+			return protoreflect.ValueOfUint32(n), nil
+		}
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if n, err := firestoreimpl.DecodeUint64(v, fd.FullName()); err == nil {
+			return protoreflect.ValueOfUint64(n), nil
+		}
+
+	case protoreflect.FloatKind:
+		switch f := v.(type) {
+		case float32:
+			return protoreflect.ValueOfFloat32(f), nil
+		case float64:
+			if float64(float32(f)) == f || math.IsNaN(f) {
+				return protoreflect.ValueOfFloat32(float32(f)), nil
+			}
+		}
+
+	case protoreflect.DoubleKind:
+		switch f := v.(type) {
+		case float32:
+			return protoreflect.ValueOfFloat64(float64(f)), nil
+		case float64:
+			return protoreflect.ValueOfFloat64(f), nil
+		}
+
+	case protoreflect.EnumKind:
+		switch e := v.(type) {
+		case protoreflect.Enum:
+			if e.Descriptor().FullName() == fd.Enum().FullName() {
+				return protoreflect.ValueOfEnum(e.Number()), nil
+			}
+		case protoreflect.EnumNumber:
+			return protoreflect.ValueOfEnum(e), nil
+		default:
+			if n, err := firestoreimpl.DecodeEnum(v, fd.Enum(), fd.FullName()); err == nil {
+				return protoreflect.ValueOfEnum(n), nil
+			}
+		}
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		var m protoreflect.Message
+		switch value := v.(type) {
+		case protoreflect.Message:
+			m = value
+		case proto.Message:
+			m = value.ProtoReflect()
+		case time.Time:
+			if fd.Message().FullName() == genid.Timestamp_message_fullname {
+				m = timestamppb.New(value).ProtoReflect()
+			}
+		}
+		if m != nil && m.Descriptor().FullName() == fd.Message().FullName() {
+			return protoreflect.ValueOfMessage(m), nil
+		}
+	}
+
+	return invalid()
+}
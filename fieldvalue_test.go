@@ -0,0 +1,295 @@
+package protofirestore_test
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pkg "github.com/daviddomkar/protofirestore"
+	pb2 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb2"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/go-test/deep"
+)
+
+func TestMarshalFieldValue(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   proto.Message
+		field   protoreflect.Name
+		value   protoreflect.Value
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			desc:  "int32",
+			input: &pb3.Scalars{},
+			field: "s_int32",
+			value: protoreflect.ValueOfInt32(42),
+			want:  int32(42),
+		}, {
+			desc:  "empty string",
+			input: &pb3.Scalars{},
+			field: "s_string",
+			value: protoreflect.ValueOfString(""),
+			want:  nil,
+		}, {
+			desc:  "enum",
+			input: &pb3.Enums{},
+			field: "s_enum",
+			value: protoreflect.ValueOfEnum(pb3.Enum_TEN.Number()),
+			want:  "TEN",
+		}, {
+			desc:  "unnamed enum",
+			input: &pb3.Enums{},
+			field: "s_enum",
+			value: protoreflect.ValueOfEnum(47),
+			want:  int64(47),
+		}, {
+			desc:  "timestamp",
+			input: &pb2.KnownTypes{},
+			field: "opt_timestamp",
+			value: protoreflect.ValueOfMessage((&timestamppb.Timestamp{Seconds: 1553036601}).ProtoReflect()),
+			want:  time.Unix(1553036601, 0).UTC(),
+		}, {
+			desc:  "message",
+			input: &pb3.Nests{},
+			field: "s_nested",
+			value: protoreflect.ValueOfMessage((&pb3.Nested{SString: "nested"}).ProtoReflect()),
+			want: map[string]interface{}{
+				"sString": "nested",
+			},
+		}, {
+			desc:  "list",
+			input: &pb3.Repeats{},
+			field: "rpt_string",
+			value: func() protoreflect.Value {
+				m := (&pb3.Repeats{RptString: []string{"a", "b"}}).ProtoReflect()
+				return m.Get(m.Descriptor().Fields().ByName("rpt_string"))
+			}(),
+			want: []interface{}{"a", "b"},
+		}, {
+			desc:  "list element",
+			input: &pb2.Enums{},
+			field: "rpt_enum",
+			value: protoreflect.ValueOfEnum(pb2.Enum_TWO.Number()),
+			want:  "TWO",
+		}, {
+			desc:  "map value",
+			input: &pb3.Maps{},
+			field: "uint64_to_enum",
+			value: protoreflect.ValueOfEnum(pb3.Enum_ONE.Number()),
+			want:  "ONE",
+		}, {
+			desc:    "mismatched kind",
+			input:   &pb3.Scalars{},
+			field:   "s_int32",
+			value:   protoreflect.ValueOfString("42"),
+			wantErr: true,
+		}, {
+			desc:    "mismatched message",
+			input:   &pb3.Nests{},
+			field:   "s_nested",
+			value:   protoreflect.ValueOfMessage((&pb3.Scalars{}).ProtoReflect()),
+			wantErr: true,
+		}, {
+			desc:    "invalid value",
+			input:   &pb3.Scalars{},
+			field:   "s_string",
+			value:   protoreflect.ValueOfString("abc\xff"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			fd := tt.input.ProtoReflect().Descriptor().Fields().ByName(tt.field)
+
+			got, err := pkg.MarshalFieldValue(fd, tt.value)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("MarshalFieldValue() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("MarshalFieldValue() got nil error, want error\n")
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestMarshalPathValue(t *testing.T) {
+	tests := []struct {
+		desc     string
+		input    proto.Message
+		path     string
+		value    interface{}
+		wantPath string
+		want     interface{}
+		wantErr  bool
+	}{
+		{
+			desc:     "untyped integer",
+			input:    &pb3.Scalars{},
+			path:     "s_int32",
+			value:    42,
+			wantPath: "sInt32",
+			want:     int32(42),
+		}, {
+			desc:     "integer to unsigned field",
+			input:    &pb3.Scalars{},
+			path:     "s_uint64",
+			value:    42,
+			wantPath: "sUint64",
+			want:     uint64(42),
+		}, {
+			desc:     "integral float to integer field",
+			input:    &pb3.Scalars{},
+			path:     "s_sint64",
+			value:    42.0,
+			wantPath: "sSint64",
+			want:     int64(42),
+		}, {
+			desc:     "integer to enum field",
+			input:    &pb3.Enums{},
+			path:     "s_enum",
+			value:    2,
+			wantPath: "sEnum",
+			want:     "TWO",
+		}, {
+			desc:     "float64 to float field",
+			input:    &pb3.Scalars{},
+			path:     "s_float",
+			value:    1.5,
+			wantPath: "sFloat",
+			want:     float32(1.5),
+		}, {
+			desc:     "field in group",
+			input:    &pb2.Nests{},
+			path:     "optgroup.opt_nested.opt_string",
+			value:    "hello",
+			wantPath: "optgroup.optNested.optString",
+			want:     "hello",
+		}, {
+			desc:     "generated enum",
+			input:    &pb3.Enums{},
+			path:     "s_nested_enum",
+			value:    pb3.Enums_DOS,
+			wantPath: "sNestedEnum",
+			want:     "DOS",
+		}, {
+			desc:     "enum value name",
+			input:    &pb3.Enums{},
+			path:     "s_enum",
+			value:    "TEN",
+			wantPath: "sEnum",
+			want:     "TEN",
+		}, {
+			desc:     "time",
+			input:    &pb2.KnownTypes{},
+			path:     "opt_timestamp",
+			value:    time.Unix(1553036601, 5).In(time.FixedZone("CET", 3600)),
+			wantPath: "optTimestamp",
+			want:     time.Unix(1553036601, 5).UTC(),
+		}, {
+			desc:     "map key",
+			input:    &pb3.Maps{},
+			path:     "str_to_nested.a b",
+			value:    &pb3.Nested{SString: "nested"},
+			wantPath: "strToNested.`a b`",
+			want: map[string]interface{}{
+				"sString": "nested",
+			},
+		}, {
+			desc:     "repeated field element",
+			input:    &pb3.Repeats{},
+			path:     "rpt_double",
+			value:    2.5,
+			wantPath: "rptDouble",
+			want:     2.5,
+		}, {
+			desc:     "protoreflect value",
+			input:    &pb3.Scalars{},
+			path:     "s_bool",
+			value:    protoreflect.ValueOfBool(true),
+			wantPath: "sBool",
+			want:     true,
+		}, {
+			desc:    "out of range integer",
+			input:   &pb3.Scalars{},
+			path:    "s_int32",
+			value:   int64(1) << 40,
+			wantErr: true,
+		}, {
+			desc:    "negative integer to unsigned field",
+			input:   &pb3.Scalars{},
+			path:    "s_uint32",
+			value:   -1,
+			wantErr: true,
+		}, {
+			desc:    "fractional float to integer field",
+			input:   &pb3.Scalars{},
+			path:    "s_uint64",
+			value:   1.5,
+			wantErr: true,
+		}, {
+			desc:    "lossy float",
+			input:   &pb3.Scalars{},
+			path:    "s_float",
+			value:   0.1,
+			wantErr: true,
+		}, {
+			desc:    "enum of another type",
+			input:   &pb3.Enums{},
+			path:    "s_enum",
+			value:   pb3.Enums_DOS,
+			wantErr: true,
+		}, {
+			desc:    "unknown enum value name",
+			input:   &pb3.Enums{},
+			path:    "s_enum",
+			value:   "ELEVEN",
+			wantErr: true,
+		}, {
+			desc:    "time for non timestamp message",
+			input:   &pb3.Nests{},
+			path:    "s_nested",
+			value:   time.Now(),
+			wantErr: true,
+		}, {
+			desc:    "unknown field",
+			input:   &pb3.Scalars{},
+			path:    "s_unknown",
+			value:   true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			path, got, err := pkg.MarshalPathValue(tt.input.ProtoReflect().Descriptor(), tt.path, tt.value)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("MarshalPathValue() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("MarshalPathValue() got nil error, want error\n")
+			}
+
+			if path != tt.wantPath {
+				t.Errorf("MarshalPathValue() path = %q, want %q\n", path, tt.wantPath)
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+// Package firestoreimpl contains the conversions between proto values and
+// firestore values shared by the reflection based encoder and decoder of the
+// protofirestore package and the code generated by protoc-gen-go-firestore,
+// so that both produce exactly the same documents.
+//
+// It is not intended to be used directly and its API may change at any time.
+package firestoreimpl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	maxTimestampSeconds = 253402300799
+	minTimestampSeconds = -62135596800
+	maxTimestampNanos   = 999999999
+)
+
+// EncodeString encodes the value of a string field. Empty strings encode to
+// nil and are omitted.
+func EncodeString(s string, field protoreflect.FullName) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	if !utf8.ValidString(s) {
+		return nil, fmt.Errorf("field %v contains invalid UTF-8", string(field))
+	}
+
+	return s, nil
+}
+
+// EncodeBytes encodes the value of a bytes field. Empty bytes encode to nil
+// and are omitted.
+func EncodeBytes(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
+
+// EncodeEnum encodes the value of an enum field as the name of the enum
+// value, or as the number if the enum has no value with that number. Values
+// of google.protobuf.NullValue encode to nil.
+func EncodeEnum(n protoreflect.EnumNumber, ed protoreflect.EnumDescriptor) interface{} {
+	if ed.FullName() == genid.NullValue_enum_fullname {
+		return nil
+	}
+
+	if desc := ed.Values().ByNumber(n); desc != nil {
+		return string(desc.Name())
+	}
+
+	return int64(n)
+}
+
+// EncodeTimestamp encodes a google.protobuf.Timestamp as a time.Time.
+func EncodeTimestamp(seconds int64, nanos int32) (interface{}, error) {
+	if seconds < minTimestampSeconds || seconds > maxTimestampSeconds {
+		return nil, fmt.Errorf("%s: seconds out of range %v", genid.Timestamp_message_fullname, seconds)
+	}
+
+	if nanos < 0 || nanos > maxTimestampNanos {
+		return nil, fmt.Errorf("%s: nanos out of range %v", genid.Timestamp_message_fullname, nanos)
+	}
+
+	return time.Unix(seconds, int64(nanos)).UTC(), nil
+}
+
+// invalidValue returns the error of a value which cannot be decoded into the
+// given field.
+func invalidValue(v interface{}, kind string, field protoreflect.FullName) error {
+	return fmt.Errorf("field %v: invalid %v value %v of type %T", field, kind, v, v)
+}
+
+// DecodeBool decodes the value of a bool field.
+func DecodeBool(v interface{}, field protoreflect.FullName) (bool, error) {
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	return false, invalidValue(v, "bool", field)
+}
+
+// DecodeString decodes the value of a string field.
+func DecodeString(v interface{}, field protoreflect.FullName) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", invalidValue(v, "string", field)
+	}
+
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("field %v contains invalid UTF-8", string(field))
+	}
+
+	return s, nil
+}
+
+// DecodeBytes decodes the value of a bytes field.
+func DecodeBytes(v interface{}, field protoreflect.FullName) ([]byte, error) {
+	if b, ok := v.([]byte); ok {
+		return b, nil
+	}
+	return nil, invalidValue(v, "bytes", field)
+}
+
+// DecodeInt32 decodes the value of a 32-bit signed integer field. Besides
+// integers of any Go type, floats without fractional part are accepted,
+// because documents written by other clients may contain them.
+func DecodeInt32(v interface{}, field protoreflect.FullName) (int32, error) {
+	if n, ok := toInt64(v); ok && n >= math.MinInt32 && n <= math.MaxInt32 {
+		return int32(n), nil
+	}
+	return 0, invalidValue(v, "int32", field)
+}
+
+// DecodeInt64 decodes the value of a 64-bit signed integer field like
+// DecodeInt32.
+func DecodeInt64(v interface{}, field protoreflect.FullName) (int64, error) {
+	if n, ok := toInt64(v); ok {
+		return n, nil
+	}
+	return 0, invalidValue(v, "int64", field)
+}
+
+// DecodeUint32 decodes the value of a 32-bit unsigned integer field like
+// DecodeInt32.
+func DecodeUint32(v interface{}, field protoreflect.FullName) (uint32, error) {
+	if n, ok := toUint64(v); ok && n <= math.MaxUint32 {
+		return uint32(n), nil
+	}
+	return 0, invalidValue(v, "uint32", field)
+}
+
+// DecodeUint64 decodes the value of a 64-bit unsigned integer field like
+// DecodeInt32.
+func DecodeUint64(v interface{}, field protoreflect.FullName) (uint64, error) {
+	if n, ok := toUint64(v); ok {
+		return n, nil
+	}
+	return 0, invalidValue(v, "uint64", field)
+}
+
+// DecodeFloat32 decodes the value of a float field. Floats of any precision
+// and integers are accepted, finite values outside of the range of float32
+// are rejected.
+func DecodeFloat32(v interface{}, field protoreflect.FullName) (float32, error) {
+	f, ok := toFloat64(v)
+	if !ok || !math.IsInf(f, 0) && math.IsInf(float64(float32(f)), 0) {
+		return 0, invalidValue(v, "float", field)
+	}
+	return float32(f), nil
+}
+
+// DecodeFloat64 decodes the value of a double field. Floats of any precision
+// and integers are accepted.
+func DecodeFloat64(v interface{}, field protoreflect.FullName) (float64, error) {
+	if f, ok := toFloat64(v); ok {
+		return f, nil
+	}
+	return 0, invalidValue(v, "double", field)
+}
+
+// DecodeEnum decodes the value of an enum field, which is either the name of
+// an enum value or an integer.
+func DecodeEnum(v interface{}, ed protoreflect.EnumDescriptor, field protoreflect.FullName) (protoreflect.EnumNumber, error) {
+	if name, ok := v.(string); ok {
+		if desc := ed.Values().ByName(protoreflect.Name(name)); desc != nil {
+			return desc.Number(), nil
+		}
+		return 0, fmt.Errorf("field %v: invalid value %q for enum %v", field, name, ed.FullName())
+	}
+
+	if n, ok := toInt64(v); ok && n >= math.MinInt32 && n <= math.MaxInt32 {
+		return protoreflect.EnumNumber(n), nil
+	}
+
+	return 0, invalidValue(v, "enum", field)
+}
+
+// DecodeTimestamp decodes the value of a google.protobuf.Timestamp field,
+// which is a time.Time.
+func DecodeTimestamp(v interface{}, field protoreflect.FullName) (*timestamppb.Timestamp, error) {
+	t, ok := v.(time.Time)
+	if !ok {
+		return nil, invalidValue(v, "timestamp", field)
+	}
+
+	ts := timestamppb.New(t)
+	if ts.GetSeconds() < minTimestampSeconds || ts.GetSeconds() > maxTimestampSeconds {
+		return nil, fmt.Errorf("field %v: timestamp %v out of range", field, t)
+	}
+
+	return ts, nil
+}
+
+// DecodeList decodes the value of a repeated field, which is an array.
+func DecodeList(v interface{}, field protoreflect.FullName) ([]interface{}, error) {
+	if array, ok := v.([]interface{}); ok {
+		return array, nil
+	}
+	return nil, invalidValue(v, "array", field)
+}
+
+// DecodeObject decodes the value of a map or message field, which is a map.
+func DecodeObject(v interface{}, field protoreflect.FullName) (map[string]interface{}, error) {
+	if object, ok := v.(map[string]interface{}); ok {
+		return object, nil
+	}
+	return nil, invalidValue(v, "map", field)
+}
+
+// DecodeMapKey decodes a key of a map field whose keys are of the given kind.
+// Keys are encoded the same way as protoreflect.MapKey.String does.
+func DecodeMapKey(key string, kind protoreflect.Kind, field protoreflect.FullName) (protoreflect.MapKey, error) {
+	switch kind {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(key).MapKey(), nil
+	case protoreflect.BoolKind:
+		switch key {
+		case "true":
+			return protoreflect.ValueOfBool(true).MapKey(), nil
+		case "false":
+			return protoreflect.ValueOfBool(false).MapKey(), nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if n, err := strconv.ParseInt(key, 10, 32); err == nil {
+			return protoreflect.ValueOfInt32(int32(n)).MapKey(), nil
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if n, err := strconv.ParseInt(key, 10, 64); err == nil {
+			return protoreflect.ValueOfInt64(n).MapKey(), nil
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if n, err := strconv.ParseUint(key, 10, 32); err == nil {
+			return protoreflect.ValueOfUint32(uint32(n)).MapKey(), nil
+		}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if n, err := strconv.ParseUint(key, 10, 64); err == nil {
+			return protoreflect.ValueOfUint64(n).MapKey(), nil
+		}
+	}
+	return protoreflect.MapKey{}, fmt.Errorf("field %v: invalid %v map key %q", field, kind, key)
+}
+
+// UnknownFieldError returns the error of a key of a document which is not a
+// field of the message the document is decoded into.
+func UnknownFieldError(key string, message protoreflect.FullName) error {
+	return fmt.Errorf("unknown field %q in message %v", key, message)
+}
+
+// OneofConflictError returns the error of a document containing more than
+// one field of a oneof.
+func OneofConflictError(oneof protoreflect.FullName) error {
+	return fmt.Errorf("more than one field of oneof %v is set", oneof)
+}
+
+// SortedKeys returns the keys of the given document in order, which decoders
+// visit them in so that errors do not depend on the iteration order of the
+// map.
+func SortedKeys(document map[string]interface{}) []string {
+	keys := make([]string, 0, len(document))
+	for key := range document {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toInt64 converts a Go integer or a float without fractional part to int64
+// if it fits.
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint, uint8, uint16, uint32, uint64:
+		if u, ok := toUint64(v); ok && u <= math.MaxInt64 {
+			return int64(u), true
+		}
+	case float32:
+		return toInt64(float64(n))
+	case float64:
+		if n == math.Trunc(n) && n >= math.MinInt64 && n < math.MaxInt64 {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+// toUint64 converts a non-negative Go integer or float without fractional
+// part to uint64 if it fits.
+func toUint64(v interface{}) (uint64, bool) {
+	switch n := v.(type) {
+	case uint:
+		return uint64(n), true
+	case uint8:
+		return uint64(n), true
+	case uint16:
+		return uint64(n), true
+	case uint32:
+		return uint64(n), true
+	case uint64:
+		return n, true
+	case int, int8, int16, int32, int64:
+		if i, ok := toInt64(v); ok && i >= 0 {
+			return uint64(i), true
+		}
+	case float32:
+		return toUint64(float64(n))
+	case float64:
+		if n == math.Trunc(n) && n >= 0 && n < math.MaxUint64 {
+			return uint64(n), true
+		}
+	}
+	return 0, false
+}
+
+// toFloat64 converts a Go float or integer to float64.
+func toFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	if i, ok := toInt64(v); ok {
+		return float64(i), true
+	}
+	if u, ok := toUint64(v); ok {
+		return float64(u), true
+	}
+	return 0, false
+}
@@ -0,0 +1,95 @@
+package protofirestore
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// MarshalFlat marshals m like Marshal, but returns a flat map keyed by
+// firestore field paths instead of nested maps.
+func MarshalFlat(m proto.Message) (map[string]interface{}, error) {
+	return MarshalOptions{}.MarshalFlat(m)
+}
+
+// MarshalFlat marshals m like Marshal, but returns a flat map keyed by
+// firestore field paths instead of nested maps. See Flatten for details.
+func (o MarshalOptions) MarshalFlat(m proto.Message) (map[string]interface{}, error) {
+	object, err := o.marshal(m)
+	if object == nil {
+		return nil, err
+	}
+
+	return Flatten(object), err
+}
+
+// Flatten converts the nested maps of a document returned by Marshal into a
+// single map keyed by firestore field paths like "nests.optNested.optString".
+// Path segments which are not simple identifiers, such as most map keys, are
+// quoted with backticks. Arrays and empty maps are kept as values because
+// firestore field paths cannot address their contents.
+//
+// Flatten is the inverse of Unflatten.
+func Flatten(object map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	flatten(flat, nil, object)
+	return flat
+}
+
+func flatten(flat map[string]interface{}, prefix []string, object map[string]interface{}) {
+	for key, value := range object {
+		path := append(prefix[:len(prefix):len(prefix)], key)
+
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) != 0 {
+			flatten(flat, path, nested)
+		} else {
+			flat[joinFieldPath(path)] = value
+		}
+	}
+}
+
+// Unflatten converts a map keyed by firestore field paths as returned by
+// Flatten back into nested maps. It fails if a key is not a valid field path
+// or if a path is both a value and a prefix of another path.
+func Unflatten(flat map[string]interface{}) (map[string]interface{}, error) {
+	object := make(map[string]interface{})
+
+	// All paths are split up front so that a value whose path is a prefix of
+	// another path is detected regardless of the map iteration order.
+	paths := make([][]string, 0, len(flat))
+	values := make(map[string]interface{}, len(flat))
+	for path, value := range flat {
+		segments, err := splitFieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, segments)
+		values[joinFieldPath(segments)] = value
+	}
+
+	for _, segments := range paths {
+		path := joinFieldPath(segments)
+
+		parent := object
+		for i, segment := range segments[:len(segments)-1] {
+			if _, ok := values[joinFieldPath(segments[:i+1])]; ok {
+				return nil, fmt.Errorf("field path %q: conflicts with value at %q", path, joinFieldPath(segments[:i+1]))
+			}
+
+			child, ok := parent[segment].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[segment] = child
+			}
+			parent = child
+		}
+
+		last := segments[len(segments)-1]
+		if _, ok := parent[last]; ok {
+			return nil, fmt.Errorf("field path %q: conflicts with another field path", path)
+		}
+		parent[last] = values[path]
+	}
+
+	return object, nil
+}
@@ -0,0 +1,204 @@
+package protofirestore_test
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pkg "github.com/daviddomkar/protofirestore"
+	pb2 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb2"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/go-test/deep"
+)
+
+func TestMarshalFlat(t *testing.T) {
+	tests := []struct {
+		desc     string
+		input    proto.Message
+		defaults bool
+		want     map[string]interface{}
+	}{
+		{
+			desc:  "empty message",
+			input: &pb3.Nests{},
+			want:  map[string]interface{}{},
+		}, {
+			desc: "nested messages",
+			input: &pb2.Nests{
+				OptNested: &pb2.Nested{
+					OptString: proto.String("nested message"),
+					OptNested: &pb2.Nested{
+						OptString: proto.String("another nested message"),
+					},
+				},
+				RptNested: []*pb2.Nested{
+					{OptString: proto.String("repeated")},
+				},
+			},
+			want: map[string]interface{}{
+				"optNested.optString":           "nested message",
+				"optNested.optNested.optString": "another nested message",
+				"rptNested": []interface{}{
+					map[string]interface{}{
+						"optString": "repeated",
+					},
+				},
+			},
+		}, {
+			desc: "map keys are quoted",
+			input: &pb3.Maps{
+				Int32ToStr: map[int32]string{
+					-101: "-101",
+					1:    "one",
+				},
+				StrToNested: map[string]*pb3.Nested{
+					"nested":  {SString: "simple key"},
+					"a.b`c\\": {SString: "special key"},
+				},
+			},
+			want: map[string]interface{}{
+				"int32ToStr.`-101`":                 "-101",
+				"int32ToStr.`1`":                    "one",
+				"strToNested.nested.sString":        "simple key",
+				"strToNested.`a.b\\`c\\\\`.sString": "special key",
+			},
+		}, {
+			desc: "empty maps are kept",
+			input: &pb3.Oneofs{
+				Union: &pb3.Oneofs_OneofNested{
+					OneofNested: &pb3.Nested{},
+				},
+			},
+			defaults: true,
+			want: map[string]interface{}{
+				"oneofNested": map[string]interface{}{},
+			},
+		}, {
+			desc: "json_name",
+			input: &pb3.JSONNames{
+				SString: "json_name",
+			},
+			want: map[string]interface{}{
+				"foo_bar": "json_name",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			opts := pkg.MarshalOptions{
+				EmitFirestoreSensibleDefaults: tt.defaults,
+			}
+
+			got, err := opts.MarshalFlat(tt.input)
+			if err != nil {
+				t.Fatalf("MarshalFlat() returned error: %v\n", err)
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+
+			nested, err := opts.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal() returned error: %v\n", err)
+			}
+
+			unflattened, err := pkg.Unflatten(got)
+			if err != nil {
+				t.Fatalf("Unflatten() returned error: %v\n", err)
+			}
+
+			if diff := deep.Equal(unflattened, nested); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   map[string]interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			desc: "quoted segments",
+			input: map[string]interface{}{
+				"a.`b.c`":  int64(1),
+				"a.`d`.e":  int64(2),
+				"`f\\`g`":  int64(3),
+				"a.`x y`":  int64(4),
+				"a.``.h_1": int64(5),
+			},
+			want: map[string]interface{}{
+				"a": map[string]interface{}{
+					"b.c": int64(1),
+					"d": map[string]interface{}{
+						"e": int64(2),
+					},
+					"x y": int64(4),
+					"": map[string]interface{}{
+						"h_1": int64(5),
+					},
+				},
+				"f`g": int64(3),
+			},
+		}, {
+			desc: "value conflicts with nested path",
+			input: map[string]interface{}{
+				"a":   map[string]interface{}{},
+				"a.b": int64(1),
+			},
+			wantErr: true,
+		}, {
+			desc: "same path quoted differently",
+			input: map[string]interface{}{
+				"a.b":   int64(1),
+				"a.`b`": int64(2),
+			},
+			wantErr: true,
+		}, {
+			desc: "invalid unquoted segment",
+			input: map[string]interface{}{
+				"a.1b": int64(1),
+			},
+			wantErr: true,
+		}, {
+			desc: "unterminated quoted segment",
+			input: map[string]interface{}{
+				"a.`b": int64(1),
+			},
+			wantErr: true,
+		}, {
+			desc: "trailing separator",
+			input: map[string]interface{}{
+				"a.": int64(1),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := pkg.Unflatten(tt.input)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("Unflatten() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("Unflatten() got nil error, want error\n")
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package protofirestore
+
+// firestoreMarshaler is implemented by messages with code generated by
+// protoc-gen-go-firestore, which encodes them without reflection.
+type firestoreMarshaler interface {
+	MarshalFirestore(MarshalOptions) (map[string]interface{}, error)
+}
+
+// firestoreUnmarshaler is implemented by messages with code generated by
+// protoc-gen-go-firestore, which decodes them without reflection.
+type firestoreUnmarshaler interface {
+	UnmarshalFirestore(UnmarshalOptions, map[string]interface{}) error
+}
@@ -0,0 +1,240 @@
+package protofirestore_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pkg "github.com/daviddomkar/protofirestore"
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/go-test/deep"
+)
+
+// The test messages have code generated by protoc-gen-go-firestore.
+var (
+	_ interface {
+		MarshalFirestore(pkg.MarshalOptions) (map[string]interface{}, error)
+		UnmarshalFirestore(pkg.UnmarshalOptions, map[string]interface{}) error
+	} = (*pb3.Scalars)(nil)
+	_ interface {
+		MarshalFirestore(pkg.MarshalOptions) (map[string]interface{}, error)
+		UnmarshalFirestore(pkg.UnmarshalOptions, map[string]interface{}) error
+	} = (*pbann.Order)(nil)
+)
+
+// dynamic returns a copy of m which has no generated code and is therefore
+// encoded and decoded using reflection.
+func dynamic(m proto.Message) *dynamicpb.Message {
+	dm := dynamicpb.NewMessage(m.ProtoReflect().Descriptor())
+	proto.Merge(dm, m)
+	return dm
+}
+
+// forms are the forms the table tests run their messages in: as is, which
+// uses the generated code of messages having any, and as dynamic messages,
+// which use reflection.
+var forms = []struct {
+	name string
+	of   func(proto.Message) proto.Message
+}{
+	{name: "generated", of: func(m proto.Message) proto.Message { return m }},
+	{name: "reflective", of: func(m proto.Message) proto.Message { return dynamic(m) }},
+}
+
+func TestGeneratedMarshal(t *testing.T) {
+	tests := []struct {
+		desc  string
+		input proto.Message
+	}{
+		{
+			desc:  "empty scalars",
+			input: &pb3.Scalars{},
+		}, {
+			desc:  "scalars",
+			input: &pb3.Scalars{SBool: true, SInt32: -1, SInt64: 2, SUint32: 3, SUint64: math.MaxUint64, SSint32: -4, SSint64: -5, SFixed32: 6, SFixed64: 7, SSfixed32: -8, SSfixed64: -9, SFloat: 1.5, SDouble: math.Inf(1), SBytes: []byte("b"), SString: "s"},
+		}, {
+			desc:  "negative zero",
+			input: &pb3.Scalars{SFloat: float32(math.Copysign(0, -1)), SDouble: math.Copysign(0, -1)},
+		}, {
+			desc:  "invalid UTF-8",
+			input: &pb3.Scalars{SString: "abc\xff"},
+		}, {
+			desc:  "repeats",
+			input: &pb3.Repeats{RptBool: []bool{false}, RptInt32: []int32{1}, RptInt64: []int64{2}, RptUint32: []uint32{3}, RptUint64: []uint64{4}, RptFloat: []float32{5}, RptDouble: []float64{6}, RptString: []string{"", "a"}, RptBytes: [][]byte{nil, []byte("b")}},
+		}, {
+			desc:  "proto3 optional zero values",
+			input: &pb3.Proto3Optional{OptBool: proto.Bool(false), OptInt32: proto.Int32(0), OptInt64: proto.Int64(0), OptUint32: proto.Uint32(0), OptUint64: proto.Uint64(0), OptFloat: proto.Float32(0), OptDouble: proto.Float64(0), OptString: proto.String(""), OptBytes: []byte{}, OptEnum: pb3.Enum_ZERO.Enum(), OptMessage: &pb3.Nested{}},
+		}, {
+			desc:  "enums",
+			input: &pb3.Enums{SEnum: pb3.Enum_TEN, SNestedEnum: 42},
+		}, {
+			desc:  "nested messages",
+			input: &pb3.Nests{SNested: &pb3.Nested{SNested: &pb3.Nested{SString: "deep"}}},
+		}, {
+			desc:  "empty nested message",
+			input: &pb3.Nests{SNested: &pb3.Nested{}},
+		}, {
+			desc:  "oneof empty message",
+			input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofNested{OneofNested: &pb3.Nested{}}},
+		}, {
+			desc:  "oneof zero enum",
+			input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofEnum{OneofEnum: pb3.Enum_ZERO}},
+		}, {
+			desc:  "oneof empty string",
+			input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{OneofString: ""}},
+		}, {
+			desc: "maps",
+			input: &pb3.Maps{
+				Int32ToStr:   map[int32]string{-1: "a", 2: ""},
+				BoolToUint32: map[bool]uint32{true: 0},
+				Uint64ToEnum: map[uint64]pb3.Enum{math.MaxUint64: pb3.Enum_TWO},
+				StrToNested:  map[string]*pb3.Nested{"a": {}, "b": {SString: "b"}},
+				StrToOneofs:  map[string]*pb3.Oneofs{"a": {Union: &pb3.Oneofs_OneofNested{OneofNested: &pb3.Nested{}}}},
+			},
+		}, {
+			desc:  "json names",
+			input: &pb3.JSONNames{SString: "json"},
+		}, {
+			desc: "annotated messages",
+			input: &pbann.Order{
+				Name:       "order",
+				State:      pbann.Order_SHIPPED,
+				CreateTime: timestamppb.New(time.Unix(1553036601, 5)),
+				Tags:       []string{"a"},
+				Address:    &pbann.Address{Street: "street"},
+				Stops:      []*pbann.Address{{}, {Instructions: "ring"}},
+			},
+		}, {
+			desc:  "timestamp out of range",
+			input: &pbann.Order{CreateTime: &timestamppb.Timestamp{Seconds: math.MaxInt64}},
+		}, {
+			desc:  "counters",
+			input: &pbann.Counters{Likes: 1, Totals: map[string]int64{"a": 0}, Child: &pbann.Counters{}, Children: []*pbann.Counters{{Rating: 1}}},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, defaults := range []bool{false, true} {
+			opts := pkg.MarshalOptions{EmitFirestoreSensibleDefaults: defaults}
+
+			got, gotErr := opts.Marshal(tt.input)
+			want, wantErr := opts.Marshal(dynamic(tt.input))
+
+			if (gotErr != nil) != (wantErr != nil) {
+				t.Errorf("%v (defaults %v): Marshal() returned error %v, want %v\n", tt.desc, defaults, gotErr, wantErr)
+			}
+
+			if diff := deep.Equal(got, want); diff != nil {
+				t.Errorf("%v (defaults %v): %v\n", tt.desc, defaults, diff)
+			}
+		}
+	}
+}
+
+func TestGeneratedUnmarshal(t *testing.T) {
+	tests := []struct {
+		desc  string
+		into  proto.Message
+		input map[string]interface{}
+	}{
+		{
+			desc:  "scalars",
+			into:  &pb3.Scalars{},
+			input: map[string]interface{}{"sBool": true, "sInt32": int64(-1), "sInt64": 2.0, "sUint32": uint32(3), "sUint64": uint64(math.MaxUint64), "sSint32": int32(-4), "sSint64": int64(-5), "sFixed32": int64(6), "sFixed64": 7, "sSfixed32": int32(-8), "sSfixed64": int64(-9), "sFloat": 1.5, "sDouble": int64(1), "sBytes": []byte("b"), "sString": "s"},
+		}, {
+			desc:  "nil values",
+			into:  &pb3.Scalars{},
+			input: map[string]interface{}{"sBool": nil, "sString": nil},
+		}, {
+			desc:  "wrong type",
+			into:  &pb3.Scalars{},
+			input: map[string]interface{}{"sInt32": "1"},
+		}, {
+			desc:  "out of range",
+			into:  &pb3.Scalars{},
+			input: map[string]interface{}{"sUint32": int64(-1)},
+		}, {
+			desc:  "unknown field",
+			into:  &pb3.Scalars{},
+			input: map[string]interface{}{"unknown": true},
+		}, {
+			desc:  "repeats with nil elements",
+			into:  &pb3.Repeats{},
+			input: map[string]interface{}{"rptString": []interface{}{nil, "a"}, "rptBytes": []interface{}{nil}, "rptDouble": []interface{}{int64(1), 2.5}},
+		}, {
+			desc:  "proto3 optional",
+			into:  &pb3.Proto3Optional{},
+			input: map[string]interface{}{"optBool": false, "optString": "", "optEnum": "ZERO", "optBytes": []byte{}, "optMessage": map[string]interface{}{}},
+		}, {
+			desc:  "enums",
+			into:  &pb3.Enums{},
+			input: map[string]interface{}{"sEnum": "TEN", "sNestedEnum": int64(42)},
+		}, {
+			desc:  "invalid enum",
+			into:  &pb3.Enums{},
+			input: map[string]interface{}{"sEnum": "ELEVEN"},
+		}, {
+			desc:  "oneof",
+			into:  &pb3.Oneofs{},
+			input: map[string]interface{}{"oneofNested": map[string]interface{}{"sString": "a"}},
+		}, {
+			desc:  "oneof conflict",
+			into:  &pb3.Oneofs{},
+			input: map[string]interface{}{"oneofNested": map[string]interface{}{}, "oneofEnum": "ONE"},
+		}, {
+			desc:  "unknown key and invalid value",
+			into:  &pb3.Scalars{},
+			input: map[string]interface{}{"sUnknown": true, "sInt32": "1", "sBool": "true"},
+		}, {
+			desc: "maps",
+			into: &pb3.Maps{},
+			input: map[string]interface{}{
+				"int32ToStr":   map[string]interface{}{"-1": "a", "2": nil},
+				"boolToUint32": map[string]interface{}{"true": int64(1)},
+				"uint64ToEnum": map[string]interface{}{"18446744073709551615": "TWO"},
+				"strToNested":  map[string]interface{}{"a": nil, "b": map[string]interface{}{"sString": "b"}},
+			},
+		}, {
+			desc:  "invalid map key",
+			into:  &pb3.Maps{},
+			input: map[string]interface{}{"boolToUint32": map[string]interface{}{"yes": int64(1)}},
+		}, {
+			desc: "annotated messages",
+			into: &pbann.Order{},
+			input: map[string]interface{}{
+				"name":       "order",
+				"state":      "SHIPPED",
+				"createTime": time.Unix(1553036601, 5),
+				"tags":       []interface{}{"a"},
+				"address":    map[string]interface{}{"street": "street"},
+				"stops":      []interface{}{nil, map[string]interface{}{"instructions": "ring"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, discard := range []bool{false, true} {
+			opts := pkg.UnmarshalOptions{DiscardUnknown: discard}
+
+			got := tt.into.ProtoReflect().New().Interface()
+			gotErr := opts.Unmarshal(tt.input, got)
+
+			want := dynamicpb.NewMessage(tt.into.ProtoReflect().Descriptor())
+			wantErr := opts.Unmarshal(tt.input, want)
+
+			if fmt.Sprint(gotErr) != fmt.Sprint(wantErr) {
+				t.Errorf("%v (discard unknown %v): Unmarshal() returned error %v, want %v\n", tt.desc, discard, gotErr, wantErr)
+			}
+
+			if gotErr == nil && !proto.Equal(got, want) {
+				t.Errorf("%v (discard unknown %v): Unmarshal()\n got: %v\nwant: %v\n", tt.desc, discard, got, want)
+			}
+		}
+	}
+}
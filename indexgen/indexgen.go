@@ -0,0 +1,306 @@
+// Package indexgen generates the firestore.indexes.json file of the firestore
+// CLI from the index annotations of messages stored as documents, so that
+// composite indexes, single-field index exemptions and TTL policies are
+// declared next to the fields they cover.
+package indexgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/docpath"
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Config is the content of a firestore.indexes.json file.
+type Config struct {
+	Indexes        []Index         `json:"indexes"`
+	FieldOverrides []FieldOverride `json:"fieldOverrides"`
+}
+
+// MarshalIndent encodes the configuration as indented JSON ready to be
+// written to a firestore.indexes.json file.
+func (c *Config) MarshalIndent() ([]byte, error) {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Index is a composite index.
+type Index struct {
+	CollectionGroup string       `json:"collectionGroup"`
+	QueryScope      string       `json:"queryScope"`
+	Fields          []IndexField `json:"fields"`
+}
+
+// IndexField is a field of an index. Exactly one of Order and ArrayConfig is
+// set. The fields of single-field indexes have a QueryScope instead of a
+// FieldPath.
+type IndexField struct {
+	FieldPath   string `json:"fieldPath,omitempty"`
+	Order       string `json:"order,omitempty"`
+	ArrayConfig string `json:"arrayConfig,omitempty"`
+	QueryScope  string `json:"queryScope,omitempty"`
+}
+
+// FieldOverride overrides the single-field indexes of a field. An empty list
+// of indexes exempts the field from indexing. TTL enables a TTL policy for
+// the field, which deletes documents once the time stored in it elapsed.
+type FieldOverride struct {
+	CollectionGroup string       `json:"collectionGroup"`
+	FieldPath       string       `json:"fieldPath"`
+	TTL             bool         `json:"ttl,omitempty"`
+	Indexes         []IndexField `json:"indexes"`
+}
+
+// defaultIndexes are the automatic single-field indexes of non-array fields.
+var defaultIndexes = []IndexField{
+	{Order: "ASCENDING", QueryScope: "COLLECTION"},
+	{Order: "DESCENDING", QueryScope: "COLLECTION"},
+}
+
+// Options configures the generation of index configurations.
+type Options struct {
+	// MarshalOptions are the options documents are encoded with, which
+	// determine the field paths of the indexes.
+	MarshalOptions protofirestore.MarshalOptions
+}
+
+// Generate generates the index configuration of the given messages.
+func Generate(mds ...protoreflect.MessageDescriptor) (*Config, error) {
+	return Options{}.Generate(mds...)
+}
+
+// Generate generates the index configuration of the given messages. Only
+// messages with a path option are stored as documents, their collection ID is
+// the collection group of their indexes. Fields marked as excluded from
+// indexes are exempted from single-field indexes, including fields of nested
+// messages which are not repeated or map values.
+//
+// Fields marked as the purge time of soft-deleted documents get a TTL
+// policy. They keep their single-field indexes unless they are excluded from
+// indexes, because the repository package queries them to purge documents.
+//
+// Indexes that are declared more than once, e.g. by messages sharing a
+// collection, are only included once.
+func (o Options) Generate(mds ...protoreflect.MessageDescriptor) (*Config, error) {
+	config := &Config{
+		Indexes:        []Index{},
+		FieldOverrides: []FieldOverride{},
+	}
+
+	for _, md := range mds {
+		opts := annotations.MessageOptionsOf(md)
+
+		if opts.GetPath() == "" {
+			if len(opts.GetIndexes()) > 0 {
+				return nil, fmt.Errorf("message %v declares indexes but has no path", md.FullName())
+			}
+			continue
+		}
+
+		pattern, err := docpath.Parse(opts.GetPath())
+		if err != nil {
+			return nil, fmt.Errorf("message %v: %w", md.FullName(), err)
+		}
+
+		collectionGroup := pattern.CollectionID()
+
+		for i, index := range opts.GetIndexes() {
+			generated, err := o.generateIndex(md, collectionGroup, index)
+			if err != nil {
+				return nil, fmt.Errorf("message %v: index %d: %w", md.FullName(), i, err)
+			}
+			config.Indexes = appendUnique(config.Indexes, generated)
+		}
+
+		excluded, err := o.excludedFieldPaths(md, "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("message %v: %w", md.FullName(), err)
+		}
+
+		for _, path := range excluded {
+			config.FieldOverrides = appendUnique(config.FieldOverrides, FieldOverride{
+				CollectionGroup: collectionGroup,
+				FieldPath:       path,
+				Indexes:         []IndexField{},
+			})
+		}
+
+		if err := o.addTTL(config, md, collectionGroup); err != nil {
+			return nil, fmt.Errorf("message %v: %w", md.FullName(), err)
+		}
+	}
+
+	return config, nil
+}
+
+// generateIndex generates the composite index of messages described by md.
+func (o Options) generateIndex(md protoreflect.MessageDescriptor, collectionGroup string, index *annotations.Index) (Index, error) {
+	generated := Index{
+		CollectionGroup: collectionGroup,
+		QueryScope:      "COLLECTION",
+	}
+
+	if index.GetQueryScope() == annotations.Index_COLLECTION_GROUP {
+		generated.QueryScope = "COLLECTION_GROUP"
+	}
+
+	if len(index.GetFields()) < 2 {
+		return Index{}, fmt.Errorf("composite index has %d fields, want at least 2", len(index.GetFields()))
+	}
+
+	seen := make(map[string]bool)
+	arrayContains := false
+
+	for _, field := range index.GetFields() {
+		path, fd, err := o.MarshalOptions.ResolveFieldPath(md, field.GetPath())
+		if err != nil {
+			return Index{}, err
+		}
+
+		if seen[path] {
+			return Index{}, fmt.Errorf("field %v is indexed more than once", field.GetPath())
+		}
+		seen[path] = true
+
+		if field.GetArrayContains() {
+			switch {
+			case !fd.IsList():
+				return Index{}, fmt.Errorf("field %v is indexed for array-contains but is not repeated", field.GetPath())
+			case field.GetOrder() != annotations.IndexField_ORDER_UNSPECIFIED:
+				return Index{}, fmt.Errorf("field %v is indexed for array-contains and cannot have an order", field.GetPath())
+			case arrayContains:
+				return Index{}, fmt.Errorf("composite index has more than one array-contains field")
+			}
+			arrayContains = true
+
+			generated.Fields = append(generated.Fields, IndexField{FieldPath: path, ArrayConfig: "CONTAINS"})
+			continue
+		}
+
+		switch {
+		case fd.IsList():
+			return Index{}, fmt.Errorf("repeated field %v can only be indexed for array-contains", field.GetPath())
+		case fd.IsMap():
+			return Index{}, fmt.Errorf("cannot index map field %v", field.GetPath())
+		case fd.Message() != nil && fd.Message().FullName() != genid.Timestamp_message_fullname:
+			return Index{}, fmt.Errorf("cannot index message field %v", field.GetPath())
+		}
+
+		order := "ASCENDING"
+		if field.GetOrder() == annotations.IndexField_DESCENDING {
+			order = "DESCENDING"
+		}
+
+		generated.Fields = append(generated.Fields, IndexField{FieldPath: path, Order: order})
+	}
+
+	return generated, nil
+}
+
+// excludedFieldPaths returns the firestore field paths of the fields of md
+// excluded from indexes, prefixed by the path of the message. Nested messages
+// are visited unless they are already being visited.
+func (o Options) excludedFieldPaths(md protoreflect.MessageDescriptor, prefix string, visiting []protoreflect.FullName) ([]string, error) {
+	for _, name := range visiting {
+		if name == md.FullName() {
+			return nil, nil
+		}
+	}
+	visiting = append(visiting, md.FullName())
+
+	var paths []string
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if annotations.FieldOptionsOf(fd).GetSubcollection() != "" {
+			continue // stored as separate documents
+		}
+
+		path, err := o.MarshalOptions.FieldPath(md, string(fd.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if annotations.FieldOptionsOf(fd).GetExcludeFromIndexes() {
+			paths = append(paths, path)
+			continue
+		}
+
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !isWellKnownType(fd.Message()) {
+			nested, err := o.excludedFieldPaths(fd.Message(), path, visiting)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, nested...)
+		}
+	}
+
+	return paths, nil
+}
+
+// addTTL adds a TTL policy for the purge time field of md, if any, to the
+// field override of the field.
+func (o Options) addTTL(config *Config, md protoreflect.MessageDescriptor, collectionGroup string) error {
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !annotations.FieldOptionsOf(fd).GetPurgeTime() {
+			continue
+		}
+
+		path, err := o.MarshalOptions.FieldPath(md, string(fd.Name()))
+		if err != nil {
+			return err
+		}
+
+		for i, override := range config.FieldOverrides {
+			if override.CollectionGroup != collectionGroup {
+				continue
+			}
+			switch {
+			case override.FieldPath == path:
+				config.FieldOverrides[i].TTL = true
+				return nil
+			case override.TTL:
+				return fmt.Errorf("collection group %v has more than one TTL field", collectionGroup)
+			}
+		}
+
+		config.FieldOverrides = append(config.FieldOverrides, FieldOverride{
+			CollectionGroup: collectionGroup,
+			FieldPath:       path,
+			TTL:             true,
+			Indexes:         defaultIndexes,
+		})
+	}
+
+	return nil
+}
+
+// isWellKnownType reports whether md is a well known type, which is not
+// encoded as a nested document.
+func isWellKnownType(md protoreflect.MessageDescriptor) bool {
+	return md.FullName().Parent() == genid.GoogleProtobuf_package
+}
+
+// appendUnique appends v to s unless s already contains it.
+func appendUnique[T any](s []T, v T) []T {
+	for _, e := range s {
+		if reflect.DeepEqual(e, v) {
+			return s
+		}
+	}
+	return append(s, v)
+}
@@ -0,0 +1,235 @@
+package indexgen_test
+
+import (
+	"testing"
+
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/indexgen"
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/go-test/deep"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGenerate(t *testing.T) {
+	got, err := indexgen.Generate(
+		(&pbann.Order{}).ProtoReflect().Descriptor(),
+		(&pbann.User{}).ProtoReflect().Descriptor(),
+		(&pbann.Address{}).ProtoReflect().Descriptor(),
+		(&pbann.Order{}).ProtoReflect().Descriptor(),
+		(&pbann.Book{}).ProtoReflect().Descriptor(),
+		(&pbann.Post{}).ProtoReflect().Descriptor(),
+	)
+	if err != nil {
+		t.Fatalf("Generate() returned error: %v\n", err)
+	}
+
+	want := &indexgen.Config{
+		Indexes: []indexgen.Index{
+			{
+				CollectionGroup: "orders",
+				QueryScope:      "COLLECTION",
+				Fields: []indexgen.IndexField{
+					{FieldPath: "state", Order: "ASCENDING"},
+					{FieldPath: "createTime", Order: "DESCENDING"},
+				},
+			}, {
+				CollectionGroup: "orders",
+				QueryScope:      "COLLECTION_GROUP",
+				Fields: []indexgen.IndexField{
+					{FieldPath: "tags", ArrayConfig: "CONTAINS"},
+					{FieldPath: "total", Order: "ASCENDING"},
+				},
+			},
+		},
+		FieldOverrides: []indexgen.FieldOverride{
+			{CollectionGroup: "orders", FieldPath: "notes", Indexes: []indexgen.IndexField{}},
+			{CollectionGroup: "orders", FieldPath: "address.instructions", Indexes: []indexgen.IndexField{}},
+			{CollectionGroup: "users", FieldPath: "avatar", Indexes: []indexgen.IndexField{}},
+			{CollectionGroup: "users", FieldPath: "home.instructions", Indexes: []indexgen.IndexField{}},
+			{
+				CollectionGroup: "books",
+				FieldPath:       "purgeTime",
+				TTL:             true,
+				Indexes: []indexgen.IndexField{
+					{Order: "ASCENDING", QueryScope: "COLLECTION"},
+					{Order: "DESCENDING", QueryScope: "COLLECTION"},
+				},
+			},
+		},
+	}
+
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestGenerateEmpty(t *testing.T) {
+	got, err := indexgen.Generate((&pbann.Address{}).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatalf("Generate() returned error: %v\n", err)
+	}
+
+	b, err := got.MarshalIndent()
+	if err != nil {
+		t.Fatalf("MarshalIndent() returned error: %v\n", err)
+	}
+
+	want := "{\n  \"indexes\": [],\n  \"fieldOverrides\": []\n}\n"
+	if string(b) != want {
+		t.Errorf("MarshalIndent() = %q, want %q\n", b, want)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	field := func(path string, order annotations.IndexField_Order, arrayContains bool) *annotations.IndexField {
+		return &annotations.IndexField{Path: path, Order: order, ArrayContains: arrayContains}
+	}
+
+	tests := []struct {
+		desc    string
+		options *annotations.MessageOptions
+	}{
+		{
+			desc: "indexes without path",
+			options: &annotations.MessageOptions{
+				Indexes: []*annotations.Index{{Fields: []*annotations.IndexField{
+					field("name", 0, false),
+					field("total", 0, false),
+				}}},
+			},
+		}, {
+			desc:    "invalid path",
+			options: &annotations.MessageOptions{Path: "bad"},
+		}, {
+			desc: "single field",
+			options: &annotations.MessageOptions{
+				Path: "bad/{bad}",
+				Indexes: []*annotations.Index{{Fields: []*annotations.IndexField{
+					field("name", 0, false),
+				}}},
+			},
+		}, {
+			desc: "unknown field",
+			options: &annotations.MessageOptions{
+				Path: "bad/{bad}",
+				Indexes: []*annotations.Index{{Fields: []*annotations.IndexField{
+					field("name", 0, false),
+					field("unknown", 0, false),
+				}}},
+			},
+		}, {
+			desc: "duplicate field",
+			options: &annotations.MessageOptions{
+				Path: "bad/{bad}",
+				Indexes: []*annotations.Index{{Fields: []*annotations.IndexField{
+					field("name", annotations.IndexField_ASCENDING, false),
+					field("name", annotations.IndexField_DESCENDING, false),
+				}}},
+			},
+		}, {
+			desc: "array-contains on singular field",
+			options: &annotations.MessageOptions{
+				Path: "bad/{bad}",
+				Indexes: []*annotations.Index{{Fields: []*annotations.IndexField{
+					field("name", 0, true),
+					field("total", 0, false),
+				}}},
+			},
+		}, {
+			desc: "array-contains with order",
+			options: &annotations.MessageOptions{
+				Path: "bad/{bad}",
+				Indexes: []*annotations.Index{{Fields: []*annotations.IndexField{
+					field("tags", annotations.IndexField_ASCENDING, true),
+					field("total", 0, false),
+				}}},
+			},
+		}, {
+			desc: "two array-contains fields",
+			options: &annotations.MessageOptions{
+				Path: "bad/{bad}",
+				Indexes: []*annotations.Index{{Fields: []*annotations.IndexField{
+					field("tags", 0, true),
+					field("labels", 0, true),
+				}}},
+			},
+		}, {
+			desc: "ordered repeated field",
+			options: &annotations.MessageOptions{
+				Path: "bad/{bad}",
+				Indexes: []*annotations.Index{{Fields: []*annotations.IndexField{
+					field("tags", 0, false),
+					field("total", 0, false),
+				}}},
+			},
+		}, {
+			desc: "message field",
+			options: &annotations.MessageOptions{
+				Path: "bad/{bad}",
+				Indexes: []*annotations.Index{{Fields: []*annotations.IndexField{
+					field("address", 0, false),
+					field("total", 0, false),
+				}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			md := newMessage(t, tt.options)
+
+			if _, err := indexgen.Generate(md); err == nil {
+				t.Errorf("Generate() got nil error, want error\n")
+			}
+		})
+	}
+}
+
+// newMessage builds a message with the given options and the fields name,
+// total, tags, labels and address.
+func newMessage(t *testing.T, options *annotations.MessageOptions) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	opts := &descriptorpb.MessageOptions{}
+	proto.SetExtension(opts, annotations.E_Message, options)
+
+	scalar := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+	}
+
+	address := scalar("address", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL)
+	address.TypeName = proto.String(".annotated.Address")
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("indexgen_test.proto"),
+		Package:    proto.String("indexgentest"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"internal/testprotos/annotatedpb/test.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Bad"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				scalar("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+				scalar("total", 2, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+				scalar("tags", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+				scalar("labels", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+				address,
+			},
+			Options: opts,
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("protodesc.NewFile() returned error: %v\n", err)
+	}
+
+	return fd.Messages().Get(0)
+}
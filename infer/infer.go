@@ -0,0 +1,592 @@
+// Package infer infers a draft proto schema from sample firestore documents,
+// which helps moving untyped collections onto messages encoded by the
+// protofirestore package.
+package infer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/internal/strs"
+)
+
+// Options configures the inference of schemas.
+type Options struct {
+	// Package is the package of the generated proto file.
+	Package string
+
+	// Message is the name of the message of the documents. It defaults to
+	// Document.
+	Message string
+
+	// MaxEnumValues is the maximum number of distinct values of a string
+	// field inferred as enum. It defaults to 16. String fields are only
+	// inferred as enums if all of their values are upper snake case names
+	// and some of them occur more than once.
+	MaxEnumValues int
+
+	// MaxMessageFields is the maximum number of distinct keys of an object
+	// inferred as message. Objects with more keys, or with keys which are
+	// not identifiers, are inferred as maps. It defaults to 64.
+	MaxMessageFields int
+}
+
+// Result is the result of inferring a schema.
+type Result struct {
+	// Proto is the content of the inferred proto file.
+	Proto string
+
+	// Conflicts are the paths whose values cannot be reproduced by a single
+	// field, sorted by path.
+	Conflicts []Conflict
+}
+
+// Conflict is a path with values of different types. The field of the path
+// has the most common type.
+type Conflict struct {
+	// Path is the firestore field path of the values. Elements of arrays
+	// are denoted by [] and values of maps by *.
+	Path string
+
+	// Types are the number of values of each type.
+	Types map[string]int
+}
+
+func (c Conflict) String() string {
+	types := make([]string, 0, len(c.Types))
+	for t := range c.Types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for i, t := range types {
+		types[i] = fmt.Sprintf("%s (%d)", t, c.Types[t])
+	}
+	return fmt.Sprintf("%s: conflicting types %s", c.Path, strings.Join(types, ", "))
+}
+
+// Infer infers the schema of the given documents.
+func Infer(documents []map[string]interface{}) (*Result, error) {
+	return Options{}.Infer(documents)
+}
+
+// Infer infers the schema of the given documents, whose values have the
+// types the firestore SDK and the protofirestore package use: bool, integers,
+// floats, string, []byte, time.Time, map[string]interface{} and
+// []interface{}. The inferred message encodes into the same documents:
+//
+//   - integers are int64 fields and floats are double fields, as are paths
+//     with both integers and whole floats.
+//   - timestamps are google.protobuf.Timestamp fields.
+//   - objects are nested messages, or maps whose keys are int64 if all keys
+//     are integers and strings otherwise.
+//   - arrays are repeated fields.
+//   - bool, number and enum fields which are stored with zero values are
+//     optional, because proto3 fields without presence omit zero values.
+//
+// Empty strings, bytes and objects as well as null values are never
+// reproduced, because the encoder omits them.
+func (o Options) Infer(documents []map[string]interface{}) (*Result, error) {
+	if o.Message == "" {
+		o.Message = "Document"
+	}
+	if o.MaxEnumValues == 0 {
+		o.MaxEnumValues = 16
+	}
+	if o.MaxMessageFields == 0 {
+		o.MaxMessageFields = 64
+	}
+
+	root := newNode()
+	for i, document := range documents {
+		if err := root.add(document, o); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+	}
+
+	r := renderer{opts: o}
+	m := r.message(o.Message, root, "")
+	m.comment = fmt.Sprintf("%s was inferred from %d documents.", o.Message, len(documents))
+
+	var b strings.Builder
+	b.WriteString("syntax = \"proto3\";\n")
+	if o.Package != "" {
+		fmt.Fprintf(&b, "\npackage %s;\n", o.Package)
+	}
+	if r.timestamps {
+		b.WriteString("\nimport \"google/protobuf/timestamp.proto\";\n")
+	}
+	b.WriteString("\n")
+	m.write(&b, "")
+
+	sort.Slice(r.conflicts, func(i, j int) bool {
+		return r.conflicts[i].Path < r.conflicts[j].Path
+	})
+
+	return &Result{Proto: b.String(), Conflicts: r.conflicts}, nil
+}
+
+// kind is the type of a sampled value.
+type kind int
+
+const (
+	boolKind kind = iota
+	intKind
+	floatKind
+	stringKind
+	bytesKind
+	timestampKind
+	objectKind
+	arrayKind
+)
+
+var kindNames = [...]string{"bool", "int", "float", "string", "bytes", "timestamp", "object", "array"}
+
+func (k kind) String() string {
+	return kindNames[k]
+}
+
+// node accumulates the values sampled at a path.
+type node struct {
+	kinds map[kind]int
+
+	// zero reports whether a zero bool or number was sampled.
+	zero bool
+
+	// strings are the distinct strings sampled, up to MaxEnumValues + 1.
+	strings map[string]bool
+	// enumLike reports whether all strings are upper snake case names.
+	enumLike bool
+
+	// fields are the values of the keys of objects.
+	fields map[string]*node
+	// items are the elements of arrays.
+	items *node
+}
+
+func newNode() *node {
+	return &node{kinds: make(map[kind]int), enumLike: true}
+}
+
+var enumValuePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// add samples the value v.
+func (n *node) add(v interface{}, o Options) error {
+	var k kind
+
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case bool:
+		k = boolKind
+		n.zero = n.zero || !v
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		k = intKind
+		n.zero = n.zero || fmt.Sprint(v) == "0"
+	case float32:
+		k = floatKind
+		n.zero = n.zero || v == 0
+	case float64:
+		k = floatKind
+		n.zero = n.zero || v == 0
+	case string:
+		k = stringKind
+		if len(n.strings) <= o.MaxEnumValues {
+			if n.strings == nil {
+				n.strings = make(map[string]bool)
+			}
+			n.strings[v] = true
+		}
+		n.enumLike = n.enumLike && enumValuePattern.MatchString(v)
+	case []byte:
+		k = bytesKind
+	case time.Time:
+		k = timestampKind
+	case map[string]interface{}:
+		k = objectKind
+		if n.fields == nil {
+			n.fields = make(map[string]*node)
+		}
+		for key, value := range v {
+			child, ok := n.fields[key]
+			if !ok {
+				child = newNode()
+				n.fields[key] = child
+			}
+			if err := child.add(value, o); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	case []interface{}:
+		k = arrayKind
+		if n.items == nil {
+			n.items = newNode()
+		}
+		for _, item := range v {
+			if err := n.items.add(item, o); err != nil {
+				return fmt.Errorf("[]: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported value type %T", v)
+	}
+
+	n.kinds[k]++
+	return nil
+}
+
+// merge merges the samples of src into n.
+func (n *node) merge(src *node) {
+	for k, count := range src.kinds {
+		n.kinds[k] += count
+	}
+	n.zero = n.zero || src.zero
+	n.enumLike = n.enumLike && src.enumLike
+	for s := range src.strings {
+		if n.strings == nil {
+			n.strings = make(map[string]bool)
+		}
+		n.strings[s] = true
+	}
+	for key, child := range src.fields {
+		if n.fields == nil {
+			n.fields = make(map[string]*node)
+		}
+		if n.fields[key] == nil {
+			n.fields[key] = newNode()
+		}
+		n.fields[key].merge(child)
+	}
+	if src.items != nil {
+		if n.items == nil {
+			n.items = newNode()
+		}
+		n.items.merge(src.items)
+	}
+}
+
+// kind returns the kind of the field of the node and whether the sampled
+// values conflict. Integers and floats are merged into floats.
+func (n *node) kind() (kind, bool) {
+	counts := make(map[kind]int, len(n.kinds))
+	for k, count := range n.kinds {
+		counts[k] = count
+	}
+	if counts[intKind] > 0 && counts[floatKind] > 0 {
+		counts[floatKind] += counts[intKind]
+		delete(counts, intKind)
+	}
+
+	best, max := kind(-1), 0
+	for k := boolKind; k <= arrayKind; k++ {
+		if counts[k] > max {
+			best, max = k, counts[k]
+		}
+	}
+	return best, len(counts) > 1
+}
+
+// renderer renders nodes into messages.
+type renderer struct {
+	opts       Options
+	timestamps bool
+	conflicts  []Conflict
+}
+
+// message renders the object node n into the message named name.
+func (r *renderer) message(name string, n *node, path string) *message {
+	m := &message{name: name}
+
+	keys := make([]string, 0, len(n.fields))
+	for key := range n.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	names := make(map[string]bool)
+	for _, key := range keys {
+		child := n.fields[key]
+		fieldName := uniqueName(fieldName(key), names)
+
+		childPath := protofirestore.JoinFieldPath(key)
+		if path != "" {
+			childPath = path + "." + childPath
+		}
+
+		f := field{name: fieldName, key: key}
+		if !r.fieldType(m, &f, child, childPath, false) {
+			continue
+		}
+		f.number = len(m.fields) + 1
+		m.fields = append(m.fields, f)
+	}
+
+	return m
+}
+
+// fieldType sets the type of the field f of message m from the node n, or
+// the type of elements of lists and values of maps if element is set. It
+// reports false if the values cannot be represented.
+func (r *renderer) fieldType(m *message, f *field, n *node, path string, element bool) bool {
+	k, conflict := n.kind()
+	if k < 0 {
+		return false // only null values
+	}
+	if conflict {
+		types := make(map[string]int)
+		for kk, count := range n.kinds {
+			types[kk.String()] = count
+		}
+		r.conflicts = append(r.conflicts, Conflict{Path: path, Types: types})
+	}
+
+	optional := n.zero && !element
+
+	switch k {
+	case boolKind:
+		f.typ = "bool"
+	case intKind:
+		f.typ = "int64"
+	case floatKind:
+		f.typ = "double"
+	case bytesKind:
+		f.typ = "bytes"
+		optional = false
+	case timestampKind:
+		f.typ = "google.protobuf.Timestamp"
+		r.timestamps = true
+		optional = false
+	case stringKind:
+		f.typ = "string"
+		optional = false
+		if e := r.enum(m, f.name, n); e != nil {
+			f.typ = e.name
+			optional = !element && n.strings[e.values[0]]
+		}
+	case objectKind:
+		optional = false
+		// Maps cannot be repeated or map values, so objects of lists and maps
+		// are messages.
+		if !element && r.isMap(n) {
+			values := newNode()
+			keyType := "int64"
+			for key, child := range n.fields {
+				values.merge(child)
+				if _, err := strconv.ParseInt(key, 10, 64); err != nil {
+					keyType = "string"
+				}
+			}
+			value := field{name: f.name}
+			if !r.fieldType(m, &value, values, path+".*", true) {
+				return false
+			}
+			f.typ = "map<" + keyType + ", " + value.typ + ">"
+			return true
+		}
+		f.typ = uniqueMessageName(m, camelCase(f.name))
+		m.messages = append(m.messages, r.message(f.typ, n, path))
+	case arrayKind:
+		if element || n.items == nil {
+			return false
+		}
+		if ik, _ := n.items.kind(); ik == arrayKind {
+			r.conflicts = append(r.conflicts, Conflict{Path: path + "[]", Types: map[string]int{"array": n.items.kinds[arrayKind]}})
+			return false
+		}
+		if !r.fieldType(m, f, n.items, path+"[]", true) {
+			return false
+		}
+		f.label = "repeated"
+		return true
+	}
+
+	if optional {
+		f.label = "optional"
+	}
+	return true
+}
+
+// isMap reports whether the object node n is inferred as map.
+func (r *renderer) isMap(n *node) bool {
+	if len(n.fields) > r.opts.MaxMessageFields {
+		return true
+	}
+	for key := range n.fields {
+		if !isIdentifier(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// enum declares the enum of the string field named name in m if the values
+// of n are enum like and returns it, or returns nil otherwise.
+func (r *renderer) enum(m *message, name string, n *node) *enum {
+	if !n.enumLike || len(n.strings) == 0 || len(n.strings) > r.opts.MaxEnumValues || n.kinds[stringKind] <= len(n.strings) {
+		return nil
+	}
+
+	zero := strings.ToUpper(name) + "_UNSPECIFIED"
+	values := []string{zero}
+	sampled := make([]string, 0, len(n.strings))
+	for s := range n.strings {
+		if s != zero {
+			sampled = append(sampled, s)
+		}
+	}
+	sort.Strings(sampled)
+	values = append(values, sampled...)
+
+	// Enum values are scoped to the enclosing message.
+	for _, other := range m.enums {
+		for _, v := range other.values {
+			for _, w := range values {
+				if v == w {
+					return nil
+				}
+			}
+		}
+	}
+
+	e := &enum{name: uniqueMessageName(m, camelCase(name)), values: values}
+	m.enums = append(m.enums, e)
+	return e
+}
+
+// message is a rendered message.
+type message struct {
+	name     string
+	comment  string
+	fields   []field
+	messages []*message
+	enums    []*enum
+}
+
+// field is a rendered field.
+type field struct {
+	label  string
+	typ    string
+	name   string
+	key    string
+	number int
+}
+
+// enum is a rendered enum.
+type enum struct {
+	name   string
+	values []string
+}
+
+// write writes the declaration of m.
+func (m *message) write(b *strings.Builder, indent string) {
+	if m.comment != "" {
+		fmt.Fprintf(b, "%s// %s\n", indent, m.comment)
+	}
+	fmt.Fprintf(b, "%smessage %s {\n", indent, m.name)
+
+	inner := indent + "  "
+	for _, e := range m.enums {
+		fmt.Fprintf(b, "%senum %s {\n", inner, e.name)
+		for i, v := range e.values {
+			fmt.Fprintf(b, "%s  %s = %d;\n", inner, v, i)
+		}
+		fmt.Fprintf(b, "%s}\n\n", inner)
+	}
+	for _, nested := range m.messages {
+		nested.write(b, inner)
+		b.WriteString("\n")
+	}
+	for _, f := range m.fields {
+		b.WriteString(inner)
+		if f.label != "" {
+			b.WriteString(f.label + " ")
+		}
+		fmt.Fprintf(b, "%s %s = %d", f.typ, f.name, f.number)
+		if strs.JSONCamelCase(f.name) != f.key {
+			fmt.Fprintf(b, " [json_name = %s]", strconv.Quote(f.key))
+		}
+		b.WriteString(";\n")
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+// fieldName converts a key to a snake case field name.
+func fieldName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		switch {
+		case 'A' <= r && r <= 'Z':
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r + 'a' - 'A')
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	name := b.String()
+	if name == "" || !('a' <= name[0] && name[0] <= 'z') {
+		name = "f_" + name
+	}
+	return name
+}
+
+// camelCase converts a snake case field name to a message or enum name.
+func camelCase(name string) string {
+	s := strs.JSONCamelCase(name)
+	s = strings.TrimLeft(s, "_")
+	if s == "" {
+		return "X"
+	}
+	if 'a' <= s[0] && s[0] <= 'z' {
+		s = string(s[0]-'a'+'A') + s[1:]
+	}
+	return s
+}
+
+// uniqueName returns name, suffixed by a number if it is taken, and marks it
+// taken.
+func uniqueName(name string, taken map[string]bool) string {
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// uniqueMessageName returns name unless a message or enum nested in m has
+// it, in which case a number is appended.
+func uniqueMessageName(m *message, name string) string {
+	taken := make(map[string]bool)
+	for _, nested := range m.messages {
+		taken[nested.name] = true
+	}
+	for _, e := range m.enums {
+		taken[e.name] = true
+	}
+	return uniqueName(name, taken)
+}
+
+// isIdentifier reports whether key is usable as JSON name of a field.
+func isIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,300 @@
+package infer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/infer"
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/go-test/deep"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		desc          string
+		opts          infer.Options
+		input         []map[string]interface{}
+		want          string
+		wantConflicts []infer.Conflict
+	}{{
+		desc:  "no documents",
+		input: nil,
+		want: `syntax = "proto3";
+
+// Document was inferred from 0 documents.
+message Document {
+}
+`,
+	}, {
+		desc: "scalars",
+		opts: infer.Options{Package: "inferred", Message: "Scalars"},
+		input: []map[string]interface{}{
+			{"bool": true, "int": int64(1), "float": 1.5, "string": "a", "bytes": []byte{1}, "time": time.Unix(1, 0)},
+			{"int": int32(2), "float": int64(2), "string": "b", "null": nil},
+		},
+		want: `syntax = "proto3";
+
+package inferred;
+
+import "google/protobuf/timestamp.proto";
+
+// Scalars was inferred from 2 documents.
+message Scalars {
+  bool bool = 1;
+  bytes bytes = 2;
+  double float = 3;
+  int64 int = 4;
+  string string = 5;
+  google.protobuf.Timestamp time = 6;
+}
+`,
+	}, {
+		desc: "stored zero values are optional",
+		input: []map[string]interface{}{
+			{"bool": false, "int": int64(0), "float": 0.0, "state": "STATE_UNSPECIFIED"},
+			{"bool": true, "int": int64(1), "float": 1.0, "state": "OPEN"},
+			{"state": "OPEN"},
+		},
+		want: `syntax = "proto3";
+
+// Document was inferred from 3 documents.
+message Document {
+  enum State {
+    STATE_UNSPECIFIED = 0;
+    OPEN = 1;
+  }
+
+  optional bool bool = 1;
+  optional double float = 2;
+  optional int64 int = 3;
+  optional State state = 4;
+}
+`,
+	}, {
+		desc: "enums",
+		input: []map[string]interface{}{
+			{"kind": "B", "code": "X1", "label": "Hello"},
+			{"kind": "A", "code": "X2", "label": "Hello"},
+			{"kind": "B", "code": "X3", "label": "Hello"},
+		},
+		want: `syntax = "proto3";
+
+// Document was inferred from 3 documents.
+message Document {
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+    A = 1;
+    B = 2;
+  }
+
+  string code = 1;
+  Kind kind = 2;
+  string label = 3;
+}
+`,
+	}, {
+		desc: "messages and maps",
+		input: []map[string]interface{}{{
+			"address":  map[string]interface{}{"street": "a", "zipCode": "1"},
+			"counts":   map[string]interface{}{"1": int64(1), "22": int64(2)},
+			"labels":   map[string]interface{}{"app-name": "a"},
+			"children": map[string]interface{}{"c-1": map[string]interface{}{"name": "c"}},
+		}, {
+			"address": map[string]interface{}{"city": "b"},
+			"labels":  map[string]interface{}{"team": "b"},
+		}},
+		want: `syntax = "proto3";
+
+// Document was inferred from 2 documents.
+message Document {
+  message Address {
+    string city = 1;
+    string street = 2;
+    string zip_code = 3;
+  }
+
+  message Children {
+    string name = 1;
+  }
+
+  Address address = 1;
+  map<string, Children> children = 2;
+  map<int64, int64> counts = 3;
+  map<string, string> labels = 4;
+}
+`,
+	}, {
+		desc: "arrays",
+		input: []map[string]interface{}{{
+			"tags":  []interface{}{"a", nil},
+			"items": []interface{}{map[string]interface{}{"id": int64(1)}, nil},
+			"empty": []interface{}{},
+		}, {
+			"nested": []interface{}{[]interface{}{int64(1)}},
+		}},
+		want: `syntax = "proto3";
+
+// Document was inferred from 2 documents.
+message Document {
+  message Items {
+    int64 id = 1;
+  }
+
+  repeated Items items = 1;
+  repeated string tags = 2;
+}
+`,
+		wantConflicts: []infer.Conflict{
+			{Path: "nested[]", Types: map[string]int{"array": 1}},
+		},
+	}, {
+		desc: "json names",
+		input: []map[string]interface{}{
+			{"snake_case": "a", "kebab-case": "b", "1st": "c", "camelCase": "d"},
+		},
+		want: `syntax = "proto3";
+
+// Document was inferred from 1 documents.
+message Document {
+  string f_1st = 1 [json_name = "1st"];
+  string camel_case = 2;
+  string kebab_case = 3 [json_name = "kebab-case"];
+  string snake_case = 4 [json_name = "snake_case"];
+}
+`,
+	}, {
+		desc: "conflicts",
+		input: []map[string]interface{}{
+			{"value": "a", "nested": map[string]interface{}{"flag": true}},
+			{"value": int64(1), "nested": map[string]interface{}{"flag": "yes"}},
+			{"value": "b", "nested": map[string]interface{}{"flag": false}},
+		},
+		want: `syntax = "proto3";
+
+// Document was inferred from 3 documents.
+message Document {
+  message Nested {
+    optional bool flag = 1;
+  }
+
+  Nested nested = 1;
+  string value = 2;
+}
+`,
+		wantConflicts: []infer.Conflict{
+			{Path: "nested.flag", Types: map[string]int{"bool": 2, "string": 1}},
+			{Path: "value", Types: map[string]int{"int": 1, "string": 2}},
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := tt.opts.Infer(tt.input)
+			if err != nil {
+				t.Fatalf("Infer() returned error: %v\n", err)
+			}
+			if got.Proto != tt.want {
+				t.Errorf("Infer()\n<got>\n%v\n<want>\n%v\n", got.Proto, tt.want)
+			}
+			if diff := deep.Equal(got.Conflicts, tt.wantConflicts); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestInferMarshaledDocuments(t *testing.T) {
+	orders := []proto.Message{
+		&pbann.Order{
+			Name:       "orders/1",
+			State:      pbann.Order_OPEN,
+			CreateTime: timestamppb.New(time.Unix(1, 0)),
+			Tags:       []string{"a", "b"},
+			Total:      1.5,
+			Address:    &pbann.Address{Street: "street"},
+		},
+		&pbann.Order{
+			Name:  "orders/2",
+			State: pbann.Order_SHIPPED,
+			Stops: []*pbann.Address{{Street: "a", Instructions: "b"}},
+		},
+		&pbann.Order{
+			Name:  "orders/3",
+			State: pbann.Order_OPEN,
+			Notes: "notes",
+		},
+	}
+
+	var documents []map[string]interface{}
+	for _, m := range orders {
+		document, err := protofirestore.Marshal(m)
+		if err != nil {
+			t.Fatalf("Marshal() returned error: %v\n", err)
+		}
+		documents = append(documents, document)
+	}
+
+	got, err := infer.Options{Package: "annotated", Message: "Order"}.Infer(documents)
+	if err != nil {
+		t.Fatalf("Infer() returned error: %v\n", err)
+	}
+
+	want := `syntax = "proto3";
+
+package annotated;
+
+import "google/protobuf/timestamp.proto";
+
+// Order was inferred from 3 documents.
+message Order {
+  enum State {
+    STATE_UNSPECIFIED = 0;
+    OPEN = 1;
+    SHIPPED = 2;
+  }
+
+  message Address {
+    string street = 1;
+  }
+
+  message Stops {
+    string instructions = 1;
+    string street = 2;
+  }
+
+  Address address = 1;
+  google.protobuf.Timestamp create_time = 2;
+  string name = 3;
+  string notes = 4;
+  State state = 5;
+  repeated Stops stops = 6;
+  repeated string tags = 7;
+  double total = 8;
+}
+`
+	if got.Proto != want {
+		t.Errorf("Infer()\n<got>\n%v\n<want>\n%v\n", got.Proto, want)
+	}
+	if len(got.Conflicts) != 0 {
+		t.Errorf("Infer() reported conflicts %v", got.Conflicts)
+	}
+}
+
+func TestInferError(t *testing.T) {
+	_, err := infer.Infer([]map[string]interface{}{{"point": struct{}{}}})
+	if err == nil {
+		t.Error("Infer() did not return error")
+	}
+}
+
+func TestConflictString(t *testing.T) {
+	c := infer.Conflict{Path: "value", Types: map[string]int{"string": 2, "int": 1}}
+	want := "value: conflicting types int (1), string (2)"
+	if got := c.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,325 @@
+// Package shape describes the shape of the firestore documents the encoder of
+// the protofirestore package produces for a message, i.e. the keys of the
+// document, the types of their values and which keys are always present. It
+// is shared by the generators of schemas and validation code so that they
+// agree with the encoder.
+package shape
+
+import (
+	"fmt"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Kind is the kind of an encoded value.
+type Kind int
+
+const (
+	Bool Kind = iota
+	Int
+	Float
+	String
+	Bytes
+	Timestamp
+	Enum
+	Object
+	Map
+	List
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Bool:
+		return "bool"
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case String:
+		return "string"
+	case Bytes:
+		return "bytes"
+	case Timestamp:
+		return "timestamp"
+	case Enum:
+		return "enum"
+	case Object:
+		return "object"
+	case Map:
+		return "map"
+	case List:
+		return "list"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Type is the type of an encoded value.
+type Type struct {
+	Kind Kind
+
+	// Scalar is the proto kind of Int and Float values, which determines
+	// their range.
+	Scalar protoreflect.Kind
+
+	// Enum is the enum of Enum values, which are encoded as the names of the
+	// enum values. Numbers without a name are encoded as integers, but never
+	// occur in valid messages.
+	Enum protoreflect.EnumDescriptor
+
+	// Message is the message of Object values.
+	Message protoreflect.MessageDescriptor
+
+	// Key is the proto kind of the keys of Map values, which are encoded as
+	// strings.
+	Key protoreflect.Kind
+
+	// Elem is the type of the elements of List values and of the values of
+	// Map values.
+	Elem *Type
+
+	// Nullable reports whether elements of List values may be null, which is
+	// the encoding of empty strings, bytes and messages.
+	Nullable bool
+}
+
+// Field is a key of a document.
+type Field struct {
+	// Key is the key of the field in the document.
+	Key string
+
+	// Desc is the descriptor of the field.
+	Desc protoreflect.FieldDescriptor
+
+	// Type is the type of the value of the field.
+	Type Type
+
+	// Required reports whether the key is present in every document.
+	Required bool
+
+	// Oneof is the oneof containing the field, if any. At most one key of a
+	// oneof is present in a document.
+	Oneof protoreflect.OneofDescriptor
+}
+
+// Message is the shape of the documents of a message.
+type Message struct {
+	Desc   protoreflect.MessageDescriptor
+	Fields []Field
+
+	// Type is the type stored under protofirestore.TypeKey by
+	// EmitType, or empty if documents are encoded without their type. The
+	// key is optional, since embedded messages have no type.
+	Type string
+
+	// OneofCases are the keys holding the cases of the oneofs stored by
+	// EmitOneofCase, which are present in every document.
+	OneofCases []OneofCase
+}
+
+// OneofCase is the key holding the case of a oneof, which is the key of the
+// field of the oneof that is set, or null if none is.
+type OneofCase struct {
+	// Key is the key of the case in the document.
+	Key string
+
+	// Desc is the descriptor of the oneof.
+	Desc protoreflect.OneofDescriptor
+
+	// Cases are the keys of all fields of the oneof, including the fields
+	// which are never encoded.
+	Cases []string
+}
+
+// Of returns the shape of documents encoded from messages described by md
+// with the given options. Extension fields are not part of the shape.
+func Of(md protoreflect.MessageDescriptor, opts protofirestore.MarshalOptions) (*Message, error) {
+	if md.FullName().Parent() == genid.GoogleProtobuf_package {
+		return nil, fmt.Errorf("no support for well known type %v as firestore document", md.FullName())
+	}
+
+	m := &Message{Desc: md}
+	if opts.EmitType {
+		m.Type = opts.TypeName(md)
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if annotations.FieldOptionsOf(fd).GetSubcollection() != "" {
+			continue // stored as separate documents
+		}
+
+		t, err := TypeOf(fd)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.Kind == Object && t.Message.FullName() == genid.Empty_message_fullname {
+			continue // empty messages are never encoded
+		}
+
+		field := Field{
+			Key:      fd.JSONName(),
+			Desc:     fd,
+			Type:     t,
+			Required: IsRequired(fd, opts),
+		}
+
+		if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() {
+			field.Oneof = od
+		}
+
+		m.Fields = append(m.Fields, field)
+	}
+
+	if opts.EmitOneofCase {
+		for i := 0; i < md.Oneofs().Len(); i++ {
+			od := md.Oneofs().Get(i)
+			if od.IsSynthetic() {
+				continue
+			}
+
+			oneof := OneofCase{Key: protofirestore.OneofCaseKey(od), Desc: od}
+			if fd := fields.ByJSONName(oneof.Key); fd != nil {
+				return nil, fmt.Errorf("oneof %v: case key %q collides with field %v", od.FullName(), oneof.Key, fd.FullName())
+			}
+			for j := 0; j < od.Fields().Len(); j++ {
+				oneof.Cases = append(oneof.Cases, od.Fields().Get(j).JSONName())
+			}
+
+			m.OneofCases = append(m.OneofCases, oneof)
+		}
+	}
+
+	return m, nil
+}
+
+// TypeOf returns the type of the value of the field fd.
+func TypeOf(fd protoreflect.FieldDescriptor) (Type, error) {
+	switch {
+	case fd.IsList():
+		return listType(fd)
+	case fd.IsMap():
+		return mapType(fd)
+	}
+	return singularType(fd)
+}
+
+// Oneofs returns the keys of the fields of each oneof of the message.
+func (m *Message) Oneofs() [][]string {
+	var oneofs [][]string
+	index := make(map[protoreflect.FullName]int)
+
+	for _, f := range m.Fields {
+		if f.Oneof == nil {
+			continue
+		}
+		i, ok := index[f.Oneof.FullName()]
+		if !ok {
+			i = len(oneofs)
+			index[f.Oneof.FullName()] = i
+			oneofs = append(oneofs, nil)
+		}
+		oneofs[i] = append(oneofs[i], f.Key)
+	}
+
+	return oneofs
+}
+
+// IsRequired reports whether the field fd is encoded in every document,
+// which is the case for non-empty values of required fields and for fields
+// with firestore sensible defaults.
+func IsRequired(fd protoreflect.FieldDescriptor, opts protofirestore.MarshalOptions) bool {
+	if fd.IsList() || fd.IsMap() || fd.Message() != nil || fd.ContainingOneof() != nil {
+		return false
+	}
+
+	switch fd.Kind() {
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return false // empty values are omitted
+	case protoreflect.EnumKind:
+		if fd.Enum().FullName() == genid.NullValue_enum_fullname {
+			return false
+		}
+	}
+
+	if fd.Cardinality() == protoreflect.Required {
+		return true
+	}
+
+	return opts.EmitFirestoreSensibleDefaults && fd.Syntax() != protoreflect.Proto2
+}
+
+// singularType returns the type of a singular value of the field fd.
+func singularType(fd protoreflect.FieldDescriptor) (Type, error) {
+	switch kind := fd.Kind(); kind {
+	case protoreflect.BoolKind:
+		return Type{Kind: Bool}, nil
+
+	case protoreflect.StringKind:
+		return Type{Kind: String}, nil
+
+	case protoreflect.BytesKind:
+		return Type{Kind: Bytes}, nil
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return Type{Kind: Int, Scalar: kind}, nil
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return Type{Kind: Float, Scalar: kind}, nil
+
+	case protoreflect.EnumKind:
+		if fd.Enum().FullName() == genid.NullValue_enum_fullname {
+			return Type{}, fmt.Errorf("field %v: no support for %v", fd.FullName(), genid.NullValue_enum_fullname)
+		}
+		return Type{Kind: Enum, Enum: fd.Enum()}, nil
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		if md.FullName().Parent() == genid.GoogleProtobuf_package {
+			switch md.Name() {
+			case genid.Timestamp_message_name:
+				return Type{Kind: Timestamp}, nil
+			case genid.Empty_message_name:
+				return Type{Kind: Object, Message: md}, nil
+			}
+			return Type{}, fmt.Errorf("field %v: no support for %v well known type", fd.FullName(), md.FullName())
+		}
+		return Type{Kind: Object, Message: md}, nil
+	}
+
+	return Type{}, fmt.Errorf("field %v has unknown kind %v", fd.FullName(), fd.Kind())
+}
+
+// listType returns the type of the value of the repeated field fd.
+func listType(fd protoreflect.FieldDescriptor) (Type, error) {
+	elem, err := singularType(fd)
+	if err != nil {
+		return Type{}, err
+	}
+
+	nullable := false
+	switch elem.Kind {
+	case String, Bytes, Object:
+		nullable = true
+	}
+
+	return Type{Kind: List, Elem: &elem, Nullable: nullable}, nil
+}
+
+// mapType returns the type of the value of the map field fd. Map values
+// encoded to nil are omitted, so values are never null.
+func mapType(fd protoreflect.FieldDescriptor) (Type, error) {
+	elem, err := singularType(fd.MapValue())
+	if err != nil {
+		return Type{}, err
+	}
+
+	return Type{Kind: Map, Key: fd.MapKey().Kind(), Elem: &elem}, nil
+}
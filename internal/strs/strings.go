@@ -0,0 +1,26 @@
+// Package strs provides string manipulation functions shared by the
+// protofirestore packages.
+package strs
+
+import "strings"
+
+// JSONCamelCase converts a snake case identifier to the lower camel case
+// used for JSON names by protoc.
+func JSONCamelCase(s string) string {
+	var b strings.Builder
+	upper := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_':
+			upper = true
+		case upper && 'a' <= c && c <= 'z':
+			b.WriteByte(c - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteByte(c)
+			upper = false
+		}
+	}
+	return b.String()
+}
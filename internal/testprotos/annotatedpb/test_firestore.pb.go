@@ -0,0 +1,1042 @@
+// Code generated by protoc-gen-go-firestore. DO NOT EDIT.
+// source: internal/testprotos/annotatedpb/test.proto
+
+package annotatedpb
+
+import (
+	fmt "fmt"
+	protofirestore "github.com/daviddomkar/protofirestore"
+	docpath "github.com/daviddomkar/protofirestore/docpath"
+	firestoreimpl "github.com/daviddomkar/protofirestore/firestoreimpl"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	math "math"
+)
+
+// Firestore field paths of the fields of Counters documents.
+const (
+	Counters_Name_FieldPath     = "name"
+	Counters_Tags_FieldPath     = "tags"
+	Counters_Scores_FieldPath   = "scores"
+	Counters_Likes_FieldPath    = "likes"
+	Counters_Views_FieldPath    = "views"
+	Counters_Shares_FieldPath   = "shares"
+	Counters_Rating_FieldPath   = "rating"
+	Counters_Version_FieldPath  = "version"
+	Counters_Child_FieldPath    = "child"
+	Counters_Totals_FieldPath   = "totals"
+	Counters_Children_FieldPath = "children"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Counters) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.Name != "" {
+		value, err := firestoreimpl.EncodeString(x.Name, "annotated.Counters.name")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["name"] = value
+		}
+	}
+	if len(x.Tags) != 0 {
+		array := make([]interface{}, len(x.Tags))
+		for i, v := range x.Tags {
+			value, err := firestoreimpl.EncodeString(v, "annotated.Counters.tags")
+			if err != nil {
+				return nil, err
+			}
+			array[i] = value
+		}
+		document["tags"] = array
+	}
+	if len(x.Scores) != 0 {
+		array := make([]interface{}, len(x.Scores))
+		for i, v := range x.Scores {
+			array[i] = v
+		}
+		document["scores"] = array
+	}
+	if x.Likes != 0 || o.EmitFirestoreSensibleDefaults {
+		document["likes"] = x.Likes
+	}
+	if x.Views != 0 || o.EmitFirestoreSensibleDefaults {
+		document["views"] = x.Views
+	}
+	if x.Shares != 0 || o.EmitFirestoreSensibleDefaults {
+		document["shares"] = x.Shares
+	}
+	if x.Rating != 0 || math.Signbit(float64(x.Rating)) || o.EmitFirestoreSensibleDefaults {
+		document["rating"] = x.Rating
+	}
+	if x.Version != 0 || o.EmitFirestoreSensibleDefaults {
+		document["version"] = x.Version
+	}
+	if x.Child != nil {
+		nested, err := o.Marshal(x.Child)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested) != 0 {
+			document["child"] = nested
+		}
+	}
+	if len(x.Totals) != 0 {
+		object := make(map[string]interface{}, len(x.Totals))
+		for k, v := range x.Totals {
+			object[k] = v
+		}
+		if len(object) != 0 {
+			document["totals"] = object
+		}
+	}
+	if len(x.Children) != 0 {
+		array := make([]interface{}, len(x.Children))
+		for i, v := range x.Children {
+			nested, err := o.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			if len(nested) != 0 {
+				array[i] = nested
+			}
+		}
+		document["children"] = array
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Counters) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "name":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.Counters.name")
+			if err != nil {
+				return err
+			}
+			x.Name = v
+		case "tags":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "annotated.Counters.tags")
+			if err != nil {
+				return err
+			}
+			x.Tags = make([]string, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeString(item, "annotated.Counters.tags")
+				if err != nil {
+					return err
+				}
+				x.Tags[i] = v
+			}
+		case "scores":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "annotated.Counters.scores")
+			if err != nil {
+				return err
+			}
+			x.Scores = make([]int64, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeInt64(item, "annotated.Counters.scores")
+				if err != nil {
+					return err
+				}
+				x.Scores[i] = v
+			}
+		case "likes":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt32(value, "annotated.Counters.likes")
+			if err != nil {
+				return err
+			}
+			x.Likes = v
+		case "views":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt64(value, "annotated.Counters.views")
+			if err != nil {
+				return err
+			}
+			x.Views = v
+		case "shares":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeUint64(value, "annotated.Counters.shares")
+			if err != nil {
+				return err
+			}
+			x.Shares = v
+		case "rating":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeFloat64(value, "annotated.Counters.rating")
+			if err != nil {
+				return err
+			}
+			x.Rating = v
+		case "version":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt64(value, "annotated.Counters.version")
+			if err != nil {
+				return err
+			}
+			x.Version = v
+		case "child":
+			if value == nil {
+				continue
+			}
+			nested, err := firestoreimpl.DecodeObject(value, "annotated.Counters.child")
+			if err != nil {
+				return err
+			}
+			v := new(Counters)
+			if err := o.Unmarshal(nested, v); err != nil {
+				return err
+			}
+			x.Child = v
+		case "totals":
+			if value == nil {
+				continue
+			}
+			object, err := firestoreimpl.DecodeObject(value, "annotated.Counters.totals")
+			if err != nil {
+				return err
+			}
+			x.Totals = make(map[string]int64, len(object))
+			for k, item := range object {
+				mk, err := firestoreimpl.DecodeMapKey(k, protoreflect.StringKind, "annotated.Counters.totals")
+				if err != nil {
+					return err
+				}
+				if item == nil {
+					x.Totals[mk.String()] = 0
+					continue
+				}
+				v, err := firestoreimpl.DecodeInt64(item, "annotated.Counters.TotalsEntry.value")
+				if err != nil {
+					return err
+				}
+				x.Totals[mk.String()] = v
+			}
+		case "children":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "annotated.Counters.children")
+			if err != nil {
+				return err
+			}
+			x.Children = make([]*Counters, len(array))
+			for i, item := range array {
+				if item == nil {
+					x.Children[i] = new(Counters)
+					continue
+				}
+				nested, err := firestoreimpl.DecodeObject(item, "annotated.Counters.children")
+				if err != nil {
+					return err
+				}
+				v := new(Counters)
+				if err := o.Unmarshal(nested, v); err != nil {
+					return err
+				}
+				x.Children[i] = v
+			}
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "annotated.Counters")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Order documents.
+const (
+	Order_Name_FieldPath       = "name"
+	Order_State_FieldPath      = "state"
+	Order_CreateTime_FieldPath = "createTime"
+	Order_Tags_FieldPath       = "tags"
+	Order_Total_FieldPath      = "total"
+	Order_Notes_FieldPath      = "notes"
+	Order_Address_FieldPath    = "address"
+	Order_Stops_FieldPath      = "stops"
+)
+
+// Order_PathPattern is the path pattern of Order documents.
+const Order_PathPattern = "users/{user}/orders/{order}"
+
+// Order_CollectionID is the ID of the collection of Order documents.
+const Order_CollectionID = "orders"
+
+var _Order_pathPattern = docpath.MustParse(Order_PathPattern)
+
+// OrderPath returns the path of the Order document with the given
+// document IDs.
+func OrderPath(user, order string) (string, error) {
+	return _Order_pathPattern.Expand(user, order)
+}
+
+// ParseOrderPath returns the document IDs user, order of the Order
+// document with the given path.
+func ParseOrderPath(path string) (string, string, error) {
+	ids, ok := _Order_pathPattern.Match(path)
+	if !ok {
+		return "", "", fmt.Errorf("%q does not match %v", path, Order_PathPattern)
+	}
+	return ids[0], ids[1], nil
+}
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Order) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.Name != "" {
+		value, err := firestoreimpl.EncodeString(x.Name, "annotated.Order.name")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["name"] = value
+		}
+	}
+	if x.State != 0 || o.EmitFirestoreSensibleDefaults {
+		document["state"] = firestoreimpl.EncodeEnum(x.State.Number(), x.State.Descriptor())
+	}
+	if x.CreateTime != nil {
+		value, err := firestoreimpl.EncodeTimestamp(x.CreateTime.GetSeconds(), x.CreateTime.GetNanos())
+		if err != nil {
+			return nil, err
+		}
+		document["createTime"] = value
+	}
+	if len(x.Tags) != 0 {
+		array := make([]interface{}, len(x.Tags))
+		for i, v := range x.Tags {
+			value, err := firestoreimpl.EncodeString(v, "annotated.Order.tags")
+			if err != nil {
+				return nil, err
+			}
+			array[i] = value
+		}
+		document["tags"] = array
+	}
+	if x.Total != 0 || math.Signbit(float64(x.Total)) || o.EmitFirestoreSensibleDefaults {
+		document["total"] = x.Total
+	}
+	if x.Notes != "" {
+		value, err := firestoreimpl.EncodeString(x.Notes, "annotated.Order.notes")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["notes"] = value
+		}
+	}
+	if x.Address != nil {
+		nested, err := o.Marshal(x.Address)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested) != 0 {
+			document["address"] = nested
+		}
+	}
+	if len(x.Stops) != 0 {
+		array := make([]interface{}, len(x.Stops))
+		for i, v := range x.Stops {
+			nested, err := o.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			if len(nested) != 0 {
+				array[i] = nested
+			}
+		}
+		document["stops"] = array
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Order) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "name":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.Order.name")
+			if err != nil {
+				return err
+			}
+			x.Name = v
+		case "state":
+			if value == nil {
+				continue
+			}
+			n, err := firestoreimpl.DecodeEnum(value, Order_State(0).Descriptor(), "annotated.Order.state")
+			if err != nil {
+				return err
+			}
+			x.State = Order_State(n)
+		case "createTime":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeTimestamp(value, "annotated.Order.create_time")
+			if err != nil {
+				return err
+			}
+			x.CreateTime = v
+		case "tags":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "annotated.Order.tags")
+			if err != nil {
+				return err
+			}
+			x.Tags = make([]string, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeString(item, "annotated.Order.tags")
+				if err != nil {
+					return err
+				}
+				x.Tags[i] = v
+			}
+		case "total":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeFloat64(value, "annotated.Order.total")
+			if err != nil {
+				return err
+			}
+			x.Total = v
+		case "notes":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.Order.notes")
+			if err != nil {
+				return err
+			}
+			x.Notes = v
+		case "address":
+			if value == nil {
+				continue
+			}
+			nested, err := firestoreimpl.DecodeObject(value, "annotated.Order.address")
+			if err != nil {
+				return err
+			}
+			v := new(Address)
+			if err := o.Unmarshal(nested, v); err != nil {
+				return err
+			}
+			x.Address = v
+		case "stops":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "annotated.Order.stops")
+			if err != nil {
+				return err
+			}
+			x.Stops = make([]*Address, len(array))
+			for i, item := range array {
+				if item == nil {
+					x.Stops[i] = new(Address)
+					continue
+				}
+				nested, err := firestoreimpl.DecodeObject(item, "annotated.Order.stops")
+				if err != nil {
+					return err
+				}
+				v := new(Address)
+				if err := o.Unmarshal(nested, v); err != nil {
+					return err
+				}
+				x.Stops[i] = v
+			}
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "annotated.Order")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Address documents.
+const (
+	Address_Street_FieldPath       = "street"
+	Address_Instructions_FieldPath = "instructions"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Address) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.Street != "" {
+		value, err := firestoreimpl.EncodeString(x.Street, "annotated.Address.street")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["street"] = value
+		}
+	}
+	if x.Instructions != "" {
+		value, err := firestoreimpl.EncodeString(x.Instructions, "annotated.Address.instructions")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["instructions"] = value
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Address) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "street":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.Address.street")
+			if err != nil {
+				return err
+			}
+			x.Street = v
+		case "instructions":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.Address.instructions")
+			if err != nil {
+				return err
+			}
+			x.Instructions = v
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "annotated.Address")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of User documents.
+const (
+	User_Name_FieldPath    = "name"
+	User_Avatar_FieldPath  = "avatar"
+	User_Address_FieldPath = "home"
+)
+
+// User_PathPattern is the path pattern of User documents.
+const User_PathPattern = "users/{user}"
+
+// User_CollectionID is the ID of the collection of User documents.
+const User_CollectionID = "users"
+
+var _User_pathPattern = docpath.MustParse(User_PathPattern)
+
+// UserPath returns the path of the User document with the given
+// document IDs.
+func UserPath(user string) (string, error) {
+	return _User_pathPattern.Expand(user)
+}
+
+// ParseUserPath returns the document IDs user of the User
+// document with the given path.
+func ParseUserPath(path string) (string, error) {
+	ids, ok := _User_pathPattern.Match(path)
+	if !ok {
+		return "", fmt.Errorf("%q does not match %v", path, User_PathPattern)
+	}
+	return ids[0], nil
+}
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *User) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.Name != "" {
+		value, err := firestoreimpl.EncodeString(x.Name, "annotated.User.name")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["name"] = value
+		}
+	}
+	if len(x.Avatar) != 0 {
+		value := firestoreimpl.EncodeBytes(x.Avatar)
+		if value != nil {
+			document["avatar"] = value
+		}
+	}
+	if x.Address != nil {
+		nested, err := o.Marshal(x.Address)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested) != 0 {
+			document["home"] = nested
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *User) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "name":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.User.name")
+			if err != nil {
+				return err
+			}
+			x.Name = v
+		case "avatar":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeBytes(value, "annotated.User.avatar")
+			if err != nil {
+				return err
+			}
+			x.Avatar = v
+		case "home":
+			if value == nil {
+				continue
+			}
+			nested, err := firestoreimpl.DecodeObject(value, "annotated.User.address")
+			if err != nil {
+				return err
+			}
+			v := new(Address)
+			if err := o.Unmarshal(nested, v); err != nil {
+				return err
+			}
+			x.Address = v
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "annotated.User")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Article documents.
+const (
+	Article_Title_FieldPath = "title"
+	Article_Etag_FieldPath  = "etag"
+)
+
+// Article_PathPattern is the path pattern of Article documents.
+const Article_PathPattern = "articles/{article}"
+
+// Article_CollectionID is the ID of the collection of Article documents.
+const Article_CollectionID = "articles"
+
+var _Article_pathPattern = docpath.MustParse(Article_PathPattern)
+
+// ArticlePath returns the path of the Article document with the given
+// document IDs.
+func ArticlePath(article string) (string, error) {
+	return _Article_pathPattern.Expand(article)
+}
+
+// ParseArticlePath returns the document IDs article of the Article
+// document with the given path.
+func ParseArticlePath(path string) (string, error) {
+	ids, ok := _Article_pathPattern.Match(path)
+	if !ok {
+		return "", fmt.Errorf("%q does not match %v", path, Article_PathPattern)
+	}
+	return ids[0], nil
+}
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Article) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.Title != "" {
+		value, err := firestoreimpl.EncodeString(x.Title, "annotated.Article.title")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["title"] = value
+		}
+	}
+	if x.Etag != "" {
+		value, err := firestoreimpl.EncodeString(x.Etag, "annotated.Article.etag")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["etag"] = value
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Article) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "title":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.Article.title")
+			if err != nil {
+				return err
+			}
+			x.Title = v
+		case "etag":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.Article.etag")
+			if err != nil {
+				return err
+			}
+			x.Etag = v
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "annotated.Article")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Draft documents.
+const (
+	Draft_Title_FieldPath = "title"
+	Draft_Etag_FieldPath  = "etag"
+)
+
+// Draft_PathPattern is the path pattern of Draft documents.
+const Draft_PathPattern = "drafts/{draft}"
+
+// Draft_CollectionID is the ID of the collection of Draft documents.
+const Draft_CollectionID = "drafts"
+
+var _Draft_pathPattern = docpath.MustParse(Draft_PathPattern)
+
+// DraftPath returns the path of the Draft document with the given
+// document IDs.
+func DraftPath(draft string) (string, error) {
+	return _Draft_pathPattern.Expand(draft)
+}
+
+// ParseDraftPath returns the document IDs draft of the Draft
+// document with the given path.
+func ParseDraftPath(path string) (string, error) {
+	ids, ok := _Draft_pathPattern.Match(path)
+	if !ok {
+		return "", fmt.Errorf("%q does not match %v", path, Draft_PathPattern)
+	}
+	return ids[0], nil
+}
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Draft) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.Title != "" {
+		value, err := firestoreimpl.EncodeString(x.Title, "annotated.Draft.title")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["title"] = value
+		}
+	}
+	if x.Etag != "" {
+		value, err := firestoreimpl.EncodeString(x.Etag, "annotated.Draft.etag")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["etag"] = value
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Draft) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "title":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.Draft.title")
+			if err != nil {
+				return err
+			}
+			x.Title = v
+		case "etag":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.Draft.etag")
+			if err != nil {
+				return err
+			}
+			x.Etag = v
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "annotated.Draft")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Book documents.
+const (
+	Book_Title_FieldPath      = "title"
+	Book_DeleteTime_FieldPath = "deleteTime"
+	Book_PurgeTime_FieldPath  = "purgeTime"
+)
+
+// Book_PathPattern is the path pattern of Book documents.
+const Book_PathPattern = "books/{book}"
+
+// Book_CollectionID is the ID of the collection of Book documents.
+const Book_CollectionID = "books"
+
+var _Book_pathPattern = docpath.MustParse(Book_PathPattern)
+
+// BookPath returns the path of the Book document with the given
+// document IDs.
+func BookPath(book string) (string, error) {
+	return _Book_pathPattern.Expand(book)
+}
+
+// ParseBookPath returns the document IDs book of the Book
+// document with the given path.
+func ParseBookPath(path string) (string, error) {
+	ids, ok := _Book_pathPattern.Match(path)
+	if !ok {
+		return "", fmt.Errorf("%q does not match %v", path, Book_PathPattern)
+	}
+	return ids[0], nil
+}
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Book) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.Title != "" {
+		value, err := firestoreimpl.EncodeString(x.Title, "annotated.Book.title")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["title"] = value
+		}
+	}
+	if x.DeleteTime != nil {
+		value, err := firestoreimpl.EncodeTimestamp(x.DeleteTime.GetSeconds(), x.DeleteTime.GetNanos())
+		if err != nil {
+			return nil, err
+		}
+		document["deleteTime"] = value
+	}
+	if x.PurgeTime != nil {
+		value, err := firestoreimpl.EncodeTimestamp(x.PurgeTime.GetSeconds(), x.PurgeTime.GetNanos())
+		if err != nil {
+			return nil, err
+		}
+		document["purgeTime"] = value
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Book) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "title":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "annotated.Book.title")
+			if err != nil {
+				return err
+			}
+			x.Title = v
+		case "deleteTime":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeTimestamp(value, "annotated.Book.delete_time")
+			if err != nil {
+				return err
+			}
+			x.DeleteTime = v
+		case "purgeTime":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeTimestamp(value, "annotated.Book.purge_time")
+			if err != nil {
+				return err
+			}
+			x.PurgeTime = v
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "annotated.Book")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Comment documents.
+const (
+	Comment_Text_FieldPath = "text"
+)
+
+// Firestore field paths of the fields of Post documents.
+const (
+	Post_Title_FieldPath = "title"
+)
+
+// Post_PathPattern is the path pattern of Post documents.
+const Post_PathPattern = "posts/{post}"
+
+// Post_CollectionID is the ID of the collection of Post documents.
+const Post_CollectionID = "posts"
+
+var _Post_pathPattern = docpath.MustParse(Post_PathPattern)
+
+// PostPath returns the path of the Post document with the given
+// document IDs.
+func PostPath(post string) (string, error) {
+	return _Post_pathPattern.Expand(post)
+}
+
+// ParsePostPath returns the document IDs post of the Post
+// document with the given path.
+func ParsePostPath(path string) (string, error) {
+	ids, ok := _Post_pathPattern.Match(path)
+	if !ok {
+		return "", fmt.Errorf("%q does not match %v", path, Post_PathPattern)
+	}
+	return ids[0], nil
+}
+
+// Firestore field paths of the fields of Thread documents.
+const (
+	Thread_Title_FieldPath      = "title"
+	Thread_DeleteTime_FieldPath = "deleteTime"
+)
+
+// Thread_PathPattern is the path pattern of Thread documents.
+const Thread_PathPattern = "threads/{thread}"
+
+// Thread_CollectionID is the ID of the collection of Thread documents.
+const Thread_CollectionID = "threads"
+
+var _Thread_pathPattern = docpath.MustParse(Thread_PathPattern)
+
+// ThreadPath returns the path of the Thread document with the given
+// document IDs.
+func ThreadPath(thread string) (string, error) {
+	return _Thread_pathPattern.Expand(thread)
+}
+
+// ParseThreadPath returns the document IDs thread of the Thread
+// document with the given path.
+func ParseThreadPath(path string) (string, error) {
+	ids, ok := _Thread_pathPattern.Match(path)
+	if !ok {
+		return "", fmt.Errorf("%q does not match %v", path, Thread_PathPattern)
+	}
+	return ids[0], nil
+}
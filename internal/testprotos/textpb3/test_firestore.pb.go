@@ -0,0 +1,1274 @@
+// Code generated by protoc-gen-go-firestore. DO NOT EDIT.
+// source: internal/testprotos/textpb3/test.proto
+
+package textpb3
+
+import (
+	protofirestore "github.com/daviddomkar/protofirestore"
+	firestoreimpl "github.com/daviddomkar/protofirestore/firestoreimpl"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	math "math"
+	strconv "strconv"
+)
+
+// Firestore field paths of the fields of Scalars documents.
+const (
+	Scalars_SBool_FieldPath     = "sBool"
+	Scalars_SInt32_FieldPath    = "sInt32"
+	Scalars_SInt64_FieldPath    = "sInt64"
+	Scalars_SUint32_FieldPath   = "sUint32"
+	Scalars_SUint64_FieldPath   = "sUint64"
+	Scalars_SSint32_FieldPath   = "sSint32"
+	Scalars_SSint64_FieldPath   = "sSint64"
+	Scalars_SFixed32_FieldPath  = "sFixed32"
+	Scalars_SFixed64_FieldPath  = "sFixed64"
+	Scalars_SSfixed32_FieldPath = "sSfixed32"
+	Scalars_SSfixed64_FieldPath = "sSfixed64"
+	Scalars_SFloat_FieldPath    = "sFloat"
+	Scalars_SDouble_FieldPath   = "sDouble"
+	Scalars_SBytes_FieldPath    = "sBytes"
+	Scalars_SString_FieldPath   = "sString"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Scalars) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.SBool || o.EmitFirestoreSensibleDefaults {
+		document["sBool"] = x.SBool
+	}
+	if x.SInt32 != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sInt32"] = x.SInt32
+	}
+	if x.SInt64 != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sInt64"] = x.SInt64
+	}
+	if x.SUint32 != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sUint32"] = x.SUint32
+	}
+	if x.SUint64 != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sUint64"] = x.SUint64
+	}
+	if x.SSint32 != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sSint32"] = x.SSint32
+	}
+	if x.SSint64 != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sSint64"] = x.SSint64
+	}
+	if x.SFixed32 != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sFixed32"] = x.SFixed32
+	}
+	if x.SFixed64 != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sFixed64"] = x.SFixed64
+	}
+	if x.SSfixed32 != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sSfixed32"] = x.SSfixed32
+	}
+	if x.SSfixed64 != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sSfixed64"] = x.SSfixed64
+	}
+	if x.SFloat != 0 || math.Signbit(float64(x.SFloat)) || o.EmitFirestoreSensibleDefaults {
+		document["sFloat"] = x.SFloat
+	}
+	if x.SDouble != 0 || math.Signbit(float64(x.SDouble)) || o.EmitFirestoreSensibleDefaults {
+		document["sDouble"] = x.SDouble
+	}
+	if len(x.SBytes) != 0 {
+		value := firestoreimpl.EncodeBytes(x.SBytes)
+		if value != nil {
+			document["sBytes"] = value
+		}
+	}
+	if x.SString != "" {
+		value, err := firestoreimpl.EncodeString(x.SString, "pb3.Scalars.s_string")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["sString"] = value
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Scalars) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "sBool":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeBool(value, "pb3.Scalars.s_bool")
+			if err != nil {
+				return err
+			}
+			x.SBool = v
+		case "sInt32":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt32(value, "pb3.Scalars.s_int32")
+			if err != nil {
+				return err
+			}
+			x.SInt32 = v
+		case "sInt64":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt64(value, "pb3.Scalars.s_int64")
+			if err != nil {
+				return err
+			}
+			x.SInt64 = v
+		case "sUint32":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeUint32(value, "pb3.Scalars.s_uint32")
+			if err != nil {
+				return err
+			}
+			x.SUint32 = v
+		case "sUint64":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeUint64(value, "pb3.Scalars.s_uint64")
+			if err != nil {
+				return err
+			}
+			x.SUint64 = v
+		case "sSint32":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt32(value, "pb3.Scalars.s_sint32")
+			if err != nil {
+				return err
+			}
+			x.SSint32 = v
+		case "sSint64":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt64(value, "pb3.Scalars.s_sint64")
+			if err != nil {
+				return err
+			}
+			x.SSint64 = v
+		case "sFixed32":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeUint32(value, "pb3.Scalars.s_fixed32")
+			if err != nil {
+				return err
+			}
+			x.SFixed32 = v
+		case "sFixed64":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeUint64(value, "pb3.Scalars.s_fixed64")
+			if err != nil {
+				return err
+			}
+			x.SFixed64 = v
+		case "sSfixed32":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt32(value, "pb3.Scalars.s_sfixed32")
+			if err != nil {
+				return err
+			}
+			x.SSfixed32 = v
+		case "sSfixed64":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt64(value, "pb3.Scalars.s_sfixed64")
+			if err != nil {
+				return err
+			}
+			x.SSfixed64 = v
+		case "sFloat":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeFloat32(value, "pb3.Scalars.s_float")
+			if err != nil {
+				return err
+			}
+			x.SFloat = v
+		case "sDouble":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeFloat64(value, "pb3.Scalars.s_double")
+			if err != nil {
+				return err
+			}
+			x.SDouble = v
+		case "sBytes":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeBytes(value, "pb3.Scalars.s_bytes")
+			if err != nil {
+				return err
+			}
+			x.SBytes = v
+		case "sString":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "pb3.Scalars.s_string")
+			if err != nil {
+				return err
+			}
+			x.SString = v
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "pb3.Scalars")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Repeats documents.
+const (
+	Repeats_RptBool_FieldPath   = "rptBool"
+	Repeats_RptInt32_FieldPath  = "rptInt32"
+	Repeats_RptInt64_FieldPath  = "rptInt64"
+	Repeats_RptUint32_FieldPath = "rptUint32"
+	Repeats_RptUint64_FieldPath = "rptUint64"
+	Repeats_RptFloat_FieldPath  = "rptFloat"
+	Repeats_RptDouble_FieldPath = "rptDouble"
+	Repeats_RptString_FieldPath = "rptString"
+	Repeats_RptBytes_FieldPath  = "rptBytes"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Repeats) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if len(x.RptBool) != 0 {
+		array := make([]interface{}, len(x.RptBool))
+		for i, v := range x.RptBool {
+			array[i] = v
+		}
+		document["rptBool"] = array
+	}
+	if len(x.RptInt32) != 0 {
+		array := make([]interface{}, len(x.RptInt32))
+		for i, v := range x.RptInt32 {
+			array[i] = v
+		}
+		document["rptInt32"] = array
+	}
+	if len(x.RptInt64) != 0 {
+		array := make([]interface{}, len(x.RptInt64))
+		for i, v := range x.RptInt64 {
+			array[i] = v
+		}
+		document["rptInt64"] = array
+	}
+	if len(x.RptUint32) != 0 {
+		array := make([]interface{}, len(x.RptUint32))
+		for i, v := range x.RptUint32 {
+			array[i] = v
+		}
+		document["rptUint32"] = array
+	}
+	if len(x.RptUint64) != 0 {
+		array := make([]interface{}, len(x.RptUint64))
+		for i, v := range x.RptUint64 {
+			array[i] = v
+		}
+		document["rptUint64"] = array
+	}
+	if len(x.RptFloat) != 0 {
+		array := make([]interface{}, len(x.RptFloat))
+		for i, v := range x.RptFloat {
+			array[i] = v
+		}
+		document["rptFloat"] = array
+	}
+	if len(x.RptDouble) != 0 {
+		array := make([]interface{}, len(x.RptDouble))
+		for i, v := range x.RptDouble {
+			array[i] = v
+		}
+		document["rptDouble"] = array
+	}
+	if len(x.RptString) != 0 {
+		array := make([]interface{}, len(x.RptString))
+		for i, v := range x.RptString {
+			value, err := firestoreimpl.EncodeString(v, "pb3.Repeats.rpt_string")
+			if err != nil {
+				return nil, err
+			}
+			array[i] = value
+		}
+		document["rptString"] = array
+	}
+	if len(x.RptBytes) != 0 {
+		array := make([]interface{}, len(x.RptBytes))
+		for i, v := range x.RptBytes {
+			value := firestoreimpl.EncodeBytes(v)
+			array[i] = value
+		}
+		document["rptBytes"] = array
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Repeats) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "rptBool":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "pb3.Repeats.rpt_bool")
+			if err != nil {
+				return err
+			}
+			x.RptBool = make([]bool, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeBool(item, "pb3.Repeats.rpt_bool")
+				if err != nil {
+					return err
+				}
+				x.RptBool[i] = v
+			}
+		case "rptInt32":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "pb3.Repeats.rpt_int32")
+			if err != nil {
+				return err
+			}
+			x.RptInt32 = make([]int32, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeInt32(item, "pb3.Repeats.rpt_int32")
+				if err != nil {
+					return err
+				}
+				x.RptInt32[i] = v
+			}
+		case "rptInt64":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "pb3.Repeats.rpt_int64")
+			if err != nil {
+				return err
+			}
+			x.RptInt64 = make([]int64, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeInt64(item, "pb3.Repeats.rpt_int64")
+				if err != nil {
+					return err
+				}
+				x.RptInt64[i] = v
+			}
+		case "rptUint32":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "pb3.Repeats.rpt_uint32")
+			if err != nil {
+				return err
+			}
+			x.RptUint32 = make([]uint32, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeUint32(item, "pb3.Repeats.rpt_uint32")
+				if err != nil {
+					return err
+				}
+				x.RptUint32[i] = v
+			}
+		case "rptUint64":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "pb3.Repeats.rpt_uint64")
+			if err != nil {
+				return err
+			}
+			x.RptUint64 = make([]uint64, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeUint64(item, "pb3.Repeats.rpt_uint64")
+				if err != nil {
+					return err
+				}
+				x.RptUint64[i] = v
+			}
+		case "rptFloat":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "pb3.Repeats.rpt_float")
+			if err != nil {
+				return err
+			}
+			x.RptFloat = make([]float32, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeFloat32(item, "pb3.Repeats.rpt_float")
+				if err != nil {
+					return err
+				}
+				x.RptFloat[i] = v
+			}
+		case "rptDouble":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "pb3.Repeats.rpt_double")
+			if err != nil {
+				return err
+			}
+			x.RptDouble = make([]float64, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeFloat64(item, "pb3.Repeats.rpt_double")
+				if err != nil {
+					return err
+				}
+				x.RptDouble[i] = v
+			}
+		case "rptString":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "pb3.Repeats.rpt_string")
+			if err != nil {
+				return err
+			}
+			x.RptString = make([]string, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeString(item, "pb3.Repeats.rpt_string")
+				if err != nil {
+					return err
+				}
+				x.RptString[i] = v
+			}
+		case "rptBytes":
+			if value == nil {
+				continue
+			}
+			array, err := firestoreimpl.DecodeList(value, "pb3.Repeats.rpt_bytes")
+			if err != nil {
+				return err
+			}
+			x.RptBytes = make([][]byte, len(array))
+			for i, item := range array {
+				if item == nil {
+					continue
+				}
+				v, err := firestoreimpl.DecodeBytes(item, "pb3.Repeats.rpt_bytes")
+				if err != nil {
+					return err
+				}
+				x.RptBytes[i] = v
+			}
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "pb3.Repeats")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Proto3Optional documents.
+const (
+	Proto3Optional_OptBool_FieldPath    = "optBool"
+	Proto3Optional_OptInt32_FieldPath   = "optInt32"
+	Proto3Optional_OptInt64_FieldPath   = "optInt64"
+	Proto3Optional_OptUint32_FieldPath  = "optUint32"
+	Proto3Optional_OptUint64_FieldPath  = "optUint64"
+	Proto3Optional_OptFloat_FieldPath   = "optFloat"
+	Proto3Optional_OptDouble_FieldPath  = "optDouble"
+	Proto3Optional_OptString_FieldPath  = "optString"
+	Proto3Optional_OptBytes_FieldPath   = "optBytes"
+	Proto3Optional_OptEnum_FieldPath    = "optEnum"
+	Proto3Optional_OptMessage_FieldPath = "optMessage"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Proto3Optional) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.OptBool != nil {
+		document["optBool"] = (*x.OptBool)
+	}
+	if x.OptInt32 != nil {
+		document["optInt32"] = (*x.OptInt32)
+	}
+	if x.OptInt64 != nil {
+		document["optInt64"] = (*x.OptInt64)
+	}
+	if x.OptUint32 != nil {
+		document["optUint32"] = (*x.OptUint32)
+	}
+	if x.OptUint64 != nil {
+		document["optUint64"] = (*x.OptUint64)
+	}
+	if x.OptFloat != nil {
+		document["optFloat"] = (*x.OptFloat)
+	}
+	if x.OptDouble != nil {
+		document["optDouble"] = (*x.OptDouble)
+	}
+	if x.OptString != nil {
+		value, err := firestoreimpl.EncodeString((*x.OptString), "pb3.Proto3Optional.opt_string")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["optString"] = value
+		}
+	}
+	if x.OptBytes != nil {
+		value := firestoreimpl.EncodeBytes(x.OptBytes)
+		if value != nil {
+			document["optBytes"] = value
+		}
+	}
+	if x.OptEnum != nil {
+		document["optEnum"] = firestoreimpl.EncodeEnum((*x.OptEnum).Number(), (*x.OptEnum).Descriptor())
+	}
+	if x.OptMessage != nil {
+		nested, err := o.Marshal(x.OptMessage)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested) != 0 || o.EmitFirestoreSensibleDefaults {
+			document["optMessage"] = nested
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Proto3Optional) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "optBool":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeBool(value, "pb3.Proto3Optional.opt_bool")
+			if err != nil {
+				return err
+			}
+			p := v
+			x.OptBool = &p
+		case "optInt32":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt32(value, "pb3.Proto3Optional.opt_int32")
+			if err != nil {
+				return err
+			}
+			p := v
+			x.OptInt32 = &p
+		case "optInt64":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeInt64(value, "pb3.Proto3Optional.opt_int64")
+			if err != nil {
+				return err
+			}
+			p := v
+			x.OptInt64 = &p
+		case "optUint32":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeUint32(value, "pb3.Proto3Optional.opt_uint32")
+			if err != nil {
+				return err
+			}
+			p := v
+			x.OptUint32 = &p
+		case "optUint64":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeUint64(value, "pb3.Proto3Optional.opt_uint64")
+			if err != nil {
+				return err
+			}
+			p := v
+			x.OptUint64 = &p
+		case "optFloat":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeFloat32(value, "pb3.Proto3Optional.opt_float")
+			if err != nil {
+				return err
+			}
+			p := v
+			x.OptFloat = &p
+		case "optDouble":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeFloat64(value, "pb3.Proto3Optional.opt_double")
+			if err != nil {
+				return err
+			}
+			p := v
+			x.OptDouble = &p
+		case "optString":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "pb3.Proto3Optional.opt_string")
+			if err != nil {
+				return err
+			}
+			p := v
+			x.OptString = &p
+		case "optBytes":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeBytes(value, "pb3.Proto3Optional.opt_bytes")
+			if err != nil {
+				return err
+			}
+			x.OptBytes = v
+		case "optEnum":
+			if value == nil {
+				continue
+			}
+			n, err := firestoreimpl.DecodeEnum(value, Enum(0).Descriptor(), "pb3.Proto3Optional.opt_enum")
+			if err != nil {
+				return err
+			}
+			p := Enum(n)
+			x.OptEnum = &p
+		case "optMessage":
+			if value == nil {
+				continue
+			}
+			nested, err := firestoreimpl.DecodeObject(value, "pb3.Proto3Optional.opt_message")
+			if err != nil {
+				return err
+			}
+			v := new(Nested)
+			if err := o.Unmarshal(nested, v); err != nil {
+				return err
+			}
+			x.OptMessage = v
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "pb3.Proto3Optional")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Enums documents.
+const (
+	Enums_SEnum_FieldPath       = "sEnum"
+	Enums_SNestedEnum_FieldPath = "sNestedEnum"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Enums) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.SEnum != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sEnum"] = firestoreimpl.EncodeEnum(x.SEnum.Number(), x.SEnum.Descriptor())
+	}
+	if x.SNestedEnum != 0 || o.EmitFirestoreSensibleDefaults {
+		document["sNestedEnum"] = firestoreimpl.EncodeEnum(x.SNestedEnum.Number(), x.SNestedEnum.Descriptor())
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Enums) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "sEnum":
+			if value == nil {
+				continue
+			}
+			n, err := firestoreimpl.DecodeEnum(value, Enum(0).Descriptor(), "pb3.Enums.s_enum")
+			if err != nil {
+				return err
+			}
+			x.SEnum = Enum(n)
+		case "sNestedEnum":
+			if value == nil {
+				continue
+			}
+			n, err := firestoreimpl.DecodeEnum(value, Enums_NestedEnum(0).Descriptor(), "pb3.Enums.s_nested_enum")
+			if err != nil {
+				return err
+			}
+			x.SNestedEnum = Enums_NestedEnum(n)
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "pb3.Enums")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Nests documents.
+const (
+	Nests_SNested_FieldPath = "sNested"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Nests) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.SNested != nil {
+		nested, err := o.Marshal(x.SNested)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested) != 0 {
+			document["sNested"] = nested
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Nests) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "sNested":
+			if value == nil {
+				continue
+			}
+			nested, err := firestoreimpl.DecodeObject(value, "pb3.Nests.s_nested")
+			if err != nil {
+				return err
+			}
+			v := new(Nested)
+			if err := o.Unmarshal(nested, v); err != nil {
+				return err
+			}
+			x.SNested = v
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "pb3.Nests")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Nested documents.
+const (
+	Nested_SString_FieldPath = "sString"
+	Nested_SNested_FieldPath = "sNested"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Nested) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.SString != "" {
+		value, err := firestoreimpl.EncodeString(x.SString, "pb3.Nested.s_string")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["sString"] = value
+		}
+	}
+	if x.SNested != nil {
+		nested, err := o.Marshal(x.SNested)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested) != 0 {
+			document["sNested"] = nested
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Nested) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "sString":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "pb3.Nested.s_string")
+			if err != nil {
+				return err
+			}
+			x.SString = v
+		case "sNested":
+			if value == nil {
+				continue
+			}
+			nested, err := firestoreimpl.DecodeObject(value, "pb3.Nested.s_nested")
+			if err != nil {
+				return err
+			}
+			v := new(Nested)
+			if err := o.Unmarshal(nested, v); err != nil {
+				return err
+			}
+			x.SNested = v
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "pb3.Nested")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Oneofs documents.
+const (
+	Oneofs_OneofEnum_FieldPath   = "oneofEnum"
+	Oneofs_OneofString_FieldPath = "oneofString"
+	Oneofs_OneofNested_FieldPath = "oneofNested"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Oneofs) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	switch v := x.Union.(type) {
+	case *Oneofs_OneofEnum:
+		document["oneofEnum"] = firestoreimpl.EncodeEnum(v.OneofEnum.Number(), v.OneofEnum.Descriptor())
+	case *Oneofs_OneofString:
+		value, err := firestoreimpl.EncodeString(v.OneofString, "pb3.Oneofs.oneof_string")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["oneofString"] = value
+		}
+	case *Oneofs_OneofNested:
+		nested, err := o.Marshal(v.OneofNested)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested) != 0 || o.EmitFirestoreSensibleDefaults {
+			document["oneofNested"] = nested
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Oneofs) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "oneofEnum":
+			if value == nil {
+				continue
+			}
+			if x.Union != nil {
+				return firestoreimpl.OneofConflictError("pb3.Oneofs.union")
+			}
+			n, err := firestoreimpl.DecodeEnum(value, Enum(0).Descriptor(), "pb3.Oneofs.oneof_enum")
+			if err != nil {
+				return err
+			}
+			x.Union = &Oneofs_OneofEnum{OneofEnum: Enum(n)}
+		case "oneofString":
+			if value == nil {
+				continue
+			}
+			if x.Union != nil {
+				return firestoreimpl.OneofConflictError("pb3.Oneofs.union")
+			}
+			v, err := firestoreimpl.DecodeString(value, "pb3.Oneofs.oneof_string")
+			if err != nil {
+				return err
+			}
+			x.Union = &Oneofs_OneofString{OneofString: v}
+		case "oneofNested":
+			if value == nil {
+				continue
+			}
+			if x.Union != nil {
+				return firestoreimpl.OneofConflictError("pb3.Oneofs.union")
+			}
+			nested, err := firestoreimpl.DecodeObject(value, "pb3.Oneofs.oneof_nested")
+			if err != nil {
+				return err
+			}
+			v := new(Nested)
+			if err := o.Unmarshal(nested, v); err != nil {
+				return err
+			}
+			x.Union = &Oneofs_OneofNested{OneofNested: v}
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "pb3.Oneofs")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of Maps documents.
+const (
+	Maps_Int32ToStr_FieldPath   = "int32ToStr"
+	Maps_BoolToUint32_FieldPath = "boolToUint32"
+	Maps_Uint64ToEnum_FieldPath = "uint64ToEnum"
+	Maps_StrToNested_FieldPath  = "strToNested"
+	Maps_StrToOneofs_FieldPath  = "strToOneofs"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *Maps) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if len(x.Int32ToStr) != 0 {
+		object := make(map[string]interface{}, len(x.Int32ToStr))
+		for k, v := range x.Int32ToStr {
+			value, err := firestoreimpl.EncodeString(v, "pb3.Maps.Int32ToStrEntry.value")
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				object[strconv.FormatInt(int64(k), 10)] = value
+			}
+		}
+		if len(object) != 0 {
+			document["int32ToStr"] = object
+		}
+	}
+	if len(x.BoolToUint32) != 0 {
+		object := make(map[string]interface{}, len(x.BoolToUint32))
+		for k, v := range x.BoolToUint32 {
+			object[strconv.FormatBool(k)] = v
+		}
+		if len(object) != 0 {
+			document["boolToUint32"] = object
+		}
+	}
+	if len(x.Uint64ToEnum) != 0 {
+		object := make(map[string]interface{}, len(x.Uint64ToEnum))
+		for k, v := range x.Uint64ToEnum {
+			object[strconv.FormatUint(k, 10)] = firestoreimpl.EncodeEnum(v.Number(), v.Descriptor())
+		}
+		if len(object) != 0 {
+			document["uint64ToEnum"] = object
+		}
+	}
+	if len(x.StrToNested) != 0 {
+		object := make(map[string]interface{}, len(x.StrToNested))
+		for k, v := range x.StrToNested {
+			nested, err := o.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			if len(nested) != 0 {
+				object[k] = nested
+			}
+		}
+		if len(object) != 0 {
+			document["strToNested"] = object
+		}
+	}
+	if len(x.StrToOneofs) != 0 {
+		object := make(map[string]interface{}, len(x.StrToOneofs))
+		for k, v := range x.StrToOneofs {
+			nested, err := o.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			if len(nested) != 0 {
+				object[k] = nested
+			}
+		}
+		if len(object) != 0 {
+			document["strToOneofs"] = object
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *Maps) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "int32ToStr":
+			if value == nil {
+				continue
+			}
+			object, err := firestoreimpl.DecodeObject(value, "pb3.Maps.int32_to_str")
+			if err != nil {
+				return err
+			}
+			x.Int32ToStr = make(map[int32]string, len(object))
+			for k, item := range object {
+				mk, err := firestoreimpl.DecodeMapKey(k, protoreflect.Int32Kind, "pb3.Maps.int32_to_str")
+				if err != nil {
+					return err
+				}
+				if item == nil {
+					x.Int32ToStr[int32(mk.Int())] = ""
+					continue
+				}
+				v, err := firestoreimpl.DecodeString(item, "pb3.Maps.Int32ToStrEntry.value")
+				if err != nil {
+					return err
+				}
+				x.Int32ToStr[int32(mk.Int())] = v
+			}
+		case "boolToUint32":
+			if value == nil {
+				continue
+			}
+			object, err := firestoreimpl.DecodeObject(value, "pb3.Maps.bool_to_uint32")
+			if err != nil {
+				return err
+			}
+			x.BoolToUint32 = make(map[bool]uint32, len(object))
+			for k, item := range object {
+				mk, err := firestoreimpl.DecodeMapKey(k, protoreflect.BoolKind, "pb3.Maps.bool_to_uint32")
+				if err != nil {
+					return err
+				}
+				if item == nil {
+					x.BoolToUint32[mk.Bool()] = 0
+					continue
+				}
+				v, err := firestoreimpl.DecodeUint32(item, "pb3.Maps.BoolToUint32Entry.value")
+				if err != nil {
+					return err
+				}
+				x.BoolToUint32[mk.Bool()] = v
+			}
+		case "uint64ToEnum":
+			if value == nil {
+				continue
+			}
+			object, err := firestoreimpl.DecodeObject(value, "pb3.Maps.uint64_to_enum")
+			if err != nil {
+				return err
+			}
+			x.Uint64ToEnum = make(map[uint64]Enum, len(object))
+			for k, item := range object {
+				mk, err := firestoreimpl.DecodeMapKey(k, protoreflect.Uint64Kind, "pb3.Maps.uint64_to_enum")
+				if err != nil {
+					return err
+				}
+				if item == nil {
+					x.Uint64ToEnum[mk.Uint()] = 0
+					continue
+				}
+				n, err := firestoreimpl.DecodeEnum(item, Enum(0).Descriptor(), "pb3.Maps.Uint64ToEnumEntry.value")
+				if err != nil {
+					return err
+				}
+				x.Uint64ToEnum[mk.Uint()] = Enum(n)
+			}
+		case "strToNested":
+			if value == nil {
+				continue
+			}
+			object, err := firestoreimpl.DecodeObject(value, "pb3.Maps.str_to_nested")
+			if err != nil {
+				return err
+			}
+			x.StrToNested = make(map[string]*Nested, len(object))
+			for k, item := range object {
+				mk, err := firestoreimpl.DecodeMapKey(k, protoreflect.StringKind, "pb3.Maps.str_to_nested")
+				if err != nil {
+					return err
+				}
+				if item == nil {
+					x.StrToNested[mk.String()] = new(Nested)
+					continue
+				}
+				nested, err := firestoreimpl.DecodeObject(item, "pb3.Maps.StrToNestedEntry.value")
+				if err != nil {
+					return err
+				}
+				v := new(Nested)
+				if err := o.Unmarshal(nested, v); err != nil {
+					return err
+				}
+				x.StrToNested[mk.String()] = v
+			}
+		case "strToOneofs":
+			if value == nil {
+				continue
+			}
+			object, err := firestoreimpl.DecodeObject(value, "pb3.Maps.str_to_oneofs")
+			if err != nil {
+				return err
+			}
+			x.StrToOneofs = make(map[string]*Oneofs, len(object))
+			for k, item := range object {
+				mk, err := firestoreimpl.DecodeMapKey(k, protoreflect.StringKind, "pb3.Maps.str_to_oneofs")
+				if err != nil {
+					return err
+				}
+				if item == nil {
+					x.StrToOneofs[mk.String()] = new(Oneofs)
+					continue
+				}
+				nested, err := firestoreimpl.DecodeObject(item, "pb3.Maps.StrToOneofsEntry.value")
+				if err != nil {
+					return err
+				}
+				v := new(Oneofs)
+				if err := o.Unmarshal(nested, v); err != nil {
+					return err
+				}
+				x.StrToOneofs[mk.String()] = v
+			}
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "pb3.Maps")
+			}
+		}
+	}
+	return nil
+}
+
+// Firestore field paths of the fields of JSONNames documents.
+const (
+	JSONNames_SString_FieldPath = "foo_bar"
+)
+
+// MarshalFirestore marshals x into a firestore document without reflection.
+// Use protofirestore.MarshalOptions.Marshal instead of calling it directly.
+func (x *JSONNames) MarshalFirestore(o protofirestore.MarshalOptions) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	if x == nil {
+		return document, nil
+	}
+	if x.SString != "" {
+		value, err := firestoreimpl.EncodeString(x.SString, "pb3.JSONNames.s_string")
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			document["foo_bar"] = value
+		}
+	}
+	return document, nil
+}
+
+// UnmarshalFirestore reads the firestore document into x without reflection.
+// Use protofirestore.UnmarshalOptions.Unmarshal instead of calling it directly.
+func (x *JSONNames) UnmarshalFirestore(o protofirestore.UnmarshalOptions, document map[string]interface{}) error {
+	x.Reset()
+	for _, key := range firestoreimpl.SortedKeys(document) {
+		value := document[key]
+		switch key {
+		case "foo_bar":
+			if value == nil {
+				continue
+			}
+			v, err := firestoreimpl.DecodeString(value, "pb3.JSONNames.s_string")
+			if err != nil {
+				return err
+			}
+			x.SString = v
+		default:
+			if !o.DiscardUnknown {
+				return firestoreimpl.UnknownFieldError(key, "pb3.JSONNames")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+// Package jsonschema generates JSON Schemas describing the firestore
+// documents the protofirestore package encodes messages into.
+//
+// The schemas describe documents represented as JSON the way encoding/json
+// encodes the result of Marshal: timestamps are RFC 3339 strings and bytes
+// are base64 strings.
+package jsonschema
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/internal/shape"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Draft is the JSON Schema dialect of generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema. Only the keywords used by generated schemas are
+// supported.
+type Schema struct {
+	Schema string             `json:"$schema,omitempty"`
+	Ref    string             `json:"$ref,omitempty"`
+	Defs   map[string]*Schema `json:"$defs,omitempty"`
+	Title  string             `json:"title,omitempty"`
+
+	Type            string   `json:"type,omitempty"`
+	Format          string   `json:"format,omitempty"`
+	ContentEncoding string   `json:"contentEncoding,omitempty"`
+	Enum            []string `json:"enum,omitempty"`
+	Pattern         string   `json:"pattern,omitempty"`
+
+	Minimum json.Number `json:"minimum,omitempty"`
+	Maximum json.Number `json:"maximum,omitempty"`
+
+	Items *Schema `json:"items,omitempty"`
+
+	Properties    map[string]*Schema `json:"properties,omitempty"`
+	Required      []string           `json:"required,omitempty"`
+	PropertyNames *Schema            `json:"propertyNames,omitempty"`
+
+	// AdditionalProperties is either false or a *Schema.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+}
+
+// MarshalIndent encodes the schema as indented JSON.
+func (s *Schema) MarshalIndent() ([]byte, error) {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Options configures the generation of schemas.
+type Options struct {
+	// MarshalOptions are the options documents are encoded with, which
+	// determine the keys required in a document.
+	MarshalOptions protofirestore.MarshalOptions
+}
+
+// Generate generates the schema of the documents of messages described by md.
+func Generate(md protoreflect.MessageDescriptor) (*Schema, error) {
+	return Options{}.Generate(md)
+}
+
+// Generate generates the schema of the documents of messages described by
+// md. The schema of every message is declared in $defs under its full name
+// and referenced from the root schema and message fields, so recursive
+// messages are supported.
+//
+// Documents only have the keys of their fields, keys which are always
+// encoded are required and at most one key of each oneof is present. Values
+// are typed like the encoder encodes them:
+//
+//   - integers are integers within the range of the field, which for 64-bit
+//     unsigned integers is limited to the range firestore supports.
+//   - enums are the names of the enum values.
+//   - google.protobuf.Timestamp values are strings in date-time format.
+//   - bytes are base64 encoded strings.
+//   - maps are objects whose property names are the keys of the map.
+//   - lists are arrays, whose string, bytes and message elements are null
+//     if they are empty.
+func (o Options) Generate(md protoreflect.MessageDescriptor) (*Schema, error) {
+	g := generator{opts: o, defs: make(map[string]*Schema)}
+	if err := g.define(md); err != nil {
+		return nil, err
+	}
+
+	return &Schema{
+		Schema: Draft,
+		Ref:    ref(md),
+		Defs:   g.defs,
+	}, nil
+}
+
+// generator declares the schemas of messages.
+type generator struct {
+	opts Options
+	defs map[string]*Schema
+}
+
+// define declares the schema of the message md and the messages it embeds
+// unless it is already declared.
+func (g *generator) define(md protoreflect.MessageDescriptor) error {
+	name := string(md.FullName())
+	if _, ok := g.defs[name]; ok {
+		return nil
+	}
+
+	m, err := shape.Of(md, g.opts.MarshalOptions)
+	if err != nil {
+		return err
+	}
+
+	s := &Schema{
+		Title:                name,
+		Type:                 "object",
+		AdditionalProperties: false,
+	}
+	g.defs[name] = s
+
+	for _, f := range m.Fields {
+		if s.Properties == nil {
+			s.Properties = make(map[string]*Schema)
+		}
+		if s.Properties[f.Key], err = g.schema(f.Type); err != nil {
+			return err
+		}
+		if f.Required {
+			s.Required = append(s.Required, f.Key)
+		}
+	}
+
+	if m.Type != "" {
+		if s.Properties == nil {
+			s.Properties = make(map[string]*Schema)
+		}
+		s.Properties[protofirestore.TypeKey] = &Schema{Type: "string", Enum: []string{m.Type}}
+	}
+
+	for _, oneof := range m.OneofCases {
+		if s.Properties == nil {
+			s.Properties = make(map[string]*Schema)
+		}
+		s.Properties[oneof.Key] = &Schema{AnyOf: []*Schema{{Type: "string", Enum: oneof.Cases}, {Type: "null"}}}
+		s.Required = append(s.Required, oneof.Key)
+	}
+
+	for _, oneof := range m.Oneofs() {
+		if len(oneof) < 2 {
+			continue
+		}
+		var pairs []*Schema
+		for i := range oneof {
+			for j := i + 1; j < len(oneof); j++ {
+				pairs = append(pairs, &Schema{Required: []string{oneof[i], oneof[j]}})
+			}
+		}
+		s.AllOf = append(s.AllOf, &Schema{Not: &Schema{AnyOf: pairs}})
+	}
+
+	return nil
+}
+
+// schema returns the schema of values of type t.
+func (g *generator) schema(t shape.Type) (*Schema, error) {
+	switch t.Kind {
+	case shape.Bool:
+		return &Schema{Type: "boolean"}, nil
+
+	case shape.Int:
+		min, max := intRange(t.Scalar)
+		return &Schema{Type: "integer", Minimum: min, Maximum: max}, nil
+
+	case shape.Float:
+		return &Schema{Type: "number"}, nil
+
+	case shape.String:
+		return &Schema{Type: "string"}, nil
+
+	case shape.Bytes:
+		return &Schema{Type: "string", ContentEncoding: "base64"}, nil
+
+	case shape.Timestamp:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+
+	case shape.Enum:
+		values := t.Enum.Values()
+		names := make([]string, values.Len())
+		for i := range names {
+			names[i] = string(values.Get(i).Name())
+		}
+		return &Schema{Type: "string", Enum: names}, nil
+
+	case shape.Object:
+		if err := g.define(t.Message); err != nil {
+			return nil, err
+		}
+		return &Schema{Ref: ref(t.Message)}, nil
+
+	case shape.List:
+		items, err := g.schema(*t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		if t.Nullable {
+			items = &Schema{AnyOf: []*Schema{items, {Type: "null"}}}
+		}
+		return &Schema{Type: "array", Items: items}, nil
+
+	case shape.Map:
+		values, err := g.schema(*t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", PropertyNames: keySchema(t.Key), AdditionalProperties: values}, nil
+	}
+
+	panic("unknown kind " + t.Kind.String())
+}
+
+// intRange returns the range of integers of the proto kind.
+func intRange(kind protoreflect.Kind) (json.Number, json.Number) {
+	var min, max int64
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		min, max = -1<<31, 1<<31-1
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		min, max = 0, 1<<32-1
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		min, max = 0, 1<<63-1
+	default:
+		min, max = -1<<63, 1<<63-1
+	}
+	return json.Number(strconv.FormatInt(min, 10)), json.Number(strconv.FormatInt(max, 10))
+}
+
+// keySchema returns the schema of the property names of maps with keys of the
+// proto kind, which are the string representations of the keys.
+func keySchema(kind protoreflect.Kind) *Schema {
+	switch kind {
+	case protoreflect.BoolKind:
+		return &Schema{Enum: []string{"false", "true"}}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Pattern: "^(0|[1-9][0-9]*)$"}
+	case protoreflect.StringKind:
+		return nil
+	}
+	return &Schema{Pattern: "^(0|-?[1-9][0-9]*)$"}
+}
+
+// ref returns the reference to the schema of the message md.
+func ref(md protoreflect.MessageDescriptor) string {
+	return "#/$defs/" + string(md.FullName())
+}
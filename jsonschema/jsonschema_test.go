@@ -0,0 +1,423 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/daviddomkar/protofirestore"
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	pb2 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb2"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/daviddomkar/protofirestore/jsonschema"
+	"github.com/go-test/deep"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGenerate(t *testing.T) {
+	nested := &jsonschema.Schema{
+		Title: "pb3.Nested",
+		Type:  "object",
+		Properties: map[string]*jsonschema.Schema{
+			"sString": {Type: "string"},
+			"sNested": {Ref: "#/$defs/pb3.Nested"},
+		},
+		AdditionalProperties: false,
+	}
+	address := &jsonschema.Schema{
+		Title: "annotated.Address",
+		Type:  "object",
+		Properties: map[string]*jsonschema.Schema{
+			"street":       {Type: "string"},
+			"instructions": {Type: "string"},
+		},
+		AdditionalProperties: false,
+	}
+
+	tests := []struct {
+		desc  string
+		mo    protofirestore.MarshalOptions
+		input proto.Message
+		want  *jsonschema.Schema
+	}{{
+		desc:  "scalars",
+		input: &pb3.Scalars{},
+		want: &jsonschema.Schema{
+			Schema: jsonschema.Draft,
+			Ref:    "#/$defs/pb3.Scalars",
+			Defs: map[string]*jsonschema.Schema{
+				"pb3.Scalars": {
+					Title: "pb3.Scalars",
+					Type:  "object",
+					Properties: map[string]*jsonschema.Schema{
+						"sBool":     {Type: "boolean"},
+						"sInt32":    {Type: "integer", Minimum: "-2147483648", Maximum: "2147483647"},
+						"sInt64":    {Type: "integer", Minimum: "-9223372036854775808", Maximum: "9223372036854775807"},
+						"sUint32":   {Type: "integer", Minimum: "0", Maximum: "4294967295"},
+						"sUint64":   {Type: "integer", Minimum: "0", Maximum: "9223372036854775807"},
+						"sSint32":   {Type: "integer", Minimum: "-2147483648", Maximum: "2147483647"},
+						"sSint64":   {Type: "integer", Minimum: "-9223372036854775808", Maximum: "9223372036854775807"},
+						"sFixed32":  {Type: "integer", Minimum: "0", Maximum: "4294967295"},
+						"sFixed64":  {Type: "integer", Minimum: "0", Maximum: "9223372036854775807"},
+						"sSfixed32": {Type: "integer", Minimum: "-2147483648", Maximum: "2147483647"},
+						"sSfixed64": {Type: "integer", Minimum: "-9223372036854775808", Maximum: "9223372036854775807"},
+						"sFloat":    {Type: "number"},
+						"sDouble":   {Type: "number"},
+						"sBytes":    {Type: "string", ContentEncoding: "base64"},
+						"sString":   {Type: "string"},
+					},
+					AdditionalProperties: false,
+				},
+			},
+		},
+	}, {
+		desc:  "sensible defaults are required",
+		mo:    protofirestore.MarshalOptions{EmitFirestoreSensibleDefaults: true},
+		input: &pb3.Enums{},
+		want: &jsonschema.Schema{
+			Schema: jsonschema.Draft,
+			Ref:    "#/$defs/pb3.Enums",
+			Defs: map[string]*jsonschema.Schema{
+				"pb3.Enums": {
+					Title: "pb3.Enums",
+					Type:  "object",
+					Properties: map[string]*jsonschema.Schema{
+						"sEnum":       {Type: "string", Enum: []string{"ZERO", "ONE", "TWO", "TEN"}},
+						"sNestedEnum": {Type: "string", Enum: []string{"CERO", "UNO", "DOS", "DIEZ"}},
+					},
+					Required:             []string{"sEnum", "sNestedEnum"},
+					AdditionalProperties: false,
+				},
+			},
+		},
+	}, {
+		desc:  "type key",
+		mo:    protofirestore.MarshalOptions{EmitType: true},
+		input: &pb3.Enums{},
+		want: &jsonschema.Schema{
+			Schema: jsonschema.Draft,
+			Ref:    "#/$defs/pb3.Enums",
+			Defs: map[string]*jsonschema.Schema{
+				"pb3.Enums": {
+					Title: "pb3.Enums",
+					Type:  "object",
+					Properties: map[string]*jsonschema.Schema{
+						"sEnum":       {Type: "string", Enum: []string{"ZERO", "ONE", "TWO", "TEN"}},
+						"sNestedEnum": {Type: "string", Enum: []string{"CERO", "UNO", "DOS", "DIEZ"}},
+						"@type":       {Type: "string", Enum: []string{"pb3.Enums"}},
+					},
+					AdditionalProperties: false,
+				},
+			},
+		},
+	}, {
+		desc:  "recursive messages and oneofs",
+		input: &pb3.Oneofs{},
+		want: &jsonschema.Schema{
+			Schema: jsonschema.Draft,
+			Ref:    "#/$defs/pb3.Oneofs",
+			Defs: map[string]*jsonschema.Schema{
+				"pb3.Oneofs": {
+					Title: "pb3.Oneofs",
+					Type:  "object",
+					Properties: map[string]*jsonschema.Schema{
+						"oneofEnum":   {Type: "string", Enum: []string{"ZERO", "ONE", "TWO", "TEN"}},
+						"oneofString": {Type: "string"},
+						"oneofNested": {Ref: "#/$defs/pb3.Nested"},
+					},
+					AdditionalProperties: false,
+					AllOf: []*jsonschema.Schema{{
+						Not: &jsonschema.Schema{AnyOf: []*jsonschema.Schema{
+							{Required: []string{"oneofEnum", "oneofString"}},
+							{Required: []string{"oneofEnum", "oneofNested"}},
+							{Required: []string{"oneofString", "oneofNested"}},
+						}},
+					}},
+				},
+				"pb3.Nested": nested,
+			},
+		},
+	}, {
+		desc:  "oneof cases",
+		mo:    protofirestore.MarshalOptions{EmitOneofCase: true},
+		input: &pb3.Oneofs{},
+		want: &jsonschema.Schema{
+			Schema: jsonschema.Draft,
+			Ref:    "#/$defs/pb3.Oneofs",
+			Defs: map[string]*jsonschema.Schema{
+				"pb3.Oneofs": {
+					Title: "pb3.Oneofs",
+					Type:  "object",
+					Properties: map[string]*jsonschema.Schema{
+						"oneofEnum":   {Type: "string", Enum: []string{"ZERO", "ONE", "TWO", "TEN"}},
+						"oneofString": {Type: "string"},
+						"oneofNested": {Ref: "#/$defs/pb3.Nested"},
+						"unionCase": {AnyOf: []*jsonschema.Schema{
+							{Type: "string", Enum: []string{"oneofEnum", "oneofString", "oneofNested"}},
+							{Type: "null"},
+						}},
+					},
+					Required:             []string{"unionCase"},
+					AdditionalProperties: false,
+					AllOf: []*jsonschema.Schema{{
+						Not: &jsonschema.Schema{AnyOf: []*jsonschema.Schema{
+							{Required: []string{"oneofEnum", "oneofString"}},
+							{Required: []string{"oneofEnum", "oneofNested"}},
+							{Required: []string{"oneofString", "oneofNested"}},
+						}},
+					}},
+				},
+				"pb3.Nested": nested,
+			},
+		},
+	}, {
+		desc:  "maps",
+		input: &pb3.Maps{},
+		want: &jsonschema.Schema{
+			Schema: jsonschema.Draft,
+			Ref:    "#/$defs/pb3.Maps",
+			Defs: map[string]*jsonschema.Schema{
+				"pb3.Maps": {
+					Title: "pb3.Maps",
+					Type:  "object",
+					Properties: map[string]*jsonschema.Schema{
+						"int32ToStr": {
+							Type:                 "object",
+							PropertyNames:        &jsonschema.Schema{Pattern: "^(0|-?[1-9][0-9]*)$"},
+							AdditionalProperties: &jsonschema.Schema{Type: "string"},
+						},
+						"boolToUint32": {
+							Type:                 "object",
+							PropertyNames:        &jsonschema.Schema{Enum: []string{"false", "true"}},
+							AdditionalProperties: &jsonschema.Schema{Type: "integer", Minimum: "0", Maximum: "4294967295"},
+						},
+						"uint64ToEnum": {
+							Type:                 "object",
+							PropertyNames:        &jsonschema.Schema{Pattern: "^(0|[1-9][0-9]*)$"},
+							AdditionalProperties: &jsonschema.Schema{Type: "string", Enum: []string{"ZERO", "ONE", "TWO", "TEN"}},
+						},
+						"strToNested": {
+							Type:                 "object",
+							AdditionalProperties: &jsonschema.Schema{Ref: "#/$defs/pb3.Nested"},
+						},
+						"strToOneofs": {
+							Type:                 "object",
+							AdditionalProperties: &jsonschema.Schema{Ref: "#/$defs/pb3.Oneofs"},
+						},
+					},
+					AdditionalProperties: false,
+				},
+				"pb3.Nested": nested,
+				"pb3.Oneofs": {
+					Title: "pb3.Oneofs",
+					Type:  "object",
+					Properties: map[string]*jsonschema.Schema{
+						"oneofEnum":   {Type: "string", Enum: []string{"ZERO", "ONE", "TWO", "TEN"}},
+						"oneofString": {Type: "string"},
+						"oneofNested": {Ref: "#/$defs/pb3.Nested"},
+					},
+					AdditionalProperties: false,
+					AllOf: []*jsonschema.Schema{{
+						Not: &jsonschema.Schema{AnyOf: []*jsonschema.Schema{
+							{Required: []string{"oneofEnum", "oneofString"}},
+							{Required: []string{"oneofEnum", "oneofNested"}},
+							{Required: []string{"oneofString", "oneofNested"}},
+						}},
+					}},
+				},
+			},
+		},
+	}, {
+		desc:  "timestamps and nullable list elements",
+		input: &pbann.Order{},
+		want: &jsonschema.Schema{
+			Schema: jsonschema.Draft,
+			Ref:    "#/$defs/annotated.Order",
+			Defs: map[string]*jsonschema.Schema{
+				"annotated.Order": {
+					Title: "annotated.Order",
+					Type:  "object",
+					Properties: map[string]*jsonschema.Schema{
+						"name":       {Type: "string"},
+						"state":      {Type: "string", Enum: []string{"STATE_UNSPECIFIED", "OPEN", "SHIPPED"}},
+						"createTime": {Type: "string", Format: "date-time"},
+						"tags": {Type: "array", Items: &jsonschema.Schema{AnyOf: []*jsonschema.Schema{
+							{Type: "string"}, {Type: "null"},
+						}}},
+						"total":   {Type: "number"},
+						"notes":   {Type: "string"},
+						"address": {Ref: "#/$defs/annotated.Address"},
+						"stops": {Type: "array", Items: &jsonschema.Schema{AnyOf: []*jsonschema.Schema{
+							{Ref: "#/$defs/annotated.Address"}, {Type: "null"},
+						}}},
+					},
+					AdditionalProperties: false,
+				},
+				"annotated.Address": address,
+			},
+		},
+	}, {
+		desc:  "required fields",
+		input: &pb2.PartialRequired{},
+		want: &jsonschema.Schema{
+			Schema: jsonschema.Draft,
+			Ref:    "#/$defs/pb2.PartialRequired",
+			Defs: map[string]*jsonschema.Schema{
+				"pb2.PartialRequired": {
+					Title: "pb2.PartialRequired",
+					Type:  "object",
+					Properties: map[string]*jsonschema.Schema{
+						"reqString": {Type: "string"},
+						"optString": {Type: "string"},
+					},
+					AdditionalProperties: false,
+				},
+			},
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := jsonschema.Options{MarshalOptions: tt.mo}.Generate(tt.input.ProtoReflect().Descriptor())
+			if err != nil {
+				t.Fatalf("Generate() returned error: %v\n", err)
+			}
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+	tests := []struct {
+		desc  string
+		input proto.Message
+	}{{
+		desc:  "unsupported well known type field",
+		input: &pb2.KnownTypes{},
+	}, {
+		desc:  "well known type document",
+		input: &timestamppb.Timestamp{},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := jsonschema.Generate(tt.input.ProtoReflect().Descriptor()); err == nil {
+				t.Error("Generate() did not return error")
+			}
+		})
+	}
+}
+
+func TestMarshalIndent(t *testing.T) {
+	schema, err := jsonschema.Generate((&pbann.Address{}).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatalf("Generate() returned error: %v\n", err)
+	}
+
+	got, err := schema.MarshalIndent()
+	if err != nil {
+		t.Fatalf("MarshalIndent() returned error: %v\n", err)
+	}
+
+	want := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$ref": "#/$defs/annotated.Address",
+  "$defs": {
+    "annotated.Address": {
+      "title": "annotated.Address",
+      "type": "object",
+      "properties": {
+        "instructions": {
+          "type": "string"
+        },
+        "street": {
+          "type": "string"
+        }
+      },
+      "additionalProperties": false
+    }
+  }
+}
+`
+	if string(got) != want {
+		t.Errorf("MarshalIndent()\n<got>\n%s\n<want>\n%s\n", got, want)
+	}
+}
+
+// TestMarshaledDocumentsHaveSchemaKeys checks that the keys of encoded
+// documents are properties of their schema and that required properties are
+// present.
+func TestMarshaledDocumentsHaveSchemaKeys(t *testing.T) {
+	tests := []struct {
+		desc  string
+		mo    protofirestore.MarshalOptions
+		input proto.Message
+	}{{
+		desc: "populated fields",
+		input: &pbann.Order{
+			Name:       "order",
+			State:      pbann.Order_SHIPPED,
+			CreateTime: timestamppb.New(time.Unix(1, 0)),
+			Tags:       []string{"a", ""},
+			Total:      1.5,
+			Address:    &pbann.Address{Street: "street"},
+		},
+	}, {
+		desc:  "sensible defaults",
+		mo:    protofirestore.MarshalOptions{EmitFirestoreSensibleDefaults: true},
+		input: &pb3.Scalars{},
+	}, {
+		desc:  "type key",
+		mo:    protofirestore.MarshalOptions{EmitType: true},
+		input: &pbann.Order{Name: "order", Address: &pbann.Address{Street: "street"}},
+	}, {
+		desc:  "oneof cases",
+		mo:    protofirestore.MarshalOptions{EmitOneofCase: true},
+		input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{OneofString: "hello"}},
+	}, {
+		desc:  "required fields",
+		input: &pb2.Requireds{ReqBool: proto.Bool(false), ReqSfixed64: proto.Int64(0), ReqDouble: proto.Float64(0), ReqString: proto.String(""), ReqEnum: pb2.Enum_ONE.Enum(), ReqNested: &pb2.Nested{}},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			document, err := tt.mo.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal() returned error: %v\n", err)
+			}
+
+			schema, err := jsonschema.Options{MarshalOptions: tt.mo}.Generate(tt.input.ProtoReflect().Descriptor())
+			if err != nil {
+				t.Fatalf("Generate() returned error: %v\n", err)
+			}
+			def := schema.Defs[string(tt.input.ProtoReflect().Descriptor().FullName())]
+
+			// Round trip through JSON the way documents are validated.
+			b, err := json.Marshal(document)
+			if err != nil {
+				t.Fatalf("json.Marshal() returned error: %v\n", err)
+			}
+			var object map[string]interface{}
+			if err := json.Unmarshal(b, &object); err != nil {
+				t.Fatalf("json.Unmarshal() returned error: %v\n", err)
+			}
+
+			for key := range object {
+				if _, ok := def.Properties[key]; !ok {
+					t.Errorf("key %q is not a property of the schema", key)
+				}
+			}
+			for _, key := range def.Required {
+				if _, ok := object[key]; !ok {
+					t.Errorf("required key %q is missing from %v", key, object)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+// Package memstore implements store.Store in memory for tests. Unlike mocks,
+// it evaluates queries with the semantics of firestore: filters only match
+// documents having the filtered field, values of different types are
+// ordered by type, queries with inequality filters are implicitly ordered by
+// the filtered fields, documents are implicitly ordered by their path, and
+// stored values read back the way firestore returns them.
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+// Store is an in-memory store.Store, which also implements store.Batcher
+// and store.Transactor. It is safe for concurrent use.
+type Store struct {
+	mu        sync.Mutex
+	documents map[string]*store.Snapshot
+	last      time.Time
+}
+
+var (
+	_ store.Store      = (*Store)(nil)
+	_ store.Batcher    = (*Store)(nil)
+	_ store.Transactor = (*Store)(nil)
+)
+
+// New returns an empty Store.
+func New() *Store {
+	return &Store{documents: make(map[string]*store.Snapshot)}
+}
+
+// now returns the time of a write, which is later than all previous writes
+// so that update time preconditions distinguish every write.
+func (s *Store) now() time.Time {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !now.After(s.last) {
+		now = s.last.Add(time.Microsecond)
+	}
+	s.last = now
+	return now
+}
+
+// checkDocumentPath checks that path is the path of a document, which has an
+// even number of non-empty segments.
+func checkDocumentPath(path string) error {
+	segments := strings.Split(path, "/")
+	if len(segments)%2 != 0 {
+		return fmt.Errorf("%q is not a document path", path)
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return fmt.Errorf("%q is not a document path", path)
+		}
+	}
+	return nil
+}
+
+// check returns the document at path if it satisfies pre.
+func (s *Store) check(path string, pre store.Precondition) (*store.Snapshot, error) {
+	if err := checkDocumentPath(path); err != nil {
+		return nil, err
+	}
+
+	snapshot, ok := s.documents[path]
+	if !ok && (pre.Exists || !pre.UpdateTime.IsZero()) {
+		return nil, fmt.Errorf("%v: %w", path, store.ErrNotFound)
+	}
+	if ok && !pre.UpdateTime.IsZero() && !pre.UpdateTime.Equal(snapshot.UpdateTime) {
+		return nil, fmt.Errorf("%v: %w: updated at %v, want %v", path, store.ErrFailedPrecondition, snapshot.UpdateTime, pre.UpdateTime)
+	}
+	return snapshot, nil
+}
+
+// write stores data at path. Snapshots are replaced rather than modified,
+// so that commit can restore the documents when a write fails.
+func (s *Store) write(path string, data map[string]interface{}, now time.Time) *store.WriteResult {
+	createTime := now
+	if old, ok := s.documents[path]; ok {
+		createTime = old.CreateTime
+	}
+
+	s.documents[path] = &store.Snapshot{Path: path, Data: data, CreateTime: createTime, UpdateTime: now}
+
+	return &store.WriteResult{UpdateTime: now}
+}
+
+// apply applies w at the time now. The caller must hold s.mu.
+func (s *Store) apply(w store.Write, now time.Time) (*store.WriteResult, error) {
+	switch w.Op {
+	case store.OpCreate, store.OpSet:
+		if err := checkDocumentPath(w.Path); err != nil {
+			return nil, err
+		}
+		if _, ok := s.documents[w.Path]; ok && w.Op == store.OpCreate {
+			return nil, fmt.Errorf("%v: %w", w.Path, store.ErrAlreadyExists)
+		}
+
+		data, err := normalizeDocument(w.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", w.Path, err)
+		}
+
+		return s.write(w.Path, data, now), nil
+
+	case store.OpUpdate:
+		pre := w.Precondition
+		pre.Exists = true
+		snapshot, err := s.check(w.Path, pre)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := applyUpdates(snapshot.Data, w.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", w.Path, err)
+		}
+
+		return s.write(w.Path, data, now), nil
+
+	case store.OpDelete:
+		if _, err := s.check(w.Path, w.Precondition); err != nil {
+			return nil, err
+		}
+
+		// Like in firestore, the documents of subcollections are not deleted.
+		delete(s.documents, w.Path)
+
+		return &store.WriteResult{}, nil
+	}
+
+	return nil, fmt.Errorf("invalid write operation %v", w.Op)
+}
+
+// commit applies all writes at the same time, or none of them if one fails.
+// The caller must hold s.mu.
+func (s *Store) commit(writes []store.Write) ([]*store.WriteResult, error) {
+	backup := make(map[string]*store.Snapshot, len(writes))
+	for _, w := range writes {
+		if _, ok := backup[w.Path]; !ok {
+			backup[w.Path] = s.documents[w.Path]
+		}
+	}
+
+	now := s.now()
+	results := make([]*store.WriteResult, len(writes))
+	for i, w := range writes {
+		result, err := s.apply(w, now)
+		if err != nil {
+			for path, snapshot := range backup {
+				if snapshot == nil {
+					delete(s.documents, path)
+				} else {
+					s.documents[path] = snapshot
+				}
+			}
+			return nil, err
+		}
+		results[i] = result
+	}
+
+	return results, nil
+}
+
+// snapshot returns a copy of the given snapshot, so that callers cannot
+// modify the stored document.
+func snapshot(s *store.Snapshot) *store.Snapshot {
+	c := *s
+	c.Data = copyValue(s.Data).(map[string]interface{})
+	return &c
+}
+
+// Get implements store.Store.
+func (s *Store) Get(ctx context.Context, path string) (*store.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.get(path)
+}
+
+func (s *Store) get(path string) (*store.Snapshot, error) {
+	if _, err := s.check(path, store.Precondition{Exists: true}); err != nil {
+		return nil, err
+	}
+
+	return snapshot(s.documents[path]), nil
+}
+
+func (s *Store) applyOne(w store.Write) (*store.WriteResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.apply(w, s.now())
+}
+
+// Create implements store.Store.
+func (s *Store) Create(ctx context.Context, path string, data map[string]interface{}) (*store.WriteResult, error) {
+	return s.applyOne(store.Write{Op: store.OpCreate, Path: path, Data: data})
+}
+
+// Set implements store.Store.
+func (s *Store) Set(ctx context.Context, path string, data map[string]interface{}) (*store.WriteResult, error) {
+	return s.applyOne(store.Write{Op: store.OpSet, Path: path, Data: data})
+}
+
+// Update implements store.Store. Besides values, updates may contain the
+// sentinels protofirestore.Delete, protofirestore.ArrayUnion,
+// protofirestore.ArrayRemove and protofirestore.Increment.
+func (s *Store) Update(ctx context.Context, path string, updates map[string]interface{}, pre store.Precondition) (*store.WriteResult, error) {
+	return s.applyOne(store.Write{Op: store.OpUpdate, Path: path, Data: updates, Precondition: pre})
+}
+
+// Delete implements store.Store.
+func (s *Store) Delete(ctx context.Context, path string, pre store.Precondition) (*store.WriteResult, error) {
+	return s.applyOne(store.Write{Op: store.OpDelete, Path: path, Precondition: pre})
+}
+
+// Commit implements store.Batcher.
+func (s *Store) Commit(ctx context.Context, writes []store.Write) ([]*store.WriteResult, error) {
+	if len(writes) > store.MaxBatchWrites {
+		return nil, fmt.Errorf("%d writes exceed the limit of %d writes per commit", len(writes), store.MaxBatchWrites)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commit(writes)
+}
+
+// applyUpdates returns a copy of data with the given updates applied.
+func applyUpdates(data map[string]interface{}, updates map[string]interface{}) (map[string]interface{}, error) {
+	paths := make(map[string][]string, len(updates))
+	for path := range updates {
+		segments, err := protofirestore.SplitFieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+		paths[path] = segments
+	}
+
+	// Firestore rejects updates in which a field path is a prefix of another
+	// one, because their outcome would depend on the order of the updates.
+	for path, segments := range paths {
+		for other, prefix := range paths {
+			if path != other && isPrefix(prefix, segments) {
+				return nil, fmt.Errorf("field path %q conflicts with %q", other, path)
+			}
+		}
+	}
+
+	data = copyValue(data).(map[string]interface{})
+
+	for path, segments := range paths {
+		parent := data
+		for _, segment := range segments[:len(segments)-1] {
+			child, ok := parent[segment].(map[string]interface{})
+			if !ok {
+				if updates[path] == protofirestore.Delete {
+					parent = nil
+					break
+				}
+				child = make(map[string]interface{})
+				parent[segment] = child
+			}
+			parent = child
+		}
+
+		if parent == nil {
+			continue // deleting a field which does not exist
+		}
+
+		key := segments[len(segments)-1]
+		value, err := applyUpdate(parent, key, updates[path])
+		if err != nil {
+			return nil, fmt.Errorf("field path %q: %w", path, err)
+		}
+
+		if updates[path] == protofirestore.Delete {
+			delete(parent, key)
+		} else {
+			parent[key] = value
+		}
+	}
+
+	return data, nil
+}
+
+func isPrefix(prefix, segments []string) bool {
+	if len(prefix) >= len(segments) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// applyUpdate returns the value of the key of the map parent after the given
+// update.
+func applyUpdate(parent map[string]interface{}, key string, update interface{}) (interface{}, error) {
+	current, exists := parent[key]
+
+	if update == protofirestore.Delete {
+		return nil, nil
+	}
+	if _, ok := update.(protofirestore.Sentinel); !ok {
+		return normalize(update)
+	}
+
+	switch u := update.(type) {
+	case protofirestore.ArrayUnion:
+		elems, err := normalize(u.Elems)
+		if err != nil {
+			return nil, err
+		}
+
+		array, _ := current.([]interface{})
+		result := append([]interface{}{}, array...)
+		for _, e := range elems.([]interface{}) {
+			if !contains(result, e) {
+				result = append(result, e)
+			}
+		}
+		return result, nil
+
+	case protofirestore.ArrayRemove:
+		elems, err := normalize(u.Elems)
+		if err != nil {
+			return nil, err
+		}
+
+		array, _ := current.([]interface{})
+		result := []interface{}{}
+		for _, e := range array {
+			if !contains(elems.([]interface{}), e) {
+				result = append(result, e)
+			}
+		}
+		return result, nil
+
+	case protofirestore.Increment:
+		n, err := normalize(u.N)
+		if err != nil {
+			return nil, err
+		}
+		if rank(n) != numberRank {
+			return nil, fmt.Errorf("cannot increment by %v of type %T", u.N, u.N)
+		}
+
+		if !exists || rank(current) != numberRank {
+			return n, nil
+		}
+		return increment(current, n), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported sentinel %v", update)
+	}
+}
+
+// increment adds the normalized number n to the normalized number current
+// the way firestore does: if either is a float, both are added as floats,
+// and integer overflows saturate.
+func increment(current, n interface{}) interface{} {
+	a, aok := current.(int64)
+	b, bok := n.(int64)
+
+	if !aok || !bok {
+		return toFloat(current) + toFloat(n)
+	}
+
+	sum := a + b
+	switch {
+	case a > 0 && b > 0 && sum < 0:
+		return int64(math.MaxInt64)
+	case a < 0 && b < 0 && sum >= 0:
+		return int64(math.MinInt64)
+	}
+	return sum
+}
+
+func toFloat(n interface{}) float64 {
+	if i, ok := n.(int64); ok {
+		return float64(i)
+	}
+	return n.(float64)
+}
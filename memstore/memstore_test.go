@@ -0,0 +1,254 @@
+package memstore_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/memstore"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	created, err := s.Create(ctx, "users/alice", map[string]interface{}{
+		"name":  "alice",
+		"age":   int32(30),
+		"score": float32(1.5),
+		"born":  time.Date(1990, 1, 2, 3, 4, 5, 6789, time.FixedZone("CET", 3600)),
+	})
+	if err != nil {
+		t.Fatalf("Create() returned error: %v\n", err)
+	}
+
+	if _, err := s.Create(ctx, "users/alice", nil); !errors.Is(err, store.ErrAlreadyExists) {
+		t.Errorf("Create() of existing document returned error %v, want %v\n", err, store.ErrAlreadyExists)
+	}
+
+	got, err := s.Get(ctx, "users/alice")
+	if err != nil {
+		t.Fatalf("Get() returned error: %v\n", err)
+	}
+
+	want := map[string]interface{}{
+		"name":  "alice",
+		"age":   int64(30),
+		"score": float64(1.5),
+		"born":  time.Date(1990, 1, 2, 2, 4, 5, 6000, time.UTC),
+	}
+	if diff := deep.Equal(got.Data, want); diff != nil {
+		t.Errorf("Get() mismatch (-want +got):\n%v\n", diff)
+	}
+	if !got.UpdateTime.Equal(created.UpdateTime) || !got.CreateTime.Equal(created.UpdateTime) {
+		t.Errorf("Get() times = %v, %v, want %v\n", got.CreateTime, got.UpdateTime, created.UpdateTime)
+	}
+
+	got.Data["name"] = "modified"
+	if got, _ := s.Get(ctx, "users/alice"); got.Data["name"] != "alice" {
+		t.Errorf("modifying a snapshot modified the stored document\n")
+	}
+
+	if _, err := s.Get(ctx, "users/bob"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get() of missing document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+
+	if _, err := s.Get(ctx, "users"); err == nil {
+		t.Errorf("Get() of collection path got nil error, want error\n")
+	}
+
+	stale := store.Precondition{UpdateTime: created.UpdateTime.Add(-time.Second)}
+	if _, err := s.Update(ctx, "users/alice", map[string]interface{}{"age": 31}, stale); !errors.Is(err, store.ErrFailedPrecondition) {
+		t.Errorf("Update() with stale update time returned error %v, want %v\n", err, store.ErrFailedPrecondition)
+	}
+
+	updated, err := s.Update(ctx, "users/alice", map[string]interface{}{"age": 31}, store.Precondition{UpdateTime: created.UpdateTime})
+	if err != nil {
+		t.Fatalf("Update() returned error: %v\n", err)
+	}
+	if !updated.UpdateTime.After(created.UpdateTime) {
+		t.Errorf("Update() update time %v is not after %v\n", updated.UpdateTime, created.UpdateTime)
+	}
+
+	if _, err := s.Update(ctx, "users/bob", map[string]interface{}{"age": 31}, store.Precondition{}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Update() of missing document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+
+	if _, err := s.Set(ctx, "users/alice/orders/1", map[string]interface{}{"total": 10}); err != nil {
+		t.Fatalf("Set() returned error: %v\n", err)
+	}
+
+	if _, err := s.Delete(ctx, "users/alice", store.Precondition{UpdateTime: created.UpdateTime}); !errors.Is(err, store.ErrFailedPrecondition) {
+		t.Errorf("Delete() with stale update time returned error %v, want %v\n", err, store.ErrFailedPrecondition)
+	}
+
+	if _, err := s.Delete(ctx, "users/alice", store.Precondition{Exists: true}); err != nil {
+		t.Fatalf("Delete() returned error: %v\n", err)
+	}
+
+	if _, err := s.Delete(ctx, "users/alice", store.Precondition{Exists: true}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Delete() of missing document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+
+	if _, err := s.Delete(ctx, "users/alice", store.Precondition{}); err != nil {
+		t.Errorf("Delete() of missing document without precondition returned error: %v\n", err)
+	}
+
+	if _, err := s.Get(ctx, "users/alice/orders/1"); err != nil {
+		t.Errorf("Get() of subcollection document of deleted document returned error: %v\n", err)
+	}
+}
+
+func TestSetInvalidValues(t *testing.T) {
+	tests := []struct {
+		desc string
+		data map[string]interface{}
+	}{
+		{
+			desc: "nested arrays",
+			data: map[string]interface{}{"a": []interface{}{[]interface{}{1}}},
+		}, {
+			desc: "uint64 overflow",
+			data: map[string]interface{}{"a": uint64(math.MaxUint64)},
+		}, {
+			desc: "sentinel",
+			data: map[string]interface{}{"a": protofirestore.Delete},
+		}, {
+			desc: "unsupported type",
+			data: map[string]interface{}{"a": struct{}{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := memstore.New().Set(context.Background(), "a/b", tt.data); err == nil {
+				t.Errorf("Set() got nil error, want error\n")
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	document := map[string]interface{}{
+		"name": "alice",
+		"tags": []interface{}{"a", "b", "a"},
+		"nested": map[string]interface{}{
+			"count": int64(1),
+			"x.y":   true,
+		},
+		"big":   int64(math.MaxInt64 - 1),
+		"score": float64(1),
+	}
+
+	tests := []struct {
+		desc    string
+		updates map[string]interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			desc:    "set nested values",
+			updates: map[string]interface{}{"nested.count": int32(2), "new.deep.value": "x"},
+			want: map[string]interface{}{
+				"nested.count":   int64(2),
+				"new.deep.value": "x",
+			},
+		}, {
+			desc:    "delete",
+			updates: map[string]interface{}{"name": protofirestore.Delete, "nested.`x.y`": protofirestore.Delete, "missing.key": protofirestore.Delete},
+			want: map[string]interface{}{
+				"name":         nil,
+				"nested.`x.y`": nil,
+			},
+		}, {
+			desc: "array union and remove",
+			updates: map[string]interface{}{
+				"tags":    protofirestore.ArrayRemove{Elems: []interface{}{"a"}},
+				"newTags": protofirestore.ArrayUnion{Elems: []interface{}{"x", "x", "y"}},
+				"name":    protofirestore.ArrayUnion{Elems: []interface{}{"z"}},
+			},
+			want: map[string]interface{}{
+				"tags":    []interface{}{"b"},
+				"newTags": []interface{}{"x", "y"},
+				"name":    []interface{}{"z"},
+			},
+		}, {
+			desc: "increment",
+			updates: map[string]interface{}{
+				"nested.count": protofirestore.Increment{N: int32(2)},
+				"big":          protofirestore.Increment{N: int64(5)},
+				"score":        protofirestore.Increment{N: int64(2)},
+				"name":         protofirestore.Increment{N: float32(0.5)},
+				"newCount":     protofirestore.Increment{N: uint32(7)},
+			},
+			want: map[string]interface{}{
+				"nested.count": int64(3),
+				"big":          int64(math.MaxInt64),
+				"score":        float64(3),
+				"name":         float64(0.5),
+				"newCount":     int64(7),
+			},
+		}, {
+			desc:    "conflicting paths",
+			updates: map[string]interface{}{"nested": map[string]interface{}{}, "nested.count": 1},
+			wantErr: true,
+		}, {
+			desc:    "invalid path",
+			updates: map[string]interface{}{"nested.": 1},
+			wantErr: true,
+		}, {
+			desc:    "non-numeric increment",
+			updates: map[string]interface{}{"name": protofirestore.Increment{N: "1"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx := context.Background()
+			s := memstore.New()
+
+			if _, err := s.Set(ctx, "users/alice", document); err != nil {
+				t.Fatalf("Set() returned error: %v\n", err)
+			}
+
+			_, err := s.Update(ctx, "users/alice", tt.updates, store.Precondition{})
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("Update() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("Update() got nil error, want error\n")
+			}
+
+			if err != nil {
+				return
+			}
+
+			got, err := s.Get(ctx, "users/alice")
+			if err != nil {
+				t.Fatalf("Get() returned error: %v\n", err)
+			}
+
+			want := protofirestore.Flatten(document)
+			for k, v := range tt.want {
+				if v == nil {
+					delete(want, k)
+				} else {
+					want[k] = v
+				}
+			}
+
+			if diff := deep.Equal(protofirestore.Flatten(got.Data), want); diff != nil {
+				t.Errorf("Update() mismatch (-want +got):\n%v\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/query"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+// Query implements store.Store.
+//
+// Filters only match documents which have a value at the filtered path,
+// which includes NotEqual and NotIn. Range filters only match values of the
+// same type as the filter value, and never NaN. Documents are ordered by
+// q.Orders followed by the paths of inequality filters which are not
+// ordered explicitly, and lastly by their path in the direction of the last
+// order. Documents without a value at an ordered path are excluded.
+func (s *Store) Query(ctx context.Context, q store.Query) ([]*store.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.query(q)
+}
+
+// query runs q. The caller must hold s.mu.
+func (s *Store) query(q store.Query) ([]*store.Snapshot, error) {
+	if q.CollectionID == "" || strings.Contains(q.CollectionID, "/") {
+		return nil, fmt.Errorf("invalid collection ID %q", q.CollectionID)
+	}
+	if q.Parent != "" {
+		if err := checkDocumentPath(q.Parent); err != nil {
+			return nil, err
+		}
+	}
+
+	match, err := compileFilter(q.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := compileOrders(q.Orders, q.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*result
+	for path, snapshot := range s.documents {
+		if !inCollection(q, path) || !match(snapshot.Data) {
+			continue
+		}
+
+		r := &result{snapshot: snapshot, values: make([]interface{}, len(orders))}
+		ordered := true
+		for i, o := range orders {
+			if r.values[i], ordered = lookup(snapshot.Data, o.segments); !ordered {
+				break
+			}
+		}
+		if ordered {
+			results = append(results, r)
+		}
+	}
+
+	descending := len(orders) != 0 && orders[len(orders)-1].descending
+	compareResults := func(a, b *result) int {
+		for i, o := range orders {
+			if c := o.compare(a.values[i], b.values[i]); c != 0 {
+				return c
+			}
+		}
+		return comparePaths(a.snapshot.Path, b.snapshot.Path, descending)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return compareResults(results[i], results[j]) < 0
+	})
+
+	var start int
+	if q.StartAfter != nil {
+		cursor, err := compileCursor(q, orders, *q.StartAfter)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(results), func(i int) bool {
+			return cursor.before(results[i])
+		})
+	}
+
+	var snapshots []*store.Snapshot
+	for _, r := range results[start:] {
+		if q.Limit > 0 && len(snapshots) == q.Limit {
+			break
+		}
+		snapshots = append(snapshots, snapshot(r.snapshot))
+	}
+
+	return snapshots, nil
+}
+
+// result is a document matched by a query along with its values at the
+// ordered paths.
+type result struct {
+	snapshot *store.Snapshot
+	values   []interface{}
+}
+
+// inCollection reports whether the document at path belongs to the
+// collection selected by q.
+func inCollection(q store.Query, path string) bool {
+	if q.Parent != "" {
+		if !strings.HasPrefix(path, q.Parent+"/") {
+			return false
+		}
+		path = path[len(q.Parent)+1:]
+	}
+
+	segments := strings.Split(path, "/")
+	if !q.AllDescendants && len(segments) != 2 {
+		return false
+	}
+	return segments[len(segments)-2] == q.CollectionID
+}
+
+// comparePaths compares document paths segment by segment.
+func comparePaths(a, b string, descending bool) int {
+	sa, sb := strings.Split(a, "/"), strings.Split(b, "/")
+
+	c := 0
+	for i := 0; i < len(sa) && i < len(sb) && c == 0; i++ {
+		c = strings.Compare(sa[i], sb[i])
+	}
+	if c == 0 {
+		c = compareInts(int64(len(sa)), int64(len(sb)))
+	}
+
+	if descending {
+		return -c
+	}
+	return c
+}
+
+// order is an order of a query with its parsed path.
+type order struct {
+	segments   []string
+	descending bool
+}
+
+func (o order) compare(a, b interface{}) int {
+	if o.descending {
+		return compare(b, a)
+	}
+	return compare(a, b)
+}
+
+// compileOrders returns the orders of a query, which are followed by the
+// paths of inequality filters which are not ordered explicitly.
+func compileOrders(orders []query.Order, filter query.Filter) ([]order, error) {
+	var compiled []order
+
+	ordered := make(map[string]bool)
+	for _, o := range orders {
+		segments, err := protofirestore.SplitFieldPath(o.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch o.Direction {
+		case query.Ascending, query.Descending:
+		default:
+			return nil, fmt.Errorf("invalid direction %q of order by %v", o.Direction, o.Path)
+		}
+
+		compiled = append(compiled, order{segments: segments, descending: o.Direction == query.Descending})
+		ordered[protofirestore.JoinFieldPath(segments...)] = true
+	}
+
+	var implicit []string
+	for _, path := range inequalityPaths(filter) {
+		segments, err := protofirestore.SplitFieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		path = protofirestore.JoinFieldPath(segments...)
+		if !ordered[path] {
+			ordered[path] = true
+			implicit = append(implicit, path)
+		}
+	}
+	sort.Strings(implicit)
+
+	for _, path := range implicit {
+		segments, _ := protofirestore.SplitFieldPath(path)
+		compiled = append(compiled, order{segments: segments})
+	}
+
+	return compiled, nil
+}
+
+// inequalityPaths returns the paths of the inequality filters of filter.
+func inequalityPaths(filter query.Filter) []string {
+	switch f := filter.(type) {
+	case query.FieldFilter:
+		switch f.Operator {
+		case query.NotEqual, query.LessThan, query.LessThanOrEqual, query.GreaterThan, query.GreaterThanOrEqual, query.NotIn:
+			return []string{f.Path}
+		}
+	case query.CompositeFilter:
+		var paths []string
+		for _, filter := range f.Filters {
+			paths = append(paths, inequalityPaths(filter)...)
+		}
+		return paths
+	}
+	return nil
+}
+
+// cursor is a compiled query.Cursor.
+type cursor struct {
+	orders     []order
+	values     []interface{}
+	path       string
+	descending bool
+}
+
+// compileCursor compiles the cursor c of q. The cursor may have fewer values
+// than the query has orders, but then it cannot have a document ID.
+func compileCursor(q store.Query, orders []order, c query.Cursor) (*cursor, error) {
+	if len(c.Values) > len(orders) {
+		return nil, fmt.Errorf("cursor has %d values for %d orders", len(c.Values), len(orders))
+	}
+	if c.DocumentID != "" && len(c.Values) != len(orders) {
+		return nil, fmt.Errorf("cursor with a document ID has %d values for %d orders", len(c.Values), len(orders))
+	}
+
+	values := make([]interface{}, len(c.Values))
+	for i, v := range c.Values {
+		n, err := normalize(v)
+		if err != nil {
+			return nil, fmt.Errorf("cursor value %d: %w", i, err)
+		}
+		values[i] = n
+	}
+
+	compiled := &cursor{
+		orders:     orders[:len(values)],
+		values:     values,
+		descending: len(orders) != 0 && orders[len(orders)-1].descending,
+	}
+
+	if c.DocumentID != "" {
+		if q.AllDescendants {
+			compiled.path = store.CollectionPath(q.Parent, c.DocumentID)
+		} else {
+			compiled.path = store.CollectionPath(store.CollectionPath(q.Parent, q.CollectionID), c.DocumentID)
+		}
+	}
+
+	return compiled, nil
+}
+
+// before reports whether the cursor position comes before r.
+func (c *cursor) before(r *result) bool {
+	for i, o := range c.orders {
+		if cmp := o.compare(c.values[i], r.values[i]); cmp != 0 {
+			return cmp < 0
+		}
+	}
+	if c.path == "" {
+		return false
+	}
+	return comparePaths(c.path, r.snapshot.Path, c.descending) < 0
+}
+
+// compileFilter returns a function reporting whether a document matches
+// filter.
+func compileFilter(filter query.Filter) (func(map[string]interface{}) bool, error) {
+	switch f := filter.(type) {
+	case nil:
+		return func(map[string]interface{}) bool { return true }, nil
+
+	case query.FieldFilter:
+		return compileFieldFilter(f)
+
+	case query.CompositeFilter:
+		if len(f.Filters) == 0 {
+			return nil, fmt.Errorf("composite filter has no filters")
+		}
+
+		matches := make([]func(map[string]interface{}) bool, len(f.Filters))
+		for i, filter := range f.Filters {
+			match, err := compileFilter(filter)
+			if err != nil {
+				return nil, err
+			}
+			matches[i] = match
+		}
+
+		switch f.Operator {
+		case query.And:
+			return func(data map[string]interface{}) bool {
+				for _, match := range matches {
+					if !match(data) {
+						return false
+					}
+				}
+				return true
+			}, nil
+		case query.Or:
+			return func(data map[string]interface{}) bool {
+				for _, match := range matches {
+					if match(data) {
+						return true
+					}
+				}
+				return false
+			}, nil
+		}
+		return nil, fmt.Errorf("invalid composite operator %q", f.Operator)
+
+	default:
+		return nil, fmt.Errorf("unsupported filter %T", filter)
+	}
+}
+
+func compileFieldFilter(f query.FieldFilter) (func(map[string]interface{}) bool, error) {
+	segments, err := protofirestore.SplitFieldPath(f.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := normalize(f.Value)
+	if err != nil {
+		return nil, fmt.Errorf("filter on %v: %w", f.Path, err)
+	}
+
+	var match func(v interface{}) bool
+
+	switch f.Operator {
+	case query.Equal:
+		match = func(v interface{}) bool { return compare(v, value) == 0 }
+
+	case query.NotEqual:
+		match = func(v interface{}) bool { return compare(v, value) != 0 }
+
+	case query.LessThan, query.LessThanOrEqual, query.GreaterThan, query.GreaterThanOrEqual:
+		if value == nil || isNaN(value) {
+			return nil, fmt.Errorf("filter on %v: %v cannot be compared with %v", f.Path, f.Operator, value)
+		}
+
+		var ok func(int) bool
+		switch f.Operator {
+		case query.LessThan:
+			ok = func(c int) bool { return c < 0 }
+		case query.LessThanOrEqual:
+			ok = func(c int) bool { return c <= 0 }
+		case query.GreaterThan:
+			ok = func(c int) bool { return c > 0 }
+		default:
+			ok = func(c int) bool { return c >= 0 }
+		}
+
+		match = func(v interface{}) bool {
+			return rank(v) == rank(value) && !isNaN(v) && ok(compare(v, value))
+		}
+
+	case query.ArrayContains:
+		match = func(v interface{}) bool {
+			array, ok := v.([]interface{})
+			return ok && contains(array, value)
+		}
+
+	case query.ArrayContainsAny, query.In, query.NotIn:
+		values, ok := value.([]interface{})
+		if !ok || len(values) == 0 {
+			return nil, fmt.Errorf("filter on %v: %v needs a non-empty array, got %v", f.Path, f.Operator, f.Value)
+		}
+
+		switch f.Operator {
+		case query.ArrayContainsAny:
+			match = func(v interface{}) bool {
+				array, ok := v.([]interface{})
+				if !ok {
+					return false
+				}
+				for _, e := range values {
+					if contains(array, e) {
+						return true
+					}
+				}
+				return false
+			}
+		case query.In:
+			match = func(v interface{}) bool { return contains(values, v) }
+		default:
+			match = func(v interface{}) bool { return !contains(values, v) }
+		}
+
+	default:
+		return nil, fmt.Errorf("filter on %v: invalid operator %q", f.Path, f.Operator)
+	}
+
+	return func(data map[string]interface{}) bool {
+		v, ok := lookup(data, segments)
+		return ok && match(v)
+	}, nil
+}
@@ -0,0 +1,269 @@
+package memstore_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/daviddomkar/protofirestore"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/daviddomkar/protofirestore/memstore"
+	"github.com/daviddomkar/protofirestore/query"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	messages := map[string]proto.Message{
+		"scalars/a": &pb3.Scalars{SInt32: 1, SString: "a", SDouble: 2.5, SBool: true},
+		"scalars/b": &pb3.Scalars{SInt32: 2, SString: "b", SUint64: math.MaxUint32 + 1},
+		"scalars/c": &pb3.Scalars{SInt32: 3, SString: "c", SFloat: 1.5},
+		"scalars/d": &pb3.Scalars{SString: "d", SDouble: 2},
+		"scalars/e": &pb3.Repeats{RptString: []string{"x", "y"}, RptInt32: []int32{1, 2}},
+		"scalars/f": &pb3.Repeats{RptString: []string{"z"}},
+
+		"scalars/a/scalars/g": &pb3.Scalars{SInt32: 4, SString: "g"},
+		"other/a/scalars/h":   &pb3.Scalars{SInt32: 5, SString: "h"},
+		"other/b":             &pb3.Scalars{SInt32: 6, SString: "i"},
+	}
+
+	for path, m := range messages {
+		data, err := protofirestore.MarshalOptions{EmitFirestoreSensibleDefaults: true}.Marshal(m)
+		if err != nil {
+			t.Fatalf("Marshal() returned error: %v\n", err)
+		}
+		if _, err := s.Set(ctx, path, data); err != nil {
+			t.Fatalf("Set() returned error: %v\n", err)
+		}
+	}
+
+	scalars := store.Query{CollectionID: "scalars"}
+	with := func(q store.Query, f func(*store.Query)) store.Query {
+		f(&q)
+		return q
+	}
+	filter := func(path string, op query.Operator, value interface{}) store.Query {
+		return with(scalars, func(q *store.Query) { q.Filter = query.FieldFilter{Path: path, Operator: op, Value: value} })
+	}
+
+	tests := []struct {
+		desc    string
+		query   store.Query
+		want    []string
+		wantErr bool
+	}{
+		{
+			desc:  "root collection",
+			query: scalars,
+			want:  []string{"scalars/a", "scalars/b", "scalars/c", "scalars/d", "scalars/e", "scalars/f"},
+		}, {
+			desc:  "subcollection",
+			query: store.Query{Parent: "scalars/a", CollectionID: "scalars"},
+			want:  []string{"scalars/a/scalars/g"},
+		}, {
+			desc:  "collection group",
+			query: with(scalars, func(q *store.Query) { q.AllDescendants = true; q.Limit = 7 }),
+			want:  []string{"other/a/scalars/h", "scalars/a", "scalars/a/scalars/g", "scalars/b", "scalars/c", "scalars/d", "scalars/e"},
+		}, {
+			desc:  "equal",
+			query: filter("sString", query.Equal, "b"),
+			want:  []string{"scalars/b"},
+		}, {
+			desc:  "equal integer and float",
+			query: filter("sDouble", query.Equal, int64(2)),
+			want:  []string{"scalars/d"},
+		}, {
+			desc:  "equal uint64",
+			query: filter("sUint64", query.Equal, uint64(math.MaxUint32+1)),
+			want:  []string{"scalars/b"},
+		}, {
+			desc:  "not equal excludes missing fields",
+			query: filter("sString", query.NotEqual, "b"),
+			want:  []string{"scalars/a", "scalars/c", "scalars/d"},
+		}, {
+			desc:  "range is ordered by the filtered field",
+			query: filter("sDouble", query.GreaterThanOrEqual, 0.5),
+			want:  []string{"scalars/d", "scalars/a"},
+		}, {
+			desc:  "range only matches the type of the value",
+			query: filter("sString", query.GreaterThan, 1),
+			want:  nil,
+		}, {
+			desc:  "in",
+			query: filter("sInt32", query.In, []interface{}{int32(1), int64(3), "2"}),
+			want:  []string{"scalars/a", "scalars/c"},
+		}, {
+			desc:  "not in",
+			query: filter("sInt32", query.NotIn, []interface{}{int32(0), int32(1)}),
+			want:  []string{"scalars/b", "scalars/c"},
+		}, {
+			desc:  "array contains",
+			query: filter("rptString", query.ArrayContains, "y"),
+			want:  []string{"scalars/e"},
+		}, {
+			desc:  "array contains any",
+			query: filter("rptString", query.ArrayContainsAny, []interface{}{"x", "z"}),
+			want:  []string{"scalars/e", "scalars/f"},
+		}, {
+			desc: "or",
+			query: with(scalars, func(q *store.Query) {
+				q.Filter = query.CompositeFilter{Operator: query.Or, Filters: []query.Filter{
+					query.FieldFilter{Path: "sBool", Operator: query.Equal, Value: true},
+					query.FieldFilter{Path: "sInt32", Operator: query.Equal, Value: int32(3)},
+				}}
+			}),
+			want: []string{"scalars/a", "scalars/c"},
+		}, {
+			desc: "and",
+			query: with(scalars, func(q *store.Query) {
+				q.Filter = query.CompositeFilter{Operator: query.And, Filters: []query.Filter{
+					query.FieldFilter{Path: "sInt32", Operator: query.LessThan, Value: int32(3)},
+					query.FieldFilter{Path: "sString", Operator: query.GreaterThan, Value: "a"},
+				}}
+			}),
+			want: []string{"scalars/d", "scalars/b"},
+		}, {
+			desc: "descending order",
+			query: with(scalars, func(q *store.Query) {
+				q.Orders = []query.Order{{Path: "sBool", Direction: query.Ascending}, {Path: "sInt32", Direction: query.Descending}}
+			}),
+			want: []string{"scalars/c", "scalars/b", "scalars/d", "scalars/a"},
+		}, {
+			desc: "cursor",
+			query: with(scalars, func(q *store.Query) {
+				q.Orders = []query.Order{{Path: "sBool", Direction: query.Ascending}}
+				q.StartAfter = &query.Cursor{Values: []interface{}{false}, DocumentID: "c"}
+				q.Limit = 2
+			}),
+			want: []string{"scalars/d", "scalars/a"},
+		}, {
+			desc: "cursor without document ID",
+			query: with(scalars, func(q *store.Query) {
+				q.Orders = []query.Order{{Path: "sInt32", Direction: query.Descending}}
+				q.StartAfter = &query.Cursor{Values: []interface{}{int32(2)}}
+			}),
+			want: []string{"scalars/a", "scalars/d"},
+		}, {
+			desc: "collection group cursor",
+			query: with(scalars, func(q *store.Query) {
+				q.AllDescendants = true
+				q.StartAfter = &query.Cursor{DocumentID: "scalars/a/scalars/g"}
+				q.Limit = 1
+			}),
+			want: []string{"scalars/b"},
+		}, {
+			desc: "cursor with too many values",
+			query: with(scalars, func(q *store.Query) {
+				q.StartAfter = &query.Cursor{Values: []interface{}{1}, DocumentID: "a"}
+			}),
+			wantErr: true,
+		}, {
+			desc:    "range on null",
+			query:   filter("sInt32", query.LessThan, nil),
+			wantErr: true,
+		}, {
+			desc:    "in without array",
+			query:   filter("sInt32", query.In, int32(1)),
+			wantErr: true,
+		}, {
+			desc:    "invalid collection",
+			query:   store.Query{CollectionID: "scalars/a"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			snapshots, err := s.Query(ctx, tt.query)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("Query() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("Query() got nil error, want error\n")
+			}
+
+			var got []string
+			for _, snapshot := range snapshots {
+				got = append(got, snapshot.Path)
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("Query() mismatch (-want +got):\n%v\n", diff)
+			}
+		})
+	}
+}
+
+func TestQueryValueOrder(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	// The values in firestore's order, one document each.
+	values := []interface{}{
+		nil,
+		false,
+		true,
+		math.NaN(),
+		math.Inf(-1),
+		int64(math.MinInt64),
+		-1.5,
+		int32(-1),
+		int64(0),
+		0.5,
+		uint64(1),
+		float32(1.5),
+		math.Inf(1),
+		time.Unix(0, 0),
+		time.Unix(1, 0),
+		"",
+		"B",
+		"a",
+		"é",
+		[]byte{},
+		[]byte{0},
+		[]byte{1},
+		[]interface{}{},
+		[]interface{}{int64(1)},
+		[]interface{}{int64(1), "a"},
+		[]interface{}{int64(2)},
+		map[string]interface{}{},
+		map[string]interface{}{"a": int64(2)},
+		map[string]interface{}{"a": int64(2), "b": int64(1)},
+		map[string]interface{}{"b": int64(0)},
+	}
+
+	// IDs in reverse order make sure documents are not ordered by ID.
+	var want []string
+	for i, v := range values {
+		path := "values/" + string(rune('z'-i))
+		if _, err := s.Set(ctx, path, map[string]interface{}{"v": v}); err != nil {
+			t.Fatalf("Set() returned error: %v\n", err)
+		}
+		want = append(want, path)
+	}
+
+	snapshots, err := s.Query(ctx, store.Query{
+		CollectionID: "values",
+		Orders:       []query.Order{{Path: "v", Direction: query.Ascending}},
+	})
+	if err != nil {
+		t.Fatalf("Query() returned error: %v\n", err)
+	}
+
+	var got []string
+	for _, snapshot := range snapshots {
+		got = append(got, snapshot.Path)
+	}
+
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Errorf("Query() mismatch (-want +got):\n%v\n", diff)
+	}
+}
@@ -0,0 +1,151 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+// MaxAttempts is the number of times RunTransaction runs a transaction
+// before giving up, which is the default of the firestore client library.
+const MaxAttempts = 5
+
+// errConflict reports that a document read by a transaction was written
+// before it committed.
+var errConflict = errors.New("conflict")
+
+// RunTransaction implements store.Transactor. Transactions are optimistic:
+// they read without locking and fail to commit if a document they read has
+// been written since, in which case they are run again. Documents which
+// would have been added to the results of queries are not detected.
+func (s *Store) RunTransaction(ctx context.Context, f func(ctx context.Context, tx store.Tx) error) error {
+	for attempt := 0; attempt < MaxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		t := &tx{store: s, reads: make(map[string]time.Time)}
+		if err := f(ctx, t); err != nil {
+			return err
+		}
+
+		err := t.commit()
+		if !errors.Is(err, errConflict) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w after %d attempts", store.ErrAborted, MaxAttempts)
+}
+
+// tx is a store.Tx of a Store.
+type tx struct {
+	store *Store
+
+	// reads are the update times of the documents read, which are zero for
+	// documents which did not exist.
+	reads  map[string]time.Time
+	writes []store.Write
+}
+
+// checkRead fails once the transaction has written.
+func (t *tx) checkRead() error {
+	if len(t.writes) != 0 {
+		return errors.New("transactions must read before writing")
+	}
+	return nil
+}
+
+// read records the update time a document was read with.
+func (t *tx) read(path string, updateTime time.Time) {
+	if _, ok := t.reads[path]; !ok {
+		t.reads[path] = updateTime
+	}
+}
+
+func (t *tx) Get(path string) (*store.Snapshot, error) {
+	if err := t.checkRead(); err != nil {
+		return nil, err
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	snapshot, err := t.store.get(path)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		t.read(path, time.Time{})
+		return nil, err
+	case err != nil:
+		return nil, err
+	}
+
+	t.read(path, snapshot.UpdateTime)
+	return snapshot, nil
+}
+
+func (t *tx) Query(q store.Query) ([]*store.Snapshot, error) {
+	if err := t.checkRead(); err != nil {
+		return nil, err
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	snapshots, err := t.store.query(q)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, snapshot := range snapshots {
+		t.read(snapshot.Path, snapshot.UpdateTime)
+	}
+	return snapshots, nil
+}
+
+func (t *tx) Create(path string, data map[string]interface{}) error {
+	t.writes = append(t.writes, store.Write{Op: store.OpCreate, Path: path, Data: data})
+	return nil
+}
+
+func (t *tx) Set(path string, data map[string]interface{}) error {
+	t.writes = append(t.writes, store.Write{Op: store.OpSet, Path: path, Data: data})
+	return nil
+}
+
+func (t *tx) Update(path string, updates map[string]interface{}, pre store.Precondition) error {
+	t.writes = append(t.writes, store.Write{Op: store.OpUpdate, Path: path, Data: updates, Precondition: pre})
+	return nil
+}
+
+func (t *tx) Delete(path string, pre store.Precondition) error {
+	t.writes = append(t.writes, store.Write{Op: store.OpDelete, Path: path, Precondition: pre})
+	return nil
+}
+
+// commit commits the writes of the transaction unless a document it read
+// has been written since.
+func (t *tx) commit() error {
+	if len(t.writes) > store.MaxBatchWrites {
+		return fmt.Errorf("%d writes exceed the limit of %d writes per commit", len(t.writes), store.MaxBatchWrites)
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	for path, updateTime := range t.reads {
+		var current time.Time
+		if snapshot, ok := t.store.documents[path]; ok {
+			current = snapshot.UpdateTime
+		}
+		if !current.Equal(updateTime) {
+			return errConflict
+		}
+	}
+
+	_, err := t.store.commit(t.writes)
+	return err
+}
@@ -0,0 +1,156 @@
+package memstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/daviddomkar/protofirestore/memstore"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+func TestRunTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("commit", func(t *testing.T) {
+		s := memstore.New()
+		if _, err := s.Set(ctx, "counters/a", map[string]interface{}{"n": 1}); err != nil {
+			t.Fatalf("Set() returned error: %v\n", err)
+		}
+
+		attempts := 0
+		err := s.RunTransaction(ctx, func(ctx context.Context, tx store.Tx) error {
+			attempts++
+
+			snapshot, err := tx.Get("counters/a")
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Get("counters/b"); !errors.Is(err, store.ErrNotFound) {
+				t.Errorf("Get() of missing document returned error %v, want %v\n", err, store.ErrNotFound)
+			}
+
+			// A concurrent write of a document read by the transaction makes
+			// the first attempt fail.
+			if attempts == 1 {
+				if _, err := s.Set(ctx, "counters/a", map[string]interface{}{"n": 2}); err != nil {
+					return err
+				}
+			}
+
+			n := snapshot.Data["n"].(int64)
+			if err := tx.Set("counters/a", map[string]interface{}{"n": n + 1}); err != nil {
+				return err
+			}
+			return tx.Create("counters/b", map[string]interface{}{"n": n})
+		})
+		if err != nil {
+			t.Fatalf("RunTransaction() returned error: %v\n", err)
+		}
+
+		if attempts != 2 {
+			t.Errorf("RunTransaction() ran %d attempts, want 2\n", attempts)
+		}
+
+		for path, want := range map[string]int64{"counters/a": 3, "counters/b": 2} {
+			snapshot, err := s.Get(ctx, path)
+			if err != nil {
+				t.Fatalf("Get() returned error: %v\n", err)
+			}
+			if got := snapshot.Data["n"]; got != want {
+				t.Errorf("%v has n = %v, want %v\n", path, got, want)
+			}
+		}
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		s := memstore.New()
+
+		attempts := 0
+		err := s.RunTransaction(ctx, func(ctx context.Context, tx store.Tx) error {
+			attempts++
+			tx.Get("counters/a")
+			if _, err := s.Set(ctx, "counters/a", nil); err != nil {
+				return err
+			}
+			return tx.Set("counters/a", nil)
+		})
+
+		if !errors.Is(err, store.ErrAborted) {
+			t.Errorf("RunTransaction() returned error %v, want %v\n", err, store.ErrAborted)
+		}
+		if attempts != memstore.MaxAttempts {
+			t.Errorf("RunTransaction() ran %d attempts, want %d\n", attempts, memstore.MaxAttempts)
+		}
+	})
+
+	t.Run("failed write", func(t *testing.T) {
+		s := memstore.New()
+		if _, err := s.Set(ctx, "counters/b", nil); err != nil {
+			t.Fatalf("Set() returned error: %v\n", err)
+		}
+
+		err := s.RunTransaction(ctx, func(ctx context.Context, tx store.Tx) error {
+			tx.Set("counters/a", nil)
+			return tx.Create("counters/b", nil)
+		})
+
+		if !errors.Is(err, store.ErrAlreadyExists) {
+			t.Errorf("RunTransaction() returned error %v, want %v\n", err, store.ErrAlreadyExists)
+		}
+		if _, err := s.Get(ctx, "counters/a"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("RunTransaction() applied writes of a failed commit\n")
+		}
+	})
+
+	t.Run("read after write", func(t *testing.T) {
+		err := memstore.New().RunTransaction(ctx, func(ctx context.Context, tx store.Tx) error {
+			tx.Set("counters/a", nil)
+			_, err := tx.Query(store.Query{CollectionID: "counters"})
+			return err
+		})
+
+		if err == nil {
+			t.Errorf("RunTransaction() got nil error, want error\n")
+		}
+	})
+}
+
+func TestCommit(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	results, err := s.Commit(ctx, []store.Write{
+		{Op: store.OpCreate, Path: "a/1", Data: map[string]interface{}{"n": 1}},
+		{Op: store.OpUpdate, Path: "a/1", Data: map[string]interface{}{"m": 2}},
+		{Op: store.OpSet, Path: "a/2"},
+	})
+	if err != nil {
+		t.Fatalf("Commit() returned error: %v\n", err)
+	}
+	if len(results) != 3 || !results[0].UpdateTime.Equal(results[2].UpdateTime) {
+		t.Errorf("Commit() = %v, want 3 results with the same update time\n", results)
+	}
+
+	_, err = s.Commit(ctx, []store.Write{
+		{Op: store.OpDelete, Path: "a/1"},
+		{Op: store.OpSet, Path: "a/3"},
+		{Op: store.OpUpdate, Path: "a/4", Data: map[string]interface{}{"n": 1}},
+	})
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Commit() returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+
+	snapshots, err := s.Query(ctx, store.Query{CollectionID: "a"})
+	if err != nil {
+		t.Fatalf("Query() returned error: %v\n", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Path != "a/1" || snapshots[1].Path != "a/2" {
+		t.Errorf("Commit() applied writes of a failed commit\n")
+	}
+
+	if _, err := s.Commit(ctx, make([]store.Write, store.MaxBatchWrites+1)); err == nil {
+		t.Errorf("Commit() of too many writes got nil error, want error\n")
+	}
+}
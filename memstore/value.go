@@ -0,0 +1,289 @@
+package memstore
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daviddomkar/protofirestore"
+)
+
+// Ranks of the value types in the order firestore sorts values of different
+// types.
+const (
+	nullRank = iota
+	boolRank
+	numberRank
+	timestampRank
+	stringRank
+	bytesRank
+	arrayRank
+	mapRank
+)
+
+// normalize converts a document value to the representation firestore reads
+// it back as: integers become int64, floats become float64 and timestamps
+// are truncated to microseconds in UTC. Maps, arrays and byte slices are
+// copied, so the stored value does not share memory with the caller.
+func normalize(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, string, int64, float64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint:
+		return normalizeUint(uint64(v))
+	case uint64:
+		return normalizeUint(v)
+	case float32:
+		return float64(v), nil
+	case []byte:
+		return append([]byte{}, v...), nil
+	case time.Time:
+		return v.UTC().Truncate(time.Microsecond), nil
+	case []interface{}:
+		array := make([]interface{}, len(v))
+		for i, e := range v {
+			if _, ok := e.([]interface{}); ok {
+				return nil, fmt.Errorf("array cannot directly contain another array")
+			}
+			n, err := normalize(e)
+			if err != nil {
+				return nil, err
+			}
+			array[i] = n
+		}
+		return array, nil
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			n, err := normalize(e)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", protofirestore.JoinFieldPath(k), err)
+			}
+			object[k] = n
+		}
+		return object, nil
+	case protofirestore.Sentinel:
+		return nil, fmt.Errorf("%v can only be used as a value of an update", v)
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+func normalizeUint(v uint64) (interface{}, error) {
+	if v > math.MaxInt64 {
+		return nil, fmt.Errorf("integer %d overflows int64", v)
+	}
+	return int64(v), nil
+}
+
+// normalizeDocument normalizes the values of a document.
+func normalizeDocument(data map[string]interface{}) (map[string]interface{}, error) {
+	if data == nil {
+		return make(map[string]interface{}), nil
+	}
+
+	v, err := normalize(data)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]interface{}), nil
+}
+
+// copyValue returns a deep copy of a normalized value.
+func copyValue(value interface{}) interface{} {
+	v, _ := normalize(value)
+	return v
+}
+
+// rank returns the rank of the type of a normalized value.
+func rank(value interface{}) int {
+	switch value.(type) {
+	case nil:
+		return nullRank
+	case bool:
+		return boolRank
+	case int64, float64:
+		return numberRank
+	case time.Time:
+		return timestampRank
+	case string:
+		return stringRank
+	case []byte:
+		return bytesRank
+	case []interface{}:
+		return arrayRank
+	default:
+		return mapRank
+	}
+}
+
+// compare compares two normalized values in firestore's order. Values of
+// different types are ordered by type. Integers and floats are compared by
+// their numeric value with NaN before all other numbers, strings and bytes
+// are compared bytewise and arrays and maps element by element.
+func compare(a, b interface{}) int {
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return compareInts(int64(ra), int64(rb))
+	}
+
+	switch a := a.(type) {
+	case nil:
+		return 0
+	case bool:
+		b := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case !a:
+			return -1
+		default:
+			return 1
+		}
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return compareInts(a, b)
+		case float64:
+			return -compareFloatInt(b, a)
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return compareFloatInt(a, b)
+		case float64:
+			return compareFloats(a, b)
+		}
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(a, b.(string))
+	case []byte:
+		return bytes.Compare(a, b.([]byte))
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(int64(len(a)), int64(len(b)))
+	case map[string]interface{}:
+		b := b.(map[string]interface{})
+		ka, kb := sortedKeys(a), sortedKeys(b)
+		for i := 0; i < len(ka) && i < len(kb); i++ {
+			if c := strings.Compare(ka[i], kb[i]); c != 0 {
+				return c
+			}
+			if c := compare(a[ka[i]], b[kb[i]]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(int64(len(ka)), int64(len(kb)))
+	}
+
+	panic(fmt.Sprintf("cannot compare %T with %T", a, b))
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareFloats compares floats like firestore, which considers NaN equal to
+// itself and smaller than all other numbers.
+func compareFloats(a, b float64) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return -1
+	case math.IsNaN(b):
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareFloatInt compares a float with an integer exactly, which converting
+// either of them would not do for large values.
+func compareFloatInt(a float64, b int64) int {
+	switch {
+	case math.IsNaN(a):
+		return -1
+	case a < math.MinInt64:
+		return -1
+	case a >= math.MaxInt64:
+		// float64(math.MaxInt64) rounds up to 2^63.
+		return 1
+	}
+
+	if c := compareInts(int64(a), b); c != 0 {
+		return c
+	}
+	return compareFloats(a-math.Trunc(a), 0)
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for k := range object {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isNaN reports whether a normalized value is a NaN float.
+func isNaN(value interface{}) bool {
+	f, ok := value.(float64)
+	return ok && math.IsNaN(f)
+}
+
+// contains reports whether the normalized array contains value.
+func contains(array []interface{}, value interface{}) bool {
+	for _, e := range array {
+		if compare(e, value) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the value at the field path segments of a document.
+func lookup(data map[string]interface{}, segments []string) (interface{}, bool) {
+	var value interface{} = data
+	for _, segment := range segments {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[segment]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
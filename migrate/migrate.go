@@ -0,0 +1,368 @@
+// Package migrate migrates stored documents between versions of their schema
+// when changing a message is not compatible with the documents written by
+// previous versions of it.
+//
+// Documents record the version of the schema they were written with under a
+// reserved key. A Migrator upgrades documents one version at a time by
+// applying the Migrations between their version and the latest one, either
+// lazily when a document is read or in batch over a collection of a
+// store.Store.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/query"
+	"github.com/daviddomkar/protofirestore/store"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DefaultVersionKey is the key the schema version is stored under unless
+// Migrator.VersionKey says otherwise.
+const DefaultVersionKey = "schemaVersion"
+
+// DefaultPageSize is the number of documents Migrator.Run reads at once
+// unless RunOptions.PageSize says otherwise.
+const DefaultPageSize = 100
+
+// Migration upgrades documents from the previous schema version to Version.
+// Exactly one of Document and Message must be set.
+type Migration struct {
+	// Version is the schema version of documents after the migration. The
+	// first migration has version 1, since documents without a version are
+	// at version 0.
+	Version int
+
+	// Description describes the migration in reports.
+	Description string
+
+	// Document transforms the document in place. The document does not
+	// contain the version key.
+	Document func(document map[string]interface{}) error
+
+	// Message transforms the document decoded into a message of the type of
+	// the Migrator, which is then encoded again.
+	Message func(m proto.Message) error
+}
+
+// Migrator applies Migrations to documents.
+type Migrator struct {
+	// Type is the message type documents are decoded into by Unmarshal and
+	// Message migrations.
+	Type protoreflect.MessageType
+
+	// Migrations are the migrations ordered by version, which must increase
+	// by one starting at 1. The version of the last migration is the latest
+	// schema version.
+	Migrations []Migration
+
+	// VersionKey is the key of the schema version in documents. If empty,
+	// this defaults to DefaultVersionKey.
+	VersionKey string
+
+	// MarshalOptions and UnmarshalOptions encode and decode documents.
+	MarshalOptions   protofirestore.MarshalOptions
+	UnmarshalOptions protofirestore.UnmarshalOptions
+}
+
+// Version returns the latest schema version.
+func (m Migrator) Version() int {
+	return len(m.Migrations)
+}
+
+func (m Migrator) versionKey() string {
+	if m.VersionKey == "" {
+		return DefaultVersionKey
+	}
+	return m.VersionKey
+}
+
+func (m Migrator) validate() error {
+	for i, migration := range m.Migrations {
+		if migration.Version != i+1 {
+			return fmt.Errorf("migration %d has version %d, want %d", i, migration.Version, i+1)
+		}
+		if (migration.Document == nil) == (migration.Message == nil) {
+			return fmt.Errorf("migration %d must set exactly one of Document and Message", migration.Version)
+		}
+		if migration.Message != nil && m.Type == nil {
+			return fmt.Errorf("migration %d migrates messages, but the migrator has no message type", migration.Version)
+		}
+	}
+	return nil
+}
+
+// VersionOf returns the schema version of the given document, which is 0 if
+// the document has no version.
+func (m Migrator) VersionOf(document map[string]interface{}) (int, error) {
+	value, ok := document[m.versionKey()]
+	if !ok {
+		return 0, nil
+	}
+
+	var version int64
+	switch v := value.(type) {
+	case int:
+		version = int64(v)
+	case int32:
+		version = int64(v)
+	case int64:
+		version = v
+	default:
+		return 0, fmt.Errorf("invalid schema version %v of type %T", value, value)
+	}
+
+	if version < 0 {
+		return 0, fmt.Errorf("invalid schema version %d", version)
+	}
+	if version > int64(m.Version()) {
+		return 0, fmt.Errorf("schema version %d is newer than the latest version %d", version, m.Version())
+	}
+
+	return int(version), nil
+}
+
+// Migrate returns the given document upgraded to the latest schema version
+// along with the version it had before. The document is not modified, and
+// returned as is if it is up to date.
+func (m Migrator) Migrate(document map[string]interface{}) (map[string]interface{}, int, error) {
+	if err := m.validate(); err != nil {
+		return nil, 0, err
+	}
+
+	from, err := m.VersionOf(document)
+	if err != nil {
+		return nil, 0, err
+	}
+	if from == m.Version() {
+		return document, from, nil
+	}
+
+	migrated := copyValue(document).(map[string]interface{})
+	delete(migrated, m.versionKey())
+
+	for _, migration := range m.Migrations[from:] {
+		if migrated, err = m.apply(migration, migrated); err != nil {
+			return nil, from, fmt.Errorf("migration %d: %w", migration.Version, err)
+		}
+	}
+
+	migrated[m.versionKey()] = int64(m.Version())
+
+	return migrated, from, nil
+}
+
+func (m Migrator) apply(migration Migration, document map[string]interface{}) (map[string]interface{}, error) {
+	if migration.Document != nil {
+		return document, migration.Document(document)
+	}
+
+	msg := m.Type.New().Interface()
+	if err := m.UnmarshalOptions.Unmarshal(document, msg); err != nil {
+		return nil, err
+	}
+
+	if err := migration.Message(msg); err != nil {
+		return nil, err
+	}
+
+	return m.MarshalOptions.Marshal(msg)
+}
+
+// Marshal encodes msg with MarshalOptions and stamps the document with the
+// latest schema version.
+func (m Migrator) Marshal(msg proto.Message) (map[string]interface{}, error) {
+	document, err := m.MarshalOptions.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	document[m.versionKey()] = int64(m.Version())
+
+	return document, nil
+}
+
+// Unmarshal upgrades the given document to the latest schema version and
+// decodes it into msg with UnmarshalOptions. It reports whether the document
+// needed to be migrated, in which case callers may want to write it back.
+func (m Migrator) Unmarshal(document map[string]interface{}, msg proto.Message) (bool, error) {
+	migrated, from, err := m.Migrate(document)
+	if err != nil {
+		return false, err
+	}
+
+	// The version key is not a field of the message, so it is decoded from a
+	// shallow copy without it.
+	fields := make(map[string]interface{}, len(migrated))
+	for k, v := range migrated {
+		if k != m.versionKey() {
+			fields[k] = v
+		}
+	}
+
+	if err := m.UnmarshalOptions.Unmarshal(fields, msg); err != nil {
+		return false, err
+	}
+
+	return from != m.Version(), nil
+}
+
+// RunOptions configures Migrator.Run.
+type RunOptions struct {
+	// DryRun reports the documents which would be migrated without writing
+	// them.
+	DryRun bool
+
+	// PageSize is the number of documents read at once. If zero, this
+	// defaults to DefaultPageSize.
+	PageSize int
+}
+
+// Report is the outcome of Migrator.Run.
+type Report struct {
+	// DryRun reports whether the documents were left unchanged.
+	DryRun bool
+
+	// Scanned is the number of documents read.
+	Scanned int
+
+	// UpToDate is the number of documents which had the latest version.
+	UpToDate int
+
+	// Migrated are the documents which were migrated, or would have been in
+	// a dry run.
+	Migrated []Result
+
+	// Failed are the documents which could not be migrated.
+	Failed []Result
+}
+
+// Result is the outcome of migrating a single document.
+type Result struct {
+	// Path is the path of the document.
+	Path string
+
+	// From and To are the schema versions of the document before and after
+	// the migration.
+	From, To int
+
+	// Updates are the updates which turn the stored document into the
+	// migrated one, keyed by firestore field paths.
+	Updates map[string]interface{}
+
+	// Err is the reason the document could not be migrated.
+	Err error
+}
+
+// Run migrates all documents selected by q to the latest schema version. The
+// orders, cursor and limit of q must be unset, since Run pages through the
+// documents in the order of their paths.
+//
+// Every migrated document is written with a precondition on the update time
+// it was read with, so documents written concurrently fail and are migrated
+// by the next run or when they are read. Failures of single documents are
+// collected in the report, while other errors of the store stop the run and
+// are returned along with the report so far.
+func (m Migrator) Run(ctx context.Context, s store.Store, q store.Query, opts RunOptions) (*Report, error) {
+	report := &Report{DryRun: opts.DryRun}
+
+	if err := m.validate(); err != nil {
+		return report, err
+	}
+
+	if len(q.Orders) != 0 || q.StartAfter != nil || q.Limit != 0 {
+		return report, errors.New("migration query cannot have orders, a cursor or a limit")
+	}
+
+	q.Limit = opts.PageSize
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+
+	for {
+		snapshots, err := s.Query(ctx, q)
+		if err != nil {
+			return report, err
+		}
+
+		for _, snapshot := range snapshots {
+			report.Scanned++
+			if err := m.migrateSnapshot(ctx, s, snapshot, report); err != nil {
+				return report, err
+			}
+		}
+
+		if len(snapshots) < q.Limit {
+			return report, nil
+		}
+
+		q.StartAfter = &query.Cursor{DocumentID: q.CursorID(snapshots[len(snapshots)-1].Path)}
+	}
+}
+
+// migrateSnapshot migrates the given document and adds the outcome to the
+// report. It only returns errors which stop the run.
+func (m Migrator) migrateSnapshot(ctx context.Context, s store.Store, snapshot *store.Snapshot, report *Report) error {
+	migrated, from, err := m.Migrate(snapshot.Data)
+	if err != nil {
+		report.Failed = append(report.Failed, Result{Path: snapshot.Path, From: from, Err: err})
+		return nil
+	}
+
+	if from == m.Version() {
+		report.UpToDate++
+		return nil
+	}
+
+	result := Result{
+		Path:    snapshot.Path,
+		From:    from,
+		To:      m.Version(),
+		Updates: protofirestore.ReplaceUpdates(snapshot.Data, migrated),
+	}
+
+	if !report.DryRun {
+		pre := store.Precondition{UpdateTime: snapshot.UpdateTime}
+		_, err := s.Update(ctx, snapshot.Path, result.Updates, pre)
+
+		switch {
+		case errors.Is(err, store.ErrFailedPrecondition), errors.Is(err, store.ErrNotFound):
+			result.Err = err
+			report.Failed = append(report.Failed, result)
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+
+	report.Migrated = append(report.Migrated, result)
+
+	return nil
+}
+
+// copyValue returns a deep copy of the given document value, so that
+// migrations do not modify the documents they are given.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			c[k] = copyValue(e)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = copyValue(e)
+		}
+		return c
+	case []byte:
+		return append([]byte(nil), v...)
+	default:
+		return v
+	}
+}
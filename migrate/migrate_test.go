@@ -0,0 +1,373 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/daviddomkar/protofirestore"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/daviddomkar/protofirestore/migrate"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+// renameMigrator renames the key "name" to "sString" and then doubles sInt32
+// of pb3.Scalars documents.
+var renameMigrator = migrate.Migrator{
+	Type: (&pb3.Scalars{}).ProtoReflect().Type(),
+	Migrations: []migrate.Migration{
+		{
+			Version:     1,
+			Description: "rename name to sString",
+			Document: func(document map[string]interface{}) error {
+				if name, ok := document["name"]; ok {
+					document["sString"] = name
+					delete(document, "name")
+				}
+				return nil
+			},
+		}, {
+			Version:     2,
+			Description: "double sInt32",
+			Message: func(m proto.Message) error {
+				s := m.(*pb3.Scalars)
+				if s.SInt32 < 0 {
+					return errors.New("negative sInt32")
+				}
+				s.SInt32 *= 2
+				return nil
+			},
+		},
+	},
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		desc     string
+		migrator migrate.Migrator
+		input    map[string]interface{}
+		want     map[string]interface{}
+		wantFrom int
+		wantErr  bool
+	}{
+		{
+			desc:     "document without version",
+			migrator: renameMigrator,
+			input:    map[string]interface{}{"name": "a", "sInt32": int64(2)},
+			want:     map[string]interface{}{"sString": "a", "sInt32": int32(4), "schemaVersion": int64(2)},
+		}, {
+			desc:     "document at intermediate version",
+			migrator: renameMigrator,
+			input:    map[string]interface{}{"sString": "a", "sInt32": int64(2), "schemaVersion": int64(1)},
+			want:     map[string]interface{}{"sString": "a", "sInt32": int32(4), "schemaVersion": int64(2)},
+			wantFrom: 1,
+		}, {
+			desc:     "unknown key in message migration",
+			migrator: renameMigrator,
+			input:    map[string]interface{}{"name": "a", "schemaVersion": int64(1)},
+			wantErr:  true,
+		}, {
+			desc:     "up to date document",
+			migrator: renameMigrator,
+			input:    map[string]interface{}{"name": "a", "schemaVersion": int64(2)},
+			want:     map[string]interface{}{"name": "a", "schemaVersion": int64(2)},
+			wantFrom: 2,
+		}, {
+			desc:     "custom version key",
+			migrator: migrate.Migrator{VersionKey: "v", Migrations: renameMigrator.Migrations[:1]},
+			input:    map[string]interface{}{"name": "a"},
+			want:     map[string]interface{}{"sString": "a", "v": int64(1)},
+		}, {
+			desc:     "failing migration",
+			migrator: renameMigrator,
+			input:    map[string]interface{}{"sInt32": int64(-1)},
+			wantErr:  true,
+		}, {
+			desc:     "newer version",
+			migrator: renameMigrator,
+			input:    map[string]interface{}{"schemaVersion": int64(3)},
+			wantErr:  true,
+		}, {
+			desc:     "invalid version",
+			migrator: renameMigrator,
+			input:    map[string]interface{}{"schemaVersion": "1"},
+			wantErr:  true,
+		}, {
+			desc: "version gap",
+			migrator: migrate.Migrator{Migrations: []migrate.Migration{
+				{Version: 2, Document: func(map[string]interface{}) error { return nil }},
+			}},
+			input:   map[string]interface{}{},
+			wantErr: true,
+		}, {
+			desc: "message migration without type",
+			migrator: migrate.Migrator{Migrations: []migrate.Migration{
+				{Version: 1, Message: func(proto.Message) error { return nil }},
+			}},
+			input:   map[string]interface{}{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			input := fmt.Sprint(tt.input)
+
+			got, from, err := tt.migrator.Migrate(tt.input)
+
+			if fmt.Sprint(tt.input) != input {
+				t.Errorf("Migrate() modified its input to %v, was %v\n", tt.input, input)
+			}
+
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("Migrate() returned error: %v\n", err)
+				}
+				return
+			}
+
+			if tt.wantErr {
+				t.Errorf("Migrate() got nil error, want error\n")
+			}
+
+			if from != tt.wantFrom {
+				t.Errorf("Migrate() from = %d, want %d\n", from, tt.wantFrom)
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("Migrate() mismatch (-want +got):\n%v\n", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	got := &pb3.Scalars{}
+	migrated, err := renameMigrator.Unmarshal(map[string]interface{}{"name": "a", "sInt32": int64(2)}, got)
+	if err != nil {
+		t.Fatalf("Unmarshal() returned error: %v\n", err)
+	}
+
+	if !migrated {
+		t.Errorf("Unmarshal() = false, want true\n")
+	}
+
+	want := &pb3.Scalars{SString: "a", SInt32: 4}
+	if !proto.Equal(got, want) {
+		t.Errorf("Unmarshal() = %v, want %v\n", got, want)
+	}
+
+	document, err := renameMigrator.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() returned error: %v\n", err)
+	}
+
+	migrated, err = renameMigrator.Unmarshal(document, got)
+	if err != nil {
+		t.Fatalf("Unmarshal() returned error: %v\n", err)
+	}
+
+	if migrated {
+		t.Errorf("Unmarshal() of marshaled message = true, want false\n")
+	}
+}
+
+func TestRun(t *testing.T) {
+	newStore := func() *fakeStore {
+		return &fakeStore{documents: map[string]*store.Snapshot{
+			"scalars/a":          {Path: "scalars/a", Data: map[string]interface{}{"name": "a", "sInt32": int64(1)}},
+			"scalars/b":          {Path: "scalars/b", Data: map[string]interface{}{"sString": "b", "schemaVersion": int64(2)}},
+			"scalars/c":          {Path: "scalars/c", Data: map[string]interface{}{"sInt32": int64(-1)}},
+			"scalars/d":          {Path: "scalars/d", Data: map[string]interface{}{"name": "d"}},
+			"scalars/d/nested/e": {Path: "scalars/d/nested/e", Data: map[string]interface{}{"name": "e"}},
+			"others/f":           {Path: "others/f", Data: map[string]interface{}{"name": "f"}},
+		}}
+	}
+
+	q := store.Query{CollectionID: "scalars"}
+	opts := migrate.RunOptions{PageSize: 2}
+
+	t.Run("dry run", func(t *testing.T) {
+		s := newStore()
+		report, err := renameMigrator.Run(context.Background(), s, q, migrate.RunOptions{DryRun: true, PageSize: 2})
+		if err != nil {
+			t.Fatalf("Run() returned error: %v\n", err)
+		}
+
+		want := &migrate.Report{
+			DryRun:   true,
+			Scanned:  4,
+			UpToDate: 1,
+			Migrated: []migrate.Result{
+				{
+					Path: "scalars/a",
+					To:   2,
+					Updates: map[string]interface{}{
+						"name":          protofirestore.Delete,
+						"sString":       "a",
+						"sInt32":        int32(2),
+						"schemaVersion": int64(2),
+					},
+				}, {
+					Path: "scalars/d",
+					To:   2,
+					Updates: map[string]interface{}{
+						"name":          protofirestore.Delete,
+						"sString":       "d",
+						"schemaVersion": int64(2),
+					},
+				},
+			},
+		}
+
+		if len(report.Failed) != 1 || report.Failed[0].Path != "scalars/c" || report.Failed[0].Err == nil {
+			t.Errorf("Run() failed = %v, want scalars/c\n", report.Failed)
+		}
+		report.Failed = nil
+
+		if diff := deep.Equal(report, want); diff != nil {
+			t.Errorf("Run() mismatch (-want +got):\n%v\n", diff)
+		}
+
+		if s.writes != 0 {
+			t.Errorf("Run() wrote %d documents in a dry run\n", s.writes)
+		}
+	})
+
+	t.Run("run", func(t *testing.T) {
+		s := newStore()
+		report, err := renameMigrator.Run(context.Background(), s, q, opts)
+		if err != nil {
+			t.Fatalf("Run() returned error: %v\n", err)
+		}
+
+		if len(report.Migrated) != 2 || len(report.Failed) != 1 {
+			t.Errorf("Run() migrated %d and failed %d documents, want 2 and 1\n", len(report.Migrated), len(report.Failed))
+		}
+
+		want := map[string]interface{}{"sString": "a", "sInt32": int32(2), "schemaVersion": int64(2)}
+		if diff := deep.Equal(s.documents["scalars/a"].Data, want); diff != nil {
+			t.Errorf("Run() mismatch (-want +got):\n%v\n", diff)
+		}
+
+		report, err = renameMigrator.Run(context.Background(), s, q, opts)
+		if err != nil {
+			t.Fatalf("Run() returned error: %v\n", err)
+		}
+
+		if report.UpToDate != 3 || len(report.Migrated) != 0 {
+			t.Errorf("second Run() = %+v, want 3 up to date documents\n", report)
+		}
+	})
+
+	t.Run("concurrent write", func(t *testing.T) {
+		s := newStore()
+		s.stale = true
+		report, err := renameMigrator.Run(context.Background(), s, q, opts)
+		if err != nil {
+			t.Fatalf("Run() returned error: %v\n", err)
+		}
+
+		if len(report.Migrated) != 0 || len(report.Failed) != 3 {
+			t.Errorf("Run() migrated %d and failed %d documents, want 0 and 3\n", len(report.Migrated), len(report.Failed))
+		}
+
+		for _, result := range report.Failed {
+			if result.Path != "scalars/c" && !errors.Is(result.Err, store.ErrFailedPrecondition) {
+				t.Errorf("Run() error of %v = %v, want %v\n", result.Path, result.Err, store.ErrFailedPrecondition)
+			}
+		}
+	})
+
+	t.Run("query with limit", func(t *testing.T) {
+		if _, err := renameMigrator.Run(context.Background(), newStore(), store.Query{CollectionID: "scalars", Limit: 1}, opts); err == nil {
+			t.Errorf("Run() got nil error, want error\n")
+		}
+	})
+}
+
+// fakeStore is a store.Store holding documents in a map. It only supports
+// the queries and top level updates used by Migrator.Run.
+type fakeStore struct {
+	documents map[string]*store.Snapshot
+	writes    int
+
+	// stale makes every update fail its update time precondition, as if the
+	// document was written concurrently.
+	stale bool
+}
+
+func (s *fakeStore) Get(ctx context.Context, path string) (*store.Snapshot, error) {
+	if snapshot, ok := s.documents[path]; ok {
+		return snapshot, nil
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *fakeStore) Create(ctx context.Context, path string, data map[string]interface{}) (*store.WriteResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeStore) Set(ctx context.Context, path string, data map[string]interface{}) (*store.WriteResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeStore) Update(ctx context.Context, path string, updates map[string]interface{}, pre store.Precondition) (*store.WriteResult, error) {
+	snapshot, ok := s.documents[path]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	if s.stale || !snapshot.UpdateTime.Equal(pre.UpdateTime) {
+		return nil, store.ErrFailedPrecondition
+	}
+
+	for k, v := range updates {
+		if v == protofirestore.Delete {
+			delete(snapshot.Data, k)
+		} else {
+			snapshot.Data[k] = v
+		}
+	}
+
+	s.writes++
+	snapshot.UpdateTime = time.Unix(int64(s.writes), 0)
+
+	return &store.WriteResult{UpdateTime: snapshot.UpdateTime}, nil
+}
+
+func (s *fakeStore) Delete(ctx context.Context, path string, pre store.Precondition) (*store.WriteResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeStore) Query(ctx context.Context, q store.Query) ([]*store.Snapshot, error) {
+	prefix := store.CollectionPath(q.Parent, q.CollectionID) + "/"
+
+	var paths []string
+	for path := range s.documents {
+		if strings.HasPrefix(path, prefix) && !strings.Contains(path[len(prefix):], "/") {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var snapshots []*store.Snapshot
+	for _, path := range paths {
+		if q.StartAfter != nil && q.CursorID(path) <= q.StartAfter.DocumentID {
+			continue
+		}
+		if len(snapshots) == q.Limit {
+			break
+		}
+		snapshots = append(snapshots, s.documents[path])
+	}
+
+	return snapshots, nil
+}
@@ -0,0 +1,122 @@
+package protofirestore
+
+import (
+	"fmt"
+
+	"github.com/daviddomkar/protofirestore/internal/strs"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// OneofCaseKey returns the key under which MarshalOptions.EmitOneofCase
+// stores the case of the oneof od, which is the lower camel case name of the
+// oneof followed by "Case", e.g. "paymentCase" for a oneof named payment.
+func OneofCaseKey(od protoreflect.OneofDescriptor) string {
+	return strs.JSONCamelCase(string(od.Name())) + "Case"
+}
+
+// oneofs returns the oneofs of md which are not synthetic, i.e. the oneofs
+// which have a case key.
+func oneofs(md protoreflect.MessageDescriptor) []protoreflect.OneofDescriptor {
+	var ods []protoreflect.OneofDescriptor
+	for i := 0; i < md.Oneofs().Len(); i++ {
+		if od := md.Oneofs().Get(i); !od.IsSynthetic() {
+			ods = append(ods, od)
+		}
+	}
+	return ods
+}
+
+// oneofCase returns the case of the oneof od of m, which is the JSON name of
+// the field that is set, or nil if none is.
+func oneofCase(m protoreflect.Message, od protoreflect.OneofDescriptor) interface{} {
+	if fd := m.WhichOneof(od); fd != nil {
+		return fd.JSONName()
+	}
+	return nil
+}
+
+// checkOneofCaseKey checks that the case key of the oneof od does not collide
+// with the key of a field.
+func checkOneofCaseKey(od protoreflect.OneofDescriptor) error {
+	key := OneofCaseKey(od)
+	if fd := od.Parent().(protoreflect.MessageDescriptor).Fields().ByJSONName(key); fd != nil {
+		return fmt.Errorf("oneof %v: case key %q collides with field %v", od.FullName(), key, fd.FullName())
+	}
+	return nil
+}
+
+// marshalOneofCases stores the case of each oneof of m in the given object.
+func (e encoder) marshalOneofCases(object map[string]interface{}, m protoreflect.Message) error {
+	for _, od := range oneofs(m.Descriptor()) {
+		if err := checkOneofCaseKey(od); err != nil {
+			return err
+		}
+		object[OneofCaseKey(od)] = oneofCase(m, od)
+	}
+	return nil
+}
+
+// findOneofCase returns the oneof of md whose case is stored under the given
+// key, or nil if there is none.
+func findOneofCase(md protoreflect.MessageDescriptor, key string) protoreflect.OneofDescriptor {
+	for _, od := range oneofs(md) {
+		if OneofCaseKey(od) == key {
+			return od
+		}
+	}
+	return nil
+}
+
+// hasOneofCases reports whether the given object stores the case of any
+// oneof of md.
+func hasOneofCases(object map[string]interface{}, md protoreflect.MessageDescriptor) bool {
+	for _, od := range oneofs(md) {
+		if _, ok := object[OneofCaseKey(od)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalOneofCases checks the fields of each oneof of m, which has been
+// read from the given object, against the case stored in the object. A null
+// case means that no field of the oneof is set. A field named by the case
+// whose key is absent, which is how empty values are encoded, is set to its
+// zero value.
+func (d decoder) unmarshalOneofCases(object map[string]interface{}, m protoreflect.Message) error {
+	for _, od := range oneofs(m.Descriptor()) {
+		key := OneofCaseKey(od)
+		value, ok := object[key]
+		if !ok || checkOneofCaseKey(od) != nil {
+			continue // the key holds a field
+		}
+
+		set := m.WhichOneof(od)
+
+		if value == nil {
+			if set != nil {
+				return fmt.Errorf("oneof %v: field %v is set, but %v is null", od.FullName(), set.FullName(), key)
+			}
+			continue
+		}
+
+		name, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("invalid value for %v: %v", key, value)
+		}
+
+		fd := od.Fields().ByJSONName(name)
+		if fd == nil {
+			return fmt.Errorf("invalid value for %v: %q is not a field of oneof %v", key, name, od.FullName())
+		}
+
+		switch set {
+		case nil:
+			m.Set(fd, m.NewField(fd))
+		case fd:
+		default:
+			return fmt.Errorf("oneof %v: field %v is set, but %v is %q", od.FullName(), set.FullName(), key, name)
+		}
+	}
+	return nil
+}
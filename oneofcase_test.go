@@ -0,0 +1,223 @@
+package protofirestore_test
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	pkg "github.com/daviddomkar/protofirestore"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/go-test/deep"
+)
+
+func TestMarshalOneofCase(t *testing.T) {
+	tests := []struct {
+		desc  string
+		input proto.Message
+		want  map[string]interface{}
+	}{
+		{
+			desc:  "no field set",
+			input: &pb3.Oneofs{},
+			want:  map[string]interface{}{"unionCase": nil},
+		}, {
+			desc:  "scalar field",
+			input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofEnum{OneofEnum: pb3.Enum_TEN}},
+			want:  map[string]interface{}{"unionCase": "oneofEnum", "oneofEnum": "TEN"},
+		}, {
+			desc:  "empty string",
+			input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{}},
+			want:  map[string]interface{}{"unionCase": "oneofString"},
+		}, {
+			desc:  "empty message",
+			input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofNested{OneofNested: &pb3.Nested{}}},
+			want:  map[string]interface{}{"unionCase": "oneofNested"},
+		}, {
+			desc: "map values",
+			input: &pb3.Maps{StrToOneofs: map[string]*pb3.Oneofs{
+				"a": {Union: &pb3.Oneofs_OneofString{OneofString: "hello"}},
+			}},
+			want: map[string]interface{}{"strToOneofs": map[string]interface{}{
+				"a": map[string]interface{}{"unionCase": "oneofString", "oneofString": "hello"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := pkg.MarshalOptions{EmitOneofCase: true}.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal() returned error: %v\n", err)
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+
+			decoded := tt.input.ProtoReflect().New().Interface()
+			if err := pkg.Unmarshal(got, decoded); err != nil {
+				t.Fatalf("Unmarshal() returned error: %v\n", err)
+			}
+			if !proto.Equal(decoded, tt.input) {
+				t.Errorf("Unmarshal() = %v, want %v\n", decoded, tt.input)
+			}
+		})
+	}
+}
+
+func TestUnmarshalOneofCase(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   map[string]interface{}
+		want    proto.Message
+		wantErr bool
+	}{
+		{
+			desc:  "null case",
+			input: map[string]interface{}{"unionCase": nil},
+			want:  &pb3.Oneofs{},
+		}, {
+			desc:  "case of present field",
+			input: map[string]interface{}{"unionCase": "oneofString", "oneofString": "hello"},
+			want:  &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{OneofString: "hello"}},
+		}, {
+			desc:  "case of absent field",
+			input: map[string]interface{}{"unionCase": "oneofNested"},
+			want:  &pb3.Oneofs{Union: &pb3.Oneofs_OneofNested{OneofNested: &pb3.Nested{}}},
+		}, {
+			desc:  "case of field with null value",
+			input: map[string]interface{}{"unionCase": "oneofString", "oneofString": nil},
+			want:  &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{}},
+		}, {
+			desc:    "null case with field set",
+			input:   map[string]interface{}{"unionCase": nil, "oneofString": "hello"},
+			wantErr: true,
+		}, {
+			desc:    "case of other field",
+			input:   map[string]interface{}{"unionCase": "oneofEnum", "oneofString": "hello"},
+			wantErr: true,
+		}, {
+			desc:    "case of unknown field",
+			input:   map[string]interface{}{"unionCase": "sString"},
+			wantErr: true,
+		}, {
+			desc:    "case is not a string",
+			input:   map[string]interface{}{"unionCase": int64(1)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := &pb3.Oneofs{}
+			err := pkg.Unmarshal(tt.input, got)
+			if err != nil && !tt.wantErr {
+				t.Errorf("Unmarshal() returned error: %v\n", err)
+			}
+			if err == nil && tt.wantErr {
+				t.Error("Unmarshal() got nil error, want error\n")
+			}
+			if err == nil && !proto.Equal(got, tt.want) {
+				t.Errorf("Unmarshal() = %v, want %v\n", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalUpdateOneofCase(t *testing.T) {
+	tests := []struct {
+		desc  string
+		input proto.Message
+		paths []string
+		want  map[string]interface{}
+	}{
+		{
+			desc:  "set field",
+			input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{OneofString: "hello"}},
+			paths: []string{"oneof_string"},
+			want:  map[string]interface{}{"oneofString": "hello", "unionCase": "oneofString"},
+		}, {
+			desc:  "other field set",
+			input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofEnum{OneofEnum: pb3.Enum_ONE}},
+			paths: []string{"oneof_string"},
+			want:  map[string]interface{}{"oneofString": pkg.Delete, "unionCase": "oneofEnum"},
+		}, {
+			desc:  "no field set",
+			input: &pb3.Oneofs{},
+			paths: []string{"oneof_string"},
+			want:  map[string]interface{}{"oneofString": pkg.Delete, "unionCase": nil},
+		}, {
+			desc: "map value",
+			input: &pb3.Maps{StrToOneofs: map[string]*pb3.Oneofs{
+				"a": {Union: &pb3.Oneofs_OneofString{OneofString: "hello"}},
+			}},
+			paths: []string{"str_to_oneofs.a.oneof_string", "str_to_oneofs.b.oneof_string"},
+			want: map[string]interface{}{
+				"strToOneofs.a.oneofString": "hello",
+				"strToOneofs.a.unionCase":   "oneofString",
+				"strToOneofs.b.oneofString": pkg.Delete,
+				"strToOneofs.b.unionCase":   pkg.Delete,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := pkg.MarshalOptions{EmitOneofCase: true}.MarshalUpdate(tt.input, &fieldmaskpb.FieldMask{Paths: tt.paths})
+			if err != nil {
+				t.Fatalf("MarshalUpdate() returned error: %v\n", err)
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestMarshalDiffOneofCase(t *testing.T) {
+	tests := []struct {
+		desc string
+		old  proto.Message
+		new  proto.Message
+		want map[string]interface{}
+	}{
+		{
+			desc: "unchanged case",
+			old:  &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{OneofString: "hello"}},
+			new:  &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{OneofString: "world"}},
+			want: map[string]interface{}{"oneofString": "world"},
+		}, {
+			desc: "changed case",
+			old:  &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{OneofString: "hello"}},
+			new:  &pb3.Oneofs{Union: &pb3.Oneofs_OneofEnum{OneofEnum: pb3.Enum_TEN}},
+			want: map[string]interface{}{"oneofString": pkg.Delete, "oneofEnum": "TEN", "unionCase": "oneofEnum"},
+		}, {
+			desc: "cleared oneof",
+			old:  &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{OneofString: "hello"}},
+			new:  &pb3.Oneofs{},
+			want: map[string]interface{}{"oneofString": pkg.Delete, "unionCase": nil},
+		}, {
+			desc: "map value",
+			old:  &pb3.Maps{StrToOneofs: map[string]*pb3.Oneofs{"a": {}}},
+			new: &pb3.Maps{StrToOneofs: map[string]*pb3.Oneofs{
+				"a": {Union: &pb3.Oneofs_OneofString{}},
+			}},
+			want: map[string]interface{}{"strToOneofs.a": map[string]interface{}{"unionCase": "oneofString"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := pkg.MarshalOptions{EmitOneofCase: true}.MarshalDiff(tt.old, tt.new)
+			if err != nil {
+				t.Fatalf("MarshalDiff() returned error: %v\n", err)
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
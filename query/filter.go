@@ -0,0 +1,730 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ParseFilter parses an AIP-160 filter expression into a Filter over the
+// documents encoded from messages described by md.
+func ParseFilter(md protoreflect.MessageDescriptor, filter string) (Filter, error) {
+	return ParseOptions{}.ParseFilter(md, filter)
+}
+
+// ParseOptions is a configurable parser of AIP-160 filter expressions.
+type ParseOptions struct {
+	// MarshalOptions are the options the queried documents are encoded with.
+	// They determine how the values of the filter are encoded and which
+	// comparisons can match documents.
+	MarshalOptions protofirestore.MarshalOptions
+}
+
+// ParseFilter parses an AIP-160 filter expression into a Filter over the
+// documents encoded from messages described by md. An empty expression
+// results in a nil Filter.
+//
+// The left side of every comparison is a path of proto field names, which is
+// resolved against md like protofirestore.FieldPath. The right side is a
+// literal converted to the type of the field: numbers, true and false, enum
+// value names and strings, including RFC 3339 timestamps for
+// google.protobuf.Timestamp fields. Literals are encoded with the same rules
+// as the fields of documents.
+//
+// The supported constructs are the comparators =, !=, <, <=, > and >=, the
+// has operator : on repeated fields (array-contains) and with * (presence),
+// AND, OR, NOT and - on equality comparisons, and parentheses. Sequences of
+// restrictions without an operator are treated as AND. Functions, global
+// restrictions, comparisons between fields and comparisons of whole messages,
+// maps and repeated fields result in errors, as do comparisons which can never
+// match because the encoder omits the compared value from documents.
+//
+// Firestore filters, including != and not-in, never match documents missing
+// the compared field. Unless MarshalOptions.EmitFirestoreSensibleDefaults is
+// set, the encoder omits the default values of fields without presence, so
+// comparisons which hold for the default value, like s_int32 != 5 or
+// s_int32 < 5, would silently miss those documents and result in errors.
+// Comparisons which do not hold for it, like s_int32 != 0 or s_int32 > 0,
+// are accepted. Negated presence tests result in errors for the same reason.
+func (o ParseOptions) ParseFilter(md protoreflect.MessageDescriptor, filter string) (Filter, error) {
+	tokens, err := lex(filter)
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", filter, err)
+	}
+
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+
+	p := parser{opts: o, md: md, tokens: tokens}
+
+	f, err := p.parseExpression()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = p.errorf(p.peek(), "unexpected %v", p.peek())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", filter, err)
+	}
+
+	return f, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenText
+	tokenString
+	tokenComparator
+	tokenLeftParen
+	tokenRightParen
+	tokenDot
+	tokenComma
+	tokenMinus
+	tokenStar
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// isKeyword reports whether t is the given keyword. Keywords are case
+// sensitive as required by AIP-160.
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenText && t.text == keyword
+}
+
+// lex splits the filter into tokens, which always end with a tokenEOF.
+func lex(filter string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(filter); {
+		c := filter[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '(':
+			tokens = append(tokens, token{tokenLeftParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRightParen, ")", i})
+			i++
+		case c == '.':
+			tokens = append(tokens, token{tokenDot, ".", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case c == '*':
+			tokens = append(tokens, token{tokenStar, "*", i})
+			i++
+		case strings.HasPrefix(filter[i:], "<=") || strings.HasPrefix(filter[i:], ">=") || strings.HasPrefix(filter[i:], "!="):
+			tokens = append(tokens, token{tokenComparator, filter[i : i+2], i})
+			i += 2
+		case c == '<' || c == '>' || c == '=' || c == ':':
+			tokens = append(tokens, token{tokenComparator, filter[i : i+1], i})
+			i++
+		case c == '"' || c == '\'':
+			text, n, err := lexString(filter[i:])
+			if err != nil {
+				return nil, fmt.Errorf("offset %d: %w", i, err)
+			}
+			tokens = append(tokens, token{tokenString, text, i})
+			i += n
+		case c == '-' && (i+1 == len(filter) || !isDigit(filter[i+1])):
+			tokens = append(tokens, token{tokenMinus, "-", i})
+			i++
+		case c == '-' || isDigit(c):
+			n := lexNumber(filter[i:])
+			tokens = append(tokens, token{tokenText, filter[i : i+n], i})
+			i += n
+		default:
+			n := strings.IndexFunc(filter[i:], func(r rune) bool {
+				return unicode.IsSpace(r) || strings.ContainsRune("().,*<>=!:\"'", r)
+			})
+			if n < 0 {
+				n = len(filter) - i
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("offset %d: unexpected character %q", i, c)
+			}
+			tokens = append(tokens, token{tokenText, filter[i : i+n], i})
+			i += n
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(filter)}), nil
+}
+
+// lexString scans the quoted string at the start of s and returns its
+// unquoted value along with the number of bytes consumed.
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			i++
+			if i == len(s) {
+				break
+			}
+			switch e := s[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// lexNumber returns the length of the number at the start of s.
+func lexNumber(s string) int {
+	i := 0
+	if s[i] == '-' {
+		i++
+	}
+	for i < len(s) {
+		switch c := s[i]; {
+		case isDigit(c), c == '.', c == 'x', c == 'X', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+		case (c == '+' || c == '-') && (s[i-1] == 'e' || s[i-1] == 'E'):
+		default:
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// parser is a recursive descent parser of the AIP-160 grammar:
+//
+//	expression  : sequence {AND sequence}
+//	sequence    : factor {factor}
+//	factor      : term {OR term}
+//	term        : [NOT | -] simple
+//	simple      : restriction | "(" expression ")"
+//	restriction : member comparator arg
+//	member      : text {"." text}
+type parser struct {
+	opts   ParseOptions
+	md     protoreflect.MessageDescriptor
+	tokens []token
+	pos    int
+
+	// negated reports whether the restriction being parsed is negated by
+	// an odd number of NOT operators.
+	negated bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", t.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseExpression() (Filter, error) {
+	return p.parseComposite(And, p.parseSequence, func(t token) bool {
+		return t.isKeyword("AND")
+	})
+}
+
+func (p *parser) parseSequence() (Filter, error) {
+	return p.parseComposite(And, p.parseFactor, func(t token) bool {
+		switch t.kind {
+		case tokenEOF, tokenRightParen:
+			return false
+		}
+		return !t.isKeyword("AND") && !t.isKeyword("OR")
+	})
+}
+
+func (p *parser) parseFactor() (Filter, error) {
+	return p.parseComposite(Or, p.parseTerm, func(t token) bool {
+		return t.isKeyword("OR")
+	})
+}
+
+// parseComposite parses operands separated by tokens for which separator
+// returns true. Separators which are keywords are consumed, the absence of
+// a separator in a sequence is not.
+func (p *parser) parseComposite(op CompositeOperator, operand func() (Filter, error), separator func(token) bool) (Filter, error) {
+	f, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []Filter{f}
+	for separator(p.peek()) {
+		if p.peek().isKeyword("AND") || p.peek().isKeyword("OR") {
+			p.next()
+		}
+		f, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	return combine(op, filters), nil
+}
+
+func (p *parser) parseTerm() (Filter, error) {
+	t := p.peek()
+	if t.isKeyword("NOT") || t.kind == tokenMinus {
+		p.next()
+		p.negated = !p.negated
+		f, err := p.parseSimple()
+		p.negated = !p.negated
+		if err != nil {
+			return nil, err
+		}
+		if f, err = negate(f); err != nil {
+			return nil, p.errorf(t, "%v", err)
+		}
+		return f, nil
+	}
+	return p.parseSimple()
+}
+
+func (p *parser) parseSimple() (Filter, error) {
+	if t := p.peek(); t.kind == tokenLeftParen {
+		p.next()
+		f, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRightParen {
+			return nil, p.errorf(t, "expected \")\", got %v", t)
+		}
+		return f, nil
+	}
+	return p.parseRestriction()
+}
+
+func (p *parser) parseRestriction() (Filter, error) {
+	start := p.peek()
+	if start.kind != tokenText || start.isKeyword("AND") || start.isKeyword("OR") || start.isKeyword("NOT") {
+		return nil, p.errorf(start, "expected field, got %v", start)
+	}
+
+	path, err := p.parseMember()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := p.peek(); t.kind {
+	case tokenLeftParen:
+		return nil, p.errorf(t, "functions are not supported")
+	case tokenComparator:
+	default:
+		return nil, p.errorf(start, "global restrictions are not supported, expected comparator after %q", path)
+	}
+
+	comparator := p.next()
+
+	arg := p.next()
+	switch arg.kind {
+	case tokenText:
+		if p.peek().kind == tokenDot {
+			return nil, p.errorf(arg, "comparisons between fields are not supported")
+		}
+		if p.peek().kind == tokenLeftParen {
+			return nil, p.errorf(arg, "functions are not supported")
+		}
+	case tokenString, tokenStar:
+	case tokenLeftParen:
+		return nil, p.errorf(arg, "composite arguments are not supported")
+	default:
+		return nil, p.errorf(arg, "expected value, got %v", arg)
+	}
+
+	f, err := p.restriction(path, comparator, arg)
+	if err != nil {
+		return nil, p.errorf(start, "%v", err)
+	}
+	return f, nil
+}
+
+func (p *parser) parseMember() (string, error) {
+	segments := []string{p.next().text}
+	for p.peek().kind == tokenDot {
+		p.next()
+		t := p.next()
+		if t.kind != tokenText && t.kind != tokenString {
+			return "", p.errorf(t, "expected field, got %v", t)
+		}
+		segments = append(segments, t.text)
+	}
+	return strings.Join(segments, "."), nil
+}
+
+// restriction type checks a comparison against the message descriptor and
+// converts it into a FieldFilter.
+func (p *parser) restriction(path string, comparator, arg token) (Filter, error) {
+	fieldPath, fd, err := p.opts.MarshalOptions.ResolveFieldPath(p.md, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if comparator.text == ":" {
+		switch {
+		case arg.kind == tokenStar:
+			return FieldFilter{Path: fieldPath, Operator: NotEqual, Value: nil}, nil
+		case fd.IsList():
+			value, err := p.value(path, fd, arg)
+			if err != nil {
+				return nil, err
+			}
+			return FieldFilter{Path: fieldPath, Operator: ArrayContains, Value: value}, nil
+		default:
+			return nil, fmt.Errorf("has operator on %v requires a repeated field or *", path)
+		}
+	}
+
+	if arg.kind == tokenStar {
+		return nil, fmt.Errorf("* can only be used with the has operator")
+	}
+
+	switch {
+	case fd.IsList():
+		return nil, fmt.Errorf("cannot compare repeated field %v, use the has operator", path)
+	case fd.IsMap():
+		return nil, fmt.Errorf("cannot compare map field %v", path)
+	case fd.Message() != nil && fd.Message().FullName() != genid.Timestamp_message_fullname:
+		return nil, fmt.Errorf("cannot compare message field %v", path)
+	}
+
+	var op Operator
+	switch comparator.text {
+	case "=":
+		op = Equal
+	case "!=":
+		op = NotEqual
+	case "<":
+		op = LessThan
+	case "<=":
+		op = LessThanOrEqual
+	case ">":
+		op = GreaterThan
+	case ">=":
+		op = GreaterThanOrEqual
+	}
+
+	if op != Equal && op != NotEqual && (fd.Kind() == protoreflect.EnumKind || fd.Kind() == protoreflect.BoolKind) {
+		return nil, fmt.Errorf("%v field %v can only be compared for equality", fd.Kind(), path)
+	}
+
+	value, err := p.value(path, fd, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.checkDefault(path, fd, op, arg, value); err != nil {
+		return nil, err
+	}
+
+	return FieldFilter{Path: fieldPath, Operator: op, Value: value}, nil
+}
+
+// value converts the literal arg into the value of the field fd at the given
+// path and encodes it.
+func (p *parser) value(path string, fd protoreflect.FieldDescriptor, arg token) (interface{}, error) {
+	literal, err := literalValue(fd, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	_, value, err := p.opts.MarshalOptions.MarshalPathValue(p.md, path, literal)
+	if err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, fmt.Errorf("%v %v is never stored in documents", path, arg)
+	}
+
+	return value, nil
+}
+
+// checkDefault checks that the comparison of the field fd at the given path
+// with the literal arg, encoded as value, does not hold for the default value
+// of the field.
+// Unless firestore sensible defaults are emitted, default values of fields
+// without presence are omitted from documents, which firestore filters never
+// match. The operator is negated if the restriction is.
+func (p *parser) checkDefault(path string, fd protoreflect.FieldDescriptor, op Operator, arg token, value interface{}) error {
+	if fd.HasPresence() || fd.ContainingMessage().IsMapEntry() || p.opts.MarshalOptions.EmitFirestoreSensibleDefaults {
+		return nil
+	}
+
+	if p.negated {
+		switch op {
+		case Equal:
+			op = NotEqual
+		case NotEqual:
+			op = Equal
+		default:
+			return nil // rejected by negate
+		}
+	}
+
+	c, err := p.compareDefault(fd, arg, value)
+	if err != nil {
+		return err
+	}
+
+	var holds bool
+	switch op {
+	case Equal:
+		holds = c == 0
+	case NotEqual:
+		holds = c != 0
+	case LessThan:
+		holds = c < 0
+	case LessThanOrEqual:
+		holds = c <= 0
+	case GreaterThan:
+		holds = c > 0
+	case GreaterThanOrEqual:
+		holds = c >= 0
+	}
+
+	if holds {
+		return fmt.Errorf("%v %v %v holds for the default value of the field, which is only stored in documents with EmitFirestoreSensibleDefaults", path, op, arg)
+	}
+	return nil
+}
+
+// compareDefault compares the default value of the field fd with the literal
+// arg, encoded as value, returning -1, 0 or +1 as the default value is less
+// than, equal to or greater than the literal. Bools and enums are only
+// compared for equality, so any non-zero result means that they differ.
+func (p *parser) compareDefault(fd protoreflect.FieldDescriptor, arg token, value interface{}) (int, error) {
+	literal, err := literalValue(fd, arg)
+	if err != nil {
+		return 0, err
+	}
+
+	def := fd.Default()
+
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return compare(def.Int(), literal.(int64)), nil
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return compare(def.Uint(), literal.(uint64)), nil
+
+	case protoreflect.FloatKind:
+		return compare(def.Float(), float64(literal.(float32))), nil
+
+	case protoreflect.DoubleKind:
+		return compare(def.Float(), literal.(float64)), nil
+
+	case protoreflect.StringKind:
+		return strings.Compare(def.String(), literal.(string)), nil
+	}
+
+	zero, err := p.opts.MarshalOptions.MarshalFieldValue(fd, def)
+	if err != nil {
+		return 0, err
+	}
+	if reflect.DeepEqual(value, zero) {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// compare compares a and b, returning -1, 0 or +1 as a is less than, equal to
+// or greater than b.
+func compare[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// literalValue converts the literal arg into a Go value accepted by
+// protofirestore.MarshalPathValue for the field fd.
+func literalValue(fd protoreflect.FieldDescriptor, arg token) (interface{}, error) {
+	invalid := func() (interface{}, error) {
+		return nil, fmt.Errorf("invalid %v value %v for field %v", fd.Kind(), arg, fd.FullName())
+	}
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		switch {
+		case arg.isKeyword("true"):
+			return true, nil
+		case arg.isKeyword("false"):
+			return false, nil
+		}
+
+	case protoreflect.StringKind:
+		return arg.text, nil
+
+	case protoreflect.EnumKind:
+		if arg.kind == tokenText {
+			if n, err := strconv.ParseInt(arg.text, 10, 32); err == nil {
+				return n, nil
+			}
+		}
+		return arg.text, nil
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if arg.kind == tokenText {
+			if n, err := strconv.ParseInt(arg.text, 10, 64); err == nil {
+				return n, nil
+			}
+		}
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if arg.kind == tokenText {
+			if n, err := strconv.ParseUint(arg.text, 10, 64); err == nil {
+				return n, nil
+			}
+		}
+
+	case protoreflect.FloatKind:
+		if arg.kind == tokenText {
+			if f, err := strconv.ParseFloat(arg.text, 32); err == nil {
+				return float32(f), nil
+			}
+		}
+
+	case protoreflect.DoubleKind:
+		if arg.kind == tokenText {
+			if f, err := strconv.ParseFloat(arg.text, 64); err == nil {
+				return f, nil
+			}
+		}
+
+	case protoreflect.MessageKind:
+		if fd.Message().FullName() == genid.Timestamp_message_fullname && arg.kind == tokenString {
+			if t, err := time.Parse(time.RFC3339Nano, arg.text); err == nil {
+				return t, nil
+			}
+		}
+	}
+
+	return invalid()
+}
+
+// combine combines filters with op, merging nested composites with the same
+// operator.
+func combine(op CompositeOperator, filters []Filter) Filter {
+	if len(filters) == 1 {
+		return filters[0]
+	}
+
+	var combined []Filter
+	for _, f := range filters {
+		if c, ok := f.(CompositeFilter); ok && c.Operator == op {
+			combined = append(combined, c.Filters...)
+		} else {
+			combined = append(combined, f)
+		}
+	}
+
+	return CompositeFilter{Operator: op, Filters: combined}
+}
+
+// negate returns the negation of f, applying De Morgan's laws to composite
+// filters. Only equality comparisons can be negated, since firestore has no
+// operators for the negation of range comparisons. Like all firestore
+// filters, the resulting != and not-in comparisons do not match documents
+// missing the compared field, so negated presence tests, which would only
+// match such documents, are rejected.
+func negate(f Filter) (Filter, error) {
+	switch f := f.(type) {
+	case FieldFilter:
+		if f.Operator == NotEqual && f.Value == nil {
+			return nil, fmt.Errorf("negation of presence tests is not supported, since firestore does not match documents missing a field")
+		}
+		switch f.Operator {
+		case Equal:
+			f.Operator = NotEqual
+		case NotEqual:
+			f.Operator = Equal
+		case In:
+			f.Operator = NotIn
+		case NotIn:
+			f.Operator = In
+		default:
+			return nil, fmt.Errorf("negation of %v comparisons is not supported", f.Operator)
+		}
+		return f, nil
+
+	case CompositeFilter:
+		op := And
+		if f.Operator == And {
+			op = Or
+		}
+
+		filters := make([]Filter, len(f.Filters))
+		for i, child := range f.Filters {
+			negated, err := negate(child)
+			if err != nil {
+				return nil, err
+			}
+			filters[i] = negated
+		}
+
+		return combine(op, filters), nil
+	}
+
+	return nil, fmt.Errorf("unknown filter %T", f)
+}
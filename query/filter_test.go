@@ -0,0 +1,395 @@
+package query_test
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/daviddomkar/protofirestore"
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	pb2 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb2"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/daviddomkar/protofirestore/query"
+	"github.com/go-test/deep"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		desc     string
+		input    proto.Message
+		filter   string
+		defaults bool
+		want     query.Filter
+		wantErr  bool
+	}{
+		{
+			desc:   "empty filter",
+			input:  &pb3.Scalars{},
+			filter: "  ",
+			want:   nil,
+		}, {
+			desc:   "comparators",
+			input:  &pb3.Scalars{},
+			filter: `s_int32 = 1 AND s_int64 != 0 AND s_sint32 < -3 AND s_sint64 <= -4 AND s_float > 1.5 AND s_double >= 2.5e3`,
+			want: query.CompositeFilter{
+				Operator: query.And,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "sInt32", Operator: query.Equal, Value: int32(1)},
+					query.FieldFilter{Path: "sInt64", Operator: query.NotEqual, Value: int64(0)},
+					query.FieldFilter{Path: "sSint32", Operator: query.LessThan, Value: int32(-3)},
+					query.FieldFilter{Path: "sSint64", Operator: query.LessThanOrEqual, Value: int64(-4)},
+					query.FieldFilter{Path: "sFloat", Operator: query.GreaterThan, Value: float32(1.5)},
+					query.FieldFilter{Path: "sDouble", Operator: query.GreaterThanOrEqual, Value: float64(2500)},
+				},
+			},
+		}, {
+			desc:   "strings",
+			input:  &pb3.Scalars{},
+			filter: `s_string = "hello \"world\"" OR s_string = 'single' OR s_string = bare`,
+			want: query.CompositeFilter{
+				Operator: query.Or,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "sString", Operator: query.Equal, Value: `hello "world"`},
+					query.FieldFilter{Path: "sString", Operator: query.Equal, Value: "single"},
+					query.FieldFilter{Path: "sString", Operator: query.Equal, Value: "bare"},
+				},
+			},
+		}, {
+			desc:   "enums",
+			input:  &pb3.Enums{},
+			filter: `s_enum = ONE AND s_nested_enum != "CERO"`,
+			want: query.CompositeFilter{
+				Operator: query.And,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "sEnum", Operator: query.Equal, Value: "ONE"},
+					query.FieldFilter{Path: "sNestedEnum", Operator: query.NotEqual, Value: "CERO"},
+				},
+			},
+		}, {
+			desc:   "unnamed enum number",
+			input:  &pb3.Enums{},
+			filter: `s_enum = 47`,
+			want:   query.FieldFilter{Path: "sEnum", Operator: query.Equal, Value: int64(47)},
+		}, {
+			desc:   "timestamp",
+			input:  &pb2.KnownTypes{},
+			filter: `opt_timestamp > "2024-01-01T00:00:00Z"`,
+			want:   query.FieldFilter{Path: "optTimestamp", Operator: query.GreaterThan, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}, {
+			desc:   "nested fields",
+			input:  &pb2.Nests{},
+			filter: `opt_nested.opt_nested.opt_string = "nested"`,
+			want:   query.FieldFilter{Path: "optNested.optNested.optString", Operator: query.Equal, Value: "nested"},
+		}, {
+			desc:   "map values",
+			input:  &pb3.Maps{},
+			filter: `str_to_nested.key.s_string = "nested" AND uint64_to_enum.10 = ZERO`,
+			want: query.CompositeFilter{
+				Operator: query.And,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "strToNested.key.sString", Operator: query.Equal, Value: "nested"},
+					query.FieldFilter{Path: "uint64ToEnum.`10`", Operator: query.Equal, Value: "ZERO"},
+				},
+			},
+		}, {
+			desc:   "json_name",
+			input:  &pb3.JSONNames{},
+			filter: `s_string = "json_name"`,
+			want:   query.FieldFilter{Path: "foo_bar", Operator: query.Equal, Value: "json_name"},
+		}, {
+			desc:   "has on repeated field",
+			input:  &pbann.Counters{},
+			filter: `tags:"a" AND scores:42`,
+			want: query.CompositeFilter{
+				Operator: query.And,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "tags", Operator: query.ArrayContains, Value: "a"},
+					query.FieldFilter{Path: "scores", Operator: query.ArrayContains, Value: int64(42)},
+				},
+			},
+		}, {
+			desc:   "presence",
+			input:  &pb2.Nests{},
+			filter: `opt_nested:*`,
+			want:   query.FieldFilter{Path: "optNested", Operator: query.NotEqual, Value: nil},
+		}, {
+			desc:   "or binds tighter than and",
+			input:  &pb3.Scalars{},
+			filter: `s_bool = true AND s_int32 = 1 OR s_int32 = 2`,
+			want: query.CompositeFilter{
+				Operator: query.And,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "sBool", Operator: query.Equal, Value: true},
+					query.CompositeFilter{
+						Operator: query.Or,
+						Filters: []query.Filter{
+							query.FieldFilter{Path: "sInt32", Operator: query.Equal, Value: int32(1)},
+							query.FieldFilter{Path: "sInt32", Operator: query.Equal, Value: int32(2)},
+						},
+					},
+				},
+			},
+		}, {
+			desc:   "parentheses and sequences",
+			input:  &pb3.Scalars{},
+			filter: `(s_int32 = 1 AND s_int64 = 2) s_bool = true`,
+			want: query.CompositeFilter{
+				Operator: query.And,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "sInt32", Operator: query.Equal, Value: int32(1)},
+					query.FieldFilter{Path: "sInt64", Operator: query.Equal, Value: int64(2)},
+					query.FieldFilter{Path: "sBool", Operator: query.Equal, Value: true},
+				},
+			},
+		}, {
+			desc:   "negation",
+			input:  &pb2.Scalars{},
+			filter: `NOT (opt_int32 = 1 OR -opt_int64 = 2)`,
+			want: query.CompositeFilter{
+				Operator: query.And,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "optInt32", Operator: query.NotEqual, Value: int32(1)},
+					query.FieldFilter{Path: "optInt64", Operator: query.Equal, Value: int64(2)},
+				},
+			},
+		}, {
+			desc:   "comparisons excluding the default value",
+			input:  &pb3.Scalars{},
+			filter: `s_int32 > 0 AND s_int64 != 0 AND s_sint32 <= -1 AND s_uint32 >= 1 AND s_double < -0.5 AND s_string > "a"`,
+			want: query.CompositeFilter{
+				Operator: query.And,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "sInt32", Operator: query.GreaterThan, Value: int32(0)},
+					query.FieldFilter{Path: "sInt64", Operator: query.NotEqual, Value: int64(0)},
+					query.FieldFilter{Path: "sSint32", Operator: query.LessThanOrEqual, Value: int32(-1)},
+					query.FieldFilter{Path: "sUint32", Operator: query.GreaterThanOrEqual, Value: uint32(1)},
+					query.FieldFilter{Path: "sDouble", Operator: query.LessThan, Value: float64(-0.5)},
+					query.FieldFilter{Path: "sString", Operator: query.GreaterThan, Value: "a"},
+				},
+			},
+		}, {
+			desc:   "negated equality with the default value",
+			input:  &pb3.Scalars{},
+			filter: `NOT s_int32 = 0 AND -s_bool = false`,
+			want: query.CompositeFilter{
+				Operator: query.And,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "sInt32", Operator: query.NotEqual, Value: int32(0)},
+					query.FieldFilter{Path: "sBool", Operator: query.NotEqual, Value: false},
+				},
+			},
+		}, {
+			desc:     "comparisons including the default value with firestore sensible defaults",
+			input:    &pb3.Scalars{},
+			filter:   `s_int32 != 5 AND s_int64 < 5`,
+			defaults: true,
+			want: query.CompositeFilter{
+				Operator: query.And,
+				Filters: []query.Filter{
+					query.FieldFilter{Path: "sInt32", Operator: query.NotEqual, Value: int32(5)},
+					query.FieldFilter{Path: "sInt64", Operator: query.LessThan, Value: int64(5)},
+				},
+			},
+		}, {
+			desc:    "not equal to other than the default value",
+			input:   &pb3.Scalars{},
+			filter:  `s_int32 != 5`,
+			wantErr: true,
+		}, {
+			desc:    "less than above the default value",
+			input:   &pb3.Scalars{},
+			filter:  `s_int32 < 5`,
+			wantErr: true,
+		}, {
+			desc:    "less than or equal to the default value",
+			input:   &pb3.Scalars{},
+			filter:  `s_uint64 <= 0`,
+			wantErr: true,
+		}, {
+			desc:    "greater than or equal to the default value",
+			input:   &pb3.Scalars{},
+			filter:  `s_float >= 0`,
+			wantErr: true,
+		}, {
+			desc:    "greater than below the default value",
+			input:   &pb3.Scalars{},
+			filter:  `s_sint64 > -1`,
+			wantErr: true,
+		}, {
+			desc:    "string less than",
+			input:   &pb3.Scalars{},
+			filter:  `s_string < "b"`,
+			wantErr: true,
+		}, {
+			desc:    "not equal to other enum value",
+			input:   &pb3.Enums{},
+			filter:  `s_enum != ONE`,
+			wantErr: true,
+		}, {
+			desc:    "negated equality with other than the default value",
+			input:   &pb3.Scalars{},
+			filter:  `NOT s_int32 = 5`,
+			wantErr: true,
+		}, {
+			desc:    "doubly negated not equal",
+			input:   &pb3.Scalars{},
+			filter:  `NOT (NOT s_int32 != 5)`,
+			wantErr: true,
+		}, {
+			desc:    "negated presence",
+			input:   &pb3.Scalars{},
+			filter:  `NOT s_string:*`,
+			wantErr: true,
+		}, {
+			desc:     "default value with firestore sensible defaults",
+			input:    &pb3.Scalars{},
+			filter:   `s_int32 = 0`,
+			defaults: true,
+			want:     query.FieldFilter{Path: "sInt32", Operator: query.Equal, Value: int32(0)},
+		}, {
+			desc:   "default value of optional field",
+			input:  &pb3.Proto3Optional{},
+			filter: `opt_bool = false`,
+			want:   query.FieldFilter{Path: "optBool", Operator: query.Equal, Value: false},
+		}, {
+			desc:    "default value",
+			input:   &pb3.Scalars{},
+			filter:  `s_int32 = 0`,
+			wantErr: true,
+		}, {
+			desc:     "empty string",
+			input:    &pb3.Scalars{},
+			filter:   `s_string = ""`,
+			defaults: true,
+			wantErr:  true,
+		}, {
+			desc:    "unknown field",
+			input:   &pb3.Scalars{},
+			filter:  `s_unknown = 1`,
+			wantErr: true,
+		}, {
+			desc:    "mismatched type",
+			input:   &pb3.Scalars{},
+			filter:  `s_int32 = "one"`,
+			wantErr: true,
+		}, {
+			desc:    "out of range",
+			input:   &pb3.Scalars{},
+			filter:  `s_int32 = 4294967296`,
+			wantErr: true,
+		}, {
+			desc:   "decimal integer with leading zero",
+			input:  &pb3.Scalars{},
+			filter: `s_int32 = 017`,
+			want:   query.FieldFilter{Path: "sInt32", Operator: query.Equal, Value: int32(17)},
+		}, {
+			desc:    "hexadecimal integer",
+			input:   &pb3.Scalars{},
+			filter:  `s_int32 = 0x1F`,
+			wantErr: true,
+		}, {
+			desc:    "octal integer",
+			input:   &pb3.Scalars{},
+			filter:  `s_uint64 = 0o17`,
+			wantErr: true,
+		}, {
+			desc:    "integer with underscores",
+			input:   &pb3.Scalars{},
+			filter:  `s_int64 = 1_000`,
+			wantErr: true,
+		}, {
+			desc:    "enum number in hexadecimal",
+			input:   &pb3.Enums{},
+			filter:  `s_enum = 0x1`,
+			wantErr: true,
+		}, {
+			desc:    "unknown enum value",
+			input:   &pb3.Enums{},
+			filter:  `s_enum = ELEVEN`,
+			wantErr: true,
+		}, {
+			desc:    "ordering enums",
+			input:   &pb3.Enums{},
+			filter:  `s_enum > ONE`,
+			wantErr: true,
+		}, {
+			desc:    "invalid timestamp",
+			input:   &pb2.KnownTypes{},
+			filter:  `opt_timestamp > "yesterday"`,
+			wantErr: true,
+		}, {
+			desc:    "comparing messages",
+			input:   &pb3.Nests{},
+			filter:  `s_nested = 1`,
+			wantErr: true,
+		}, {
+			desc:    "comparing repeated fields",
+			input:   &pbann.Counters{},
+			filter:  `tags = "a"`,
+			wantErr: true,
+		}, {
+			desc:    "has on scalar field",
+			input:   &pb3.Scalars{},
+			filter:  `s_string:"a"`,
+			wantErr: true,
+		}, {
+			desc:    "negating range comparisons",
+			input:   &pb3.Scalars{},
+			filter:  `NOT s_int32 > 1`,
+			wantErr: true,
+		}, {
+			desc:    "functions",
+			input:   &pb3.Scalars{},
+			filter:  `s_string = lower("A")`,
+			wantErr: true,
+		}, {
+			desc:    "global restriction",
+			input:   &pb3.Scalars{},
+			filter:  `hello`,
+			wantErr: true,
+		}, {
+			desc:    "field comparison",
+			input:   &pb2.Nests{},
+			filter:  `opt_nested.opt_string = opt_nested.opt_string`,
+			wantErr: true,
+		}, {
+			desc:    "unbalanced parentheses",
+			input:   &pb3.Scalars{},
+			filter:  `(s_int32 = 1`,
+			wantErr: true,
+		}, {
+			desc:    "unterminated string",
+			input:   &pb3.Scalars{},
+			filter:  `s_string = "hello`,
+			wantErr: true,
+		}, {
+			desc:    "dangling operator",
+			input:   &pb3.Scalars{},
+			filter:  `s_int32 = 1 AND`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := query.ParseOptions{
+				MarshalOptions: protofirestore.MarshalOptions{
+					EmitFirestoreSensibleDefaults: tt.defaults,
+				},
+			}.ParseFilter(tt.input.ProtoReflect().Descriptor(), tt.filter)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("ParseFilter() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("ParseFilter() got nil error, want error\n")
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
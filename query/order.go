@@ -0,0 +1,83 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Direction is the direction of an Order.
+type Direction string
+
+const (
+	Ascending  Direction = "ASCENDING"
+	Descending Direction = "DESCENDING"
+)
+
+// Order orders documents by the value at a firestore field path.
+type Order struct {
+	Path      string
+	Direction Direction
+}
+
+// ParseOrderBy parses an AIP-132 order_by string into the orders of the
+// documents encoded from messages described by md.
+func ParseOrderBy(md protoreflect.MessageDescriptor, orderBy string) ([]Order, error) {
+	return ParseOptions{}.ParseOrderBy(md, orderBy)
+}
+
+// ParseOrderBy parses an AIP-132 order_by string into the orders of the
+// documents encoded from messages described by md. An empty string results
+// in no orders.
+//
+// The string is a comma separated list of proto field paths, which are
+// resolved against md like protofirestore.FieldPath, each optionally followed
+// by "desc" or "asc". Ordering by repeated, map and message fields other than
+// google.protobuf.Timestamp and ordering by a field more than once results in
+// an error.
+func (o ParseOptions) ParseOrderBy(md protoreflect.MessageDescriptor, orderBy string) ([]Order, error) {
+	if strings.TrimSpace(orderBy) == "" {
+		return nil, nil
+	}
+
+	var orders []Order
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(orderBy, ",") {
+		words := strings.Fields(part)
+
+		direction := Ascending
+		switch {
+		case len(words) == 2 && words[1] == "desc":
+			direction = Descending
+		case len(words) == 2 && words[1] == "asc":
+		case len(words) != 1:
+			return nil, fmt.Errorf("order by %q: invalid field order %q", orderBy, strings.TrimSpace(part))
+		}
+
+		path, fd, err := o.MarshalOptions.ResolveFieldPath(md, words[0])
+		if err != nil {
+			return nil, fmt.Errorf("order by %q: %w", orderBy, err)
+		}
+
+		switch {
+		case fd.IsList():
+			return nil, fmt.Errorf("order by %q: cannot order by repeated field %v", orderBy, words[0])
+		case fd.IsMap():
+			return nil, fmt.Errorf("order by %q: cannot order by map field %v", orderBy, words[0])
+		case fd.Message() != nil && fd.Message().FullName() != genid.Timestamp_message_fullname:
+			return nil, fmt.Errorf("order by %q: cannot order by message field %v", orderBy, words[0])
+		}
+
+		if seen[path] {
+			return nil, fmt.Errorf("order by %q: field %v is ordered by more than once", orderBy, words[0])
+		}
+		seen[path] = true
+
+		orders = append(orders, Order{Path: path, Direction: direction})
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,108 @@
+package query_test
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	pb2 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb2"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/daviddomkar/protofirestore/query"
+	"github.com/go-test/deep"
+)
+
+func TestParseOrderBy(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   proto.Message
+		orderBy string
+		want    []query.Order
+		wantErr bool
+	}{
+		{
+			desc:    "empty",
+			input:   &pb3.Scalars{},
+			orderBy: " ",
+			want:    nil,
+		}, {
+			desc:    "single field",
+			input:   &pb3.Scalars{},
+			orderBy: "s_string",
+			want: []query.Order{
+				{Path: "sString", Direction: query.Ascending},
+			},
+		}, {
+			desc:    "multiple fields with directions",
+			input:   &pb3.Scalars{},
+			orderBy: "s_int32 desc,  s_string asc , s_bool",
+			want: []query.Order{
+				{Path: "sInt32", Direction: query.Descending},
+				{Path: "sString", Direction: query.Ascending},
+				{Path: "sBool", Direction: query.Ascending},
+			},
+		}, {
+			desc:    "nested fields and timestamps",
+			input:   &pb2.KnownTypes{},
+			orderBy: "opt_timestamp desc",
+			want: []query.Order{
+				{Path: "optTimestamp", Direction: query.Descending},
+			},
+		}, {
+			desc:    "map values",
+			input:   &pb3.Maps{},
+			orderBy: "str_to_nested.a.s_string",
+			want: []query.Order{
+				{Path: "strToNested.a.sString", Direction: query.Ascending},
+			},
+		}, {
+			desc:    "unknown field",
+			input:   &pb3.Scalars{},
+			orderBy: "s_unknown",
+			wantErr: true,
+		}, {
+			desc:    "invalid direction",
+			input:   &pb3.Scalars{},
+			orderBy: "s_string descending",
+			wantErr: true,
+		}, {
+			desc:    "empty field",
+			input:   &pb3.Scalars{},
+			orderBy: "s_string,",
+			wantErr: true,
+		}, {
+			desc:    "duplicate field",
+			input:   &pb3.Scalars{},
+			orderBy: "s_string, s_string desc",
+			wantErr: true,
+		}, {
+			desc:    "repeated field",
+			input:   &pbann.Counters{},
+			orderBy: "tags",
+			wantErr: true,
+		}, {
+			desc:    "message field",
+			input:   &pb3.Nests{},
+			orderBy: "s_nested",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := query.ParseOrderBy(tt.input.ProtoReflect().Descriptor(), tt.orderBy)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("ParseOrderBy() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("ParseOrderBy() got nil error, want error\n")
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
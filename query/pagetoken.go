@@ -0,0 +1,212 @@
+package query
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/daviddomkar/protofirestore"
+)
+
+// Cursor is the position of the last document of a page, from which the next
+// page continues.
+type Cursor struct {
+	// Values are the values of the last document at the paths of the orders
+	// of the query, encoded the same way as the document.
+	Values []interface{}
+
+	// DocumentID is the ID of the last document. It breaks ties between
+	// documents with equal values, for which the query additionally needs to
+	// be ordered by document ID.
+	DocumentID string
+}
+
+// CursorOf returns the cursor positioned at the given encoded document, which
+// has been returned by a query ordered by orders.
+func CursorOf(orders []Order, id string, document map[string]interface{}) (Cursor, error) {
+	flat := protofirestore.Flatten(document)
+
+	values := make([]interface{}, len(orders))
+	for i, order := range orders {
+		value, ok := flat[order.Path]
+		if !ok {
+			return Cursor{}, fmt.Errorf("document %v has no value at %v", id, order.Path)
+		}
+		values[i] = value
+	}
+
+	return Cursor{Values: values, DocumentID: id}, nil
+}
+
+// PageQuery identifies the query a page token belongs to, so that a page
+// token of one query cannot be used to continue another one as required by
+// AIP-158. Changing the page size between pages is allowed and therefore not
+// part of the query.
+type PageQuery struct {
+	// Parent is the resource or collection being listed.
+	Parent string
+
+	// Filter is the filter string of the query.
+	Filter string
+
+	// OrderBy is the order_by string of the query.
+	OrderBy string
+}
+
+// ErrInvalidPageToken is returned when a page token cannot be decoded, has
+// been tampered with or belongs to another query.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// PageTokenCodec encodes cursors into opaque page tokens and decodes them back.
+// Page tokens are signed, so they can be handed out to clients.
+type PageTokenCodec struct {
+	// Key is the secret key page tokens are signed with. It must not be empty
+	// and should be at least 32 bytes long.
+	Key []byte
+}
+
+// pageToken is the payload of a page token.
+type pageToken struct {
+	Values     []pageTokenValue `json:"v"`
+	DocumentID string           `json:"d"`
+}
+
+// pageTokenValue is an encoded document value with its Go type, because JSON
+// alone cannot tell apart the numeric types, byte slices and timestamps.
+type pageTokenValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v,omitempty"`
+}
+
+// Encode encodes the cursor into a page token for the given query.
+func (c PageTokenCodec) Encode(q PageQuery, cursor Cursor) (string, error) {
+	if len(c.Key) == 0 {
+		return "", errors.New("page token codec has no key")
+	}
+
+	token := pageToken{DocumentID: cursor.DocumentID}
+	for _, value := range cursor.Values {
+		v, err := encodePageTokenValue(value)
+		if err != nil {
+			return "", err
+		}
+		token.Values = append(token.Values, v)
+	}
+
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(append(payload, c.sign(q, payload)...)), nil
+}
+
+// Decode decodes a page token created by Encode for the same query. It
+// returns ErrInvalidPageToken if the token is malformed, was not signed with
+// the key of the codec or belongs to another query.
+func (c PageTokenCodec) Decode(q PageQuery, token string) (Cursor, error) {
+	if len(c.Key) == 0 {
+		return Cursor{}, errors.New("page token codec has no key")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < sha256.Size {
+		return Cursor{}, ErrInvalidPageToken
+	}
+
+	payload, signature := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if !hmac.Equal(signature, c.sign(q, payload)) {
+		return Cursor{}, ErrInvalidPageToken
+	}
+
+	var decoded pageToken
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return Cursor{}, ErrInvalidPageToken
+	}
+
+	cursor := Cursor{DocumentID: decoded.DocumentID}
+	for _, v := range decoded.Values {
+		value, err := decodePageTokenValue(v)
+		if err != nil {
+			return Cursor{}, ErrInvalidPageToken
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+
+	return cursor, nil
+}
+
+// sign returns the signature of the payload for the given query.
+func (c PageTokenCodec) sign(q PageQuery, payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.Key)
+	for _, part := range []string{q.Parent, q.Filter, q.OrderBy} {
+		mac.Write([]byte(strconv.Quote(part)))
+	}
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func encodePageTokenValue(value interface{}) (pageTokenValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return pageTokenValue{Type: "null"}, nil
+	case bool:
+		return pageTokenValue{Type: "bool", Value: strconv.FormatBool(v)}, nil
+	case int32:
+		return pageTokenValue{Type: "int32", Value: strconv.FormatInt(int64(v), 10)}, nil
+	case int64:
+		return pageTokenValue{Type: "int64", Value: strconv.FormatInt(v, 10)}, nil
+	case uint32:
+		return pageTokenValue{Type: "uint32", Value: strconv.FormatUint(uint64(v), 10)}, nil
+	case uint64:
+		return pageTokenValue{Type: "uint64", Value: strconv.FormatUint(v, 10)}, nil
+	case float32:
+		return pageTokenValue{Type: "float32", Value: strconv.FormatFloat(float64(v), 'g', -1, 32)}, nil
+	case float64:
+		return pageTokenValue{Type: "float64", Value: strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case string:
+		return pageTokenValue{Type: "string", Value: v}, nil
+	case []byte:
+		return pageTokenValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(v)}, nil
+	case time.Time:
+		return pageTokenValue{Type: "timestamp", Value: v.UTC().Format(time.RFC3339Nano)}, nil
+	}
+	return pageTokenValue{}, fmt.Errorf("cannot encode %T value into page token", value)
+}
+
+func decodePageTokenValue(v pageTokenValue) (interface{}, error) {
+	switch v.Type {
+	case "null":
+		return nil, nil
+	case "bool":
+		return strconv.ParseBool(v.Value)
+	case "int32":
+		n, err := strconv.ParseInt(v.Value, 10, 32)
+		return int32(n), err
+	case "int64":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "uint32":
+		n, err := strconv.ParseUint(v.Value, 10, 32)
+		return uint32(n), err
+	case "uint64":
+		return strconv.ParseUint(v.Value, 10, 64)
+	case "float32":
+		f, err := strconv.ParseFloat(v.Value, 32)
+		return float32(f), err
+	case "float64":
+		return strconv.ParseFloat(v.Value, 64)
+	case "string":
+		return v.Value, nil
+	case "bytes":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "timestamp":
+		t, err := time.Parse(time.RFC3339Nano, v.Value)
+		return t.UTC(), err
+	}
+	return nil, fmt.Errorf("unknown page token value type %q", v.Type)
+}
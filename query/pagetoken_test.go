@@ -0,0 +1,139 @@
+package query_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/daviddomkar/protofirestore/query"
+	"github.com/go-test/deep"
+)
+
+func TestPageTokenCodec(t *testing.T) {
+	codec := query.PageTokenCodec{Key: []byte("0123456789abcdef0123456789abcdef")}
+	q := query.PageQuery{
+		Parent:  "users/alice/orders",
+		Filter:  `state = ACTIVE`,
+		OrderBy: "create_time desc",
+	}
+
+	cursor := query.Cursor{
+		Values: []interface{}{
+			nil,
+			true,
+			int32(-32),
+			int64(math.MinInt64),
+			uint32(math.MaxUint32),
+			uint64(math.MaxUint64),
+			float32(1.1),
+			math.Inf(-1),
+			"谷歌",
+			[]byte("\x00\xff"),
+			time.Date(2024, 1, 1, 12, 30, 0, 123456789, time.UTC),
+		},
+		DocumentID: "order-1",
+	}
+
+	token, err := codec.Encode(q, cursor)
+	if err != nil {
+		t.Fatalf("Encode() returned error: %v\n", err)
+	}
+
+	got, err := codec.Decode(q, token)
+	if err != nil {
+		t.Fatalf("Decode() returned error: %v\n", err)
+	}
+
+	if diff := deep.Equal(got, cursor); diff != nil {
+		t.Error(diff)
+	}
+
+	invalid := []struct {
+		desc  string
+		codec query.PageTokenCodec
+		query query.PageQuery
+		token string
+	}{
+		{
+			desc:  "other filter",
+			codec: codec,
+			query: query.PageQuery{Parent: q.Parent, Filter: `state = DELETED`, OrderBy: q.OrderBy},
+			token: token,
+		}, {
+			desc:  "other order",
+			codec: codec,
+			query: query.PageQuery{Parent: q.Parent, Filter: q.Filter, OrderBy: "create_time"},
+			token: token,
+		}, {
+			desc:  "other parent",
+			codec: codec,
+			query: query.PageQuery{Parent: "users/bob/orders", Filter: q.Filter, OrderBy: q.OrderBy},
+			token: token,
+		}, {
+			desc:  "other key",
+			codec: query.PageTokenCodec{Key: []byte("another key")},
+			query: q,
+			token: token,
+		}, {
+			desc:  "tampered token",
+			codec: codec,
+			query: q,
+			token: "A" + token[1:],
+		}, {
+			desc:  "truncated token",
+			codec: codec,
+			query: q,
+			token: token[:20],
+		}, {
+			desc:  "malformed token",
+			codec: codec,
+			query: q,
+			token: "not a token!",
+		},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := tt.codec.Decode(tt.query, tt.token); err != query.ErrInvalidPageToken {
+				t.Errorf("Decode() returned error %v, want %v\n", err, query.ErrInvalidPageToken)
+			}
+		})
+	}
+}
+
+func TestCursorOf(t *testing.T) {
+	orders := []query.Order{
+		{Path: "createTime", Direction: query.Descending},
+		{Path: "author.name", Direction: query.Ascending},
+		{Path: "labels.`a b`", Direction: query.Ascending},
+	}
+
+	createTime := time.Unix(1553036601, 0).UTC()
+	document := map[string]interface{}{
+		"createTime": createTime,
+		"author": map[string]interface{}{
+			"name": "alice",
+		},
+		"labels": map[string]interface{}{
+			"a b": "c",
+		},
+	}
+
+	got, err := query.CursorOf(orders, "doc", document)
+	if err != nil {
+		t.Fatalf("CursorOf() returned error: %v\n", err)
+	}
+
+	want := query.Cursor{
+		Values:     []interface{}{createTime, "alice", "c"},
+		DocumentID: "doc",
+	}
+
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+
+	if _, err := query.CursorOf([]query.Order{{Path: "missing"}}, "doc", document); err == nil {
+		t.Errorf("CursorOf() got nil error for missing value, want error\n")
+	}
+}
@@ -0,0 +1,54 @@
+// Package query describes firestore queries over documents encoded by the
+// protofirestore package independently of the firestore client library, and
+// builds them from the filter strings used by List methods of resource
+// oriented APIs.
+package query
+
+// Operator is the operator of a FieldFilter. Its values are the operator
+// strings accepted by firestore.Query.Where in the firestore client library.
+type Operator string
+
+const (
+	Equal              Operator = "=="
+	NotEqual           Operator = "!="
+	LessThan           Operator = "<"
+	LessThanOrEqual    Operator = "<="
+	GreaterThan        Operator = ">"
+	GreaterThanOrEqual Operator = ">="
+	ArrayContains      Operator = "array-contains"
+	ArrayContainsAny   Operator = "array-contains-any"
+	In                 Operator = "in"
+	NotIn              Operator = "not-in"
+)
+
+// CompositeOperator is the operator of a CompositeFilter.
+type CompositeOperator string
+
+const (
+	And CompositeOperator = "AND"
+	Or  CompositeOperator = "OR"
+)
+
+// Filter is a firestore query filter. It is either a FieldFilter or a
+// CompositeFilter.
+type Filter interface {
+	isFilter()
+}
+
+// FieldFilter compares the value at a firestore field path with Value, which
+// is encoded the same way the protofirestore package encodes documents.
+type FieldFilter struct {
+	Path     string
+	Operator Operator
+	Value    interface{}
+}
+
+func (FieldFilter) isFilter() {}
+
+// CompositeFilter combines Filters with either And or Or.
+type CompositeFilter struct {
+	Operator CompositeOperator
+	Filters  []Filter
+}
+
+func (CompositeFilter) isFilter() {}
@@ -0,0 +1,148 @@
+// Package registry maps collections to the message types stored in them, so
+// that any document can be decoded knowing only its path, as admin, export
+// and command line tooling must.
+package registry
+
+import (
+	"fmt"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/docpath"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Registry maps document path patterns to the full names of the messages
+// stored at the paths they match. The zero value is an empty registry.
+type Registry struct {
+	// UnmarshalOptions decode documents. Its Resolver, which defaults to
+	// protoregistry.GlobalTypes, resolves the registered names to message
+	// types.
+	UnmarshalOptions protofirestore.UnmarshalOptions
+
+	entries []entry
+}
+
+type entry struct {
+	pattern *docpath.Pattern
+	name    protoreflect.FullName
+}
+
+// Register maps the documents matched by the path pattern to the message
+// with the given full name. An empty name registers a collection holding
+// several message types, whose documents store their type under
+// protofirestore.TypeKey. Patterns matching the documents of a registered
+// pattern, which are the patterns with the same collection IDs, are
+// rejected.
+func (r *Registry) Register(pattern string, name protoreflect.FullName) error {
+	p, err := docpath.Parse(pattern)
+	if err != nil {
+		return err
+	}
+
+	if name != "" && !name.IsValid() {
+		return fmt.Errorf("%q is not a message name", name)
+	}
+
+	// Variables match any document ID, so patterns overlap if an expansion
+	// of one matches the other.
+	ids := make([]string, len(p.Variables()))
+	for i := range ids {
+		ids[i] = "id"
+	}
+	path, err := p.Expand(ids...)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range r.entries {
+		if _, ok := e.pattern.Match(path); ok {
+			return fmt.Errorf("pattern %v overlaps pattern %v of %v", p, e.pattern, e.name)
+		}
+	}
+
+	r.entries = append(r.entries, entry{pattern: p, name: name})
+	return nil
+}
+
+// RegisterFile registers the messages of fd with a path option, including
+// nested messages, at their path pattern.
+func (r *Registry) RegisterFile(fd protoreflect.FileDescriptor) error {
+	return r.registerMessages(fd.Messages())
+}
+
+func (r *Registry) registerMessages(mds protoreflect.MessageDescriptors) error {
+	for i := 0; i < mds.Len(); i++ {
+		md := mds.Get(i)
+
+		if path := annotations.MessageOptionsOf(md).GetPath(); path != "" {
+			if err := r.Register(path, md.FullName()); err != nil {
+				return fmt.Errorf("message %v: %w", md.FullName(), err)
+			}
+		}
+
+		if err := r.registerMessages(md.Messages()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Range calls f for each registered pattern and message name in the order
+// they were registered, until f returns false.
+func (r *Registry) Range(f func(pattern *docpath.Pattern, name protoreflect.FullName) bool) {
+	for _, e := range r.entries {
+		if !f(e.pattern, e.name) {
+			return
+		}
+	}
+}
+
+// Match returns the name of the message registered for the document at path
+// and the document IDs of the variables of its pattern. It reports false if
+// no registered pattern matches path.
+func (r *Registry) Match(path string) (protoreflect.FullName, []string, bool) {
+	for _, e := range r.entries {
+		if ids, ok := e.pattern.Match(path); ok {
+			return e.name, ids, true
+		}
+	}
+	return "", nil, false
+}
+
+// Decode decodes the document stored at path into a new message of the type
+// registered for path, which is resolved by the Resolver of
+// UnmarshalOptions. Documents of collections holding several message types
+// are decoded with protofirestore.UnmarshalOptions.UnmarshalNew.
+func (r *Registry) Decode(path string, data map[string]interface{}) (proto.Message, error) {
+	name, _, ok := r.Match(path)
+	if !ok {
+		return nil, fmt.Errorf("no message registered for %v", path)
+	}
+
+	if name == "" {
+		m, err := r.UnmarshalOptions.UnmarshalNew(data)
+		if err != nil {
+			return nil, fmt.Errorf("decode %v: %w", path, err)
+		}
+		return m, nil
+	}
+
+	var resolver protoregistry.MessageTypeResolver = r.UnmarshalOptions.Resolver
+	if r.UnmarshalOptions.Resolver == nil {
+		resolver = protoregistry.GlobalTypes
+	}
+
+	mt, err := resolver.FindMessageByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("decode %v: unable to resolve %v: %w", path, name, err)
+	}
+
+	m := mt.New().Interface()
+	if err := r.UnmarshalOptions.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("decode %v: %w", path, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,122 @@
+package registry_test
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/daviddomkar/protofirestore/docpath"
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/daviddomkar/protofirestore/registry"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestRegistry(t *testing.T) {
+	r := &registry.Registry{}
+	if err := r.RegisterFile(pbann.File_internal_testprotos_annotatedpb_test_proto); err != nil {
+		t.Fatalf("RegisterFile() returned error: %v\n", err)
+	}
+	if err := r.Register("events/{event}", ""); err != nil {
+		t.Fatalf("Register() returned error: %v\n", err)
+	}
+
+	tests := []struct {
+		desc    string
+		path    string
+		data    map[string]interface{}
+		want    proto.Message
+		wantErr bool
+	}{
+		{
+			desc: "root collection",
+			path: "users/alice",
+			data: map[string]interface{}{"name": "alice"},
+			want: &pbann.User{Name: "alice"},
+		}, {
+			desc: "nested collection",
+			path: "users/alice/orders/1",
+			data: map[string]interface{}{"name": "first"},
+			want: &pbann.Order{Name: "first"},
+		}, {
+			desc: "collection holding several types",
+			path: "events/1",
+			data: map[string]interface{}{"@type": "annotated.Article", "title": "hello"},
+			want: &pbann.Article{Title: "hello"},
+		}, {
+			desc:    "unregistered collection",
+			path:    "users/alice/carts/1",
+			data:    map[string]interface{}{},
+			wantErr: true,
+		}, {
+			desc:    "collection path",
+			path:    "users",
+			data:    map[string]interface{}{},
+			wantErr: true,
+		}, {
+			desc:    "invalid document",
+			path:    "users/alice",
+			data:    map[string]interface{}{"name": int64(1)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := r.Decode(tt.path, tt.data)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("Decode() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("Decode() got nil error, want error\n")
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("Decode() = %v, want %v\n", got, tt.want)
+			}
+		})
+	}
+
+	if name, ids, ok := r.Match("users/alice/orders/1"); !ok || name != "annotated.Order" || len(ids) != 2 || ids[0] != "alice" || ids[1] != "1" {
+		t.Errorf("Match() = %v, %v, %v, want annotated.Order, [alice 1], true\n", name, ids, ok)
+	}
+
+	n := 0
+	r.Range(func(*docpath.Pattern, protoreflect.FullName) bool {
+		n++
+		return true
+	})
+	if want := 8; n != want {
+		t.Errorf("Range() visited %d patterns, want %d\n", n, want)
+	}
+}
+
+func TestRegisterError(t *testing.T) {
+	tests := []struct {
+		desc    string
+		pattern string
+		name    protoreflect.FullName
+	}{
+		{desc: "overlapping pattern", pattern: "users/{id}", name: "annotated.Article"},
+		{desc: "invalid pattern", pattern: "users", name: "annotated.User"},
+		{desc: "invalid name", pattern: "carts/{cart}", name: "not a name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			r := &registry.Registry{}
+			if err := r.Register("users/{user}", "annotated.User"); err != nil {
+				t.Fatalf("Register() returned error: %v\n", err)
+			}
+
+			if err := r.Register(tt.pattern, tt.name); err == nil {
+				t.Errorf("Register(%q) got nil error, want error\n", tt.pattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/store"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// BatchWriter collects writes of messages and commits them in as few
+// commits as firestore allows. Unlike a single firestore batch, it is not
+// limited in size, but only the writes of each commit of at most
+// store.MaxBatchWrites writes are applied atomically.
+type BatchWriter struct {
+	store  store.Store
+	opts   Options
+	writes []store.Write
+}
+
+// NewBatchWriter returns a BatchWriter writing to s. Messages are encoded
+// with the MarshalOptions of opts. Stores which do not implement
+// store.Batcher are written one document at a time.
+func NewBatchWriter(s store.Store, opts Options) *BatchWriter {
+	return &BatchWriter{store: s, opts: opts}
+}
+
+// Len returns the number of writes which have not been committed yet.
+func (b *BatchWriter) Len() int {
+	return len(b.writes)
+}
+
+// Create adds a write storing m at path, which must not exist yet, followed
+// by the writes of the documents of its subcollections.
+func (b *BatchWriter) Create(path string, m proto.Message) error {
+	data, children, e, err := b.opts.encode(m)
+	if err != nil {
+		return fmt.Errorf("encode %v: %w", path, err)
+	}
+
+	if e != nil && e.source == annotations.FieldOptions_VERSION {
+		data[e.key()] = "1"
+	}
+
+	b.writes = append(b.writes, store.Write{Op: store.OpCreate, Path: path, Data: data})
+	b.writes = append(b.writes, childWrites(path, children, nil)...)
+	return nil
+}
+
+// Set adds a write storing m at path. Messages with an etag, or with an etag
+// derived from a version counter at all, cannot be set in batches, because
+// that requires reading the document. Neither can messages with
+// subcollections, whose stale documents must be read to be deleted.
+func (b *BatchWriter) Set(path string, m proto.Message) error {
+	data, _, e, err := b.opts.encode(m)
+	if err != nil {
+		return fmt.Errorf("encode %v: %w", path, err)
+	}
+
+	if e != nil && (e.source == annotations.FieldOptions_VERSION || e.get(m) != "") {
+		return fmt.Errorf("set %v: etags cannot be checked in batches", path)
+	}
+
+	if len(subcollectionFields(m.ProtoReflect().Descriptor())) != 0 {
+		return fmt.Errorf("set %v: subcollections cannot be replaced in batches", path)
+	}
+
+	b.writes = append(b.writes, store.Write{Op: store.OpSet, Path: path, Data: data})
+	return nil
+}
+
+// Update adds a write of the fields of m selected by mask to the document at
+// path, like Collection.Update. Etags derived from the update time are
+// checked by the precondition of the write, while messages with etags
+// derived from a version counter cannot be updated in batches.
+func (b *BatchWriter) Update(path string, m proto.Message, mask *fieldmaskpb.FieldMask, pre store.Precondition) error {
+	updates, e, err := b.opts.encodeUpdate(m, mask)
+	if err != nil {
+		return fmt.Errorf("encode %v: %w", path, err)
+	}
+
+	if e != nil {
+		if e.source == annotations.FieldOptions_VERSION {
+			return fmt.Errorf("update %v: versions cannot be incremented in batches", path)
+		}
+		if pre, _, err = e.precondition(m, pre); err != nil {
+			return fmt.Errorf("update %v: %w", path, err)
+		}
+	}
+
+	b.writes = append(b.writes, store.Write{Op: store.OpUpdate, Path: path, Data: updates, Precondition: pre})
+	return nil
+}
+
+// Delete adds a write deleting the document at path, which stores a message
+// described by md. Messages with a delete time cannot be deleted in
+// batches, because soft-deleting them requires reading the document, like
+// Collection.Delete does.
+func (b *BatchWriter) Delete(path string, md protoreflect.MessageDescriptor, pre store.Precondition) error {
+	sd, err := softDeleteOf(md)
+	if err != nil {
+		return fmt.Errorf("delete %v: %w", path, err)
+	}
+	if sd != nil {
+		return fmt.Errorf("delete %v: messages with a delete time cannot be deleted in batches", path)
+	}
+
+	b.writes = append(b.writes, store.Write{Op: store.OpDelete, Path: path, Precondition: pre})
+	return nil
+}
+
+// Commit commits the collected writes in order, in chunks of at most
+// store.MaxBatchWrites writes. It stops at the first chunk which fails and
+// keeps its writes and the following ones, so Len reports how many writes
+// have not been committed and Commit can be retried.
+func (b *BatchWriter) Commit(ctx context.Context) error {
+	n, err := commit(ctx, b.store, b.writes)
+	b.writes = b.writes[n:]
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// commit applies writes in order, in chunks of at most store.MaxBatchWrites
+// writes for stores implementing store.Batcher, and returns the number of
+// writes committed before the first chunk which fails.
+func commit(ctx context.Context, s store.Store, writes []store.Write) (int, error) {
+	batcher, ok := s.(store.Batcher)
+
+	committed := 0
+	for committed < len(writes) {
+		n := 1
+
+		var err error
+		if ok {
+			n = min(len(writes)-committed, store.MaxBatchWrites)
+			_, err = batcher.Commit(ctx, writes[committed:committed+n])
+		} else {
+			err = writeOne(ctx, s, writes[committed])
+		}
+		if err != nil {
+			return committed, err
+		}
+
+		committed += n
+	}
+
+	return committed, nil
+}
+
+// writeOne applies w to a store which does not implement store.Batcher.
+func writeOne(ctx context.Context, s store.Store, w store.Write) error {
+	var err error
+	switch w.Op {
+	case store.OpCreate:
+		_, err = s.Create(ctx, w.Path, w.Data)
+	case store.OpSet:
+		_, err = s.Set(ctx, w.Path, w.Data)
+	case store.OpUpdate:
+		_, err = s.Update(ctx, w.Path, w.Data, w.Precondition)
+	case store.OpDelete:
+		_, err = s.Delete(ctx, w.Path, w.Precondition)
+	default:
+		err = fmt.Errorf("invalid write operation %v", w.Op)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	pb "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/daviddomkar/protofirestore/memstore"
+	"github.com/daviddomkar/protofirestore/repository"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+func TestBatchWriter(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		desc        string
+		writes      int
+		batcher     bool
+		wantCommits int
+	}{
+		{
+			desc:        "single commit",
+			writes:      store.MaxBatchWrites - 1,
+			batcher:     true,
+			wantCommits: 1,
+		}, {
+			desc:        "chunked commits",
+			writes:      2 * store.MaxBatchWrites,
+			batcher:     true,
+			wantCommits: 3,
+		}, {
+			desc:   "store without batches",
+			writes: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := &countingStore{Store: memstore.New()}
+
+			var target store.Store = storeOnly{s}
+			if tt.batcher {
+				target = s
+			}
+
+			b := repository.NewBatchWriter(target, repository.Options{})
+			for i := 0; i < tt.writes; i++ {
+				if err := b.Set(fmt.Sprintf("users/%d", i), &pb.User{Name: "user"}); err != nil {
+					t.Fatalf("Set() returned error: %v\n", err)
+				}
+			}
+			if err := b.Delete("users/0", (&pb.User{}).ProtoReflect().Descriptor(), store.Precondition{Exists: true}); err != nil {
+				t.Fatalf("Delete() returned error: %v\n", err)
+			}
+
+			if err := b.Commit(ctx); err != nil {
+				t.Fatalf("Commit() returned error: %v\n", err)
+			}
+
+			if b.Len() != 0 {
+				t.Errorf("Len() after Commit() = %d, want 0\n", b.Len())
+			}
+
+			if s.commits != tt.wantCommits {
+				t.Errorf("Commit() made %d commits, want %d\n", s.commits, tt.wantCommits)
+			}
+
+			snapshots, err := s.Query(ctx, store.Query{CollectionID: "users"})
+			if err != nil {
+				t.Fatalf("Query() returned error: %v\n", err)
+			}
+			if len(snapshots) != tt.writes-1 {
+				t.Errorf("Commit() wrote %d documents, want %d\n", len(snapshots), tt.writes-1)
+			}
+		})
+	}
+}
+
+func TestBatchWriterFailure(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	b := repository.NewBatchWriter(s, repository.Options{})
+	for i := 0; i < store.MaxBatchWrites; i++ {
+		if err := b.Set(fmt.Sprintf("users/%d", i), &pb.User{}); err != nil {
+			t.Fatalf("Set() returned error: %v\n", err)
+		}
+	}
+	if err := b.Create("users/0", &pb.User{}); err != nil {
+		t.Fatalf("Create() returned error: %v\n", err)
+	}
+
+	if err := b.Commit(ctx); !errors.Is(err, store.ErrAlreadyExists) {
+		t.Errorf("Commit() returned error %v, want %v\n", err, store.ErrAlreadyExists)
+	}
+
+	if b.Len() != 1 {
+		t.Errorf("Len() after failed Commit() = %d, want 1\n", b.Len())
+	}
+}
+
+func TestBatchWriterSoftDelete(t *testing.T) {
+	b := repository.NewBatchWriter(memstore.New(), repository.Options{})
+
+	if err := b.Delete("books/a", (&pb.Book{}).ProtoReflect().Descriptor(), store.Precondition{}); err == nil {
+		t.Error("Delete() of message with a delete time did not return error")
+	}
+
+	if b.Len() != 0 {
+		t.Errorf("Len() after failed Delete() = %d, want 0\n", b.Len())
+	}
+}
+
+// countingStore counts the commits of a memstore.Store.
+type countingStore struct {
+	*memstore.Store
+	commits int
+}
+
+func (s *countingStore) Commit(ctx context.Context, writes []store.Write) ([]*store.WriteResult, error) {
+	s.commits++
+	return s.Store.Commit(ctx, writes)
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/daviddomkar/protofirestore/store"
+	"google.golang.org/protobuf/proto"
+)
+
+// Change is a store.Change with the decoded messages of the document.
+type Change[T proto.Message] struct {
+	Kind store.ChangeKind
+	Path string
+
+	// Old is the message before the change, which is the zero value for
+	// added documents and for modified documents whose previous version was
+	// not seen by the decoder.
+	Old T
+
+	// New is the message after the change, which is the zero value for
+	// removed documents.
+	New T
+
+	// UpdateTime is the update time of the document after the change, or of
+	// its last version for removed documents.
+	UpdateTime time.Time
+
+	// Err reports documents which could not be decoded, in which case Old
+	// or New is the zero value. Later changes are decoded regardless.
+	Err error
+}
+
+// ChangeDecoder decodes the changes of a snapshot listener into messages of
+// type T. It remembers the last version of every document it has seen to
+// decode the old message of modified documents, so it must see all changes
+// of a listener in order. Subcollections are not decoded, since listeners
+// report changes of a single collection.
+type ChangeDecoder[T proto.Message] struct {
+	opts      Options
+	snapshots map[string]*store.Snapshot
+}
+
+// NewChangeDecoder returns a ChangeDecoder which decodes documents with the
+// UnmarshalOptions of opts.
+func NewChangeDecoder[T proto.Message](opts Options) *ChangeDecoder[T] {
+	return &ChangeDecoder[T]{opts: opts, snapshots: make(map[string]*store.Snapshot)}
+}
+
+// Decode decodes a change.
+func (d *ChangeDecoder[T]) Decode(change store.Change) Change[T] {
+	snapshot := change.Snapshot
+	c := Change[T]{Kind: change.Kind, Path: snapshot.Path, UpdateTime: snapshot.UpdateTime}
+
+	var oldErr, newErr error
+	switch change.Kind {
+	case store.ChangeAdded:
+		c.New, newErr = d.decode(snapshot)
+		d.snapshots[snapshot.Path] = snapshot
+	case store.ChangeModified:
+		if old, ok := d.snapshots[snapshot.Path]; ok {
+			c.Old, oldErr = d.decode(old)
+		}
+		c.New, newErr = d.decode(snapshot)
+		d.snapshots[snapshot.Path] = snapshot
+	case store.ChangeRemoved:
+		c.Old, oldErr = d.decode(snapshot)
+		delete(d.snapshots, snapshot.Path)
+	default:
+		c.Err = fmt.Errorf("%v: invalid change kind %d", snapshot.Path, change.Kind)
+		return c
+	}
+
+	if oldErr != nil {
+		oldErr = fmt.Errorf("decode old %v: %w", snapshot.Path, oldErr)
+	}
+	if newErr != nil {
+		newErr = fmt.Errorf("decode %v: %w", snapshot.Path, newErr)
+	}
+	c.Err = errors.Join(oldErr, newErr)
+
+	return c
+}
+
+func (d *ChangeDecoder[T]) decode(snapshot *store.Snapshot) (T, error) {
+	var zero T
+	m := zero.ProtoReflect().Type().New().Interface().(T)
+	if err := d.opts.decode(snapshot, nil, m); err != nil {
+		return zero, err
+	}
+	return m, nil
+}
+
+// Changes decodes the changes received from in until in is closed or ctx is
+// done, after which the returned channel is closed. Changes of documents
+// which do not match the path pattern of the collection are reported with an
+// error.
+func (c *Collection[T]) Changes(ctx context.Context, in <-chan store.Change) <-chan Change[T] {
+	out := make(chan Change[T])
+	d := NewChangeDecoder[T](c.opts)
+
+	go func() {
+		defer close(out)
+
+		for {
+			var change store.Change
+			select {
+			case <-ctx.Done():
+				return
+			case ch, ok := <-in:
+				if !ok {
+					return
+				}
+				change = ch
+			}
+
+			var decoded Change[T]
+			if err := c.checkPath(change.Snapshot.Path); err != nil {
+				decoded = Change[T]{Kind: change.Kind, Path: change.Snapshot.Path, UpdateTime: change.Snapshot.UpdateTime, Err: err}
+			} else {
+				decoded = d.Decode(change)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- decoded:
+			}
+		}
+	}()
+
+	return out
+}
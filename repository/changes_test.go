@@ -0,0 +1,102 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/daviddomkar/protofirestore/memstore"
+	"github.com/daviddomkar/protofirestore/repository"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+func TestChanges(t *testing.T) {
+	c, err := repository.NewCollection[*pb.Order](memstore.New(), repository.Options{})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	change := func(kind store.ChangeKind, path string, data map[string]interface{}) store.Change {
+		return store.Change{Kind: kind, Snapshot: &store.Snapshot{Path: path, Data: data}}
+	}
+
+	tests := []struct {
+		desc    string
+		change  store.Change
+		old     *pb.Order
+		new     *pb.Order
+		wantErr bool
+	}{
+		{
+			desc:   "added",
+			change: change(store.ChangeAdded, "users/alice/orders/1", map[string]interface{}{"name": "first"}),
+			new:    &pb.Order{Name: "first"},
+		}, {
+			desc:   "modified",
+			change: change(store.ChangeModified, "users/alice/orders/1", map[string]interface{}{"name": "first", "total": int64(10)}),
+			old:    &pb.Order{Name: "first"},
+			new:    &pb.Order{Name: "first", Total: 10},
+		}, {
+			desc:   "modified without previous version",
+			change: change(store.ChangeModified, "users/alice/orders/2", map[string]interface{}{"name": "second"}),
+			new:    &pb.Order{Name: "second"},
+		}, {
+			desc:    "added invalid document",
+			change:  change(store.ChangeAdded, "users/bob/orders/3", map[string]interface{}{"name": int64(3)}),
+			wantErr: true,
+		}, {
+			desc:    "modified invalid document",
+			change:  change(store.ChangeModified, "users/bob/orders/3", map[string]interface{}{"name": "third"}),
+			new:     &pb.Order{Name: "third"},
+			wantErr: true,
+		}, {
+			desc:    "path not matching the pattern",
+			change:  change(store.ChangeAdded, "orders/4", map[string]interface{}{"name": "fourth"}),
+			wantErr: true,
+		}, {
+			desc:   "removed",
+			change: change(store.ChangeRemoved, "users/alice/orders/1", map[string]interface{}{"name": "first", "total": int64(10)}),
+			old:    &pb.Order{Name: "first", Total: 10},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan store.Change)
+	out := c.Changes(ctx, in)
+
+	for _, tt := range tests {
+		in <- tt.change
+		got := <-out
+
+		if got.Kind != tt.change.Kind || got.Path != tt.change.Snapshot.Path {
+			t.Errorf("%v: got %v change of %v, want %v change of %v\n", tt.desc, got.Kind, got.Path, tt.change.Kind, tt.change.Snapshot.Path)
+		}
+		if (got.Err != nil) != tt.wantErr {
+			t.Errorf("%v: got error %v, want error: %v\n", tt.desc, got.Err, tt.wantErr)
+		}
+		if !equalOrders(got.Old, tt.old) {
+			t.Errorf("%v: got old message %v, want %v\n", tt.desc, got.Old, tt.old)
+		}
+		if !equalOrders(got.New, tt.new) {
+			t.Errorf("%v: got new message %v, want %v\n", tt.desc, got.New, tt.new)
+		}
+	}
+
+	close(in)
+	if _, ok := <-out; ok {
+		t.Errorf("Changes() did not close the channel after its input was closed\n")
+	}
+}
+
+// equalOrders reports whether a and b are equal, treating nil as absent
+// rather than empty.
+func equalOrders(a, b *pb.Order) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return proto.Equal(a, b)
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/store"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// encode marshals m and the documents of its subcollections, leaving out
+// etags which are not stored. Documents of messages with a delete time are
+// stored as not deleted, with a null delete time which queries can filter
+// on, since firestore cannot query documents missing a field.
+func (o Options) encode(m proto.Message) (map[string]interface{}, []protofirestore.Document, *etagField, error) {
+	md := m.ProtoReflect().Descriptor()
+
+	e, err := etagFieldOf(md)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sd, err := softDeleteOf(md)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	data, children, err := o.MarshalOptions.MarshalDocuments(m)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if e != nil && e.source == annotations.FieldOptions_UPDATE_TIME {
+		delete(data, e.key())
+	}
+
+	if sd != nil {
+		data[sd.deleteKey()] = nil
+		if sd.purgeTime != nil {
+			delete(data, sd.purgeTime.JSONName())
+		}
+	}
+
+	return data, children, e, nil
+}
+
+// encodeUpdate marshals the fields of m selected by mask like encode. The
+// delete and purge times are left out, since they are only written by Delete
+// and Undelete.
+func (o Options) encodeUpdate(m proto.Message, mask *fieldmaskpb.FieldMask) (map[string]interface{}, *etagField, error) {
+	md := m.ProtoReflect().Descriptor()
+
+	e, err := etagFieldOf(md)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sd, err := softDeleteOf(md)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mask == nil {
+		mask = &fieldmaskpb.FieldMask{Paths: []string{"*"}}
+	}
+
+	updates, err := o.MarshalOptions.MarshalUpdate(m, mask)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if e != nil && e.source == annotations.FieldOptions_UPDATE_TIME {
+		delete(updates, protofirestore.JoinFieldPath(e.key()))
+	}
+
+	if sd != nil {
+		delete(updates, protofirestore.JoinFieldPath(sd.deleteKey()))
+		if sd.purgeTime != nil {
+			delete(updates, protofirestore.JoinFieldPath(sd.purgeTime.JSONName()))
+		}
+	}
+
+	return updates, e, nil
+}
+
+// decode unmarshals the document of snapshot and the documents of its
+// subcollections into m and fills its etag.
+func (o Options) decode(snapshot *store.Snapshot, children []protofirestore.Document, m proto.Message) error {
+	e, err := etagFieldOf(m.ProtoReflect().Descriptor())
+	if err != nil {
+		return err
+	}
+
+	if err := o.UnmarshalOptions.UnmarshalDocuments(snapshot.Data, children, m); err != nil {
+		return err
+	}
+
+	if e != nil && e.source == annotations.FieldOptions_UPDATE_TIME {
+		e.set(m, formatUpdateTime(snapshot.UpdateTime))
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/store"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ErrEtagMismatch is returned when writing a message whose etag does not
+// match the stored document, i.e. the document has been written since the
+// message was read. It wraps store.ErrAborted, which corresponds to the
+// ABORTED error code AIP-154 requires for this case.
+var ErrEtagMismatch = fmt.Errorf("etag mismatch: %w", store.ErrAborted)
+
+// etagField is a field marked by the etag option.
+type etagField struct {
+	fd     protoreflect.FieldDescriptor
+	source annotations.FieldOptions_EtagSource
+}
+
+// etagFieldOf returns the etag field of md, or nil if it has none.
+func etagFieldOf(md protoreflect.MessageDescriptor) (*etagField, error) {
+	var e *etagField
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+
+		source := annotations.FieldOptionsOf(fd).GetEtag()
+		if source == annotations.FieldOptions_ETAG_SOURCE_UNSPECIFIED {
+			continue
+		}
+
+		switch {
+		case e != nil:
+			return nil, fmt.Errorf("message %v has more than one etag field", md.FullName())
+		case fd.Kind() != protoreflect.StringKind || fd.Cardinality() == protoreflect.Repeated:
+			return nil, fmt.Errorf("etag field %v is not a singular string field", fd.FullName())
+		case fd.ContainingOneof() != nil && !fd.ContainingOneof().IsSynthetic():
+			return nil, fmt.Errorf("etag field %v is part of a oneof", fd.FullName())
+		}
+
+		e = &etagField{fd: fd, source: source}
+	}
+
+	return e, nil
+}
+
+// key returns the key of the field in documents.
+func (e *etagField) key() string {
+	return e.fd.JSONName()
+}
+
+func (e *etagField) get(m proto.Message) string {
+	return m.ProtoReflect().Get(e.fd).String()
+}
+
+func (e *etagField) set(m proto.Message, etag string) {
+	m.ProtoReflect().Set(e.fd, protoreflect.ValueOfString(etag))
+}
+
+// version returns the version counter stored in a document with a VERSION
+// etag, which is zero for documents without one.
+func (e *etagField) version(data map[string]interface{}) (int64, error) {
+	value, ok := data[e.key()]
+	if !ok {
+		return 0, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid version %v", value)
+	}
+
+	version, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid version %q", s)
+	}
+
+	return version, nil
+}
+
+// check fails with ErrEtagMismatch if m has an etag which does not match the
+// document of snapshot, which is nil if the document does not exist. For
+// VERSION etags, it returns the version the document is written with next.
+func (e *etagField) check(m proto.Message, snapshot *store.Snapshot) (string, error) {
+	etag := e.get(m)
+
+	if snapshot == nil {
+		if etag != "" {
+			return "", ErrEtagMismatch // deleted since it was read
+		}
+		if e.source == annotations.FieldOptions_VERSION {
+			return "1", nil
+		}
+		return "", nil
+	}
+
+	if e.source != annotations.FieldOptions_VERSION {
+		if etag != "" && etag != formatUpdateTime(snapshot.UpdateTime) {
+			return "", ErrEtagMismatch
+		}
+		return "", nil
+	}
+
+	version, err := e.version(snapshot.Data)
+	if err != nil {
+		return "", err
+	}
+
+	if etag != "" && etag != strconv.FormatInt(version, 10) {
+		return "", ErrEtagMismatch
+	}
+
+	return strconv.FormatInt(version+1, 10), nil
+}
+
+// next returns the version a document with a VERSION etag is written with
+// next.
+func (e *etagField) next(data map[string]interface{}) (string, error) {
+	version, err := e.version(data)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(version+1, 10), nil
+}
+
+// precondition returns pre restricted to the update time of the etag of m,
+// which must have an UPDATE_TIME etag. The second result reports whether m
+// has an etag.
+func (e *etagField) precondition(m proto.Message, pre store.Precondition) (store.Precondition, bool, error) {
+	etag := e.get(m)
+	if etag == "" {
+		return pre, false, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, etag)
+	if err != nil {
+		return pre, false, ErrEtagMismatch // no document has this etag
+	}
+
+	pre.UpdateTime = t
+	return pre, true, nil
+}
+
+// formatUpdateTime returns the UPDATE_TIME etag of a document with the given
+// update time.
+func formatUpdateTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
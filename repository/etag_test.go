@@ -0,0 +1,154 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	pb "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/daviddomkar/protofirestore/memstore"
+	"github.com/daviddomkar/protofirestore/repository"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+func TestUpdateTimeEtag(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	c, err := repository.NewCollection[*pb.Article](s, repository.Options{})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	if err := c.Create(ctx, "articles/a", &pb.Article{Title: "first", Etag: "ignored"}); err != nil {
+		t.Fatalf("Create() returned error: %v\n", err)
+	}
+
+	snapshot, err := s.Get(ctx, "articles/a")
+	if err != nil {
+		t.Fatalf("Get() returned error: %v\n", err)
+	}
+	if _, ok := snapshot.Data["etag"]; ok {
+		t.Errorf("Create() stored the etag in %v\n", snapshot.Data)
+	}
+
+	article, err := c.Get(ctx, "articles/a", repository.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() returned error: %v\n", err)
+	}
+	if article.GetEtag() == "" {
+		t.Fatalf("Get() = %v, want etag\n", article)
+	}
+
+	stale := &pb.Article{Title: "stale", Etag: article.GetEtag()}
+	mask := &fieldmaskpb.FieldMask{Paths: []string{"title"}}
+
+	article.Title = "second"
+	if err := c.Update(ctx, "articles/a", article, mask, store.Precondition{}); err != nil {
+		t.Fatalf("Update() returned error: %v\n", err)
+	}
+
+	if err := c.Update(ctx, "articles/a", stale, mask, store.Precondition{}); !errors.Is(err, repository.ErrEtagMismatch) || !errors.Is(err, store.ErrAborted) {
+		t.Errorf("Update() with outdated etag returned error %v, want %v\n", err, repository.ErrEtagMismatch)
+	}
+
+	if err := c.Set(ctx, "articles/a", stale); !errors.Is(err, repository.ErrEtagMismatch) {
+		t.Errorf("Set() with outdated etag returned error %v, want %v\n", err, repository.ErrEtagMismatch)
+	}
+
+	if err := c.Update(ctx, "articles/a", &pb.Article{Etag: "invalid"}, nil, store.Precondition{}); !errors.Is(err, repository.ErrEtagMismatch) {
+		t.Errorf("Update() with invalid etag returned error %v, want %v\n", err, repository.ErrEtagMismatch)
+	}
+
+	article, err = c.Get(ctx, "articles/a", repository.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() returned error: %v\n", err)
+	}
+	if article.GetTitle() != "second" || article.GetEtag() == stale.GetEtag() {
+		t.Errorf("Get() after Update() = %v, want second title and new etag\n", article)
+	}
+
+	article.Title = "third"
+	if err := c.Set(ctx, "articles/a", article); err != nil {
+		t.Fatalf("Set() returned error: %v\n", err)
+	}
+
+	if err := c.Set(ctx, "articles/b", &pb.Article{Title: "new", Etag: article.GetEtag()}); !errors.Is(err, repository.ErrEtagMismatch) {
+		t.Errorf("Set() of missing document with etag returned error %v, want %v\n", err, repository.ErrEtagMismatch)
+	}
+}
+
+func TestVersionEtag(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	c, err := repository.NewCollection[*pb.Draft](s, repository.Options{})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	get := func() *pb.Draft {
+		t.Helper()
+		draft, err := c.Get(ctx, "drafts/a", repository.GetOptions{})
+		if err != nil {
+			t.Fatalf("Get() returned error: %v\n", err)
+		}
+		return draft
+	}
+
+	if err := c.Create(ctx, "drafts/a", &pb.Draft{Title: "first"}); err != nil {
+		t.Fatalf("Create() returned error: %v\n", err)
+	}
+	if got := get().GetEtag(); got != "1" {
+		t.Errorf("Get() after Create() has etag %q, want 1\n", got)
+	}
+
+	if err := c.Update(ctx, "drafts/a", &pb.Draft{Title: "second", Etag: "1"}, nil, store.Precondition{}); err != nil {
+		t.Fatalf("Update() returned error: %v\n", err)
+	}
+	if got := get(); got.GetEtag() != "2" || got.GetTitle() != "second" {
+		t.Errorf("Get() after Update() = %v, want second title and etag 2\n", got)
+	}
+
+	if err := c.Update(ctx, "drafts/a", &pb.Draft{Title: "stale", Etag: "1"}, nil, store.Precondition{}); !errors.Is(err, repository.ErrEtagMismatch) {
+		t.Errorf("Update() with outdated etag returned error %v, want %v\n", err, repository.ErrEtagMismatch)
+	}
+
+	if err := c.Set(ctx, "drafts/a", &pb.Draft{Title: "third"}); err != nil {
+		t.Fatalf("Set() returned error: %v\n", err)
+	}
+	if got := get(); got.GetEtag() != "3" || got.GetTitle() != "third" {
+		t.Errorf("Get() after Set() = %v, want third title and etag 3\n", got)
+	}
+
+	if err := c.Set(ctx, "drafts/b", &pb.Draft{Title: "new"}); err != nil {
+		t.Fatalf("Set() returned error: %v\n", err)
+	}
+
+	err = repository.RunTransaction(ctx, s, func(ctx context.Context, tx *repository.Tx) error {
+		draft := &pb.Draft{}
+		if err := tx.Get("drafts/a", draft); err != nil {
+			return err
+		}
+
+		if err := tx.Update("drafts/b", draft, nil, store.Precondition{}); err == nil {
+			t.Errorf("Update() of document not read by the transaction got nil error, want error\n")
+		}
+
+		draft.Title = "fourth"
+		return tx.Set("drafts/a", draft)
+	})
+	if err != nil {
+		t.Fatalf("RunTransaction() returned error: %v\n", err)
+	}
+	if got := get(); got.GetEtag() != "4" || got.GetTitle() != "fourth" {
+		t.Errorf("Get() after transaction = %v, want fourth title and etag 4\n", got)
+	}
+
+	b := repository.NewBatchWriter(s, repository.Options{})
+	if err := b.Update("drafts/a", &pb.Draft{}, nil, store.Precondition{}); err == nil {
+		t.Errorf("BatchWriter.Update() of versioned message got nil error, want error\n")
+	}
+}
@@ -0,0 +1,477 @@
+// Package repository reads and writes messages as documents of a store.Store,
+// taking care of the document paths, encoding and decoding which would
+// otherwise be repeated by every service storing messages.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/docpath"
+	"github.com/daviddomkar/protofirestore/query"
+	"github.com/daviddomkar/protofirestore/store"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Options configures a Collection.
+type Options struct {
+	// Pattern is the path pattern of the documents. If empty, this defaults
+	// to the path option of the message.
+	Pattern string
+
+	// MarshalOptions and UnmarshalOptions encode and decode documents.
+	MarshalOptions   protofirestore.MarshalOptions
+	UnmarshalOptions protofirestore.UnmarshalOptions
+
+	// Retention is the time soft-deleted documents are kept before they are
+	// purged. If zero, this defaults to DefaultRetention.
+	Retention time.Duration
+}
+
+// Collection reads and writes messages of type T, which must be a generated
+// message type, as documents of a store.Store. Errors of the store are
+// wrapped, so store.ErrNotFound, store.ErrAlreadyExists and
+// store.ErrFailedPrecondition can be checked with errors.Is.
+//
+// If T has a field marked by the etag option, the etag is filled when
+// reading messages, and writes of messages with an etag fail with
+// ErrEtagMismatch unless it matches the stored document. Etags derived from
+// a version counter require reading the document before every write other
+// than Create.
+//
+// If T has a field marked by the delete_time option, documents are
+// soft-deleted as described by AIP-164: Delete sets the delete time, Get and
+// List hide documents which have one unless asked to show them, Undelete
+// clears it again and Purge deletes the documents once their retention has
+// elapsed. Since firestore cannot query documents missing a field, the
+// documents must be created by the repository, which stores a null delete
+// time.
+//
+// If T has fields marked by the subcollection option, Create and Set write
+// their documents along with the document of the message, in a single
+// commit if the store implements store.Batcher and there are no more than
+// store.MaxBatchWrites writes. Set and Delete delete the documents which are
+// no longer part of the message, and Get reads them unless asked to skip
+// them. Update, List and soft deletes leave subcollections alone.
+type Collection[T proto.Message] struct {
+	store          store.Store
+	pattern        *docpath.Pattern
+	etag           *etagField
+	softDelete     *softDelete
+	subcollections bool
+	opts           Options
+}
+
+// NewCollection returns a Collection of messages of type T stored in s at
+// the paths of the path pattern of opts or of the message.
+func NewCollection[T proto.Message](s store.Store, opts Options) (*Collection[T], error) {
+	var zero T
+	md := zero.ProtoReflect().Descriptor()
+
+	path := opts.Pattern
+	if path == "" {
+		path = annotations.MessageOptionsOf(md).GetPath()
+	}
+	if path == "" {
+		return nil, fmt.Errorf("message %v has no path option", md.FullName())
+	}
+
+	pattern, err := docpath.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("message %v: %w", md.FullName(), err)
+	}
+
+	etag, err := etagFieldOf(md)
+	if err != nil {
+		return nil, err
+	}
+
+	softDelete, err := softDeleteOf(md)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Collection[T]{
+		store:          s,
+		pattern:        pattern,
+		etag:           etag,
+		softDelete:     softDelete,
+		subcollections: len(subcollectionFields(md)) != 0,
+		opts:           opts,
+	}, nil
+}
+
+// Pattern returns the path pattern of the documents.
+func (c *Collection[T]) Pattern() *docpath.Pattern {
+	return c.pattern
+}
+
+// Path returns the path of the document with the given document IDs, one for
+// each variable of the path pattern in order.
+func (c *Collection[T]) Path(ids ...string) (string, error) {
+	return c.pattern.Expand(ids...)
+}
+
+func (c *Collection[T]) checkPath(path string) error {
+	if _, ok := c.pattern.Match(path); !ok {
+		return fmt.Errorf("%q does not match %v", path, c.pattern)
+	}
+	return nil
+}
+
+func (c *Collection[T]) checkParent(parent string) error {
+	// Any valid document ID completes the parent to a document path.
+	if _, ok := c.pattern.Match(store.CollectionPath(parent, c.pattern.CollectionID()) + "/id"); !ok {
+		return fmt.Errorf("%q is not a parent of %v", parent, c.pattern)
+	}
+	return nil
+}
+
+func (c *Collection[T]) new() T {
+	var zero T
+	return zero.ProtoReflect().Type().New().Interface().(T)
+}
+
+func (c *Collection[T]) decode(snapshot *store.Snapshot, children []protofirestore.Document) (T, error) {
+	m := c.new()
+	if err := c.opts.decode(snapshot, children, m); err != nil {
+		var zero T
+		return zero, fmt.Errorf("decode %v: %w", snapshot.Path, err)
+	}
+	return m, nil
+}
+
+// GetOptions configures Get.
+type GetOptions struct {
+	// ShowDeleted returns soft-deleted messages, which are otherwise not
+	// found.
+	ShowDeleted bool
+
+	// SkipSubcollections does not read the subcollections of the message,
+	// which can be loaded later by LoadSubcollections.
+	SkipSubcollections bool
+}
+
+// Get returns the message stored at path.
+func (c *Collection[T]) Get(ctx context.Context, path string, opts GetOptions) (T, error) {
+	var zero T
+
+	if err := c.checkPath(path); err != nil {
+		return zero, err
+	}
+
+	snapshot, err := c.store.Get(ctx, path)
+	if err != nil {
+		return zero, fmt.Errorf("get %v: %w", path, err)
+	}
+
+	if c.softDelete != nil && !opts.ShowDeleted && c.softDelete.isDeleted(snapshot.Data) {
+		return zero, fmt.Errorf("get %v: %w", path, store.ErrNotFound)
+	}
+
+	var children []protofirestore.Document
+	if c.subcollections && !opts.SkipSubcollections {
+		if children, err = c.readChildren(ctx, path); err != nil {
+			return zero, fmt.Errorf("get %v: %w", path, err)
+		}
+	}
+
+	return c.decode(snapshot, children)
+}
+
+// Create stores m at path, which must not exist yet.
+func (c *Collection[T]) Create(ctx context.Context, path string, m T) error {
+	if err := c.checkPath(path); err != nil {
+		return err
+	}
+
+	data, children, _, err := c.opts.encode(m)
+	if err != nil {
+		return fmt.Errorf("encode %v: %w", path, err)
+	}
+
+	if c.isVersioned() {
+		data[c.etag.key()] = "1"
+	}
+
+	writes := []store.Write{{Op: store.OpCreate, Path: path, Data: data}}
+	if err := c.write(ctx, append(writes, childWrites(path, children, nil)...)); err != nil {
+		return fmt.Errorf("create %v: %w", path, err)
+	}
+
+	return nil
+}
+
+// Set stores m at path, replacing the document if it exists. If m has an
+// etag, the document must exist and match it.
+func (c *Collection[T]) Set(ctx context.Context, path string, m T) error {
+	if err := c.checkPath(path); err != nil {
+		return err
+	}
+
+	data, children, _, err := c.opts.encode(m)
+	if err != nil {
+		return fmt.Errorf("encode %v: %w", path, err)
+	}
+
+	w := store.Write{Op: store.OpSet, Path: path, Data: data}
+
+	checked := c.isVersioned() || c.etag != nil && c.etag.get(m) != ""
+	if checked {
+		if w, err = c.setChecked(ctx, path, m, data); err != nil {
+			return fmt.Errorf("set %v: %w", path, err)
+		}
+	}
+
+	writes := []store.Write{w}
+	if c.subcollections {
+		old, err := c.readChildren(ctx, path)
+		if err != nil {
+			return fmt.Errorf("set %v: %w", path, err)
+		}
+		writes = append(writes, childWrites(path, children, old)...)
+	}
+
+	if err := c.write(ctx, writes); err != nil {
+		if checked && (errors.Is(err, store.ErrAlreadyExists) || errors.Is(err, store.ErrFailedPrecondition) || errors.Is(err, store.ErrNotFound)) {
+			err = ErrEtagMismatch // written or deleted concurrently
+		}
+		return fmt.Errorf("set %v: %w", path, err)
+	}
+
+	return nil
+}
+
+// Update writes the fields of m selected by mask to the existing document at
+// path, as described by protofirestore.MarshalUpdate. A nil mask selects all
+// fields except subcollections, which cannot be selected. The etag of m, if
+// any, replaces the update time of pre. Soft-deleted documents are not
+// found.
+func (c *Collection[T]) Update(ctx context.Context, path string, m T, mask *fieldmaskpb.FieldMask, pre store.Precondition) error {
+	if err := c.checkPath(path); err != nil {
+		return err
+	}
+
+	updates, _, err := c.opts.encodeUpdate(m, mask)
+	if err != nil {
+		return fmt.Errorf("encode %v: %w", path, err)
+	}
+
+	checked := false
+	if c.etag != nil && !c.isVersioned() {
+		if pre, checked, err = c.etag.precondition(m, pre); err != nil {
+			return fmt.Errorf("update %v: %w", path, err)
+		}
+	}
+
+	// Soft-deleted documents are not found, and versioned etags are checked
+	// against the stored version, so the document is read first and the
+	// update guarded by its update time.
+	read := false
+	if c.isVersioned() || c.softDelete != nil {
+		snapshot, err := c.store.Get(ctx, path)
+		if err != nil {
+			return fmt.Errorf("update %v: %w", path, err)
+		}
+		if c.softDelete != nil && c.softDelete.isDeleted(snapshot.Data) {
+			return fmt.Errorf("update %v: %w", path, store.ErrNotFound)
+		}
+		if !pre.UpdateTime.IsZero() && !pre.UpdateTime.Equal(snapshot.UpdateTime) {
+			err := store.ErrFailedPrecondition
+			if checked {
+				err = ErrEtagMismatch
+			}
+			return fmt.Errorf("update %v: %w", path, err)
+		}
+
+		if c.isVersioned() {
+			version, err := c.etag.check(m, snapshot)
+			if err != nil {
+				return fmt.Errorf("update %v: %w", path, err)
+			}
+			updates[protofirestore.JoinFieldPath(c.etag.key())] = version
+			checked = true
+		}
+
+		read = pre.UpdateTime.IsZero()
+		pre = store.Precondition{UpdateTime: snapshot.UpdateTime}
+	}
+
+	if _, err := c.store.Update(ctx, path, updates, pre); err != nil {
+		switch {
+		case checked && errors.Is(err, store.ErrFailedPrecondition):
+			err = ErrEtagMismatch
+		case read && (errors.Is(err, store.ErrFailedPrecondition) || errors.Is(err, store.ErrNotFound)):
+			err = fmt.Errorf("written concurrently: %w", store.ErrAborted)
+		}
+		return fmt.Errorf("update %v: %w", path, err)
+	}
+
+	return nil
+}
+
+// isVersioned reports whether the messages have an etag derived from a
+// version counter.
+func (c *Collection[T]) isVersioned() bool {
+	return c.etag != nil && c.etag.source == annotations.FieldOptions_VERSION
+}
+
+// setChecked checks the etag of m against the document at path and returns
+// the write storing the encoded message m, with the version of the document
+// incremented. Since stores cannot set documents conditionally, missing
+// documents are created and existing documents are replaced by an update
+// guarded by their update time, so that the write fails if the document is
+// written concurrently.
+func (c *Collection[T]) setChecked(ctx context.Context, path string, m T, data map[string]interface{}) (store.Write, error) {
+	snapshot, err := c.store.Get(ctx, path)
+	if errors.Is(err, store.ErrNotFound) {
+		snapshot = nil
+	} else if err != nil {
+		return store.Write{}, err
+	}
+
+	version, err := c.etag.check(m, snapshot)
+	if err != nil {
+		return store.Write{}, err
+	}
+	if version != "" {
+		data[c.etag.key()] = version
+	}
+
+	if snapshot == nil {
+		return store.Write{Op: store.OpCreate, Path: path, Data: data}, nil
+	}
+
+	return store.Write{
+		Op:           store.OpUpdate,
+		Path:         path,
+		Data:         protofirestore.ReplaceUpdates(snapshot.Data, data),
+		Precondition: store.Precondition{UpdateTime: snapshot.UpdateTime},
+	}, nil
+}
+
+// Delete deletes the document at path along with its subcollections.
+// Soft-deleted documents are not found.
+func (c *Collection[T]) Delete(ctx context.Context, path string, pre store.Precondition) error {
+	if err := c.checkPath(path); err != nil {
+		return err
+	}
+
+	if c.softDelete != nil {
+		if _, err := c.setDeleted(ctx, path, pre, true); err != nil {
+			return fmt.Errorf("delete %v: %w", path, err)
+		}
+		return nil
+	}
+
+	writes := []store.Write{{Op: store.OpDelete, Path: path, Precondition: pre}}
+	if c.subcollections {
+		old, err := c.readChildren(ctx, path)
+		if err != nil {
+			return fmt.Errorf("delete %v: %w", path, err)
+		}
+		writes = append(writes, childWrites(path, nil, old)...)
+	}
+
+	if err := c.write(ctx, writes); err != nil {
+		return fmt.Errorf("delete %v: %w", path, err)
+	}
+
+	return nil
+}
+
+// ListOptions selects the documents returned by List.
+type ListOptions struct {
+	// Filter, if not nil, restricts the documents to the ones it matches.
+	Filter query.Filter
+
+	// Orders are the orders of the documents, which are ordered by their
+	// path if Orders is empty.
+	Orders []query.Order
+
+	// StartAfter, if not nil, continues a previous List at the Next cursor
+	// of its Page.
+	StartAfter *query.Cursor
+
+	// Limit, if positive, is the maximum number of documents returned.
+	Limit int
+
+	// ShowDeleted includes soft-deleted documents.
+	ShowDeleted bool
+}
+
+// Page is a page of documents returned by List.
+type Page[T proto.Message] struct {
+	// Paths are the paths of the documents.
+	Paths []string
+
+	// Messages are the messages stored in the documents.
+	Messages []T
+
+	// Next is the cursor continuing after the last document, or nil if there
+	// are no more documents.
+	Next *query.Cursor
+}
+
+// List returns the messages stored in the collection below the document at
+// parent, which is the empty string for root collections. Subcollections are
+// not read, see LoadSubcollections.
+func (c *Collection[T]) List(ctx context.Context, parent string, opts ListOptions) (*Page[T], error) {
+	if err := c.checkParent(parent); err != nil {
+		return nil, err
+	}
+
+	q := store.Query{
+		Parent:       parent,
+		CollectionID: c.pattern.CollectionID(),
+		Filter:       opts.Filter,
+		Orders:       opts.Orders,
+		StartAfter:   opts.StartAfter,
+		Limit:        opts.Limit,
+	}
+
+	if c.softDelete != nil && !opts.ShowDeleted {
+		notDeleted := query.FieldFilter{
+			Path:     protofirestore.JoinFieldPath(c.softDelete.deleteKey()),
+			Operator: query.Equal,
+			Value:    nil,
+		}
+
+		if q.Filter == nil {
+			q.Filter = notDeleted
+		} else {
+			q.Filter = query.CompositeFilter{Operator: query.And, Filters: []query.Filter{q.Filter, notDeleted}}
+		}
+	}
+
+	snapshots, err := c.store.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list %v: %w", store.CollectionPath(parent, q.CollectionID), err)
+	}
+
+	page := &Page[T]{}
+	for _, snapshot := range snapshots {
+		m, err := c.decode(snapshot, nil)
+		if err != nil {
+			return nil, err
+		}
+		page.Paths = append(page.Paths, snapshot.Path)
+		page.Messages = append(page.Messages, m)
+	}
+
+	if n := len(snapshots); n != 0 && n == opts.Limit {
+		last := snapshots[n-1]
+		cursor, err := query.CursorOf(opts.Orders, q.CursorID(last.Path), last.Data)
+		if err != nil {
+			return nil, err
+		}
+		page.Next = &cursor
+	}
+
+	return page, nil
+}
@@ -0,0 +1,142 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	pb "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/daviddomkar/protofirestore/memstore"
+	"github.com/daviddomkar/protofirestore/repository"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+func TestNewCollection(t *testing.T) {
+	if _, err := repository.NewCollection[*pb3.Scalars](memstore.New(), repository.Options{}); err == nil {
+		t.Errorf("NewCollection() of message without path option got nil error, want error\n")
+	}
+
+	c, err := repository.NewCollection[*pb3.Scalars](memstore.New(), repository.Options{Pattern: "scalars/{scalars}"})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	if got, want := c.Pattern().String(), "scalars/{scalars}"; got != want {
+		t.Errorf("Pattern() = %v, want %v\n", got, want)
+	}
+}
+
+func TestCollection(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	c, err := repository.NewCollection[*pb.Order](s, repository.Options{})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	path, err := c.Path("alice", "1")
+	if err != nil {
+		t.Fatalf("Path() returned error: %v\n", err)
+	}
+	if path != "users/alice/orders/1" {
+		t.Errorf("Path() = %v, want users/alice/orders/1\n", path)
+	}
+
+	order := &pb.Order{Name: "first", State: pb.Order_OPEN, Total: 10}
+
+	if _, err := c.Get(ctx, path, repository.GetOptions{}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get() of missing document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+
+	if err := c.Create(ctx, path, order); err != nil {
+		t.Fatalf("Create() returned error: %v\n", err)
+	}
+
+	if err := c.Create(ctx, path, order); !errors.Is(err, store.ErrAlreadyExists) {
+		t.Errorf("Create() of existing document returned error %v, want %v\n", err, store.ErrAlreadyExists)
+	}
+
+	if err := c.Create(ctx, "users/alice", order); err == nil {
+		t.Errorf("Create() at path not matching the pattern got nil error, want error\n")
+	}
+
+	got, err := c.Get(ctx, path, repository.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() returned error: %v\n", err)
+	}
+	if !proto.Equal(got, order) {
+		t.Errorf("Get() = %v, want %v\n", got, order)
+	}
+
+	update := &pb.Order{State: pb.Order_SHIPPED, Notes: "ignored"}
+	mask := &fieldmaskpb.FieldMask{Paths: []string{"state", "total"}}
+	if err := c.Update(ctx, path, update, mask, store.Precondition{}); err != nil {
+		t.Fatalf("Update() returned error: %v\n", err)
+	}
+
+	got, err = c.Get(ctx, path, repository.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() returned error: %v\n", err)
+	}
+	if want := (&pb.Order{Name: "first", State: pb.Order_SHIPPED}); !proto.Equal(got, want) {
+		t.Errorf("Get() after Update() = %v, want %v\n", got, want)
+	}
+
+	stale := store.Precondition{UpdateTime: time.Unix(1, 0)}
+	if err := c.Update(ctx, path, update, mask, stale); !errors.Is(err, store.ErrFailedPrecondition) {
+		t.Errorf("Update() with stale update time returned error %v, want %v\n", err, store.ErrFailedPrecondition)
+	}
+
+	if err := c.Update(ctx, "users/alice/orders/2", update, nil, store.Precondition{}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Update() of missing document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+
+	if err := c.Set(ctx, "users/alice/orders/2", &pb.Order{Name: "second"}); err != nil {
+		t.Fatalf("Set() returned error: %v\n", err)
+	}
+	if err := c.Set(ctx, "users/bob/orders/3", &pb.Order{Name: "third"}); err != nil {
+		t.Fatalf("Set() returned error: %v\n", err)
+	}
+
+	page, err := c.List(ctx, "users/alice", repository.ListOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("List() returned error: %v\n", err)
+	}
+	if len(page.Messages) != 1 || page.Messages[0].GetName() != "first" || page.Paths[0] != path || page.Next == nil {
+		t.Errorf("List() = %v, want first order and a cursor\n", page)
+	}
+
+	page, err = c.List(ctx, "users/alice", repository.ListOptions{Limit: 1, StartAfter: page.Next})
+	if err != nil {
+		t.Fatalf("List() returned error: %v\n", err)
+	}
+	if len(page.Messages) != 1 || page.Messages[0].GetName() != "second" {
+		t.Errorf("List() of second page = %v, want second order\n", page)
+	}
+
+	page, err = c.List(ctx, "users/alice", repository.ListOptions{Limit: 1, StartAfter: page.Next})
+	if err != nil {
+		t.Fatalf("List() returned error: %v\n", err)
+	}
+	if len(page.Messages) != 0 || page.Next != nil {
+		t.Errorf("List() of last page = %v, want no orders\n", page)
+	}
+
+	if _, err := c.List(ctx, "orders/1", repository.ListOptions{}); err == nil {
+		t.Errorf("List() with invalid parent got nil error, want error\n")
+	}
+
+	if err := c.Delete(ctx, path, store.Precondition{Exists: true}); err != nil {
+		t.Fatalf("Delete() returned error: %v\n", err)
+	}
+
+	if err := c.Delete(ctx, path, store.Precondition{Exists: true}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Delete() of missing document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/internal/genid"
+	"github.com/daviddomkar/protofirestore/query"
+	"github.com/daviddomkar/protofirestore/store"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DefaultRetention is the time soft-deleted documents are kept before they
+// are purged, which is the 30 days recommended by AIP-164.
+const DefaultRetention = 30 * 24 * time.Hour
+
+// softDelete are the fields marked by the delete_time and purge_time
+// options.
+type softDelete struct {
+	deleteTime protoreflect.FieldDescriptor
+
+	// purgeTime is nil for messages without a purge time.
+	purgeTime protoreflect.FieldDescriptor
+}
+
+// softDeleteOf returns the soft delete fields of md, or nil if it has none.
+func softDeleteOf(md protoreflect.MessageDescriptor) (*softDelete, error) {
+	var deleteTime, purgeTime protoreflect.FieldDescriptor
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		opts := annotations.FieldOptionsOf(fd)
+
+		var field *protoreflect.FieldDescriptor
+		switch {
+		case opts.GetDeleteTime() && opts.GetPurgeTime():
+			return nil, fmt.Errorf("field %v is both delete time and purge time", fd.FullName())
+		case opts.GetDeleteTime():
+			field = &deleteTime
+		case opts.GetPurgeTime():
+			field = &purgeTime
+		default:
+			continue
+		}
+
+		switch {
+		case *field != nil:
+			return nil, fmt.Errorf("message %v has more than one %v field", md.FullName(), optionName(opts))
+		case fd.Message() == nil || fd.Message().FullName() != genid.Timestamp_message_fullname || fd.Cardinality() == protoreflect.Repeated:
+			return nil, fmt.Errorf("%v field %v is not a singular google.protobuf.Timestamp field", optionName(opts), fd.FullName())
+		case fd.ContainingOneof() != nil:
+			return nil, fmt.Errorf("%v field %v is part of a oneof", optionName(opts), fd.FullName())
+		}
+
+		*field = fd
+	}
+
+	switch {
+	case deleteTime == nil && purgeTime != nil:
+		return nil, fmt.Errorf("message %v has a purge time but no delete time", md.FullName())
+	case deleteTime == nil:
+		return nil, nil
+	}
+
+	return &softDelete{deleteTime: deleteTime, purgeTime: purgeTime}, nil
+}
+
+// optionName returns the name of the soft delete option set in opts.
+func optionName(opts *annotations.FieldOptions) string {
+	if opts.GetDeleteTime() {
+		return "delete_time"
+	}
+	return "purge_time"
+}
+
+// deleteKey returns the key of the delete time in documents.
+func (s *softDelete) deleteKey() string {
+	return s.deleteTime.JSONName()
+}
+
+// isDeleted reports whether the document data has been soft-deleted.
+func (s *softDelete) isDeleted(data map[string]interface{}) bool {
+	return data[s.deleteKey()] != nil
+}
+
+// retention returns the time soft-deleted documents are kept.
+func (o Options) retention() time.Duration {
+	if o.Retention == 0 {
+		return DefaultRetention
+	}
+	return o.Retention
+}
+
+// purgePageSize is the number of documents Purge reads at a time.
+const purgePageSize = 100
+
+// Undelete restores the soft-deleted document at path and returns its
+// message. Documents which are not deleted fail with store.ErrAlreadyExists,
+// as required by AIP-164.
+func (c *Collection[T]) Undelete(ctx context.Context, path string) (T, error) {
+	var zero T
+
+	if err := c.checkPath(path); err != nil {
+		return zero, err
+	}
+
+	if c.softDelete == nil {
+		return zero, fmt.Errorf("message %v has no delete time", zero.ProtoReflect().Descriptor().FullName())
+	}
+
+	snapshot, err := c.setDeleted(ctx, path, store.Precondition{}, false)
+	if err != nil {
+		return zero, fmt.Errorf("undelete %v: %w", path, err)
+	}
+
+	var children []protofirestore.Document
+	if c.subcollections {
+		if children, err = c.readChildren(ctx, path); err != nil {
+			return zero, fmt.Errorf("undelete %v: %w", path, err)
+		}
+	}
+
+	return c.decode(snapshot, children)
+}
+
+// setDeleted sets or clears the delete time of the document at path and
+// returns the document as it has been written.
+func (c *Collection[T]) setDeleted(ctx context.Context, path string, pre store.Precondition, deleted bool) (*store.Snapshot, error) {
+	snapshot, err := c.store.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.softDelete.check(snapshot, pre, deleted); err != nil {
+		return nil, err
+	}
+
+	updates, data, err := c.opts.deletedUpdates(c.softDelete, c.etag, snapshot.Data, deleted)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.store.Update(ctx, path, updates, store.Precondition{UpdateTime: snapshot.UpdateTime})
+	if errors.Is(err, store.ErrFailedPrecondition) || errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("written concurrently: %w", store.ErrAborted)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &store.Snapshot{
+		Path:       snapshot.Path,
+		Data:       data,
+		CreateTime: snapshot.CreateTime,
+		UpdateTime: result.UpdateTime,
+	}, nil
+}
+
+// check checks that the delete time of the document read into snapshot can
+// be set, or cleared if deleted is false, under the precondition pre.
+func (s *softDelete) check(snapshot *store.Snapshot, pre store.Precondition, deleted bool) error {
+	switch {
+	case deleted && s.isDeleted(snapshot.Data):
+		return store.ErrNotFound
+	case !deleted && !s.isDeleted(snapshot.Data):
+		return store.ErrAlreadyExists
+	case !pre.UpdateTime.IsZero() && !pre.UpdateTime.Equal(snapshot.UpdateTime):
+		return store.ErrFailedPrecondition
+	}
+	return nil
+}
+
+// deletedUpdates returns the updates setting or clearing the delete time of
+// the document data, along with the document they result in. Etags derived
+// from a version counter, if e is one, are incremented.
+func (o Options) deletedUpdates(sd *softDelete, e *etagField, data map[string]interface{}, deleted bool) (map[string]interface{}, map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		result[k] = v
+	}
+
+	updates := make(map[string]interface{})
+	set := func(key string, value interface{}) {
+		updates[protofirestore.JoinFieldPath(key)] = value
+		result[key] = value
+	}
+
+	if deleted {
+		now := time.Now().UTC()
+		set(sd.deleteKey(), now)
+		if sd.purgeTime != nil {
+			set(sd.purgeTime.JSONName(), now.Add(o.retention()))
+		}
+	} else {
+		set(sd.deleteKey(), nil)
+		if sd.purgeTime != nil {
+			key := sd.purgeTime.JSONName()
+			updates[protofirestore.JoinFieldPath(key)] = protofirestore.Delete
+			delete(result, key)
+		}
+	}
+
+	if e != nil && e.source == annotations.FieldOptions_VERSION {
+		version, err := e.next(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		set(e.key(), version)
+	}
+
+	return updates, result, nil
+}
+
+// Purge deletes the soft-deleted documents of the collection below the
+// document at parent whose purge time has elapsed, or whose retention has
+// elapsed for messages without a purge time, and returns the number of
+// documents deleted. Documents written while purging are kept.
+//
+// Purge is an alternative to the TTL policies of firestore, which the
+// indexes generator configures for purge times.
+func (c *Collection[T]) Purge(ctx context.Context, parent string) (int, error) {
+	if c.softDelete == nil {
+		var zero T
+		return 0, fmt.Errorf("message %v has no delete time", zero.ProtoReflect().Descriptor().FullName())
+	}
+
+	if err := c.checkParent(parent); err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	key, before := c.softDelete.deleteKey(), now.Add(-c.opts.retention())
+	if c.softDelete.purgeTime != nil {
+		key, before = c.softDelete.purgeTime.JSONName(), now
+	}
+
+	path := protofirestore.JoinFieldPath(key)
+	q := store.Query{
+		Parent:       parent,
+		CollectionID: c.pattern.CollectionID(),
+		Filter:       query.FieldFilter{Path: path, Operator: query.LessThanOrEqual, Value: before},
+		Orders:       []query.Order{{Path: path, Direction: query.Ascending}},
+		Limit:        purgePageSize,
+	}
+
+	purged := 0
+	for {
+		snapshots, err := c.store.Query(ctx, q)
+		if err != nil {
+			return purged, fmt.Errorf("purge %v: %w", store.CollectionPath(parent, q.CollectionID), err)
+		}
+
+		for _, snapshot := range snapshots {
+			writes := []store.Write{{Op: store.OpDelete, Path: snapshot.Path, Precondition: store.Precondition{UpdateTime: snapshot.UpdateTime}}}
+			if c.subcollections {
+				old, err := c.readChildren(ctx, snapshot.Path)
+				if err != nil {
+					return purged, fmt.Errorf("purge %v: %w", snapshot.Path, err)
+				}
+				writes = append(writes, childWrites(snapshot.Path, nil, old)...)
+			}
+
+			err := c.write(ctx, writes)
+			switch {
+			case errors.Is(err, store.ErrFailedPrecondition), errors.Is(err, store.ErrNotFound):
+				continue // written since, e.g. undeleted
+			case err != nil:
+				return purged, fmt.Errorf("purge %v: %w", snapshot.Path, err)
+			}
+			purged++
+		}
+
+		if len(snapshots) < q.Limit {
+			return purged, nil
+		}
+
+		last := snapshots[len(snapshots)-1]
+		cursor, err := query.CursorOf(q.Orders, q.CursorID(last.Path), last.Data)
+		if err != nil {
+			return purged, err
+		}
+		q.StartAfter = &cursor
+	}
+}
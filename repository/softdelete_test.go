@@ -0,0 +1,115 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/daviddomkar/protofirestore/memstore"
+	"github.com/daviddomkar/protofirestore/repository"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	c, err := repository.NewCollection[*pb.Book](s, repository.Options{Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	for _, path := range []string{"books/a", "books/b"} {
+		if err := c.Create(ctx, path, &pb.Book{Title: path}); err != nil {
+			t.Fatalf("Create() returned error: %v\n", err)
+		}
+	}
+
+	if err := c.Delete(ctx, "books/a", store.Precondition{}); err != nil {
+		t.Fatalf("Delete() returned error: %v\n", err)
+	}
+
+	if err := c.Delete(ctx, "books/a", store.Precondition{}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Delete() of deleted document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+
+	if _, err := c.Get(ctx, "books/a", repository.GetOptions{}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get() of deleted document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+
+	if err := c.Update(ctx, "books/a", &pb.Book{Title: "updated"}, nil, store.Precondition{}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Update() of deleted document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+
+	if err := c.Update(ctx, "books/b", &pb.Book{Title: "books/b"}, nil, store.Precondition{}); err != nil {
+		t.Errorf("Update() returned error: %v\n", err)
+	}
+
+	deleted, err := c.Get(ctx, "books/a", repository.GetOptions{ShowDeleted: true})
+	if err != nil {
+		t.Fatalf("Get() returned error: %v\n", err)
+	}
+	if deleted.GetDeleteTime() == nil || deleted.GetPurgeTime().AsTime().Sub(deleted.GetDeleteTime().AsTime()) != time.Hour {
+		t.Errorf("Get() of deleted document = %v, want delete time and purge time an hour later\n", deleted)
+	}
+
+	for _, tt := range []struct {
+		opts repository.ListOptions
+		want []string
+	}{
+		{opts: repository.ListOptions{}, want: []string{"books/b"}},
+		{opts: repository.ListOptions{ShowDeleted: true}, want: []string{"books/a", "books/b"}},
+	} {
+		page, err := c.List(ctx, "", tt.opts)
+		if err != nil {
+			t.Fatalf("List() returned error: %v\n", err)
+		}
+		if len(page.Paths) != len(tt.want) || page.Paths[0] != tt.want[0] {
+			t.Errorf("List(ShowDeleted: %v) = %v, want %v\n", tt.opts.ShowDeleted, page.Paths, tt.want)
+		}
+	}
+
+	if n, err := c.Purge(ctx, ""); err != nil || n != 0 {
+		t.Errorf("Purge() before the purge time = %d, %v, want 0, nil\n", n, err)
+	}
+
+	got, err := c.Undelete(ctx, "books/a")
+	if err != nil {
+		t.Fatalf("Undelete() returned error: %v\n", err)
+	}
+	if want := (&pb.Book{Title: "books/a"}); !proto.Equal(got, want) {
+		t.Errorf("Undelete() = %v, want %v\n", got, want)
+	}
+
+	if _, err := c.Undelete(ctx, "books/a"); !errors.Is(err, store.ErrAlreadyExists) {
+		t.Errorf("Undelete() of document which is not deleted returned error %v, want %v\n", err, store.ErrAlreadyExists)
+	}
+
+	if _, err := c.Get(ctx, "books/a", repository.GetOptions{}); err != nil {
+		t.Errorf("Get() after Undelete() returned error: %v\n", err)
+	}
+
+	expired, err := repository.NewCollection[*pb.Book](s, repository.Options{Retention: -time.Hour})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	if err := expired.Delete(ctx, "books/b", store.Precondition{}); err != nil {
+		t.Fatalf("Delete() returned error: %v\n", err)
+	}
+
+	if n, err := expired.Purge(ctx, ""); err != nil || n != 1 {
+		t.Errorf("Purge() = %d, %v, want 1, nil\n", n, err)
+	}
+
+	if _, err := s.Get(ctx, "books/b"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Purge() kept purged document\n")
+	}
+	if _, err := s.Get(ctx, "books/a"); err != nil {
+		t.Errorf("Purge() deleted document which is not deleted\n")
+	}
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/store"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// subcollectionFields returns the fields of md marked by the subcollection
+// option.
+func subcollectionFields(md protoreflect.MessageDescriptor) []protoreflect.FieldDescriptor {
+	var fds []protoreflect.FieldDescriptor
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if fd := fields.Get(i); annotations.FieldOptionsOf(fd).GetSubcollection() != "" {
+			fds = append(fds, fd)
+		}
+	}
+
+	return fds
+}
+
+// readChildren returns the documents of the subcollections of md below the
+// document at path, including the subcollections of their elements, with
+// paths relative to path. The documents are read with query, which is
+// store.Store.Query or store.Tx.Query.
+func readChildren(query func(q store.Query) ([]*store.Snapshot, error), path string, md protoreflect.MessageDescriptor) ([]protofirestore.Document, error) {
+	var docs []protofirestore.Document
+
+	for _, fd := range subcollectionFields(md) {
+		elem := fd
+		if fd.IsMap() {
+			elem = fd.MapValue()
+		}
+		if elem.Message() == nil {
+			return nil, fmt.Errorf("subcollection field %v is not a repeated or map message field", fd.FullName())
+		}
+
+		q := store.Query{Parent: path, CollectionID: annotations.FieldOptionsOf(fd).GetSubcollection()}
+		snapshots, err := query(q)
+		if err != nil {
+			return nil, fmt.Errorf("read %v: %w", store.CollectionPath(path, q.CollectionID), err)
+		}
+
+		for _, snapshot := range snapshots {
+			rel := strings.TrimPrefix(snapshot.Path, path+"/")
+			docs = append(docs, protofirestore.Document{Path: rel, Data: snapshot.Data})
+
+			nested, err := readChildren(query, snapshot.Path, elem.Message())
+			if err != nil {
+				return nil, err
+			}
+			for _, doc := range nested {
+				docs = append(docs, protofirestore.Document{Path: rel + "/" + doc.Path, Data: doc.Data})
+			}
+		}
+	}
+
+	return docs, nil
+}
+
+// childWrites returns the writes storing the documents children below the
+// document at path and deleting the documents old which are not among them.
+func childWrites(path string, children, old []protofirestore.Document) []store.Write {
+	writes := make([]store.Write, 0, len(children))
+
+	kept := make(map[string]bool, len(children))
+	for _, child := range children {
+		kept[child.Path] = true
+		writes = append(writes, store.Write{Op: store.OpSet, Path: path + "/" + child.Path, Data: child.Data})
+	}
+
+	for _, doc := range old {
+		if !kept[doc.Path] {
+			writes = append(writes, store.Write{Op: store.OpDelete, Path: path + "/" + doc.Path})
+		}
+	}
+
+	return writes
+}
+
+// readChildren returns the documents of the subcollections below the
+// document at path.
+func (c *Collection[T]) readChildren(ctx context.Context, path string) ([]protofirestore.Document, error) {
+	var zero T
+	return readChildren(func(q store.Query) ([]*store.Snapshot, error) {
+		return c.store.Query(ctx, q)
+	}, path, zero.ProtoReflect().Descriptor())
+}
+
+// write applies writes, which start with the write of a document followed
+// by the writes of its subcollections, in as few commits as possible.
+func (c *Collection[T]) write(ctx context.Context, writes []store.Write) error {
+	if len(writes) == 1 {
+		return writeOne(ctx, c.store, writes[0])
+	}
+	_, err := commit(ctx, c.store, writes)
+	return err
+}
+
+// LoadSubcollections reads the subcollections of the document at path into
+// the subcollection fields of m, replacing their elements, while the other
+// fields of m are kept. It loads the subcollections of messages read with
+// GetOptions.SkipSubcollections or by List.
+func (c *Collection[T]) LoadSubcollections(ctx context.Context, path string, m T) error {
+	if err := c.checkPath(path); err != nil {
+		return err
+	}
+
+	children, err := c.readChildren(ctx, path)
+	if err != nil {
+		return fmt.Errorf("load %v: %w", path, err)
+	}
+
+	loaded := c.new()
+	if err := c.opts.UnmarshalOptions.UnmarshalDocuments(nil, children, loaded); err != nil {
+		return fmt.Errorf("decode %v: %w", path, err)
+	}
+
+	dst, src := m.ProtoReflect(), loaded.ProtoReflect()
+	for _, fd := range subcollectionFields(dst.Descriptor()) {
+		if src.Has(fd) {
+			dst.Set(fd, src.Get(fd))
+		} else {
+			dst.Clear(fd)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/daviddomkar/protofirestore/memstore"
+	"github.com/daviddomkar/protofirestore/repository"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+func TestSubcollections(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	c, err := repository.NewCollection[*pb.Post](s, repository.Options{})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	post := &pb.Post{
+		Title: "hello",
+		Comments: []*pb.Comment{
+			{Text: "first", Replies: []*pb.Comment{{Text: "reply"}}},
+			{Text: "second"},
+		},
+		Reactions: map[string]*pb.Comment{"alice": {Text: "like"}},
+	}
+
+	if err := c.Create(ctx, "posts/a", post); err != nil {
+		t.Fatalf("Create() returned error: %v\n", err)
+	}
+
+	exists := func(path string) bool {
+		t.Helper()
+		_, err := s.Get(ctx, path)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Get() returned error: %v\n", err)
+		}
+		return err == nil
+	}
+
+	for _, path := range []string{"posts/a/comments/0", "posts/a/comments/0/replies/0", "posts/a/comments/1", "posts/a/reactions/alice"} {
+		if !exists(path) {
+			t.Errorf("Create() did not store %v\n", path)
+		}
+	}
+
+	got, err := c.Get(ctx, "posts/a", repository.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() returned error: %v\n", err)
+	}
+	if !proto.Equal(got, post) {
+		t.Errorf("Get() = %v, want %v\n", got, post)
+	}
+
+	lazy, err := c.Get(ctx, "posts/a", repository.GetOptions{SkipSubcollections: true})
+	if err != nil {
+		t.Fatalf("Get() returned error: %v\n", err)
+	}
+	if want := (&pb.Post{Title: "hello"}); !proto.Equal(lazy, want) {
+		t.Errorf("Get(SkipSubcollections: true) = %v, want %v\n", lazy, want)
+	}
+	if err := c.LoadSubcollections(ctx, "posts/a", lazy); err != nil {
+		t.Fatalf("LoadSubcollections() returned error: %v\n", err)
+	}
+	if !proto.Equal(lazy, post) {
+		t.Errorf("LoadSubcollections() = %v, want %v\n", lazy, post)
+	}
+
+	post = &pb.Post{Title: "edited", Comments: []*pb.Comment{{Text: "only"}}}
+	if err := c.Set(ctx, "posts/a", post); err != nil {
+		t.Fatalf("Set() returned error: %v\n", err)
+	}
+	for _, path := range []string{"posts/a/comments/0/replies/0", "posts/a/comments/1", "posts/a/reactions/alice"} {
+		if exists(path) {
+			t.Errorf("Set() kept stale document %v\n", path)
+		}
+	}
+	if got, err := c.Get(ctx, "posts/a", repository.GetOptions{}); err != nil || !proto.Equal(got, post) {
+		t.Errorf("Get() after Set() = %v, %v, want %v\n", got, err, post)
+	}
+
+	err = repository.RunTransaction(ctx, s, func(ctx context.Context, tx *repository.Tx) error {
+		if err := tx.Set("posts/b", &pb.Post{}); err == nil {
+			t.Errorf("Set() of post not read by the transaction got nil error, want error\n")
+		}
+
+		post := &pb.Post{}
+		if err := tx.Get("posts/a", post); err != nil {
+			return err
+		}
+		post.Comments = append(post.Comments, &pb.Comment{Text: "added"})
+		return tx.Set("posts/a", post)
+	})
+	if err != nil {
+		t.Fatalf("RunTransaction() returned error: %v\n", err)
+	}
+	if !exists("posts/a/comments/1") {
+		t.Errorf("transaction did not store posts/a/comments/1\n")
+	}
+
+	b := repository.NewBatchWriter(s, repository.Options{})
+	if err := b.Set("posts/a", post); err == nil {
+		t.Errorf("BatchWriter.Set() of message with subcollections got nil error, want error\n")
+	}
+
+	if err := c.Delete(ctx, "posts/a", store.Precondition{}); err != nil {
+		t.Fatalf("Delete() returned error: %v\n", err)
+	}
+	for _, path := range []string{"posts/a", "posts/a/comments/0", "posts/a/comments/1"} {
+		if exists(path) {
+			t.Errorf("Delete() kept %v\n", path)
+		}
+	}
+}
+
+func TestPurgeSubcollections(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	c, err := repository.NewCollection[*pb.Thread](s, repository.Options{Retention: -time.Hour})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	thread := &pb.Thread{
+		Title:    "hello",
+		Comments: []*pb.Comment{{Text: "first", Replies: []*pb.Comment{{Text: "reply"}}}},
+	}
+	if err := c.Create(ctx, "threads/a", thread); err != nil {
+		t.Fatalf("Create() returned error: %v\n", err)
+	}
+	if err := c.Delete(ctx, "threads/a", store.Precondition{}); err != nil {
+		t.Fatalf("Delete() returned error: %v\n", err)
+	}
+
+	if _, err := s.Get(ctx, "threads/a/comments/0"); err != nil {
+		t.Errorf("Get(%q) after soft Delete() returned error %v, want nil\n", "threads/a/comments/0", err)
+	}
+
+	if n, err := c.Purge(ctx, ""); err != nil || n != 1 {
+		t.Fatalf("Purge() = %d, %v, want 1, nil\n", n, err)
+	}
+
+	for _, path := range []string{"threads/a", "threads/a/comments/0", "threads/a/comments/0/replies/0"} {
+		if _, err := s.Get(ctx, path); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Get(%q) after Purge() returned error %v, want %v\n", path, err, store.ErrNotFound)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/annotations"
+	"github.com/daviddomkar/protofirestore/store"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// RunTransaction runs f in a transaction of s, which must implement
+// store.Transactor. See Options.RunTransaction for details.
+func RunTransaction(ctx context.Context, s store.Store, f func(ctx context.Context, tx *Tx) error) error {
+	return Options{}.RunTransaction(ctx, s, f)
+}
+
+// RunTransaction runs f in a transaction of s, which must implement
+// store.Transactor, and commits the writes of f if it returns nil. The store
+// runs f again if the documents it read were written concurrently, so f must
+// not have side effects besides the transaction. Messages are encoded and
+// decoded with MarshalOptions and UnmarshalOptions, while Pattern is not
+// used.
+func (o Options) RunTransaction(ctx context.Context, s store.Store, f func(ctx context.Context, tx *Tx) error) error {
+	transactor, ok := s.(store.Transactor)
+	if !ok {
+		return errors.New("store does not support transactions")
+	}
+
+	return transactor.RunTransaction(ctx, func(ctx context.Context, tx store.Tx) error {
+		return f(ctx, &Tx{tx: tx, opts: o, reads: make(map[string]*store.Snapshot), children: make(map[string][]protofirestore.Document)})
+	})
+}
+
+// Tx reads and writes messages in a transaction. All reads must happen
+// before the first write.
+//
+// Etags are handled like by Collection, except that writing a message with
+// an etag, or with an etag derived from a version counter at all, requires
+// reading the document in the transaction first. Since the transaction
+// fails if the document is written concurrently, the etag is checked
+// against the document read.
+//
+// Soft-deleted documents are read like other documents, with their delete
+// time set, while Delete soft-deletes documents of messages with a delete
+// time.
+//
+// Get reads the subcollections of messages along with their document. Set
+// replaces them, which requires reading the document in the transaction
+// first, and Delete deletes the subcollections of documents read.
+type Tx struct {
+	tx   store.Tx
+	opts Options
+
+	// reads are the documents read by Get, which are nil for documents which
+	// do not exist.
+	reads map[string]*store.Snapshot
+
+	// children are the documents of the subcollections of the documents
+	// read by Get.
+	children map[string][]protofirestore.Document
+}
+
+// Get reads the message stored at path and its subcollections into m.
+func (t *Tx) Get(path string, m proto.Message) error {
+	snapshot, err := t.tx.Get(path)
+	if errors.Is(err, store.ErrNotFound) {
+		t.reads[path] = nil
+	}
+	if err != nil {
+		return fmt.Errorf("get %v: %w", path, err)
+	}
+	t.reads[path] = snapshot
+
+	children, err := readChildren(t.tx.Query, path, m.ProtoReflect().Descriptor())
+	if err != nil {
+		return fmt.Errorf("get %v: %w", path, err)
+	}
+	t.children[path] = children
+
+	if err := t.opts.decode(snapshot, children, m); err != nil {
+		return fmt.Errorf("decode %v: %w", path, err)
+	}
+
+	return nil
+}
+
+// checkEtag checks the etag of m, if any, against the document at path read
+// by the transaction and returns the version the document is written with
+// for etags derived from a version counter.
+func (t *Tx) checkEtag(path string, m proto.Message, e *etagField) (string, error) {
+	if e == nil || e.source != annotations.FieldOptions_VERSION && e.get(m) == "" {
+		return "", nil
+	}
+
+	snapshot, ok := t.reads[path]
+	if !ok {
+		return "", fmt.Errorf("%v must be read before writing its etag", path)
+	}
+
+	return e.check(m, snapshot)
+}
+
+// Create stores m at path and its subcollections when the transaction
+// commits, which fails if the document exists.
+func (t *Tx) Create(path string, m proto.Message) error {
+	data, children, e, err := t.opts.encode(m)
+	if err != nil {
+		return fmt.Errorf("encode %v: %w", path, err)
+	}
+
+	if e != nil && e.source == annotations.FieldOptions_VERSION {
+		data[e.key()] = "1"
+	}
+
+	if err := t.tx.Create(path, data); err != nil {
+		return err
+	}
+	return t.write(childWrites(path, children, nil))
+}
+
+// Set stores m at path and replaces its subcollections when the transaction
+// commits.
+func (t *Tx) Set(path string, m proto.Message) error {
+	data, children, e, err := t.opts.encode(m)
+	if err != nil {
+		return fmt.Errorf("encode %v: %w", path, err)
+	}
+
+	if _, ok := t.reads[path]; !ok && len(subcollectionFields(m.ProtoReflect().Descriptor())) != 0 {
+		return fmt.Errorf("set %v: must be read before replacing its subcollections", path)
+	}
+
+	version, err := t.checkEtag(path, m, e)
+	if err != nil {
+		return fmt.Errorf("set %v: %w", path, err)
+	}
+	if version != "" {
+		data[e.key()] = version
+	}
+
+	if err := t.tx.Set(path, data); err != nil {
+		return err
+	}
+	return t.write(childWrites(path, children, t.children[path]))
+}
+
+// Update writes the fields of m selected by mask to the document at path when
+// the transaction commits, like Collection.Update.
+func (t *Tx) Update(path string, m proto.Message, mask *fieldmaskpb.FieldMask, pre store.Precondition) error {
+	updates, e, err := t.opts.encodeUpdate(m, mask)
+	if err != nil {
+		return fmt.Errorf("encode %v: %w", path, err)
+	}
+
+	version, err := t.checkEtag(path, m, e)
+	if err != nil {
+		return fmt.Errorf("update %v: %w", path, err)
+	}
+	if version != "" {
+		updates[protofirestore.JoinFieldPath(e.key())] = version
+	}
+
+	return t.tx.Update(path, updates, pre)
+}
+
+// Delete deletes the document at path, which stores a message described by
+// md, when the transaction commits, along with its subcollections if it has
+// been read by the transaction. Messages with a delete time are soft-deleted
+// like by Collection.Delete instead, which requires reading the document in
+// the transaction first.
+func (t *Tx) Delete(path string, md protoreflect.MessageDescriptor, pre store.Precondition) error {
+	sd, err := softDeleteOf(md)
+	if err != nil {
+		return fmt.Errorf("delete %v: %w", path, err)
+	}
+	if sd != nil {
+		return t.softDelete(path, md, sd, pre)
+	}
+
+	if err := t.tx.Delete(path, pre); err != nil {
+		return err
+	}
+	return t.write(childWrites(path, nil, t.children[path]))
+}
+
+// softDelete sets the delete time of the document at path, which has been
+// read by the transaction, when the transaction commits.
+func (t *Tx) softDelete(path string, md protoreflect.MessageDescriptor, sd *softDelete, pre store.Precondition) error {
+	snapshot, ok := t.reads[path]
+	switch {
+	case !ok:
+		return fmt.Errorf("delete %v: must be read before soft-deleting it", path)
+	case snapshot == nil:
+		return fmt.Errorf("delete %v: %w", path, store.ErrNotFound)
+	}
+
+	if err := sd.check(snapshot, pre, true); err != nil {
+		return fmt.Errorf("delete %v: %w", path, err)
+	}
+
+	e, err := etagFieldOf(md)
+	if err != nil {
+		return fmt.Errorf("delete %v: %w", path, err)
+	}
+
+	updates, _, err := t.opts.deletedUpdates(sd, e, snapshot.Data, true)
+	if err != nil {
+		return fmt.Errorf("delete %v: %w", path, err)
+	}
+
+	return t.tx.Update(path, updates, pre)
+}
+
+// write adds the writes of subcollections to the transaction.
+func (t *Tx) write(writes []store.Write) error {
+	for _, w := range writes {
+		var err error
+		if w.Op == store.OpDelete {
+			err = t.tx.Delete(w.Path, w.Precondition)
+		} else {
+			err = t.tx.Set(w.Path, w.Data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	pb "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/daviddomkar/protofirestore/memstore"
+	"github.com/daviddomkar/protofirestore/repository"
+	"github.com/daviddomkar/protofirestore/store"
+)
+
+func TestRunTransaction(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	c, err := repository.NewCollection[*pb.Order](s, repository.Options{})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	if err := c.Set(ctx, "users/alice/orders/1", &pb.Order{Name: "first", Total: 10}); err != nil {
+		t.Fatalf("Set() returned error: %v\n", err)
+	}
+
+	err = repository.RunTransaction(ctx, s, func(ctx context.Context, tx *repository.Tx) error {
+		order := &pb.Order{}
+		if err := tx.Get("users/alice/orders/1", order); err != nil {
+			return err
+		}
+
+		if err := tx.Get("users/alice/orders/2", &pb.Order{}); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Get() of missing document returned error %v, want %v\n", err, store.ErrNotFound)
+		}
+
+		order.Total++
+		mask := &fieldmaskpb.FieldMask{Paths: []string{"total"}}
+		if err := tx.Update("users/alice/orders/1", order, mask, store.Precondition{}); err != nil {
+			return err
+		}
+		return tx.Create("users/alice/orders/2", &pb.Order{Name: "second", Total: order.Total})
+	})
+	if err != nil {
+		t.Fatalf("RunTransaction() returned error: %v\n", err)
+	}
+
+	for path, want := range map[string]*pb.Order{
+		"users/alice/orders/1": {Name: "first", Total: 11},
+		"users/alice/orders/2": {Name: "second", Total: 11},
+	} {
+		got, err := c.Get(ctx, path, repository.GetOptions{})
+		if err != nil {
+			t.Fatalf("Get() returned error: %v\n", err)
+		}
+		if !proto.Equal(got, want) {
+			t.Errorf("Get(%v) = %v, want %v\n", path, got, want)
+		}
+	}
+
+	err = repository.RunTransaction(ctx, storeOnly{s}, func(ctx context.Context, tx *repository.Tx) error {
+		return nil
+	})
+	if err == nil {
+		t.Errorf("RunTransaction() on store without transactions got nil error, want error\n")
+	}
+}
+
+func TestTxSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+
+	c, err := repository.NewCollection[*pb.Book](s, repository.Options{})
+	if err != nil {
+		t.Fatalf("NewCollection() returned error: %v\n", err)
+	}
+
+	if err := c.Create(ctx, "books/a", &pb.Book{Title: "a"}); err != nil {
+		t.Fatalf("Create() returned error: %v\n", err)
+	}
+
+	md := (&pb.Book{}).ProtoReflect().Descriptor()
+
+	err = repository.RunTransaction(ctx, s, func(ctx context.Context, tx *repository.Tx) error {
+		if err := tx.Delete("books/a", md, store.Precondition{}); err == nil {
+			t.Error("Delete() of unread document did not return error")
+		}
+
+		if err := tx.Get("books/a", &pb.Book{}); err != nil {
+			return err
+		}
+		return tx.Delete("books/a", md, store.Precondition{})
+	})
+	if err != nil {
+		t.Fatalf("RunTransaction() returned error: %v\n", err)
+	}
+
+	if _, err := c.Get(ctx, "books/a", repository.GetOptions{}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get() of deleted document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+
+	deleted, err := c.Get(ctx, "books/a", repository.GetOptions{ShowDeleted: true})
+	if err != nil {
+		t.Fatalf("Get() returned error: %v\n", err)
+	}
+	if deleted.GetDeleteTime() == nil || deleted.GetPurgeTime() == nil {
+		t.Errorf("Get() of deleted document = %v, want delete time and purge time\n", deleted)
+	}
+
+	err = repository.RunTransaction(ctx, s, func(ctx context.Context, tx *repository.Tx) error {
+		if err := tx.Get("books/a", &pb.Book{}); err != nil {
+			return err
+		}
+		return tx.Delete("books/a", md, store.Precondition{})
+	})
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Delete() of deleted document returned error %v, want %v\n", err, store.ErrNotFound)
+	}
+}
+
+// storeOnly hides the optional interfaces of a store.Store.
+type storeOnly struct {
+	store.Store
+}
@@ -0,0 +1,264 @@
+// Package rulesgen generates firestore security rules functions validating
+// that documents written by clients have the shape the protofirestore
+// package encodes messages into, so that clients writing directly to
+// firestore cannot store documents the server fails to decode.
+package rulesgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/daviddomkar/protofirestore"
+	"github.com/daviddomkar/protofirestore/internal/shape"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Options configures the generation of security rules.
+type Options struct {
+	// MarshalOptions are the options documents are encoded with, which
+	// determine the keys required in a document.
+	MarshalOptions protofirestore.MarshalOptions
+}
+
+// Generate generates the validation functions of the given messages.
+func Generate(mds ...protoreflect.MessageDescriptor) (string, error) {
+	return Options{}.Generate(mds...)
+}
+
+// Generate generates an isValid<Message>(data) function for each of the given
+// messages and the messages they embed, e.g. isValidOrder or
+// isValidOrder_Item for a nested message. Each function checks that data
+// only has keys of the message, that keys which are always encoded are
+// present, that at most one key of each oneof is present and that the
+// values have the types they are encoded with:
+//
+//   - bool fields are bools and bytes fields are bytes.
+//   - string fields are strings.
+//   - integer fields are ints within the range of the field.
+//   - float and double fields are numbers, because the JavaScript SDKs store
+//     whole numbers as ints, which the decoder accepts.
+//   - enum fields are one of the names of the enum values.
+//   - google.protobuf.Timestamp fields are timestamps.
+//   - message fields are valid according to the function of their message.
+//   - repeated fields are lists and map fields are maps, whose values the
+//     rules language has no means to iterate over.
+//
+// If documents are encoded with EmitType, the TypeKey key is allowed and
+// must hold the type of the message if present.
+//
+// If documents are encoded with EmitOneofCase, the case key of each oneof is
+// required and must be null or the key of a field of the oneof, which must
+// be the field of the oneof that is present, if any.
+//
+// The rules language does not allow recursion, so fields referring to a
+// message which refers back to the message containing the field are only
+// checked to be maps.
+//
+// Function names do not include the proto package, so an error is returned
+// if two of the messages, e.g. a.v1.User and b.v1.User, share a function
+// name.
+func (o Options) Generate(mds ...protoreflect.MessageDescriptor) (string, error) {
+	var b strings.Builder
+
+	seen := make(map[protoreflect.FullName]bool)
+	names := make(map[string]protoreflect.FullName)
+	queue := append([]protoreflect.MessageDescriptor(nil), mds...)
+
+	for len(queue) > 0 {
+		md := queue[0]
+		queue = queue[1:]
+
+		if seen[md.FullName()] {
+			continue
+		}
+		seen[md.FullName()] = true
+
+		name := FunctionName(md)
+		if other, ok := names[name]; ok {
+			return "", fmt.Errorf("messages %v and %v share the validation function name %v", other, md.FullName(), name)
+		}
+		names[name] = md.FullName()
+
+		m, err := shape.Of(md, o.MarshalOptions)
+		if err != nil {
+			return "", err
+		}
+
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		writeFunction(&b, m)
+
+		for _, f := range m.Fields {
+			if f.Type.Kind == shape.Object {
+				queue = append(queue, f.Type.Message)
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+// FunctionName returns the name of the validation function of the message
+// md, which is isValid followed by the name of the message and the names of
+// the messages it is nested in, separated by underscores.
+func FunctionName(md protoreflect.MessageDescriptor) string {
+	name := strings.TrimPrefix(string(md.FullName()), string(md.ParentFile().Package())+".")
+	return "isValid" + strings.ReplaceAll(name, ".", "_")
+}
+
+// writeFunction writes the validation function of the message m.
+func writeFunction(b *strings.Builder, m *shape.Message) {
+	conditions := []string{"data is map"}
+
+	keys := make([]string, len(m.Fields))
+	var required []string
+	for i, f := range m.Fields {
+		keys[i] = quote(f.Key)
+		if f.Required {
+			required = append(required, quote(f.Key))
+		}
+	}
+	if m.Type != "" {
+		keys = append(keys, quote(protofirestore.TypeKey))
+	}
+	for _, oneof := range m.OneofCases {
+		keys = append(keys, quote(oneof.Key))
+		required = append(required, quote(oneof.Key))
+	}
+	conditions = append(conditions, "data.keys().hasOnly(["+strings.Join(keys, ", ")+"])")
+	if len(required) > 0 {
+		conditions = append(conditions, "data.keys().hasAll(["+strings.Join(required, ", ")+"])")
+	}
+
+	for _, oneof := range m.Oneofs() {
+		members := make([]string, len(oneof))
+		for i, key := range oneof {
+			members[i] = quote(key)
+		}
+		conditions = append(conditions, "data.keys().toSet().intersection(["+strings.Join(members, ", ")+"].toSet()).size() <= 1")
+	}
+
+	for _, f := range m.Fields {
+		value := "data[" + quote(f.Key) + "]"
+		condition := typeCondition(value, f.Type, m.Desc)
+		if !f.Required {
+			condition = "(!(" + quote(f.Key) + " in data) || " + condition + ")"
+		}
+		conditions = append(conditions, condition)
+	}
+
+	for _, oneof := range m.OneofCases {
+		value := "data[" + quote(oneof.Key) + "]"
+		cases := make([]string, len(oneof.Cases))
+		for i, key := range oneof.Cases {
+			cases[i] = quote(key)
+		}
+		conditions = append(conditions, "("+value+" == null || "+value+" in ["+strings.Join(cases, ", ")+"])")
+		for _, f := range m.Fields {
+			if f.Oneof == oneof.Desc {
+				conditions = append(conditions, "(!("+quote(f.Key)+" in data) || "+value+" == "+quote(f.Key)+")")
+			}
+		}
+	}
+
+	if m.Type != "" {
+		key := quote(protofirestore.TypeKey)
+		conditions = append(conditions, "(!("+key+" in data) || data["+key+"] == "+quote(m.Type)+")")
+	}
+
+	fmt.Fprintf(b, "// %s reports whether data is a valid %v document.\n", FunctionName(m.Desc), m.Desc.FullName())
+	fmt.Fprintf(b, "function %s(data) {\n", FunctionName(m.Desc))
+	fmt.Fprintf(b, "  return %s;\n", strings.Join(conditions, "\n      && "))
+	b.WriteString("}\n")
+}
+
+// typeCondition returns the condition checking that value has the type t.
+// The field holding the value belongs to the message md.
+func typeCondition(value string, t shape.Type, md protoreflect.MessageDescriptor) string {
+	switch t.Kind {
+	case shape.Bool:
+		return value + " is bool"
+	case shape.Int:
+		return intCondition(value, t.Scalar)
+	case shape.Float:
+		return value + " is number"
+	case shape.String:
+		return value + " is string"
+	case shape.Bytes:
+		return value + " is bytes"
+	case shape.Timestamp:
+		return value + " is timestamp"
+	case shape.Enum:
+		return value + " in " + enumNames(t.Enum)
+	case shape.Object:
+		if refersTo(t.Message, md.FullName(), nil) {
+			return value + " is map"
+		}
+		return FunctionName(t.Message) + "(" + value + ")"
+	case shape.Map:
+		return value + " is map"
+	case shape.List:
+		return value + " is list"
+	}
+	panic(fmt.Sprintf("unknown kind %v", t.Kind))
+}
+
+// intCondition returns the condition checking that value is an int within
+// the range of the proto kind.
+func intCondition(value string, kind protoreflect.Kind) string {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return fmt.Sprintf("(%s is int && %s >= -2147483648 && %s <= 2147483647)", value, value, value)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return fmt.Sprintf("(%s is int && %s >= 0 && %s <= 4294967295)", value, value, value)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return fmt.Sprintf("(%s is int && %s >= 0)", value, value)
+	}
+	return value + " is int"
+}
+
+// enumNames returns the list literal of the names of the values of ed.
+func enumNames(ed protoreflect.EnumDescriptor) string {
+	values := ed.Values()
+	names := make([]string, values.Len())
+	for i := range names {
+		names[i] = quote(string(values.Get(i).Name()))
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// refersTo reports whether the message md or any message whose validation
+// function it calls is the message with the given name.
+func refersTo(md protoreflect.MessageDescriptor, name protoreflect.FullName, visited map[protoreflect.FullName]bool) bool {
+	if md.FullName() == name {
+		return true
+	}
+	if visited == nil {
+		visited = make(map[protoreflect.FullName]bool)
+	}
+	if visited[md.FullName()] {
+		return false
+	}
+	visited[md.FullName()] = true
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.IsList() || fd.IsMap() {
+			continue // only checked to be lists and maps
+		}
+		if fd.Message() != nil && refersTo(fd.Message(), name, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// quote returns the single quoted string literal of s.
+func quote(s string) string {
+	q := strconv.Quote(s)
+	q = strings.ReplaceAll(q[1:len(q)-1], `\"`, `"`)
+	return "'" + strings.ReplaceAll(q, "'", `\'`) + "'"
+}
@@ -0,0 +1,253 @@
+package rulesgen_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/daviddomkar/protofirestore"
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	pb2 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb2"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/daviddomkar/protofirestore/rulesgen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		desc  string
+		mo    protofirestore.MarshalOptions
+		input []proto.Message
+		want  string
+	}{{
+		desc:  "strings",
+		input: []proto.Message{&pbann.Address{}},
+		want: `// isValidAddress reports whether data is a valid annotated.Address document.
+function isValidAddress(data) {
+  return data is map
+      && data.keys().hasOnly(['street', 'instructions'])
+      && (!('street' in data) || data['street'] is string)
+      && (!('instructions' in data) || data['instructions'] is string);
+}
+`,
+	}, {
+		desc:  "embedded messages are validated once",
+		input: []proto.Message{&pbann.User{}, &pbann.Address{}},
+		want: `// isValidUser reports whether data is a valid annotated.User document.
+function isValidUser(data) {
+  return data is map
+      && data.keys().hasOnly(['name', 'avatar', 'home'])
+      && (!('name' in data) || data['name'] is string)
+      && (!('avatar' in data) || data['avatar'] is bytes)
+      && (!('home' in data) || isValidAddress(data['home']));
+}
+
+// isValidAddress reports whether data is a valid annotated.Address document.
+function isValidAddress(data) {
+  return data is map
+      && data.keys().hasOnly(['street', 'instructions'])
+      && (!('street' in data) || data['street'] is string)
+      && (!('instructions' in data) || data['instructions'] is string);
+}
+`,
+	}, {
+		desc:  "sensible defaults are required",
+		mo:    protofirestore.MarshalOptions{EmitFirestoreSensibleDefaults: true},
+		input: []proto.Message{&pb3.Enums{}},
+		want: `// isValidEnums reports whether data is a valid pb3.Enums document.
+function isValidEnums(data) {
+  return data is map
+      && data.keys().hasOnly(['sEnum', 'sNestedEnum'])
+      && data.keys().hasAll(['sEnum', 'sNestedEnum'])
+      && data['sEnum'] in ['ZERO', 'ONE', 'TWO', 'TEN']
+      && data['sNestedEnum'] in ['CERO', 'UNO', 'DOS', 'DIEZ'];
+}
+`,
+	}, {
+		desc:  "required fields",
+		input: []proto.Message{&pb2.Requireds{}},
+		want: `// isValidRequireds reports whether data is a valid pb2.Requireds document.
+function isValidRequireds(data) {
+  return data is map
+      && data.keys().hasOnly(['reqBool', 'reqSfixed64', 'reqDouble', 'reqString', 'reqEnum', 'reqNested'])
+      && data.keys().hasAll(['reqBool', 'reqSfixed64', 'reqDouble', 'reqEnum'])
+      && data['reqBool'] is bool
+      && data['reqSfixed64'] is int
+      && data['reqDouble'] is number
+      && (!('reqString' in data) || data['reqString'] is string)
+      && data['reqEnum'] in ['ONE', 'TWO', 'TEN']
+      && (!('reqNested' in data) || isValidNested(data['reqNested']));
+}
+
+// isValidNested reports whether data is a valid pb2.Nested document.
+function isValidNested(data) {
+  return data is map
+      && data.keys().hasOnly(['optString', 'optNested'])
+      && (!('optString' in data) || data['optString'] is string)
+      && (!('optNested' in data) || data['optNested'] is map);
+}
+`,
+	}, {
+		desc:  "oneofs",
+		mo:    protofirestore.MarshalOptions{EmitFirestoreSensibleDefaults: true},
+		input: []proto.Message{&pb3.Oneofs{}},
+		want: `// isValidOneofs reports whether data is a valid pb3.Oneofs document.
+function isValidOneofs(data) {
+  return data is map
+      && data.keys().hasOnly(['oneofEnum', 'oneofString', 'oneofNested'])
+      && data.keys().toSet().intersection(['oneofEnum', 'oneofString', 'oneofNested'].toSet()).size() <= 1
+      && (!('oneofEnum' in data) || data['oneofEnum'] in ['ZERO', 'ONE', 'TWO', 'TEN'])
+      && (!('oneofString' in data) || data['oneofString'] is string)
+      && (!('oneofNested' in data) || isValidNested(data['oneofNested']));
+}
+
+// isValidNested reports whether data is a valid pb3.Nested document.
+function isValidNested(data) {
+  return data is map
+      && data.keys().hasOnly(['sString', 'sNested'])
+      && (!('sString' in data) || data['sString'] is string)
+      && (!('sNested' in data) || data['sNested'] is map);
+}
+`,
+	}, {
+		desc:  "oneof cases",
+		mo:    protofirestore.MarshalOptions{EmitOneofCase: true},
+		input: []proto.Message{&pb3.Oneofs{}},
+		want: `// isValidOneofs reports whether data is a valid pb3.Oneofs document.
+function isValidOneofs(data) {
+  return data is map
+      && data.keys().hasOnly(['oneofEnum', 'oneofString', 'oneofNested', 'unionCase'])
+      && data.keys().hasAll(['unionCase'])
+      && data.keys().toSet().intersection(['oneofEnum', 'oneofString', 'oneofNested'].toSet()).size() <= 1
+      && (!('oneofEnum' in data) || data['oneofEnum'] in ['ZERO', 'ONE', 'TWO', 'TEN'])
+      && (!('oneofString' in data) || data['oneofString'] is string)
+      && (!('oneofNested' in data) || isValidNested(data['oneofNested']))
+      && (data['unionCase'] == null || data['unionCase'] in ['oneofEnum', 'oneofString', 'oneofNested'])
+      && (!('oneofEnum' in data) || data['unionCase'] == 'oneofEnum')
+      && (!('oneofString' in data) || data['unionCase'] == 'oneofString')
+      && (!('oneofNested' in data) || data['unionCase'] == 'oneofNested');
+}
+
+// isValidNested reports whether data is a valid pb3.Nested document.
+function isValidNested(data) {
+  return data is map
+      && data.keys().hasOnly(['sString', 'sNested'])
+      && (!('sString' in data) || data['sString'] is string)
+      && (!('sNested' in data) || data['sNested'] is map);
+}
+`,
+	}, {
+		desc:  "integer ranges, timestamps, lists and maps",
+		input: []proto.Message{&pbann.Counters{}},
+		want: `// isValidCounters reports whether data is a valid annotated.Counters document.
+function isValidCounters(data) {
+  return data is map
+      && data.keys().hasOnly(['name', 'tags', 'scores', 'likes', 'views', 'shares', 'rating', 'version', 'child', 'totals', 'children'])
+      && (!('name' in data) || data['name'] is string)
+      && (!('tags' in data) || data['tags'] is list)
+      && (!('scores' in data) || data['scores'] is list)
+      && (!('likes' in data) || (data['likes'] is int && data['likes'] >= -2147483648 && data['likes'] <= 2147483647))
+      && (!('views' in data) || data['views'] is int)
+      && (!('shares' in data) || (data['shares'] is int && data['shares'] >= 0))
+      && (!('rating' in data) || data['rating'] is number)
+      && (!('version' in data) || data['version'] is int)
+      && (!('child' in data) || data['child'] is map)
+      && (!('totals' in data) || data['totals'] is map)
+      && (!('children' in data) || data['children'] is list);
+}
+`,
+	}, {
+		desc:  "type key",
+		mo:    protofirestore.MarshalOptions{EmitType: true, TypeAliases: protofirestore.TypeAliases{"address": "annotated.Address"}},
+		input: []proto.Message{&pbann.Address{}},
+		want: `// isValidAddress reports whether data is a valid annotated.Address document.
+function isValidAddress(data) {
+  return data is map
+      && data.keys().hasOnly(['street', 'instructions', '@type'])
+      && (!('street' in data) || data['street'] is string)
+      && (!('instructions' in data) || data['instructions'] is string)
+      && (!('@type' in data) || data['@type'] == 'address');
+}
+`,
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			mds := make([]protoreflect.MessageDescriptor, len(tt.input))
+			for i, m := range tt.input {
+				mds[i] = m.ProtoReflect().Descriptor()
+			}
+
+			got, err := rulesgen.Options{MarshalOptions: tt.mo}.Generate(mds...)
+			if err != nil {
+				t.Fatalf("Generate() returned error: %v\n", err)
+			}
+			if got != tt.want {
+				t.Errorf("Generate()\n<got>\n%v\n<want>\n%v\n", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTimestamp(t *testing.T) {
+	got, err := rulesgen.Generate((&pbann.Order{}).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatalf("Generate() returned error: %v\n", err)
+	}
+
+	const want = "&& (!('createTime' in data) || data['createTime'] is timestamp)\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("Generate() = %v, want it to contain %q", got, want)
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+	tests := []struct {
+		desc  string
+		input proto.Message
+	}{{
+		desc:  "unsupported well known type field",
+		input: &pb2.KnownTypes{},
+	}, {
+		desc:  "well known type document",
+		input: &timestamppb.Timestamp{},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := rulesgen.Generate(tt.input.ProtoReflect().Descriptor()); err == nil {
+				t.Error("Generate() did not return error")
+			}
+		})
+	}
+}
+
+func TestGenerateNameCollision(t *testing.T) {
+	_, err := rulesgen.Generate(
+		(&pb2.Nested{}).ProtoReflect().Descriptor(),
+		(&pb3.Nested{}).ProtoReflect().Descriptor(),
+	)
+	if err == nil {
+		t.Error("Generate() did not return error")
+	}
+}
+
+func TestFunctionName(t *testing.T) {
+	tests := []struct {
+		input proto.Message
+		want  string
+	}{
+		{input: &pbann.Order{}, want: "isValidOrder"},
+		{input: &pb3.Nested{}, want: "isValidNested"},
+		{input: &pb2.Nests_OptGroup{}, want: "isValidNests_OptGroup"},
+	}
+
+	for _, tt := range tests {
+		if got := rulesgen.FunctionName(tt.input.ProtoReflect().Descriptor()); got != tt.want {
+			t.Errorf("FunctionName(%v) = %v, want %v", tt.input.ProtoReflect().Descriptor().FullName(), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package protofirestore
+
+// Sentinel is implemented by the special values which may be returned in
+// place of a regular field value. They have no meaning on their own and need
+// to be translated to their counterparts in the firestore client library
+// before being written.
+type Sentinel interface {
+	isSentinel()
+}
+
+// Delete is a Sentinel which marks a field for deletion. It corresponds to
+// firestore.Delete in the firestore client library.
+var Delete Sentinel = deleteSentinel{}
+
+type deleteSentinel struct{}
+
+func (deleteSentinel) isSentinel() {}
+
+func (deleteSentinel) String() string {
+	return "Delete"
+}
+
+// ArrayUnion is a Sentinel which adds the elements that are not already
+// present to an array field. It corresponds to firestore.ArrayUnion in the
+// firestore client library.
+type ArrayUnion struct {
+	Elems []interface{}
+}
+
+func (ArrayUnion) isSentinel() {}
+
+// ArrayRemove is a Sentinel which removes all instances of the elements from
+// an array field. It corresponds to firestore.ArrayRemove in the firestore
+// client library.
+type ArrayRemove struct {
+	Elems []interface{}
+}
+
+func (ArrayRemove) isSentinel() {}
+
+// Increment is a Sentinel which adds N to a numeric field. It corresponds to
+// firestore.Increment in the firestore client library. N has the same type as
+// the value Marshal produces for the field.
+type Increment struct {
+	N interface{}
+}
+
+func (Increment) isSentinel() {}
@@ -0,0 +1,223 @@
+// Package store abstracts reading and writing documents encoded by the
+// protofirestore package, so that code built on top of it works with the
+// firestore client library through an adapter as well as with fakes in tests.
+//
+// Documents are the maps returned by protofirestore.Marshal, keyed by the
+// slash separated path of the document relative to the root of the database,
+// e.g. "users/alice/orders/1".
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/daviddomkar/protofirestore/query"
+)
+
+var (
+	// ErrNotFound is returned when a document which is required to exist
+	// does not exist.
+	ErrNotFound = errors.New("document not found")
+
+	// ErrAlreadyExists is returned by Create when the document exists.
+	ErrAlreadyExists = errors.New("document already exists")
+
+	// ErrFailedPrecondition is returned when the update time of a document
+	// does not match the one required by a Precondition.
+	ErrFailedPrecondition = errors.New("failed precondition")
+
+	// ErrAborted is returned by RunTransaction when a transaction could not
+	// be committed because of concurrent writes, even after retrying it.
+	ErrAborted = errors.New("transaction aborted")
+)
+
+// MaxBatchWrites is the maximum number of writes firestore accepts in a
+// single commit.
+const MaxBatchWrites = 500
+
+// Store reads and writes documents. Implementations return errors wrapping
+// ErrNotFound, ErrAlreadyExists and ErrFailedPrecondition, which callers
+// check with errors.Is.
+type Store interface {
+	// Get returns the document at path, or ErrNotFound.
+	Get(ctx context.Context, path string) (*Snapshot, error)
+
+	// Create writes a new document at path, or returns ErrAlreadyExists.
+	Create(ctx context.Context, path string, data map[string]interface{}) (*WriteResult, error)
+
+	// Set writes the document at path, replacing it if it exists.
+	Set(ctx context.Context, path string, data map[string]interface{}) (*WriteResult, error)
+
+	// Update applies updates keyed by firestore field paths, as returned by
+	// protofirestore.MarshalUpdate, to the existing document at path. Values
+	// may be protofirestore.Sentinels. It returns ErrNotFound if the document
+	// does not exist.
+	Update(ctx context.Context, path string, updates map[string]interface{}, pre Precondition) (*WriteResult, error)
+
+	// Delete deletes the document at path. Deleting a document which does
+	// not exist succeeds unless pre requires it to exist.
+	Delete(ctx context.Context, path string, pre Precondition) (*WriteResult, error)
+
+	// Query returns the documents matched by q.
+	Query(ctx context.Context, q Query) ([]*Snapshot, error)
+}
+
+// Snapshot is a document read from a Store.
+type Snapshot struct {
+	// Path is the path of the document.
+	Path string
+
+	// Data is the content of the document.
+	Data map[string]interface{}
+
+	// CreateTime and UpdateTime are the times the document was created and
+	// last written.
+	CreateTime time.Time
+	UpdateTime time.Time
+}
+
+// ID returns the document ID, which is the last segment of the path.
+func (s *Snapshot) ID() string {
+	return s.Path[strings.LastIndexByte(s.Path, '/')+1:]
+}
+
+// WriteResult is the result of a write.
+type WriteResult struct {
+	// UpdateTime is the update time of the document after the write. It is
+	// zero for deletes.
+	UpdateTime time.Time
+}
+
+// Precondition restricts a write to documents in a given state. The zero
+// value has no restrictions.
+type Precondition struct {
+	// Exists requires the document to exist, which otherwise results in
+	// ErrNotFound.
+	Exists bool
+
+	// UpdateTime, if non-zero, requires the document to exist and to have
+	// been last written at UpdateTime, which otherwise results in
+	// ErrFailedPrecondition.
+	UpdateTime time.Time
+}
+
+// Query selects documents of a collection.
+type Query struct {
+	// Parent is the path of the document containing the collection, or the
+	// empty string for root collections.
+	Parent string
+
+	// CollectionID is the ID of the collection.
+	CollectionID string
+
+	// AllDescendants selects the documents of all collections with the given
+	// ID below Parent rather than only the one directly below it, like a
+	// collection group query.
+	AllDescendants bool
+
+	// Filter, if not nil, restricts the documents to the ones it matches.
+	Filter query.Filter
+
+	// Orders are the orders of the documents. Documents with equal values
+	// are ordered by their path, and so are all documents if Orders is empty.
+	Orders []query.Order
+
+	// StartAfter, if not nil, skips the documents up to and including the
+	// cursor position. The cursor holds the values at the paths of Orders
+	// and the ID of the document, or for AllDescendants queries its path
+	// relative to Parent, since IDs are only unique within a collection.
+	StartAfter *query.Cursor
+
+	// Limit, if positive, is the maximum number of documents returned.
+	Limit int
+}
+
+// CursorID returns the document ID of the cursor positioned at the document
+// at path, which has been returned by q.
+func (q Query) CursorID(path string) string {
+	if q.AllDescendants {
+		if q.Parent == "" {
+			return path
+		}
+		return strings.TrimPrefix(path, q.Parent+"/")
+	}
+	return path[strings.LastIndexByte(path, '/')+1:]
+}
+
+// CollectionPath returns the path of the collection with the given ID below
+// the document at parent.
+func CollectionPath(parent, collectionID string) string {
+	if parent == "" {
+		return collectionID
+	}
+	return parent + "/" + collectionID
+}
+
+// Op is the operation of a Write.
+type Op int
+
+const (
+	OpCreate Op = iota
+	OpSet
+	OpUpdate
+	OpDelete
+)
+
+// Write is a write of a batch or transaction.
+type Write struct {
+	Op   Op
+	Path string
+
+	// Data is the document of creates and sets, and the updates keyed by
+	// firestore field paths of updates.
+	Data map[string]interface{}
+
+	// Precondition is the precondition of updates and deletes.
+	Precondition Precondition
+}
+
+// Batcher is implemented by stores which commit several writes atomically.
+type Batcher interface {
+	// Commit applies all writes, of which there are at most MaxBatchWrites,
+	// or none of them.
+	Commit(ctx context.Context, writes []Write) ([]*WriteResult, error)
+}
+
+// Transactor is implemented by stores which support transactions.
+type Transactor interface {
+	// RunTransaction runs f in a transaction and commits its writes if f
+	// returns nil. If the documents read by f were written concurrently, f
+	// is run again, and ErrAborted is returned once the store gives up.
+	RunTransaction(ctx context.Context, f func(ctx context.Context, tx Tx) error) error
+}
+
+// Tx reads and writes documents in a transaction. Like in firestore, all
+// reads must happen before the first write, and writes are only applied
+// when the transaction commits.
+type Tx interface {
+	Get(path string) (*Snapshot, error)
+	Query(q Query) ([]*Snapshot, error)
+	Create(path string, data map[string]interface{}) error
+	Set(path string, data map[string]interface{}) error
+	Update(path string, updates map[string]interface{}, pre Precondition) error
+	Delete(path string, pre Precondition) error
+}
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeModified
+	ChangeRemoved
+)
+
+// Change is a change of a document reported by a snapshot listener. Like in
+// firestore, listeners report every document they start listening to as
+// added, and the Snapshot of removed documents is their last version.
+type Change struct {
+	Kind     ChangeKind
+	Snapshot *Snapshot
+}
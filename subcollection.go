@@ -0,0 +1,277 @@
+package protofirestore
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daviddomkar/protofirestore/firestoreimpl"
+	"github.com/daviddomkar/protofirestore/internal/order"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Document is a document stored in a subcollection below the document of a
+// message.
+type Document struct {
+	// Path is the path of the document relative to the document of the
+	// message, e.g. "comments/0" or "comments/0/replies/1".
+	Path string
+
+	// Data is the content of the document.
+	Data map[string]interface{}
+}
+
+// MarshalDocuments marshals m like Marshal and additionally returns the
+// documents of the fields of m stored in subcollections.
+func MarshalDocuments(m proto.Message) (map[string]interface{}, []Document, error) {
+	return MarshalOptions{}.MarshalDocuments(m)
+}
+
+// MarshalDocuments marshals m like Marshal and additionally returns the
+// documents of the fields of m marked by the subcollection option, which
+// Marshal leaves out. Each element is marshaled with the same options into
+// its own document, named by its index for repeated fields and by its key
+// for maps. Documents of subcollections of the elements follow the document
+// of their element.
+//
+// Subcollection fields of embedded messages are not stored, since only
+// documents have subcollections.
+func (o MarshalOptions) MarshalDocuments(m proto.Message) (map[string]interface{}, []Document, error) {
+	data, err := o.marshal(m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if m == nil {
+		return data, nil, nil
+	}
+
+	docs, err := o.marshalSubcollections(m.ProtoReflect(), "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, docs, nil
+}
+
+// marshalSubcollections returns the documents of the subcollection fields of
+// m, with their paths prefixed by the given prefix.
+func (o MarshalOptions) marshalSubcollections(m protoreflect.Message, prefix string) ([]Document, error) {
+	var docs []Document
+
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !isSubcollection(fd) {
+			continue
+		}
+
+		if err := checkSubcollection(fd); err != nil {
+			return nil, err
+		}
+
+		collection := prefix + fieldOptions(fd).GetSubcollection()
+
+		add := func(id string, v protoreflect.Value) error {
+			path := collection + "/" + id
+
+			data, err := o.marshal(v.Message().Interface())
+			if err != nil {
+				return fmt.Errorf("document %v: %w", path, err)
+			}
+			docs = append(docs, Document{Path: path, Data: data})
+
+			children, err := o.marshalSubcollections(v.Message(), path+"/")
+			if err != nil {
+				return err
+			}
+			docs = append(docs, children...)
+
+			return nil
+		}
+
+		switch {
+		case fd.IsList():
+			list := m.Get(fd).List()
+			for j := 0; j < list.Len(); j++ {
+				if err := add(strconv.Itoa(j), list.Get(j)); err != nil {
+					return nil, err
+				}
+			}
+		case fd.IsMap():
+			var err error
+			order.RangeEntries(m.Get(fd).Map(), order.GenericKeyOrder, func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				if !isDocumentID(k.String()) {
+					err = fmt.Errorf("field %v: key %q is not a valid document ID", fd.FullName(), k.String())
+				} else {
+					err = add(k.String(), v)
+				}
+				return err == nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return docs, nil
+}
+
+// checkSubcollection checks that the field fd marked by the subcollection
+// option can be stored in a subcollection.
+func checkSubcollection(fd protoreflect.FieldDescriptor) error {
+	if id := fieldOptions(fd).GetSubcollection(); !isDocumentID(id) {
+		return fmt.Errorf("field %v: %q is not a valid collection ID", fd.FullName(), id)
+	}
+
+	value := fd
+	if fd.IsMap() {
+		value = fd.MapValue()
+	}
+
+	switch {
+	case !fd.IsList() && !fd.IsMap(), value.Message() == nil:
+		return fmt.Errorf("subcollection field %v is not a repeated or map message field", fd.FullName())
+	case wellKnownTypeMarshaler(value.Message().FullName()) != nil:
+		return fmt.Errorf("subcollection field %v holds well known type %v", fd.FullName(), value.Message().FullName())
+	}
+
+	return nil
+}
+
+// isDocumentID reports whether s is a valid firestore collection or document
+// ID.
+func isDocumentID(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.Contains(s, "/") &&
+		!(strings.HasPrefix(s, "__") && strings.HasSuffix(s, "__")) && len(s) <= 1500
+}
+
+// UnmarshalDocuments reads the given firestore document and the documents of
+// its subcollections into the given proto.Message.
+func UnmarshalDocuments(object map[string]interface{}, docs []Document, m proto.Message) error {
+	return UnmarshalOptions{}.UnmarshalDocuments(object, docs, m)
+}
+
+// UnmarshalDocuments reads the given firestore document into the given
+// proto.Message like Unmarshal, and the documents of its subcollections, as
+// returned by MarshalOptions.MarshalDocuments, into the fields marked by the
+// subcollection option. The order of docs does not matter: elements of
+// repeated fields are ordered by the index in their document ID.
+//
+// Documents of subcollections which are not fields of the message are
+// rejected unless DiscardUnknown is set.
+func (o UnmarshalOptions) UnmarshalDocuments(object map[string]interface{}, docs []Document, m proto.Message) error {
+	if err := o.Unmarshal(object, m); err != nil {
+		return err
+	}
+	return o.unmarshalSubcollections(docs, m.ProtoReflect())
+}
+
+// unmarshalSubcollections reads the documents docs, with paths relative to
+// the document of m, into the subcollection fields of m.
+func (o UnmarshalOptions) unmarshalSubcollections(docs []Document, m protoreflect.Message) error {
+	md := m.Descriptor()
+
+	// Documents directly in a subcollection of m are keyed by their path,
+	// while deeper documents are grouped below the path of their ancestor
+	// in a subcollection of m.
+	var paths []string
+	direct := make(map[string]map[string]interface{})
+	nested := make(map[string][]Document)
+
+	for _, doc := range docs {
+		segments := strings.Split(doc.Path, "/")
+		if len(segments)%2 != 0 {
+			return fmt.Errorf("%q is not a document path", doc.Path)
+		}
+
+		path := segments[0] + "/" + segments[1]
+		if len(segments) == 2 {
+			if _, ok := direct[path]; ok {
+				return fmt.Errorf("duplicate document %v", path)
+			}
+			direct[path] = doc.Data
+			paths = append(paths, path)
+		} else {
+			nested[path] = append(nested[path], Document{Path: strings.Join(segments[2:], "/"), Data: doc.Data})
+		}
+	}
+
+	for path := range nested {
+		if _, ok := direct[path]; !ok {
+			return fmt.Errorf("documents below missing document %v", path)
+		}
+	}
+
+	fields := make(map[string]protoreflect.FieldDescriptor)
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		if id := fieldOptions(fd).GetSubcollection(); id != "" {
+			if err := checkSubcollection(fd); err != nil {
+				return err
+			}
+			fields[id] = fd
+		}
+	}
+
+	type element struct {
+		index int
+		value protoreflect.Value
+	}
+	elements := make(map[protoreflect.FieldDescriptor][]element)
+
+	// Visit the documents in order so that errors do not depend on the order
+	// of docs.
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		collection, id, _ := strings.Cut(path, "/")
+
+		fd, ok := fields[collection]
+		if !ok {
+			if o.DiscardUnknown {
+				continue
+			}
+			return fmt.Errorf("unknown subcollection %q of message %v", collection, md.FullName())
+		}
+
+		var v protoreflect.Value
+		if fd.IsMap() {
+			v = m.Mutable(fd).Map().NewValue()
+		} else {
+			v = m.Mutable(fd).List().NewElement()
+		}
+
+		if err := o.UnmarshalDocuments(direct[path], nested[path], v.Message().Interface()); err != nil {
+			return fmt.Errorf("document %v: %w", path, err)
+		}
+
+		if fd.IsMap() {
+			key, err := firestoreimpl.DecodeMapKey(id, fd.MapKey().Kind(), fd.FullName())
+			if err != nil {
+				return err
+			}
+			m.Mutable(fd).Map().Set(key, v)
+			continue
+		}
+
+		index, err := strconv.Atoi(id)
+		if err != nil || index < 0 || strconv.Itoa(index) != id {
+			return fmt.Errorf("field %v: document ID %q is not an index", fd.FullName(), id)
+		}
+		elements[fd] = append(elements[fd], element{index: index, value: v})
+	}
+
+	for fd, elems := range elements {
+		sort.Slice(elems, func(i, j int) bool { return elems[i].index < elems[j].index })
+
+		list := m.Mutable(fd).List()
+		for _, elem := range elems {
+			list.Append(elem.value)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package protofirestore_test
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pkg "github.com/daviddomkar/protofirestore"
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/go-test/deep"
+)
+
+func TestMarshalDocuments(t *testing.T) {
+	tests := []struct {
+		desc     string
+		input    *pbann.Post
+		want     map[string]interface{}
+		wantDocs []pkg.Document
+		wantErr  bool
+	}{
+		{
+			desc:  "no subcollections",
+			input: &pbann.Post{Title: "hello"},
+			want:  map[string]interface{}{"title": "hello"},
+		}, {
+			desc: "repeated and map fields",
+			input: &pbann.Post{
+				Title: "hello",
+				Comments: []*pbann.Comment{
+					{Text: "first", Replies: []*pbann.Comment{{Text: "reply"}}},
+					{Text: "second"},
+				},
+				Reactions: map[string]*pbann.Comment{
+					"alice": {Text: "like"},
+				},
+			},
+			want: map[string]interface{}{"title": "hello"},
+			wantDocs: []pkg.Document{
+				{Path: "comments/0", Data: map[string]interface{}{"text": "first"}},
+				{Path: "comments/0/replies/0", Data: map[string]interface{}{"text": "reply"}},
+				{Path: "comments/1", Data: map[string]interface{}{"text": "second"}},
+				{Path: "reactions/alice", Data: map[string]interface{}{"text": "like"}},
+			},
+		}, {
+			desc: "map key which is not a document ID",
+			input: &pbann.Post{
+				Reactions: map[string]*pbann.Comment{"a/b": {}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, gotDocs, err := pkg.MarshalDocuments(tt.input)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("MarshalDocuments() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("MarshalDocuments() got nil error, want error\n")
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+
+			if diff := deep.Equal(gotDocs, tt.wantDocs); diff != nil {
+				t.Error(diff)
+			}
+
+			// Marshal leaves the subcollections out of the document.
+			data, err := pkg.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal() returned error: %v\n", err)
+			}
+			if diff := deep.Equal(data, tt.want); diff != nil {
+				t.Error(diff)
+			}
+
+			decoded := &pbann.Post{}
+			if err := pkg.UnmarshalDocuments(got, gotDocs, decoded); err != nil {
+				t.Fatalf("UnmarshalDocuments() returned error: %v\n", err)
+			}
+			if !proto.Equal(decoded, tt.input) {
+				t.Errorf("UnmarshalDocuments() = %v, want %v\n", decoded, tt.input)
+			}
+		})
+	}
+}
+
+func TestUnmarshalDocuments(t *testing.T) {
+	doc := func(path string) pkg.Document {
+		return pkg.Document{Path: path, Data: map[string]interface{}{"text": path}}
+	}
+
+	tests := []struct {
+		desc    string
+		docs    []pkg.Document
+		discard bool
+		want    *pbann.Post
+		wantErr bool
+	}{
+		{
+			desc: "elements ordered by index",
+			docs: []pkg.Document{doc("comments/10"), doc("comments/2"), doc("comments/2/replies/0")},
+			want: &pbann.Post{
+				Comments: []*pbann.Comment{
+					{Text: "comments/2", Replies: []*pbann.Comment{{Text: "comments/2/replies/0"}}},
+					{Text: "comments/10"},
+				},
+			},
+		}, {
+			desc:    "document ID which is not an index",
+			docs:    []pkg.Document{doc("comments/first")},
+			wantErr: true,
+		}, {
+			desc:    "documents below missing document",
+			docs:    []pkg.Document{doc("comments/0/replies/0")},
+			wantErr: true,
+		}, {
+			desc:    "unknown subcollection",
+			docs:    []pkg.Document{doc("likes/0")},
+			wantErr: true,
+		}, {
+			desc:    "unknown subcollection discarded",
+			docs:    []pkg.Document{doc("likes/0")},
+			discard: true,
+			want:    &pbann.Post{},
+		}, {
+			desc:    "collection path",
+			docs:    []pkg.Document{doc("comments")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := &pbann.Post{}
+			err := pkg.UnmarshalOptions{DiscardUnknown: tt.discard}.UnmarshalDocuments(nil, tt.docs, got)
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("UnmarshalDocuments() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("UnmarshalDocuments() got nil error, want error\n")
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("UnmarshalDocuments() = %v, want %v\n", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package protofirestore
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// TypeKey is the key under which MarshalOptions.EmitType stores the type of
+// a message. It is never the JSON name of a field, so it does not collide
+// with the keys of fields.
+const TypeKey = "@type"
+
+// TypeAliases maps the short names stored under TypeKey to the full names of
+// the messages they stand for.
+type TypeAliases map[string]protoreflect.FullName
+
+// alias returns the alias of the message with the given full name, or the
+// empty string if it has none. If a message has several aliases, the first
+// one in lexical order is used, so that the result does not depend on the
+// iteration order of the map.
+func (a TypeAliases) alias(name protoreflect.FullName) string {
+	alias := ""
+	for k, v := range a {
+		if v == name && (alias == "" || k < alias) {
+			alias = k
+		}
+	}
+	return alias
+}
+
+// resolve returns the full name of the message stored under TypeKey as the
+// given type.
+func (a TypeAliases) resolve(typ string) protoreflect.FullName {
+	if name, ok := a[typ]; ok {
+		return name
+	}
+	return protoreflect.FullName(typ)
+}
+
+// TypeName returns the type EmitType stores under TypeKey for messages
+// described by md, which is their alias in TypeAliases or their full name.
+func (o MarshalOptions) TypeName(md protoreflect.MessageDescriptor) string {
+	if alias := o.TypeAliases.alias(md.FullName()); alias != "" {
+		return alias
+	}
+	return string(md.FullName())
+}
+
+// UnmarshalNew reads the given firestore document into a new message of the
+// type stored under TypeKey.
+func UnmarshalNew(object map[string]interface{}) (proto.Message, error) {
+	return UnmarshalOptions{}.UnmarshalNew(object)
+}
+
+// UnmarshalNew reads the given firestore document, written with
+// MarshalOptions.EmitType, into a new message of the type stored under
+// TypeKey, which is looked up in TypeAliases and resolved by Resolver. This
+// reads documents of collections holding several message types without
+// knowing their type in advance.
+func (o UnmarshalOptions) UnmarshalNew(object map[string]interface{}) (proto.Message, error) {
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+
+	if object[TypeKey] == nil {
+		return nil, fmt.Errorf("document has no %v key", TypeKey)
+	}
+
+	name, err := o.typeOf(object)
+	if err != nil {
+		return nil, err
+	}
+
+	mt, err := o.Resolver.FindMessageByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve %v %q: %w", TypeKey, object[TypeKey], err)
+	}
+
+	m := mt.New().Interface()
+	if err := o.Unmarshal(object, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// typeOf returns the full name of the message stored under TypeKey in the
+// given document.
+func (o UnmarshalOptions) typeOf(object map[string]interface{}) (protoreflect.FullName, error) {
+	typ, ok := object[TypeKey].(string)
+	if !ok {
+		return "", fmt.Errorf("invalid value for %v: %v", TypeKey, object[TypeKey])
+	}
+
+	name := o.TypeAliases.resolve(typ)
+	if !name.IsValid() {
+		return "", fmt.Errorf("invalid value for %v: %q is not a message name", TypeKey, typ)
+	}
+
+	return name, nil
+}
+
+// checkType checks that the type stored under TypeKey in the given document,
+// if any, is the message described by md.
+func (o UnmarshalOptions) checkType(object map[string]interface{}, md protoreflect.MessageDescriptor) error {
+	if object[TypeKey] == nil {
+		return nil
+	}
+
+	name, err := o.typeOf(object)
+	if err != nil {
+		return err
+	}
+
+	if name != md.FullName() {
+		return fmt.Errorf("document holds a %v message, not %v", name, md.FullName())
+	}
+
+	return nil
+}
+
+// withoutKey returns a copy of object without the given key.
+func withoutKey(object map[string]interface{}, key string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(object))
+	for k, v := range object {
+		if k != key {
+			copied[k] = v
+		}
+	}
+	return copied
+}
@@ -0,0 +1,125 @@
+package protofirestore_test
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pkg "github.com/daviddomkar/protofirestore"
+	pbann "github.com/daviddomkar/protofirestore/internal/testprotos/annotatedpb"
+	"github.com/go-test/deep"
+)
+
+func TestMarshalType(t *testing.T) {
+	aliases := pkg.TypeAliases{"draft": "annotated.Draft", "d": "annotated.Draft"}
+
+	tests := []struct {
+		desc  string
+		mo    pkg.MarshalOptions
+		input proto.Message
+		want  map[string]interface{}
+	}{
+		{
+			desc:  "full name",
+			mo:    pkg.MarshalOptions{EmitType: true},
+			input: &pbann.Article{Title: "hello"},
+			want:  map[string]interface{}{"@type": "annotated.Article", "title": "hello"},
+		}, {
+			desc:  "first alias",
+			mo:    pkg.MarshalOptions{EmitType: true, TypeAliases: aliases},
+			input: &pbann.Draft{Title: "hello"},
+			want:  map[string]interface{}{"@type": "d", "title": "hello"},
+		}, {
+			desc:  "message without alias",
+			mo:    pkg.MarshalOptions{EmitType: true, TypeAliases: aliases},
+			input: &pbann.Article{},
+			want:  map[string]interface{}{"@type": "annotated.Article"},
+		}, {
+			desc:  "embedded messages have no type",
+			mo:    pkg.MarshalOptions{EmitType: true},
+			input: &pbann.User{Address: &pbann.Address{Street: "main"}},
+			want:  map[string]interface{}{"@type": "annotated.User", "home": map[string]interface{}{"street": "main"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := tt.mo.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal() returned error: %v\n", err)
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+
+			decoded, err := pkg.UnmarshalOptions{TypeAliases: tt.mo.TypeAliases}.UnmarshalNew(got)
+			if err != nil {
+				t.Fatalf("UnmarshalNew() returned error: %v\n", err)
+			}
+			if !proto.Equal(decoded, tt.input) {
+				t.Errorf("UnmarshalNew() = %v, want %v\n", decoded, tt.input)
+			}
+		})
+	}
+}
+
+func TestUnmarshalType(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   map[string]interface{}
+		into    proto.Message
+		wantErr bool
+	}{
+		{
+			desc:  "matching type",
+			input: map[string]interface{}{"@type": "annotated.Article", "title": "hello"},
+			into:  &pbann.Article{},
+		}, {
+			desc:  "null type",
+			input: map[string]interface{}{"@type": nil},
+			into:  &pbann.Article{},
+		}, {
+			desc:    "other type",
+			input:   map[string]interface{}{"@type": "annotated.Draft"},
+			into:    &pbann.Article{},
+			wantErr: true,
+		}, {
+			desc:    "invalid type",
+			input:   map[string]interface{}{"@type": int64(1)},
+			into:    &pbann.Article{},
+			wantErr: true,
+		}, {
+			desc:    "missing type",
+			input:   map[string]interface{}{"title": "hello"},
+			wantErr: true,
+		}, {
+			desc:    "unknown type",
+			input:   map[string]interface{}{"@type": "annotated.Missing"},
+			wantErr: true,
+		}, {
+			desc:    "type which is not a message name",
+			input:   map[string]interface{}{"@type": "not a name"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var err error
+			if tt.into != nil {
+				err = pkg.Unmarshal(tt.input, tt.into)
+			} else {
+				_, err = pkg.UnmarshalNew(tt.input)
+			}
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("Unmarshal() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("Unmarshal() got nil error, want error\n")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package protofirestore
+
+import (
+	"errors"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// MarshalUpdate marshals the fields of m selected by mask into a map of
+// firestore field paths to values, suitable for a firestore update.
+func MarshalUpdate(m proto.Message, mask *fieldmaskpb.FieldMask) (map[string]interface{}, error) {
+	return MarshalOptions{}.MarshalUpdate(m, mask)
+}
+
+// MarshalUpdate marshals the fields of m selected by mask into a map of
+// firestore field paths to values, suitable for a firestore update.
+//
+// The paths of the mask use proto field names as described in AIP-134 and are
+// converted to the JSON names used by Marshal as described by FieldPath. A
+// single "*" path selects all fields of m. Fields selected by the mask that
+// Marshal would omit are mapped to Delete. Paths which do not exist in the
+// descriptor of m are rejected. With EmitOneofCase, selecting a field of a
+// oneof also updates the case of the oneof.
+func (o MarshalOptions) MarshalUpdate(m proto.Message, mask *fieldmaskpb.FieldMask) (map[string]interface{}, error) {
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+
+	if m == nil {
+		return nil, errors.New("cannot marshal update of nil message")
+	}
+
+	if len(mask.GetPaths()) == 0 {
+		return nil, errors.New("empty field mask")
+	}
+
+	md := m.ProtoReflect().Descriptor()
+
+	if marshal := wellKnownTypeMarshaler(md.FullName()); marshal != nil {
+		return nil, errors.New("no support for well known types as top level objects in firestore documents")
+	}
+
+	var paths []string
+	if len(mask.GetPaths()) == 1 && mask.GetPaths()[0] == "*" {
+		fds := md.Fields()
+		for i := 0; i < fds.Len(); i++ {
+			if !isSubcollection(fds.Get(i)) {
+				paths = append(paths, string(fds.Get(i).Name()))
+			}
+		}
+	} else {
+		// Normalize a copy of the mask so that paths covered by a shorter
+		// path do not produce conflicting firestore updates.
+		normalized := &fieldmaskpb.FieldMask{Paths: append([]string(nil), mask.GetPaths()...)}
+		normalized.Normalize()
+		paths = normalized.GetPaths()
+	}
+
+	enc := encoder{o}
+	updates := make(map[string]interface{}, len(paths))
+
+	for _, path := range paths {
+		segments, err := resolveFieldPath(o.Resolver, md, path)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := enc.marshalFieldPath(m.ProtoReflect(), segments)
+		if err != nil {
+			return nil, err
+		}
+
+		if value == nil {
+			value = Delete
+		}
+
+		updates[firestoreFieldPath(segments)] = value
+
+		if o.EmitOneofCase {
+			if path, value, ok := enc.marshalOneofCasePath(m.ProtoReflect(), segments); ok {
+				updates[path] = value
+			}
+		}
+	}
+
+	return updates, nil
+}
+
+// marshalFieldPath marshals the value at the resolved field path of the given
+// protoreflect.Message the same way marshalMessage does. It returns nil if
+// marshalMessage would omit the value.
+func (e encoder) marshalFieldPath(m protoreflect.Message, segments []fieldPathSegment) (interface{}, error) {
+	value := protoreflect.ValueOfMessage(m)
+
+	for _, segment := range segments[:len(segments)-1] {
+		if segment.isMapKey() {
+			if value = value.Map().Get(segment.key); !value.IsValid() {
+				return nil, nil
+			}
+		} else {
+			value = value.Message().Get(segment.fd)
+		}
+	}
+
+	last := segments[len(segments)-1]
+	if last.isMapKey() {
+		v := value.Map().Get(last.key)
+		if !v.IsValid() {
+			return nil, nil
+		}
+		return e.marshalSingular(v, last.fd.MapValue())
+	}
+
+	return e.marshalField(value.Message(), last.fd)
+}
+
+// marshalOneofCasePath returns the firestore field path and value of the case
+// of the oneof containing the last field of the resolved field path, if it
+// is contained in one.
+func (e encoder) marshalOneofCasePath(m protoreflect.Message, segments []fieldPathSegment) (string, interface{}, bool) {
+	last := segments[len(segments)-1]
+	if last.isMapKey() {
+		return "", nil, false
+	}
+
+	od := last.fd.ContainingOneof()
+	if od == nil || od.IsSynthetic() || checkOneofCaseKey(od) != nil {
+		return "", nil, false
+	}
+
+	names := make([]string, len(segments))
+	for i, segment := range segments[:len(segments)-1] {
+		names[i] = segment.name()
+	}
+	names[len(names)-1] = OneofCaseKey(od)
+	path := joinFieldPath(names)
+
+	value := protoreflect.ValueOfMessage(m)
+	for _, segment := range segments[:len(segments)-1] {
+		if segment.isMapKey() {
+			if value = value.Map().Get(segment.key); !value.IsValid() {
+				return path, Delete, true
+			}
+		} else {
+			value = value.Message().Get(segment.fd)
+		}
+	}
+
+	return path, oneofCase(value.Message(), od), true
+}
@@ -0,0 +1,251 @@
+package protofirestore_test
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pkg "github.com/daviddomkar/protofirestore"
+	pb2 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb2"
+	pb3 "github.com/daviddomkar/protofirestore/internal/testprotos/textpb3"
+	"github.com/go-test/deep"
+)
+
+func TestMarshalUpdate(t *testing.T) {
+	tests := []struct {
+		desc     string
+		input    proto.Message
+		paths    []string
+		defaults bool
+		want     map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			desc: "scalar fields",
+			input: &pb3.Scalars{
+				SBool:   true,
+				SInt32:  42,
+				SString: "hello",
+			},
+			paths: []string{"s_bool", "s_string"},
+			want: map[string]interface{}{
+				"sBool":   true,
+				"sString": "hello",
+			},
+		}, {
+			desc:  "unset scalar fields are deleted",
+			input: &pb3.Scalars{},
+			paths: []string{"s_bool", "s_int64", "s_string", "s_bytes"},
+			want: map[string]interface{}{
+				"sBool":   pkg.Delete,
+				"sInt64":  pkg.Delete,
+				"sString": pkg.Delete,
+				"sBytes":  pkg.Delete,
+			},
+		}, {
+			desc:     "unset scalar fields with firestore sensible defaults",
+			input:    &pb3.Scalars{},
+			paths:    []string{"s_bool", "s_int64"},
+			defaults: true,
+			want: map[string]interface{}{
+				"sBool":  false,
+				"sInt64": int64(0),
+			},
+		}, {
+			desc: "proto3 optional set to zero value",
+			input: &pb3.Proto3Optional{
+				OptInt32: proto.Int32(0),
+			},
+			paths: []string{"opt_int32", "opt_enum"},
+			want: map[string]interface{}{
+				"optInt32": int32(0),
+				"optEnum":  pkg.Delete,
+			},
+		}, {
+			desc: "nested message fields",
+			input: &pb2.Nests{
+				OptNested: &pb2.Nested{
+					OptString: proto.String("nested message"),
+				},
+			},
+			paths: []string{"opt_nested.opt_string", "opt_nested.opt_nested"},
+			want: map[string]interface{}{
+				"optNested.optString": "nested message",
+				"optNested.optNested": pkg.Delete,
+			},
+		}, {
+			desc:  "nested message fields of unset message",
+			input: &pb2.Nests{},
+			paths: []string{"opt_nested.opt_nested.opt_string"},
+			want: map[string]interface{}{
+				"optNested.optNested.optString": pkg.Delete,
+			},
+		}, {
+			desc: "whole nested message",
+			input: &pb3.Nests{
+				SNested: &pb3.Nested{
+					SString: "nested message",
+				},
+			},
+			paths: []string{"s_nested"},
+			want: map[string]interface{}{
+				"sNested": map[string]interface{}{
+					"sString": "nested message",
+				},
+			},
+		}, {
+			desc: "paths covered by other paths are ignored",
+			input: &pb3.Nests{
+				SNested: &pb3.Nested{
+					SString: "nested message",
+				},
+			},
+			paths: []string{"s_nested.s_string", "s_nested"},
+			want: map[string]interface{}{
+				"sNested": map[string]interface{}{
+					"sString": "nested message",
+				},
+			},
+		}, {
+			desc: "repeated and map fields",
+			input: &pb3.Maps{
+				Int32ToStr: map[int32]string{
+					1: "one",
+				},
+			},
+			paths: []string{"int32_to_str", "str_to_nested"},
+			want: map[string]interface{}{
+				"int32ToStr": map[string]interface{}{
+					"1": "one",
+				},
+				"strToNested": pkg.Delete,
+			},
+		}, {
+			desc: "map entries",
+			input: &pb3.Maps{
+				Int32ToStr: map[int32]string{
+					1: "one",
+				},
+				StrToNested: map[string]*pb3.Nested{
+					"nested": {SString: "nested in a map"},
+				},
+			},
+			paths: []string{"int32_to_str.1", "int32_to_str.2", "str_to_nested.nested.s_string", "str_to_nested.missing.s_string"},
+			want: map[string]interface{}{
+				"int32ToStr.`1`":              "one",
+				"int32ToStr.`2`":              pkg.Delete,
+				"strToNested.nested.sString":  "nested in a map",
+				"strToNested.missing.sString": pkg.Delete,
+			},
+		}, {
+			desc: "oneof fields",
+			input: &pb3.Oneofs{
+				Union: &pb3.Oneofs_OneofString{
+					OneofString: "hello",
+				},
+			},
+			paths: []string{"oneof_string", "oneof_nested"},
+			want: map[string]interface{}{
+				"oneofString": "hello",
+				"oneofNested": pkg.Delete,
+			},
+		}, {
+			desc: "json_name",
+			input: &pb3.JSONNames{
+				SString: "json_name",
+			},
+			paths: []string{"s_string"},
+			want: map[string]interface{}{
+				"foo_bar": "json_name",
+			},
+		}, {
+			desc: "well known type",
+			input: &pb2.KnownTypes{
+				OptTimestamp: &timestamppb.Timestamp{Seconds: 1553036601},
+			},
+			paths: []string{"opt_timestamp", "opt_empty"},
+			want: map[string]interface{}{
+				"optTimestamp": time.Unix(1553036601, 0).UTC(),
+				"optEmpty":     pkg.Delete,
+			},
+		}, {
+			desc: "wildcard",
+			input: &pb3.Oneofs{
+				Union: &pb3.Oneofs_OneofEnum{
+					OneofEnum: pb3.Enum_ONE,
+				},
+			},
+			paths: []string{"*"},
+			want: map[string]interface{}{
+				"oneofEnum":   "ONE",
+				"oneofString": pkg.Delete,
+				"oneofNested": pkg.Delete,
+			},
+		}, {
+			desc:    "empty mask",
+			input:   &pb3.Scalars{},
+			wantErr: true,
+		}, {
+			desc:    "unknown field",
+			input:   &pb3.Scalars{},
+			paths:   []string{"s_unknown"},
+			wantErr: true,
+		}, {
+			desc:    "json name instead of proto name",
+			input:   &pb3.Scalars{},
+			paths:   []string{"sString"},
+			wantErr: true,
+		}, {
+			desc:    "path through scalar field",
+			input:   &pb3.Scalars{},
+			paths:   []string{"s_string.length"},
+			wantErr: true,
+		}, {
+			desc:    "path through repeated field",
+			input:   &pb2.Nests{},
+			paths:   []string{"rpt_nested.opt_string"},
+			wantErr: true,
+		}, {
+			desc:    "path through well known type",
+			input:   &pb2.KnownTypes{},
+			paths:   []string{"opt_timestamp.seconds"},
+			wantErr: true,
+		}, {
+			desc: "invalid value",
+			input: &pb3.Scalars{
+				SString: "abc\xff",
+			},
+			paths:   []string{"s_string"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := pkg.MarshalOptions{
+				EmitFirestoreSensibleDefaults: tt.defaults,
+			}.MarshalUpdate(tt.input, &fieldmaskpb.FieldMask{Paths: tt.paths})
+
+			if err != nil && !tt.wantErr {
+				t.Errorf("MarshalUpdate() returned error: %v\n", err)
+			}
+
+			if err == nil && tt.wantErr {
+				t.Errorf("MarshalUpdate() got nil error, want error\n")
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			diff := deep.Equal(got, tt.want)
+
+			if diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -2,9 +2,8 @@ package protofirestore
 
 import (
 	"errors"
-	"fmt"
-	"time"
 
+	"github.com/daviddomkar/protofirestore/firestoreimpl"
 	"github.com/daviddomkar/protofirestore/internal/genid"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
@@ -49,37 +48,14 @@ func (e encoder) marshalAny(m protoreflect.Message) (interface{}, error) {
 	return nil, errors.New("no support for any well known type")
 }
 
-const (
-	maxTimestampSeconds = 253402300799
-	minTimestampSeconds = -62135596800
-)
-
 func (e encoder) marshalTimestamp(m protoreflect.Message) (interface{}, error) {
 	fds := m.Descriptor().Fields()
 	fdSeconds := fds.ByNumber(genid.Timestamp_Seconds_field_number)
 	fdNanos := fds.ByNumber(genid.Timestamp_Nanos_field_number)
 
-	secsVal := m.Get(fdSeconds)
-	nanosVal := m.Get(fdNanos)
-	secs := secsVal.Int()
-	nanos := nanosVal.Int()
-
-	if secs < minTimestampSeconds || secs > maxTimestampSeconds {
-		return nil, fmt.Errorf("%s: seconds out of range %v", genid.Timestamp_message_fullname, secs)
-	}
-
-	if nanos < 0 || nanos > secondsInNanos {
-		return nil, fmt.Errorf("%s: nanos out of range %v", genid.Timestamp_message_fullname, nanos)
-	}
-
-	return time.Unix(secs, nanos).UTC(), nil
+	return firestoreimpl.EncodeTimestamp(m.Get(fdSeconds).Int(), int32(m.Get(fdNanos).Int()))
 }
 
-const (
-	secondsInNanos       = 999999999
-	maxSecondsInDuration = 315576000000
-)
-
 func (e encoder) marshalDuration(m protoreflect.Message) (interface{}, error) {
 	return nil, errors.New("no support for duration well known type")
 }